@@ -37,6 +37,8 @@ func SetupEnterprise() *TestHelper {
 		utils.InitTranslations(utils.Cfg.LocalizationSettings)
 		utils.Cfg.TeamSettings.MaxUsersPerTeam = 50
 		*utils.Cfg.RateLimitSettings.Enable = false
+		*utils.Cfg.ServiceSettings.EnableLoginLockout = false
+		*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = "localhost 127.0.0.1"
 		utils.DisableDebugLogForTest()
 		utils.License.Features.SetDefaults()
 		app.NewServer()
@@ -62,6 +64,8 @@ func Setup() *TestHelper {
 		utils.InitTranslations(utils.Cfg.LocalizationSettings)
 		utils.Cfg.TeamSettings.MaxUsersPerTeam = 50
 		*utils.Cfg.RateLimitSettings.Enable = false
+		*utils.Cfg.ServiceSettings.EnableLoginLockout = false
+		*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = "localhost 127.0.0.1"
 		utils.Cfg.EmailSettings.SendEmailNotifications = true
 		utils.Cfg.EmailSettings.SMTPServer = "dockerhost"
 		utils.Cfg.EmailSettings.SMTPPort = "2500"
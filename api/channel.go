@@ -611,7 +611,7 @@ func getPinnedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		posts = result.Data.(*model.PostList)
 	}
 
-	w.Write([]byte(posts.ToJson()))
+	w.Write([]byte(app.PreparePostListForClient(posts, c.GetSiteURL()).ToJson()))
 }
 
 func addMember(c *Context, w http.ResponseWriter, r *http.Request) {
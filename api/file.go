@@ -76,6 +76,11 @@ func getFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := app.CheckFileCanBeServed(info); err != nil {
+		c.Err = err
+		return
+	}
+
 	if data, err := app.ReadFile(info.Path); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
@@ -92,6 +97,11 @@ func getFileThumbnail(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := app.CheckFileCanBeServed(info); err != nil {
+		c.Err = err
+		return
+	}
+
 	if info.ThumbnailPath == "" {
 		c.Err = model.NewLocAppError("getFileThumbnail", "api.file.get_file_thumbnail.no_thumbnail.app_error", nil, "file_id="+info.Id)
 		c.Err.StatusCode = http.StatusBadRequest
@@ -101,7 +111,7 @@ func getFileThumbnail(c *Context, w http.ResponseWriter, r *http.Request) {
 	if data, err := app.ReadFile(info.ThumbnailPath); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
-	} else if err := writeFileResponse(info.Name, "", data, w, r); err != nil {
+	} else if err := writeFileResponse(info.Name, app.GetPreviewContentType(info.ThumbnailPath), data, w, r); err != nil {
 		c.Err = err
 		return
 	}
@@ -114,6 +124,11 @@ func getFilePreview(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := app.CheckFileCanBeServed(info); err != nil {
+		c.Err = err
+		return
+	}
+
 	if info.PreviewPath == "" {
 		c.Err = model.NewLocAppError("getFilePreview", "api.file.get_file_preview.no_preview.app_error", nil, "file_id="+info.Id)
 		c.Err.StatusCode = http.StatusBadRequest
@@ -123,7 +138,7 @@ func getFilePreview(c *Context, w http.ResponseWriter, r *http.Request) {
 	if data, err := app.ReadFile(info.PreviewPath); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
-	} else if err := writeFileResponse(info.Name, "", data, w, r); err != nil {
+	} else if err := writeFileResponse(info.Name, app.GetPreviewContentType(info.PreviewPath), data, w, r); err != nil {
 		c.Err = err
 		return
 	}
@@ -170,6 +185,11 @@ func getPublicFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := app.CheckFileCanBeServed(info); err != nil {
+		c.Err = err
+		return
+	}
+
 	if data, err := app.ReadFile(info.Path); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
@@ -267,6 +287,11 @@ func getPublicFileOld(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := app.CheckFileCanBeServed(info); err != nil {
+		c.Err = err
+		return
+	}
+
 	if data, err := app.ReadFile(info.Path); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
@@ -36,7 +36,9 @@ func TestUploadFile(t *testing.T) {
 	channel := th.BasicChannel
 
 	var uploadInfo *model.FileInfo
-	if data, err := readTestFile("test.png"); err != nil {
+	var data []byte
+	var err error
+	if data, err = readTestFile("test.png"); err != nil {
 		t.Fatal(err)
 	} else if resp, err := Client.UploadPostAttachment(data, channel.Id, "test.png"); err != nil {
 		t.Fatal(err)
@@ -80,9 +82,11 @@ func TestUploadFile(t *testing.T) {
 		t.Fatal("file preview path should be set in database")
 	}
 
-	// This also makes sure that the relative path provided above is sanitized out
-	expectedPath := fmt.Sprintf("teams/%v/channels/%v/users/%v/%v/test.png", team.Id, channel.Id, user.Id, info.Id)
-	if info.Path != expectedPath {
+	// Files are shared between uploads with the same content
+	expectedPath := app.FILE_BLOB_PATH_PREFIX + info.ContentHash[:2] + "/" + info.ContentHash
+	if info.ContentHash != app.GetContentHash(data) {
+		t.Fatal("file content hash should be set in database")
+	} else if info.Path != expectedPath {
 		t.Logf("file is saved in %v", info.Path)
 		t.Fatalf("file should've been saved in %v", expectedPath)
 	}
@@ -760,9 +764,14 @@ func TestGetInfoForFilename(t *testing.T) {
 		t.Fatal(err)
 	} else {
 		fileId1 = Client.MustGeneric(Client.UploadPostAttachment(data, channel1.Id, "test.png")).(*model.FileUploadResponse).FileInfos[0].Id
-		path = store.Must(app.Srv.Store.FileInfo().Get(fileId1)).(*model.FileInfo).Path
 		thumbnailPath = store.Must(app.Srv.Store.FileInfo().Get(fileId1)).(*model.FileInfo).ThumbnailPath
 		previewPath = store.Must(app.Srv.Store.FileInfo().Get(fileId1)).(*model.FileInfo).PreviewPath
+
+		// Uploaded files are stored in shared storage, so put a copy where files were stored before that
+		path = fmt.Sprintf("teams/%v/channels/%v/users/%v/%v/test.png", team1.Id, channel1.Id, user1.Id, fileId1)
+		if err := app.WriteFile(data, path); err != nil {
+			t.Fatal(err)
+		}
 	}
 
 	// Bypass the Client whenever possible since we're trying to simulate a pre-3.5 post
@@ -819,8 +828,10 @@ func cleanupTestFile(info *model.FileInfo) error {
 			return err
 		}
 		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
-		if err := s3Clnt.RemoveObject(bucket, info.Path); err != nil {
-			return err
+		if info.ContentHash == "" {
+			if err := s3Clnt.RemoveObject(bucket, info.Path); err != nil {
+				return err
+			}
 		}
 
 		if info.ThumbnailPath != "" {
@@ -835,8 +846,11 @@ func cleanupTestFile(info *model.FileInfo) error {
 			}
 		}
 	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if err := os.Remove(utils.Cfg.FileSettings.Directory + info.Path); err != nil {
-			return err
+		// Files with the same content are shared between tests, so they're left in place
+		if info.ContentHash == "" {
+			if err := os.Remove(utils.Cfg.FileSettings.Directory + info.Path); err != nil {
+				return err
+			}
 		}
 
 		if info.ThumbnailPath != "" {
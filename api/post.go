@@ -14,10 +14,6 @@ import (
 	"github.com/mattermost/platform/utils"
 )
 
-const OPEN_GRAPH_METADATA_CACHE_SIZE = 10000
-
-var openGraphDataCache = utils.NewLru(OPEN_GRAPH_METADATA_CACHE_SIZE)
-
 func InitPost() {
 	l4g.Debug(utils.T("api.post.init.debug"))
 
@@ -66,7 +62,7 @@ func createPost(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Write([]byte(rp.ToJson()))
+	w.Write([]byte(app.PreparePostForClient(rp, c.GetSiteURL()).ToJson()))
 }
 
 func updatePost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -84,13 +80,13 @@ func updatePost(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	post.UserId = c.Session.UserId
 
-	rpost, err := app.UpdatePost(post)
+	rpost, err := app.UpdatePost(post, true)
 	if err != nil {
 		c.Err = err
 		return
 	}
 
-	w.Write([]byte(rpost.ToJson()))
+	w.Write([]byte(app.PreparePostForClient(rpost, c.GetSiteURL()).ToJson()))
 }
 
 func saveIsPinnedPost(c *Context, w http.ResponseWriter, r *http.Request, isPinned bool) {
@@ -120,20 +116,21 @@ func saveIsPinnedPost(c *Context, w http.ResponseWriter, r *http.Request, isPinn
 		*newPost = *oldPost
 		newPost.IsPinned = isPinned
 
-		if result := <-app.Srv.Store.Post().Update(newPost, oldPost); result.Err != nil {
+		// Pinning isn't an edit, so the post doesn't need to keep its previous version
+		if result := <-app.Srv.Store.Post().Overwrite(newPost); result.Err != nil {
 			c.Err = result.Err
 			return
 		} else {
 			rpost := result.Data.(*model.Post)
 
 			message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POST_EDITED, "", rpost.ChannelId, "", nil)
-			message.Add("post", rpost.ToJson())
+			message.Add("post", app.PreparePostForClient(rpost, c.GetSiteURL()).ToJson())
 
 			go app.Publish(message)
 
 			app.InvalidateCacheForChannelPosts(rpost.ChannelId)
 
-			w.Write([]byte(rpost.ToJson()))
+			w.Write([]byte(app.PreparePostForClient(rpost, c.GetSiteURL()).ToJson()))
 		}
 	}
 }
@@ -165,7 +162,7 @@ func getFlaggedPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		c.Err = err
 		return
 	} else {
-		w.Write([]byte(posts.ToJson()))
+		w.Write([]byte(app.PreparePostListForClient(posts, c.GetSiteURL()).ToJson()))
 	}
 }
 
@@ -206,7 +203,7 @@ func getPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	} else {
 		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.PreparePostListForClient(list, c.GetSiteURL()).ToJson()))
 	}
 
 }
@@ -235,7 +232,7 @@ func getPostsSince(c *Context, w http.ResponseWriter, r *http.Request) {
 		c.Err = err
 		return
 	} else {
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.PreparePostListForClient(list, c.GetSiteURL()).ToJson()))
 	}
 
 }
@@ -273,7 +270,7 @@ func getPost(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 
 		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.PreparePostListForClient(list, c.GetSiteURL()).ToJson()))
 	}
 }
 
@@ -306,7 +303,7 @@ func getPostById(c *Context, w http.ResponseWriter, r *http.Request) {
 		}
 
 		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.PreparePostListForClient(list, c.GetSiteURL()).ToJson()))
 	}
 }
 
@@ -346,7 +343,7 @@ func getPermalinkTmp(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	} else {
 		w.Header().Set(model.HEADER_ETAG_SERVER, list.Etag())
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.PreparePostListForClient(list, c.GetSiteURL()).ToJson()))
 	}
 }
 
@@ -443,7 +440,7 @@ func getPostsBeforeOrAfter(c *Context, w http.ResponseWriter, r *http.Request, b
 		return
 	} else {
 		w.Header().Set(model.HEADER_ETAG_SERVER, etag)
-		w.Write([]byte(list.ToJson()))
+		w.Write([]byte(app.PreparePostListForClient(list, c.GetSiteURL()).ToJson()))
 	}
 }
 
@@ -468,7 +465,7 @@ func searchPosts(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
-	w.Write([]byte(posts.ToJson()))
+	w.Write([]byte(app.PreparePostListForClient(posts, c.GetSiteURL()).ToJson()))
 }
 
 func getFileInfosForPost(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -514,15 +511,8 @@ func getOpenGraphMetadata(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	props := model.StringInterfaceFromJson(r.Body)
 
-	ogJSONGeneric, ok := openGraphDataCache.Get(props["url"])
-	if ok {
-		w.Write(ogJSONGeneric.([]byte))
-		return
-	}
-
-	url := ""
-	ok = false
-	if url, ok = props["url"].(string); len(url) == 0 || !ok {
+	url, ok := props["url"].(string)
+	if len(url) == 0 || !ok {
 		c.SetInvalidParam("getOpenGraphMetadata", "url")
 		return
 	}
@@ -530,7 +520,6 @@ func getOpenGraphMetadata(c *Context, w http.ResponseWriter, r *http.Request) {
 	og := app.GetOpenGraphMetadata(url)
 
 	ogJSON, err := og.ToJSON()
-	openGraphDataCache.AddWithExpiresInSecs(props["url"], ogJSON, 3600) // Cache would expire after 1 hour
 	if err != nil {
 		w.Write([]byte(`{"url": ""}`))
 		return
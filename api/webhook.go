@@ -5,6 +5,7 @@ package api
 
 import (
 	"io"
+	"mime/multipart"
 	"net/http"
 	"strings"
 
@@ -34,6 +35,8 @@ func InitWebhook() {
 	// Old route. Remove eventually.
 	mr := app.Srv.Router
 	mr.Handle("/hooks/{id:[A-Za-z0-9]+}", ApiAppHandler(incomingWebhook)).Methods("POST")
+
+	mr.Handle("/hooks/commands/{id:[A-Za-z0-9]+}", ApiAppHandler(commandWebhook)).Methods("POST")
 }
 
 func createIncomingHook(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -348,9 +351,23 @@ func incomingWebhook(c *Context, w http.ResponseWriter, r *http.Request) {
 	r.ParseForm()
 
 	var payload io.Reader
-	contentType := r.Header.Get("Content-Type")
-	if strings.Split(contentType, "; ")[0] == "application/x-www-form-urlencoded" {
+	var files []*multipart.FileHeader
+	contentType := strings.Split(r.Header.Get("Content-Type"), "; ")[0]
+	if contentType == "application/x-www-form-urlencoded" {
 		payload = strings.NewReader(r.FormValue("payload"))
+	} else if contentType == "multipart/form-data" {
+		if r.ContentLength > *utils.Cfg.FileSettings.MaxFileSize {
+			c.Err = model.NewAppError("incomingWebhook", "api.file.upload_file.too_large.app_error", nil, "", http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if err := r.ParseMultipartForm(*utils.Cfg.FileSettings.MaxFileSize); err != nil {
+			c.Err = model.NewAppError("incomingWebhook", "web.incoming_webhook.parse.app_error", nil, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		payload = strings.NewReader(r.FormValue("payload"))
+		files = r.MultipartForm.File["files"]
 	} else {
 		payload = r.Body
 	}
@@ -374,7 +391,36 @@ func incomingWebhook(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	parsedRequest := model.IncomingWebhookRequestFromJson(payload)
 
-	err := app.HandleIncomingWebhook(id, parsedRequest, c.GetSiteURL())
+	post, err := app.HandleIncomingWebhook(id, parsedRequest, files, c.GetSiteURL())
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	// Slack compatible integrations expect a plain ok so the post id is only returned when asked for
+	if contentType == "multipart/form-data" || strings.Contains(r.Header.Get("Accept"), "application/json") {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(model.MapToJson(map[string]string{"id": post.Id})))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("ok"))
+}
+
+func commandWebhook(c *Context, w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+	id := params["id"]
+
+	// the token is part of the response_url, but integrations may also send it in a header
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = app.ParseAuthTokenFromRequest(r)
+	}
+
+	response := model.CommandResponseFromJson(r.Body)
+
+	err := app.HandleCommandWebhook(id, token, response, c.GetSiteURL())
 	if err != nil {
 		c.Err = err
 		return
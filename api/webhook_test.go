@@ -4,10 +4,18 @@
 package api
 
 import (
+	"bytes"
 	"fmt"
+	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"strings"
 	"testing"
 
+	"github.com/mattermost/platform/app"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 )
@@ -1097,3 +1105,256 @@ func TestIncomingWebhooks(t *testing.T) {
 		t.Fatal("should have failed - webhooks turned off")
 	}
 }
+
+func TestIncomingWebhookFilesAndReplies(t *testing.T) {
+	th := Setup().InitSystemAdmin()
+	Client := th.SystemAdminClient
+	team := th.SystemAdminTeam
+	channel1 := th.CreateChannel(Client, team)
+	channel2 := th.CreateChannel(Client, team)
+
+	enableIncomingHooks := utils.Cfg.ServiceSettings.EnableIncomingWebhooks
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableIncomingWebhooks = enableIncomingHooks
+	}()
+	utils.Cfg.ServiceSettings.EnableIncomingWebhooks = true
+
+	hook := &model.IncomingWebhook{ChannelId: channel1.Id}
+	hook = Client.Must(Client.CreateIncomingWebhook(hook)).Data.(*model.IncomingWebhook)
+
+	url := "/hooks/" + hook.Id
+
+	postToHook := func(payload string, fileCount int) (map[string]string, *model.AppError) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		writer.WriteField("payload", payload)
+
+		for i := 0; i < fileCount; i++ {
+			part, _ := writer.CreateFormFile("files", fmt.Sprintf("report%v.txt", i))
+			part.Write([]byte("all tests passed"))
+		}
+		writer.Close()
+
+		if r, err := Client.DoPost(url, body.String(), writer.FormDataContentType()); err != nil {
+			return nil, err
+		} else {
+			defer r.Body.Close()
+			return model.MapFromJson(r.Body), nil
+		}
+	}
+
+	result, err := postToHook(`{"text": "build started"}`, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rootId := result["id"]
+	if len(rootId) != 26 {
+		t.Fatal("should have returned the post id")
+	}
+
+	result, err = postToHook(fmt.Sprintf(`{"root_id": "%v"}`, rootId), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reply := Client.Must(Client.GetPost(channel1.Id, result["id"], "")).Data.(*model.PostList).Posts[result["id"]]
+	if reply.RootId != rootId {
+		t.Fatal("should have replied to the thread")
+	}
+
+	if len(reply.FileIds) != 2 {
+		t.Fatal("should have attached the files")
+	}
+
+	result, err = postToHook(fmt.Sprintf(`{"text": "nested", "root_id": "%v"}`, reply.Id), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	nested := Client.Must(Client.GetPost(channel1.Id, result["id"], "")).Data.(*model.PostList).Posts[result["id"]]
+	if nested.RootId != rootId {
+		t.Fatal("replying to a reply should post in the same thread")
+	}
+
+	if _, err := postToHook(`{"text": "too many"}`, model.INCOMING_WEBHOOK_MAX_FILES+1); err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should have failed - too many files")
+	}
+
+	if _, err := postToHook(`{"text": "bad root", "root_id": "junk"}`, 0); err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should have failed - invalid root id")
+	}
+
+	otherPost := Client.Must(Client.CreatePost(&model.Post{ChannelId: channel2.Id, Message: "other"})).Data.(*model.Post)
+	if _, err := postToHook(fmt.Sprintf(`{"text": "wrong channel", "root_id": "%v"}`, otherPost.Id), 0); err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should have failed - root post in another channel")
+	}
+
+	if r, err := Client.DoPost(url, `{"text": "plain"}`, "application/json"); err != nil {
+		t.Fatal(err)
+	} else {
+		defer r.Body.Close()
+		if body, _ := ioutil.ReadAll(r.Body); string(body) != "ok" {
+			t.Fatal("should have kept the slack compatible response")
+		}
+	}
+}
+
+func TestCommandWebhooks(t *testing.T) {
+	th := Setup().InitSystemAdmin()
+	Client := th.SystemAdminClient
+	team := th.SystemAdminTeam
+	channel := th.CreateChannel(Client, team)
+
+	enableCommands := *utils.Cfg.ServiceSettings.EnableCommands
+	maxUses := *utils.Cfg.ServiceSettings.CommandWebhookMaxUses
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableCommands = &enableCommands
+		utils.Cfg.ServiceSettings.CommandWebhookMaxUses = &maxUses
+	}()
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+	*utils.Cfg.ServiceSettings.CommandWebhookMaxUses = 2
+
+	responseURL := ""
+	responseToken := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		responseURL = r.FormValue("response_url")
+		responseToken = r.FormValue("response_token")
+		w.Write([]byte(`{"response_type": "in_channel", "text": "working on it"}`))
+	}))
+	defer ts.Close()
+
+	cmd := &model.Command{URL: ts.URL, Method: model.COMMAND_METHOD_POST, Trigger: "delayed"}
+	Client.Must(Client.CreateCommand(cmd))
+
+	Client.Must(Client.Command(channel.Id, "/delayed"))
+
+	if !strings.Contains(responseURL, model.API_URL_SUFFIX+"/hooks/commands/") {
+		t.Fatal("should have sent a response url, got " + responseURL)
+	}
+
+	parsed, err := url.Parse(responseURL)
+	if err != nil {
+		t.Fatal(err)
+	} else if parsed.Query().Get("token") != responseToken || len(responseToken) != 26 {
+		t.Fatal("should have sent the response token")
+	}
+
+	// this api version still serves the webhook from its old address
+	hookPath := "/hooks/commands/" + path.Base(parsed.Path)
+	hookURI := hookPath + "?token=" + responseToken
+
+	if _, err := Client.DoPost(hookPath, `{"response_type": "in_channel", "text": "done"}`, "application/json"); err == nil || err.StatusCode != http.StatusUnauthorized {
+		t.Fatal("should have failed - missing token")
+	}
+
+	if _, err := Client.DoPost(hookPath+"?token="+model.NewId(), `{"response_type": "in_channel", "text": "done"}`, "application/json"); err == nil || err.StatusCode != http.StatusUnauthorized {
+		t.Fatal("should have failed - wrong token")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableCommands = false
+	if _, err := Client.DoPost(hookURI, `{"response_type": "in_channel", "text": "done"}`, "application/json"); err == nil || err.StatusCode != http.StatusNotImplemented {
+		t.Fatal("should have failed - commands disabled")
+	}
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+
+	for i := 0; i < *utils.Cfg.ServiceSettings.CommandWebhookMaxUses; i++ {
+		if _, err := Client.DoPost(hookURI, `{"response_type": "in_channel", "text": "done"}`, "application/json"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := Client.DoPost(hookURI, `{"response_type": "in_channel", "text": "done"}`, "application/json"); err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should have failed - use limit reached")
+	}
+
+	posts := Client.Must(Client.GetPosts(channel.Id, 0, 20, "")).Data.(*model.PostList)
+	count := 0
+	for _, post := range posts.Posts {
+		if post.Message == "done" {
+			count++
+		}
+	}
+
+	if count != *utils.Cfg.ServiceSettings.CommandWebhookMaxUses {
+		t.Fatal("should have posted the delayed responses")
+	}
+
+	if _, err := Client.DoPost("/hooks/commands/"+model.NewId(), `{"text": "done"}`, "application/json"); err == nil || err.StatusCode != http.StatusNotFound {
+		t.Fatal("should have failed - bad hook id")
+	}
+
+	if _, err := Client.DoPost(hookURI, "junk", "application/json"); err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should have failed - bad payload")
+	}
+}
+
+func TestIncomingWebhookRestrictions(t *testing.T) {
+	th := Setup().InitSystemAdmin()
+	Client := th.SystemAdminClient
+	team := th.SystemAdminTeam
+	channel1 := th.CreateChannel(Client, team)
+	channel2 := th.CreateChannel(Client, team)
+
+	enableIncomingHooks := utils.Cfg.ServiceSettings.EnableIncomingWebhooks
+	enableUsernameOverride := utils.Cfg.ServiceSettings.EnablePostUsernameOverride
+	enableIconOverride := utils.Cfg.ServiceSettings.EnablePostIconOverride
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableIncomingWebhooks = enableIncomingHooks
+		utils.Cfg.ServiceSettings.EnablePostUsernameOverride = enableUsernameOverride
+		utils.Cfg.ServiceSettings.EnablePostIconOverride = enableIconOverride
+	}()
+	utils.Cfg.ServiceSettings.EnableIncomingWebhooks = true
+	utils.Cfg.ServiceSettings.EnablePostUsernameOverride = true
+	utils.Cfg.ServiceSettings.EnablePostIconOverride = true
+
+	hook := &model.IncomingWebhook{ChannelId: channel1.Id, ChannelLocked: true, DisableUsernameOverride: true, DisableIconOverride: true, RateLimit: 3, PostCount: 100, LastUsedAt: model.GetMillis()}
+	hook = Client.Must(Client.CreateIncomingWebhook(hook)).Data.(*model.IncomingWebhook)
+	if hook.PostCount != 0 || hook.LastUsedAt != 0 {
+		t.Fatal("shouldn't have saved the usage sent by the client")
+	}
+
+	url := "/hooks/" + hook.Id
+
+	postToHook := func(payload string) *model.AppError {
+		if r, err := Client.DoPost(url, payload, "application/json"); err != nil {
+			return err
+		} else {
+			r.Body.Close()
+			return nil
+		}
+	}
+
+	if err := postToHook(fmt.Sprintf(`{"text": "locked", "channel": "%v"}`, channel2.Name)); err == nil || err.StatusCode != http.StatusForbidden {
+		t.Fatal("should have failed - hook is locked to its channel")
+	}
+
+	if err := postToHook(fmt.Sprintf(`{"text": "locked", "channel": "@%v"}`, th.SystemAdminUser.Username)); err == nil || err.StatusCode != http.StatusForbidden {
+		t.Fatal("should have failed - hook is locked to its channel")
+	}
+
+	if err := postToHook(fmt.Sprintf(`{"text": "same channel", "channel": "%v", "username": "imposter", "icon_url": "http://example.com/icon.png"}`, channel1.Name)); err != nil {
+		t.Fatal(err)
+	}
+
+	postList := Client.Must(Client.GetPosts(channel1.Id, 0, 1, "")).Data.(*model.PostList)
+	post := postList.Posts[postList.Order[0]]
+	if post.Props["override_username"] != model.DEFAULT_WEBHOOK_USERNAME {
+		t.Fatal("shouldn't have overridden the username")
+	}
+	if _, ok := post.Props["override_icon_url"]; ok {
+		t.Fatal("shouldn't have overridden the icon")
+	}
+
+	if err := postToHook(`{"text": "over the limit"}`); err == nil || err.StatusCode != http.StatusTooManyRequests {
+		t.Fatal("should have failed - rate limit exceeded")
+	}
+
+	// the hook is cached by now, so this makes sure that the usage isn't read from the cache
+	if usedHook, err := app.GetIncomingWebhook(hook.Id); err != nil {
+		t.Fatal(err)
+	} else if usedHook.PostCount != 1 || usedHook.LastUsedAt != post.CreateAt {
+		t.Fatal("should have recorded the hook's usage")
+	}
+}
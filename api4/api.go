@@ -47,6 +47,9 @@ type Routes struct {
 	Post            *mux.Router // 'api/v4/posts/{post_id:[A-Za-z0-9]+}'
 	PostsForChannel *mux.Router // 'api/v4/channels/{channel_id:[A-Za-z0-9]+}/posts'
 
+	Reactions                    *mux.Router // 'api/v4/reactions'
+	ReactionByNameForPostForUser *mux.Router // 'api/v4/users/{user_id:[A-Za-z0-9]+}/posts/{post_id:[A-Za-z0-9]+}/reactions/{emoji_name:[A-Za-z0-9_-+]+}'
+
 	Files *mux.Router // 'api/v4/files'
 	File  *mux.Router // 'api/v4/files/{file_id:[A-Za-z0-9]+}'
 
@@ -64,6 +67,8 @@ type Routes struct {
 
 	Admin      *mux.Router // 'api/v4/admin'
 	OAuth      *mux.Router // 'api/v4/oauth'
+	OAuthApps  *mux.Router // 'api/v4/oauth/apps'
+	OAuthApp   *mux.Router // 'api/v4/oauth/apps/{app_id:[A-Za-z0-9]+}'
 	SAML       *mux.Router // 'api/v4/saml'
 	Compliance *mux.Router // 'api/v4/compliance'
 	Cluster    *mux.Router // 'api/v4/cluster'
@@ -84,6 +89,18 @@ type Routes struct {
 	Emoji  *mux.Router // 'api/v4/emoji/{emoji_id:[A-Za-z0-9]+}'
 
 	Webrtc *mux.Router // 'api/v4/webrtc'
+
+	LoginLockouts *mux.Router // 'api/v4/login_lockouts'
+	LoginLockout  *mux.Router // 'api/v4/login_lockouts/{lockout_id:[A-Za-z0-9]+}'
+
+	LegalHolds *mux.Router // 'api/v4/legal_holds'
+	LegalHold  *mux.Router // 'api/v4/legal_holds/{legal_hold_id:[A-Za-z0-9]+}'
+
+	MentionGroups        *mux.Router // 'api/v4/mention_groups'
+	MentionGroup         *mux.Router // 'api/v4/mention_groups/{mention_group_id:[A-Za-z0-9]+}'
+	MentionGroupsForTeam *mux.Router // 'api/v4/teams/{team_id:[A-Za-z0-9]+}/mention_groups'
+
+	Image *mux.Router // 'api/v4/image'
 }
 
 var BaseRoutes *Routes
@@ -126,6 +143,9 @@ func InitApi(full bool) {
 	BaseRoutes.Post = BaseRoutes.Posts.PathPrefix("/{post_id:[A-Za-z0-9]+}").Subrouter()
 	BaseRoutes.PostsForChannel = BaseRoutes.Channel.PathPrefix("/posts").Subrouter()
 
+	BaseRoutes.Reactions = BaseRoutes.ApiRoot.PathPrefix("/reactions").Subrouter()
+	BaseRoutes.ReactionByNameForPostForUser = BaseRoutes.User.PathPrefix("/posts/{post_id:[A-Za-z0-9]+}/reactions/{emoji_name:[A-Za-z0-9\\_\\-\\+]+}").Subrouter()
+
 	BaseRoutes.Files = BaseRoutes.ApiRoot.PathPrefix("/files").Subrouter()
 	BaseRoutes.File = BaseRoutes.Files.PathPrefix("/{file_id:[A-Za-z0-9]+}").Subrouter()
 	BaseRoutes.PublicFile = BaseRoutes.Root.PathPrefix("/files/{file_id:[A-Za-z0-9]+}/public").Subrouter()
@@ -142,6 +162,8 @@ func InitApi(full bool) {
 
 	BaseRoutes.SAML = BaseRoutes.ApiRoot.PathPrefix("/saml").Subrouter()
 	BaseRoutes.OAuth = BaseRoutes.ApiRoot.PathPrefix("/oauth").Subrouter()
+	BaseRoutes.OAuthApps = BaseRoutes.OAuth.PathPrefix("/apps").Subrouter()
+	BaseRoutes.OAuthApp = BaseRoutes.OAuthApps.PathPrefix("/{app_id:[A-Za-z0-9]+}").Subrouter()
 	BaseRoutes.Admin = BaseRoutes.ApiRoot.PathPrefix("/admin").Subrouter()
 	BaseRoutes.Compliance = BaseRoutes.ApiRoot.PathPrefix("/compliance").Subrouter()
 	BaseRoutes.Cluster = BaseRoutes.ApiRoot.PathPrefix("/cluster").Subrouter()
@@ -157,6 +179,18 @@ func InitApi(full bool) {
 
 	BaseRoutes.Webrtc = BaseRoutes.ApiRoot.PathPrefix("/webrtc").Subrouter()
 
+	BaseRoutes.LoginLockouts = BaseRoutes.ApiRoot.PathPrefix("/login_lockouts").Subrouter()
+	BaseRoutes.LoginLockout = BaseRoutes.LoginLockouts.PathPrefix("/{lockout_id:[A-Za-z0-9]+}").Subrouter()
+
+	BaseRoutes.LegalHolds = BaseRoutes.ApiRoot.PathPrefix("/legal_holds").Subrouter()
+	BaseRoutes.LegalHold = BaseRoutes.LegalHolds.PathPrefix("/{legal_hold_id:[A-Za-z0-9]+}").Subrouter()
+
+	BaseRoutes.MentionGroups = BaseRoutes.ApiRoot.PathPrefix("/mention_groups").Subrouter()
+	BaseRoutes.MentionGroup = BaseRoutes.MentionGroups.PathPrefix("/{mention_group_id:[A-Za-z0-9]+}").Subrouter()
+	BaseRoutes.MentionGroupsForTeam = BaseRoutes.Team.PathPrefix("/mention_groups").Subrouter()
+
+	BaseRoutes.Image = BaseRoutes.ApiRoot.PathPrefix("/image").Subrouter()
+
 	InitUser()
 	InitTeam()
 	InitChannel()
@@ -172,6 +206,16 @@ func InitApi(full bool) {
 	InitBrand()
 	InitCommand()
 	InitStatus()
+	InitLoginLockout()
+	InitLegalHold()
+	InitMentionGroup()
+	InitEmailDigest()
+	InitEmoji()
+	InitReaction()
+	InitOAuth()
+	InitLicense()
+	InitWebrtc()
+	InitImage()
 
 	app.Srv.Router.Handle("/api/v4/{anything:.*}", http.HandlerFunc(Handle404))
 
@@ -46,6 +46,8 @@ func SetupEnterprise() *TestHelper {
 		utils.InitTranslations(utils.Cfg.LocalizationSettings)
 		utils.Cfg.TeamSettings.MaxUsersPerTeam = 50
 		*utils.Cfg.RateLimitSettings.Enable = false
+		*utils.Cfg.ServiceSettings.EnableLoginLockout = false
+		*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = "localhost 127.0.0.1"
 		utils.Cfg.EmailSettings.SendEmailNotifications = true
 		utils.Cfg.EmailSettings.SMTPServer = "dockerhost"
 		utils.Cfg.EmailSettings.SMTPPort = "2500"
@@ -77,6 +79,8 @@ func Setup() *TestHelper {
 		utils.InitTranslations(utils.Cfg.LocalizationSettings)
 		utils.Cfg.TeamSettings.MaxUsersPerTeam = 50
 		*utils.Cfg.RateLimitSettings.Enable = false
+		*utils.Cfg.ServiceSettings.EnableLoginLockout = false
+		*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = "localhost 127.0.0.1"
 		utils.Cfg.EmailSettings.SendEmailNotifications = true
 		utils.Cfg.EmailSettings.SMTPServer = "dockerhost"
 		utils.Cfg.EmailSettings.SMTPPort = "2500"
@@ -358,6 +362,10 @@ func GenerateTestChannelName() string {
 	return "fakechannel" + model.NewRandomString(10)
 }
 
+func GenerateTestAppName() string {
+	return "fakeoauthapp" + model.NewRandomString(10)
+}
+
 func GenerateTestId() string {
 	return model.NewId()
 }
@@ -551,8 +559,10 @@ func cleanupTestFile(info *model.FileInfo) error {
 			return err
 		}
 		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
-		if err := s3Clnt.RemoveObject(bucket, info.Path); err != nil {
-			return err
+		if info.ContentHash == "" {
+			if err := s3Clnt.RemoveObject(bucket, info.Path); err != nil {
+				return err
+			}
 		}
 
 		if info.ThumbnailPath != "" {
@@ -567,8 +577,11 @@ func cleanupTestFile(info *model.FileInfo) error {
 			}
 		}
 	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
-		if err := os.Remove(utils.Cfg.FileSettings.Directory + info.Path); err != nil {
-			return err
+		// Files with the same content are shared between tests, so they're left in place
+		if info.ContentHash == "" {
+			if err := os.Remove(utils.Cfg.FileSettings.Directory + info.Path); err != nil {
+				return err
+			}
 		}
 
 		if info.ThumbnailPath != "" {
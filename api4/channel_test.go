@@ -1338,6 +1338,51 @@ func TestUpdateChannelRoles(t *testing.T) {
 	CheckForbiddenStatus(t, resp)
 }
 
+func TestUpdateChannelNotifyProps(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	keywords := model.MentionKeywordsToJson([]*model.MentionKeyword{{Keyword: "deploy", WholeWord: true}})
+	props := map[string]string{
+		model.DESKTOP_NOTIFY_PROP:  model.CHANNEL_NOTIFY_MENTION,
+		model.KEYWORDS_NOTIFY_PROP: keywords,
+	}
+
+	pass, resp := Client.UpdateChannelNotifyProps(th.BasicChannel.Id, th.BasicUser.Id, props)
+	CheckNoError(t, resp)
+
+	if !pass {
+		t.Fatal("should have passed")
+	}
+
+	member, err := app.GetChannelMember(th.BasicChannel.Id, th.BasicUser.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if member.NotifyProps[model.DESKTOP_NOTIFY_PROP] != model.CHANNEL_NOTIFY_MENTION || member.NotifyProps[model.KEYWORDS_NOTIFY_PROP] != keywords {
+		t.Fatal("bad update", member.NotifyProps)
+	}
+
+	props[model.KEYWORDS_NOTIFY_PROP] = model.MentionKeywordsToJson([]*model.MentionKeyword{{Keyword: "(", Regex: true}})
+	_, resp = Client.UpdateChannelNotifyProps(th.BasicChannel.Id, th.BasicUser.Id, props)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.UpdateChannelNotifyProps(th.BasicChannel.Id, th.BasicUser2.Id, props)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.UpdateChannelNotifyProps("junk", th.BasicUser.Id, props)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.UpdateChannelNotifyProps(th.BasicChannel.Id, th.BasicUser.Id, map[string]string{model.MARK_UNREAD_NOTIFY_PROP: model.CHANNEL_MARK_UNREAD_MENTION})
+	CheckNoError(t, resp)
+
+	Client.Logout()
+	_, resp = Client.UpdateChannelNotifyProps(th.BasicChannel.Id, th.BasicUser.Id, props)
+	CheckUnauthorizedStatus(t, resp)
+}
+
 func TestAddChannelMember(t *testing.T) {
 	th := Setup().InitBasic().InitSystemAdmin()
 	defer TearDown()
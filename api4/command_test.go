@@ -4,9 +4,12 @@
 package api4
 
 import (
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	// "time"
 
+	"github.com/mattermost/platform/app"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 )
@@ -58,3 +61,391 @@ func TestCreateCommand(t *testing.T) {
 	CheckNotImplementedStatus(t, resp)
 	CheckErrorMessage(t, resp, "api.command.disabled.app_error")
 }
+
+func TestUpdateCommand(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.SystemAdminClient
+	user := th.SystemAdminUser
+	team := th.BasicTeam
+
+	enableCommands := *utils.Cfg.ServiceSettings.EnableCommands
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableCommands = &enableCommands
+	}()
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+
+	cmd1 := &model.Command{
+		CreatorId: user.Id,
+		TeamId:    team.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.COMMAND_METHOD_POST,
+		Trigger:   "trigger1",
+	}
+
+	cmd1, _ = app.CreateCommand(cmd1)
+
+	cmd2 := &model.Command{
+		CreatorId: GenerateTestId(),
+		TeamId:    team.Id,
+		URL:       "http://nowhere.com/change",
+		Method:    model.COMMAND_METHOD_GET,
+		Trigger:   "trigger2",
+		Id:        cmd1.Id,
+		Token:     "tokenchange",
+	}
+
+	rcmd, resp := Client.UpdateCommand(cmd2)
+	CheckNoError(t, resp)
+
+	if rcmd.Trigger != cmd2.Trigger {
+		t.Fatal("Trigger should have updated")
+	}
+
+	if rcmd.Method != cmd2.Method {
+		t.Fatal("Method should have updated")
+	}
+
+	if rcmd.URL != cmd2.URL {
+		t.Fatal("URL should have updated")
+	}
+
+	if rcmd.CreatorId != cmd1.CreatorId {
+		t.Fatal("CreatorId should have not updated")
+	}
+
+	if rcmd.Token != cmd1.Token {
+		t.Fatal("Token should have not updated")
+	}
+
+	cmd2.Id = GenerateTestId()
+
+	rcmd, resp = Client.UpdateCommand(cmd2)
+	CheckNotFoundStatus(t, resp)
+
+	if rcmd != nil {
+		t.Fatal("should be empty")
+	}
+
+	cmd2.Id = "junk"
+
+	_, resp = Client.UpdateCommand(cmd2)
+	CheckBadRequestStatus(t, resp)
+
+	cmd2.Id = cmd1.Id
+	cmd2.TeamId = GenerateTestId()
+
+	_, resp = Client.UpdateCommand(cmd2)
+	CheckBadRequestStatus(t, resp)
+
+	cmd2.TeamId = team.Id
+
+	_, resp = th.Client.UpdateCommand(cmd2)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.UpdateCommand(cmd2)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestDeleteCommand(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.SystemAdminClient
+	user := th.SystemAdminUser
+	team := th.BasicTeam
+
+	enableCommands := *utils.Cfg.ServiceSettings.EnableCommands
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableCommands = &enableCommands
+	}()
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+
+	cmd1 := &model.Command{
+		CreatorId: user.Id,
+		TeamId:    team.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.COMMAND_METHOD_POST,
+		Trigger:   "trigger1",
+	}
+
+	rcmd1, _ := app.CreateCommand(cmd1)
+
+	_, resp := th.Client.DeleteCommand(rcmd1.Id)
+	CheckForbiddenStatus(t, resp)
+
+	ok, resp := Client.DeleteCommand(rcmd1.Id)
+	CheckNoError(t, resp)
+
+	if !ok {
+		t.Fatal("should have returned true")
+	}
+
+	rcmd1, _ = app.GetCommand(rcmd1.Id)
+	if rcmd1 != nil {
+		t.Fatal("should be nil")
+	}
+
+	ok, resp = Client.DeleteCommand("junk")
+	CheckBadRequestStatus(t, resp)
+
+	if ok {
+		t.Fatal("should have returned false")
+	}
+
+	_, resp = Client.DeleteCommand(GenerateTestId())
+	CheckNotFoundStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.DeleteCommand(GenerateTestId())
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestListCommands(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	enableCommands := *utils.Cfg.ServiceSettings.EnableCommands
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableCommands = &enableCommands
+	}()
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+
+	newCmd := &model.Command{
+		CreatorId:    th.BasicUser.Id,
+		TeamId:       th.BasicTeam.Id,
+		URL:          "http://nowhere.com",
+		Method:       model.COMMAND_METHOD_POST,
+		Trigger:      "custom_command",
+		AutoComplete: true,
+	}
+
+	_, resp := th.SystemAdminClient.CreateCommand(newCmd)
+	CheckNoError(t, resp)
+
+	listCommands, resp := Client.ListCommands(th.BasicTeam.Id, false)
+	CheckNoError(t, resp)
+
+	foundEcho := false
+	foundCustom := false
+	for _, command := range listCommands {
+		if command.Trigger == "echo" {
+			foundEcho = true
+		}
+		if command.Trigger == "custom_command" {
+			foundCustom = true
+		}
+	}
+	if !foundEcho {
+		t.Fatal("Couldn't find echo command")
+	}
+	if !foundCustom {
+		t.Fatal("Should list the custom command")
+	}
+
+	_, resp = Client.ListCommands(th.BasicTeam.Id, true)
+	CheckForbiddenStatus(t, resp)
+
+	listCommands, resp = th.SystemAdminClient.ListCommands(th.BasicTeam.Id, true)
+	CheckNoError(t, resp)
+
+	if len(listCommands) != 1 || listCommands[0].Trigger != "custom_command" {
+		t.Fatal("should only list the custom command")
+	}
+
+	_, resp = Client.ListCommands("junk", false)
+	CheckBadRequestStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.ListCommands(th.BasicTeam.Id, false)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestRegenToken(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	enableCommands := *utils.Cfg.ServiceSettings.EnableCommands
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableCommands = &enableCommands
+	}()
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+
+	newCmd := &model.Command{
+		CreatorId: th.BasicUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       "http://nowhere.com",
+		Method:    model.COMMAND_METHOD_POST,
+		Trigger:   "trigger"}
+
+	createdCmd, resp := th.SystemAdminClient.CreateCommand(newCmd)
+	CheckNoError(t, resp)
+
+	token, resp := th.SystemAdminClient.RegenCommandToken(createdCmd.Id)
+	CheckNoError(t, resp)
+	if token == createdCmd.Token {
+		t.Fatal("should update the token")
+	}
+
+	token, resp = Client.RegenCommandToken(createdCmd.Id)
+	CheckForbiddenStatus(t, resp)
+	if token != "" {
+		t.Fatal("should not return the token")
+	}
+}
+
+func TestGetCommandAutocompleteSuggestions(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	enableCommands := *utils.Cfg.ServiceSettings.EnableCommands
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableCommands = &enableCommands
+	}()
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+
+	var query map[string][]string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte(model.AutocompleteListItemsToJson([]*model.AutocompleteListItem{{Item: "red"}, {Item: "green"}})))
+	}))
+	defer ts.Close()
+
+	cmd := &model.Command{
+		CreatorId: th.SystemAdminUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       ts.URL,
+		Method:    model.COMMAND_METHOD_POST,
+		Trigger:   "paint",
+		AutocompleteArgs: model.AutocompleteArgList{
+			{
+				HelpText: "color",
+				Type:     model.AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST,
+			},
+			{
+				Name:     "finish",
+				HelpText: "finish",
+				Type:     model.AUTOCOMPLETE_ARG_TYPE_STATIC_LIST,
+				Choices:  []*model.AutocompleteListItem{{Item: "matte"}, {Item: "gloss"}},
+			},
+		},
+	}
+	if _, err := app.CreateCommand(cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	suggestions, resp := Client.GetCommandAutocompleteSuggestions("/ec", th.BasicTeam.Id, "")
+	CheckNoError(t, resp)
+
+	if len(suggestions) == 0 || suggestions[0].Complete != "/echo" {
+		t.Fatal("should have suggested the echo command")
+	}
+
+	suggestions, resp = Client.GetCommandAutocompleteSuggestions("/join "+th.BasicChannel.Name[:5], th.BasicTeam.Id, th.BasicChannel.Id)
+	CheckNoError(t, resp)
+
+	found := false
+	for _, suggestion := range suggestions {
+		if suggestion.Complete == "/join "+th.BasicChannel.Name {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should have suggested the channel")
+	}
+
+	suggestions, resp = Client.GetCommandAutocompleteSuggestions("/paint gr", th.BasicTeam.Id, th.BasicChannel.Id)
+	CheckNoError(t, resp)
+
+	if len(suggestions) != 1 || suggestions[0].Complete != "/paint green" {
+		t.Fatal("should have suggested the values from the integration")
+	}
+
+	if query["token"][0] != cmd.Token || query["channel_id"][0] != th.BasicChannel.Id || query["user_id"][0] != th.BasicUser.Id || query["partial"][0] != "gr" {
+		t.Fatal("integration should have received the command context")
+	}
+
+	suggestions, resp = Client.GetCommandAutocompleteSuggestions("/paint red --", th.BasicTeam.Id, "")
+	CheckNoError(t, resp)
+
+	if len(suggestions) != 1 || suggestions[0].Complete != "/paint red --finish" {
+		t.Fatal("should have suggested the flag")
+	}
+
+	suggestions, resp = Client.GetCommandAutocompleteSuggestions("/paint red --finish ", th.BasicTeam.Id, "")
+	CheckNoError(t, resp)
+
+	if len(suggestions) != 2 || suggestions[0].Suggestion != "gloss" {
+		t.Fatal("should have suggested the static choices")
+	}
+
+	suggestions, resp = Client.GetCommandAutocompleteSuggestions("/junk ", th.BasicTeam.Id, "")
+	CheckNoError(t, resp)
+
+	if len(suggestions) != 0 {
+		t.Fatal("should not have suggested anything")
+	}
+
+	_, resp = Client.GetCommandAutocompleteSuggestions("/ec", "junk", "")
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.GetCommandAutocompleteSuggestions("/ec", model.NewId(), "")
+	CheckForbiddenStatus(t, resp)
+
+	privateChannel := th.CreateChannelWithClient(th.SystemAdminClient, model.CHANNEL_PRIVATE)
+	_, resp = Client.GetCommandAutocompleteSuggestions("/paint ", th.BasicTeam.Id, privateChannel.Id)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetCommandAutocompleteSuggestions("/ec", th.BasicTeam.Id, "")
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestExecuteCommand(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	enableCommands := *utils.Cfg.ServiceSettings.EnableCommands
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableCommands = &enableCommands
+	}()
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+
+	commandResponse, resp := Client.ExecuteCommand(channel.Id, "/echo hello")
+	CheckNoError(t, resp)
+
+	if commandResponse == nil {
+		t.Fatal("command response should have returned")
+	}
+
+	_, resp = Client.ExecuteCommand(channel.Id, "echo")
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.ExecuteCommand("junk", "/echo hello")
+	CheckBadRequestStatus(t, resp)
+
+	// can't run commands in channels the user doesn't belong to
+	privateChannel := th.CreateChannelWithClient(th.SystemAdminClient, model.CHANNEL_PRIVATE)
+	_, resp = Client.ExecuteCommand(privateChannel.Id, "/echo hello")
+	CheckForbiddenStatus(t, resp)
+
+	// direct channels need a team the user belongs to
+	dmChannel, resp := Client.CreateDirectChannel(th.BasicUser.Id, th.BasicUser2.Id)
+	CheckNoError(t, resp)
+
+	_, resp = Client.ExecuteCommandWithTeam(dmChannel.Id, th.BasicTeam.Id, "/echo hello")
+	CheckNoError(t, resp)
+
+	_, resp = Client.ExecuteCommandWithTeam(dmChannel.Id, model.NewId(), "/echo hello")
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.ExecuteCommand(channel.Id, "/echo hello")
+	CheckUnauthorizedStatus(t, resp)
+}
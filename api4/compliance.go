@@ -21,6 +21,11 @@ func InitCompliance() {
 	BaseRoutes.Compliance.Handle("/reports", ApiSessionRequired(getComplianceReports)).Methods("GET")
 	BaseRoutes.Compliance.Handle("/reports/{report_id:[A-Za-z0-9]+}", ApiSessionRequired(getComplianceReport)).Methods("GET")
 	BaseRoutes.Compliance.Handle("/reports/{report_id:[A-Za-z0-9]+}/download", ApiSessionRequired(downloadComplianceReport)).Methods("GET")
+
+	BaseRoutes.Compliance.Handle("/exports", ApiSessionRequired(createComplianceExport)).Methods("POST")
+	BaseRoutes.Compliance.Handle("/exports", ApiSessionRequired(getComplianceExports)).Methods("GET")
+	BaseRoutes.Compliance.Handle("/exports/{report_id:[A-Za-z0-9]+}", ApiSessionRequired(getComplianceExport)).Methods("GET")
+	BaseRoutes.Compliance.Handle("/exports/{report_id:[A-Za-z0-9]+}/download", ApiSessionRequired(downloadComplianceExport)).Methods("GET")
 }
 
 func createComplianceReport(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -100,13 +105,17 @@ func downloadComplianceReport(c *Context, w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	writeComplianceFile(c, w, r, job, "downloaded "+job.Desc)
+}
+
+func writeComplianceFile(c *Context, w http.ResponseWriter, r *http.Request, job *model.Compliance, audit string) {
 	reportBytes, err := app.GetComplianceFile(job)
 	if err != nil {
 		c.Err = err
 		return
 	}
 
-	c.LogAudit("downloaded " + job.Desc)
+	c.LogAudit(audit)
 
 	w.Header().Set("Cache-Control", "max-age=2592000, public")
 	w.Header().Set("Content-Length", strconv.Itoa(len(reportBytes)))
@@ -125,3 +134,80 @@ func downloadComplianceReport(c *Context, w http.ResponseWriter, r *http.Request
 
 	w.Write(reportBytes)
 }
+
+func createComplianceExport(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	job, err := app.StartComplianceExport(c.Session.UserId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("")
+	w.WriteHeader(http.StatusCreated)
+	w.Write([]byte(job.ToJson()))
+}
+
+func getComplianceExports(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	jobs, err := app.GetComplianceExports(c.Params.Page, c.Params.PerPage)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(jobs.ToJson()))
+}
+
+func getComplianceExport(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireReportId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	job, err := app.GetComplianceExport(c.Params.ReportId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Write([]byte(job.ToJson()))
+}
+
+func downloadComplianceExport(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireReportId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	job, err := app.GetComplianceExport(c.Params.ReportId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if job.Status != model.COMPLIANCE_STATUS_FINISHED {
+		c.Err = model.NewAppError("downloadComplianceExport", "api.compliance.export.not_finished.app_error", nil, "status="+job.Status, http.StatusBadRequest)
+		return
+	}
+
+	writeComplianceFile(c, w, r, job, "downloaded "+job.JobName())
+}
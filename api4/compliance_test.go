@@ -0,0 +1,83 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestComplianceExports(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	enableExport := *utils.Cfg.ComplianceSettings.EnableExport
+	exportFormat := *utils.Cfg.ComplianceSettings.ExportFormat
+	defer func() {
+		*utils.Cfg.ComplianceSettings.EnableExport = enableExport
+		*utils.Cfg.ComplianceSettings.ExportFormat = exportFormat
+	}()
+
+	*utils.Cfg.ComplianceSettings.EnableExport = false
+	_, resp := th.SystemAdminClient.CreateComplianceExport()
+	CheckNotImplementedStatus(t, resp)
+
+	*utils.Cfg.ComplianceSettings.EnableExport = true
+	*utils.Cfg.ComplianceSettings.ExportFormat = model.COMPLIANCE_EXPORT_FORMAT_CSV
+
+	_, resp = Client.CreateComplianceExport()
+	CheckForbiddenStatus(t, resp)
+
+	job, resp := th.SystemAdminClient.CreateComplianceExport()
+	CheckNoError(t, resp)
+
+	if job.Type != model.COMPLIANCE_TYPE_EXPORT || job.Desc != model.COMPLIANCE_EXPORT_FORMAT_CSV {
+		t.Fatal("created wrong job", job)
+	}
+
+	for i := 0; i < 50 && job.Status == model.COMPLIANCE_STATUS_RUNNING; i++ {
+		time.Sleep(100 * time.Millisecond)
+
+		job, resp = th.SystemAdminClient.GetComplianceExport(job.Id)
+		CheckNoError(t, resp)
+	}
+
+	if job.Status != model.COMPLIANCE_STATUS_FINISHED {
+		t.Fatal("export should've finished", job.Status)
+	}
+
+	_, resp = Client.GetComplianceExport(job.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetComplianceExport(model.NewId())
+	CheckNotFoundStatus(t, resp)
+
+	jobs, resp := th.SystemAdminClient.GetComplianceExports(0, 10)
+	CheckNoError(t, resp)
+
+	if len(jobs) == 0 || jobs[0].Id != job.Id {
+		t.Fatal("should've returned the export")
+	}
+
+	_, resp = Client.GetComplianceExports(0, 10)
+	CheckForbiddenStatus(t, resp)
+
+	data, resp := th.SystemAdminClient.DownloadComplianceExport(job.Id)
+	CheckNoError(t, resp)
+
+	if reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatal(err)
+	} else if len(reader.File) != 1 || reader.File[0].Name != "posts.csv" {
+		t.Fatal("should've downloaded the csv export")
+	}
+
+	_, resp = Client.DownloadComplianceExport(job.Id)
+	CheckForbiddenStatus(t, resp)
+}
@@ -400,6 +400,39 @@ func (c *Context) RequireFileId() *Context {
 	return c
 }
 
+func (c *Context) RequireCommandId() *Context {
+	if c.Err != nil {
+		return c
+	}
+
+	if len(c.Params.CommandId) != 26 {
+		c.SetInvalidUrlParam("command_id")
+	}
+	return c
+}
+
+func (c *Context) RequireEmojiId() *Context {
+	if c.Err != nil {
+		return c
+	}
+
+	if len(c.Params.EmojiId) != 26 {
+		c.SetInvalidUrlParam("emoji_id")
+	}
+	return c
+}
+
+func (c *Context) RequireAppId() *Context {
+	if c.Err != nil {
+		return c
+	}
+
+	if len(c.Params.AppId) != 26 {
+		c.SetInvalidUrlParam("app_id")
+	}
+	return c
+}
+
 func (c *Context) RequireReportId() *Context {
 	if c.Err != nil {
 		return c
@@ -471,6 +504,66 @@ func (c *Context) RequirePreferenceName() *Context {
 	return c
 }
 
+func (c *Context) RequireEmojiName() *Context {
+	if c.Err != nil {
+		return c
+	}
+
+	if len(c.Params.EmojiName) == 0 || len(c.Params.EmojiName) > 64 {
+		c.SetInvalidUrlParam("emoji_name")
+	}
+
+	return c
+}
+
+func (c *Context) RequireActionId() *Context {
+	if c.Err != nil {
+		return c
+	}
+
+	if len(c.Params.ActionId) != 26 {
+		c.SetInvalidUrlParam("action_id")
+	}
+
+	return c
+}
+
+func (c *Context) RequireLockoutId() *Context {
+	if c.Err != nil {
+		return c
+	}
+
+	if len(c.Params.LockoutId) != 26 {
+		c.SetInvalidUrlParam("lockout_id")
+	}
+
+	return c
+}
+
+func (c *Context) RequireLegalHoldId() *Context {
+	if c.Err != nil {
+		return c
+	}
+
+	if len(c.Params.LegalHoldId) != 26 {
+		c.SetInvalidUrlParam("legal_hold_id")
+	}
+
+	return c
+}
+
+func (c *Context) RequireMentionGroupId() *Context {
+	if c.Err != nil {
+		return c
+	}
+
+	if len(c.Params.MentionGroupId) != 26 {
+		c.SetInvalidUrlParam("mention_group_id")
+	}
+
+	return c
+}
+
 func (c *Context) RequireHookId() *Context {
 	if c.Err != nil {
 		return c
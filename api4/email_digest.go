@@ -0,0 +1,88 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitEmailDigest() {
+	l4g.Debug(utils.T("api.email_digest.init.debug"))
+
+	BaseRoutes.User.Handle("/email_digest", ApiSessionRequired(getEmailDigest)).Methods("GET")
+	BaseRoutes.User.Handle("/email_digest", ApiSessionRequired(updateEmailDigest)).Methods("PUT")
+	BaseRoutes.User.Handle("/email_digest", ApiSessionRequired(deleteEmailDigest)).Methods("DELETE")
+}
+
+func getEmailDigest(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionToUser(c.Session, c.Params.UserId) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	if digest, err := app.GetEmailDigest(c.Params.UserId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(digest.ToJson()))
+	}
+}
+
+func updateEmailDigest(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	digest := model.EmailDigestFromJson(r.Body)
+	if digest == nil {
+		c.SetInvalidParam("email_digest")
+		return
+	}
+
+	if !app.SessionHasPermissionToUser(c.Session, c.Params.UserId) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	digest.UserId = c.Params.UserId
+
+	if rdigest, err := app.SetEmailDigest(digest); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("frequency=" + rdigest.Frequency)
+		w.Write([]byte(rdigest.ToJson()))
+	}
+}
+
+func deleteEmailDigest(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionToUser(c.Session, c.Params.UserId) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	if err := app.DeleteEmailDigest(c.Params.UserId); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("")
+	ReturnStatusOK(w)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestEmailDigest(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+	user := th.BasicUser
+
+	_, resp := Client.GetEmailDigest(user.Id)
+	CheckNotFoundStatus(t, resp)
+
+	digest, resp := Client.UpdateEmailDigest(user.Id, &model.EmailDigest{Frequency: model.EMAIL_DIGEST_FREQUENCY_DAILY, Hour: 9})
+	CheckNoError(t, resp)
+
+	if digest.UserId != user.Id || digest.Frequency != model.EMAIL_DIGEST_FREQUENCY_DAILY || digest.Timezone != "UTC" || digest.NextSendAt == 0 {
+		t.Fatal("should've subscribed the user", digest)
+	}
+
+	digest, resp = Client.UpdateEmailDigest(user.Id, &model.EmailDigest{Frequency: model.EMAIL_DIGEST_FREQUENCY_WEEKLY, Hour: 9, DayOfWeek: 1})
+	CheckNoError(t, resp)
+
+	if rdigest, resp := Client.GetEmailDigest(user.Id); resp.Error != nil {
+		t.Fatal(resp.Error)
+	} else if rdigest.Frequency != model.EMAIL_DIGEST_FREQUENCY_WEEKLY || rdigest.CreateAt != digest.CreateAt {
+		t.Fatal("should've updated the subscription", rdigest)
+	}
+
+	_, resp = Client.UpdateEmailDigest(user.Id, &model.EmailDigest{Frequency: "monthly"})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.UpdateEmailDigest(user.Id, &model.EmailDigest{Frequency: model.EMAIL_DIGEST_FREQUENCY_DAILY, Timezone: "Nowhere/Junk"})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.GetEmailDigest(th.BasicUser2.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.UpdateEmailDigest(th.BasicUser2.Id, &model.EmailDigest{Frequency: model.EMAIL_DIGEST_FREQUENCY_HOURLY})
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.DeleteEmailDigest(th.BasicUser2.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetEmailDigest(user.Id)
+	CheckNoError(t, resp)
+
+	pass, resp := Client.DeleteEmailDigest(user.Id)
+	CheckNoError(t, resp)
+
+	if !pass {
+		t.Fatal("should have passed")
+	}
+
+	_, resp = Client.DeleteEmailDigest(user.Id)
+	CheckNotFoundStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetEmailDigest(user.Id)
+	CheckUnauthorizedStatus(t, resp)
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"bytes"
+	"image"
+	"image/gif"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func createTestGif(t *testing.T, width int, height int) []byte {
+	var buffer bytes.Buffer
+
+	if err := gif.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, width, height)), nil); err != nil {
+		t.Fatalf("failed to create gif: %v", err.Error())
+	}
+
+	return buffer.Bytes()
+}
+
+func TestCreateEmoji(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	EnableCustomEmoji := *utils.Cfg.ServiceSettings.EnableCustomEmoji
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableCustomEmoji = EnableCustomEmoji
+	}()
+	*utils.Cfg.ServiceSettings.EnableCustomEmoji = false
+
+	emoji := &model.Emoji{
+		CreatorId: th.BasicUser.Id,
+		Name:      model.NewId(),
+	}
+
+	// try to create an emoji when they're disabled
+	_, resp := Client.CreateEmoji(emoji, createTestGif(t, 10, 10), "image.gif")
+	CheckNotImplementedStatus(t, resp)
+
+	*utils.Cfg.ServiceSettings.EnableCustomEmoji = true
+
+	newEmoji, resp := Client.CreateEmoji(emoji, createTestGif(t, 10, 10), "image.gif")
+	CheckNoError(t, resp)
+	if newEmoji.Name != emoji.Name {
+		t.Fatal("create with wrong name")
+	}
+
+	// try to create an emoji with a duplicate name
+	_, resp = Client.CreateEmoji(emoji, createTestGif(t, 10, 10), "image.gif")
+	CheckBadRequestStatus(t, resp)
+	CheckErrorMessage(t, resp, "api.emoji.create.duplicate.app_error")
+
+	// larger images are resized
+	emoji = &model.Emoji{
+		CreatorId: th.BasicUser.Id,
+		Name:      model.NewId(),
+	}
+
+	newEmoji, resp = Client.CreateEmoji(emoji, createTestGif(t, 1000, 10), "image.gif")
+	CheckNoError(t, resp)
+	if newEmoji.Name != emoji.Name {
+		t.Fatal("create with wrong name")
+	}
+
+	// try to create an emoji that's not an image
+	emoji = &model.Emoji{
+		CreatorId: th.BasicUser.Id,
+		Name:      model.NewId(),
+	}
+
+	_, resp = Client.CreateEmoji(emoji, make([]byte, 100, 100), "image.gif")
+	CheckInternalErrorStatus(t, resp)
+	CheckErrorMessage(t, resp, "api.emoji.upload.image.app_error")
+
+	// try to create an emoji as another user
+	emoji = &model.Emoji{
+		CreatorId: th.BasicUser2.Id,
+		Name:      model.NewId(),
+	}
+
+	_, resp = Client.CreateEmoji(emoji, createTestGif(t, 10, 10), "image.gif")
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestGetEmojiList(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	EnableCustomEmoji := *utils.Cfg.ServiceSettings.EnableCustomEmoji
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableCustomEmoji = EnableCustomEmoji
+	}()
+	*utils.Cfg.ServiceSettings.EnableCustomEmoji = true
+
+	emojis := []*model.Emoji{
+		{
+			CreatorId: th.BasicUser.Id,
+			Name:      model.NewId(),
+		},
+		{
+			CreatorId: th.BasicUser.Id,
+			Name:      model.NewId(),
+		},
+		{
+			CreatorId: th.BasicUser.Id,
+			Name:      model.NewId(),
+		},
+	}
+
+	for idx, emoji := range emojis {
+		emoji, resp := Client.CreateEmoji(emoji, createTestGif(t, 10, 10), "image.gif")
+		CheckNoError(t, resp)
+		emojis[idx] = emoji
+	}
+
+	listEmoji, resp := Client.GetEmojiList(0, 100)
+	CheckNoError(t, resp)
+	for _, emoji := range emojis {
+		found := false
+		for _, savedEmoji := range listEmoji {
+			if emoji.Id == savedEmoji.Id {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Fatalf("failed to get emoji with id %v", emoji.Id)
+		}
+	}
+
+	_, resp = Client.DeleteEmoji(emojis[0].Id)
+	CheckNoError(t, resp)
+	listEmoji, resp = Client.GetEmojiList(0, 100)
+	CheckNoError(t, resp)
+	for _, savedEmoji := range listEmoji {
+		if savedEmoji.Id == emojis[0].Id {
+			t.Fatal("should not get a deleted emoji")
+		}
+	}
+
+	listEmoji, resp = Client.GetEmojiList(0, 1)
+	CheckNoError(t, resp)
+	if len(listEmoji) != 1 {
+		t.Fatal("should only return 1")
+	}
+}
+
+func TestDeleteEmoji(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	EnableCustomEmoji := *utils.Cfg.ServiceSettings.EnableCustomEmoji
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableCustomEmoji = EnableCustomEmoji
+	}()
+	*utils.Cfg.ServiceSettings.EnableCustomEmoji = true
+
+	emoji := &model.Emoji{
+		CreatorId: th.BasicUser.Id,
+		Name:      model.NewId(),
+	}
+
+	newEmoji, resp := Client.CreateEmoji(emoji, createTestGif(t, 10, 10), "image.gif")
+	CheckNoError(t, resp)
+
+	// another user can't delete it
+	th.LoginBasic2()
+	_, resp = Client.DeleteEmoji(newEmoji.Id)
+	CheckForbiddenStatus(t, resp)
+
+	th.LoginBasic()
+	ok, resp := Client.DeleteEmoji(newEmoji.Id)
+	CheckNoError(t, resp)
+	if !ok {
+		t.Fatal("should have returned true")
+	}
+
+	_, resp = Client.GetEmoji(newEmoji.Id)
+	CheckNotFoundStatus(t, resp)
+
+	// a system admin can delete anyone's emoji
+	emoji = &model.Emoji{
+		CreatorId: th.BasicUser.Id,
+		Name:      model.NewId(),
+	}
+
+	newEmoji, resp = Client.CreateEmoji(emoji, createTestGif(t, 10, 10), "image.gif")
+	CheckNoError(t, resp)
+
+	_, resp = th.SystemAdminClient.DeleteEmoji(newEmoji.Id)
+	CheckNoError(t, resp)
+
+	_, resp = Client.DeleteEmoji("junk")
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.DeleteEmoji(model.NewId())
+	CheckNotFoundStatus(t, resp)
+}
+
+func TestGetEmoji(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	EnableCustomEmoji := *utils.Cfg.ServiceSettings.EnableCustomEmoji
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableCustomEmoji = EnableCustomEmoji
+	}()
+	*utils.Cfg.ServiceSettings.EnableCustomEmoji = true
+
+	emoji := &model.Emoji{
+		CreatorId: th.BasicUser.Id,
+		Name:      model.NewId(),
+	}
+
+	newEmoji, resp := Client.CreateEmoji(emoji, createTestGif(t, 10, 10), "image.gif")
+	CheckNoError(t, resp)
+
+	emoji, resp = Client.GetEmoji(newEmoji.Id)
+	CheckNoError(t, resp)
+	if emoji.Id != newEmoji.Id {
+		t.Fatal("wrong emoji was returned")
+	}
+
+	_, resp = Client.GetEmoji(model.NewId())
+	CheckNotFoundStatus(t, resp)
+
+	image, resp := Client.GetEmojiImage(newEmoji.Id)
+	CheckNoError(t, resp)
+	if len(image) == 0 {
+		t.Fatal("should have returned the image")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableCustomEmoji = false
+
+	_, resp = Client.GetEmoji(newEmoji.Id)
+	CheckNotImplementedStatus(t, resp)
+
+	_, resp = Client.GetEmojiImage(newEmoji.Id)
+	CheckNotImplementedStatus(t, resp)
+}
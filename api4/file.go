@@ -28,6 +28,10 @@ func InitFile() {
 	BaseRoutes.File.Handle("/preview", ApiSessionRequired(getFilePreview)).Methods("GET")
 	BaseRoutes.File.Handle("/info", ApiSessionRequired(getFileInfo)).Methods("GET")
 
+	BaseRoutes.Team.Handle("/files/search", ApiSessionRequired(searchFiles)).Methods("POST")
+	BaseRoutes.Team.Handle("/files/usage", ApiSessionRequired(getTeamStorageUsage)).Methods("GET")
+	BaseRoutes.User.Handle("/files/usage", ApiSessionRequired(getUserStorageUsage)).Methods("GET")
+
 	BaseRoutes.PublicFile.Handle("", ApiHandler(getPublicFile)).Methods("GET")
 
 }
@@ -89,6 +93,11 @@ func getFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := app.CheckFileCanBeServed(info); err != nil {
+		c.Err = err
+		return
+	}
+
 	if data, err := app.ReadFile(info.Path); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
@@ -115,6 +124,11 @@ func getFileThumbnail(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := app.CheckFileCanBeServed(info); err != nil {
+		c.Err = err
+		return
+	}
+
 	if info.ThumbnailPath == "" {
 		c.Err = model.NewLocAppError("getFileThumbnail", "api.file.get_file_thumbnail.no_thumbnail.app_error", nil, "file_id="+info.Id)
 		c.Err.StatusCode = http.StatusBadRequest
@@ -124,7 +138,7 @@ func getFileThumbnail(c *Context, w http.ResponseWriter, r *http.Request) {
 	if data, err := app.ReadFile(info.ThumbnailPath); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
-	} else if err := writeFileResponse(info.Name, info.MimeType, data, w, r); err != nil {
+	} else if err := writeFileResponse(info.Name, app.GetPreviewContentType(info.ThumbnailPath), data, w, r); err != nil {
 		c.Err = err
 		return
 	}
@@ -182,6 +196,11 @@ func getFilePreview(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := app.CheckFileCanBeServed(info); err != nil {
+		c.Err = err
+		return
+	}
+
 	if info.PreviewPath == "" {
 		c.Err = model.NewLocAppError("getFilePreview", "api.file.get_file_preview.no_preview.app_error", nil, "file_id="+info.Id)
 		c.Err.StatusCode = http.StatusBadRequest
@@ -191,7 +210,7 @@ func getFilePreview(c *Context, w http.ResponseWriter, r *http.Request) {
 	if data, err := app.ReadFile(info.PreviewPath); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
-	} else if err := writeFileResponse(info.Name, info.MimeType, data, w, r); err != nil {
+	} else if err := writeFileResponse(info.Name, app.GetPreviewContentType(info.PreviewPath), data, w, r); err != nil {
 		c.Err = err
 		return
 	}
@@ -250,6 +269,11 @@ func getPublicFile(c *Context, w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := app.CheckFileCanBeServed(info); err != nil {
+		c.Err = err
+		return
+	}
+
 	if data, err := app.ReadFile(info.Path); err != nil {
 		c.Err = err
 		c.Err.StatusCode = http.StatusNotFound
@@ -259,6 +283,76 @@ func getPublicFile(c *Context, w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func searchFiles(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionToTeam(c.Session, c.Params.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	params := model.FileSearchFromJson(r.Body)
+	if params == nil {
+		c.SetInvalidParam("file_search")
+		return
+	}
+
+	if params.IsEmpty() {
+		c.SetInvalidParam("terms")
+		return
+	}
+
+	infos, err := app.SearchFilesInTeam(params, c.Session.UserId, c.Params.TeamId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
+	w.Write([]byte(model.FileInfosToJson(infos)))
+}
+
+func getTeamStorageUsage(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionToTeam(c.Session, c.Params.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	if usage, err := app.GetStorageUsageForTeam(c.Params.TeamId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(usage.ToJson()))
+	}
+}
+
+func getUserStorageUsage(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireUserId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionToUser(c.Session, c.Params.UserId) {
+		c.SetPermissionError(model.PERMISSION_EDIT_OTHER_USERS)
+		return
+	}
+
+	if usage, err := app.GetStorageUsageForUser(c.Params.UserId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(usage.ToJson()))
+	}
+}
+
 func writeFileResponse(filename string, contentType string, bytes []byte, w http.ResponseWriter, r *http.Request) *model.AppError {
 	w.Header().Set("Cache-Control", "max-age=2592000, public")
 	w.Header().Set("Content-Length", strconv.Itoa(len(bytes)))
@@ -11,6 +11,7 @@ import (
 	"time"
 
 	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/store"
 	"github.com/mattermost/platform/utils"
@@ -71,9 +72,11 @@ func TestUploadFile(t *testing.T) {
 		t.Fatal("file preview path should be set in database")
 	}
 
-	// This also makes sure that the relative path provided above is sanitized out
-	expectedPath := fmt.Sprintf("teams/%v/channels/%v/users/%v/%v/test.png", FILE_TEAM_ID, channel.Id, user.Id, info.Id)
-	if info.Path != expectedPath {
+	// Files are shared between uploads with the same content
+	expectedPath := app.FILE_BLOB_PATH_PREFIX + info.ContentHash[:2] + "/" + info.ContentHash
+	if info.ContentHash != app.GetContentHash(data) {
+		t.Fatal("file content hash should be set in database")
+	} else if info.Path != expectedPath {
 		t.Logf("file is saved in %v", info.Path)
 		t.Fatalf("file should've been saved in %v", expectedPath)
 	}
@@ -153,6 +156,62 @@ func TestGetFile(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+type infectedFileScanner struct{}
+
+func (s *infectedFileScanner) ScanFile(data []byte) (string, *model.AppError) {
+	return "Test-Virus", nil
+}
+
+func TestGetQuarantinedFile(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+	channel := th.BasicChannel
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	enabled := *utils.Cfg.FileSettings.EnableFileScanning
+	action := *utils.Cfg.FileSettings.InfectedFileAction
+	scanner := einterfaces.GetFileScannerInterface()
+	defer func() {
+		*utils.Cfg.FileSettings.EnableFileScanning = enabled
+		*utils.Cfg.FileSettings.InfectedFileAction = action
+		einterfaces.RegisterFileScannerInterface(scanner)
+	}()
+	*utils.Cfg.FileSettings.EnableFileScanning = true
+	*utils.Cfg.FileSettings.InfectedFileAction = model.INFECTED_FILE_ACTION_REJECT
+	einterfaces.RegisterFileScannerInterface(&infectedFileScanner{})
+
+	sent, err := readTestFile("test.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, resp := Client.UploadFile(sent, channel.Id, "test.png")
+	CheckBadRequestStatus(t, resp)
+
+	*utils.Cfg.FileSettings.InfectedFileAction = model.INFECTED_FILE_ACTION_QUARANTINE
+
+	fileResp, resp := Client.UploadFile(sent, channel.Id, "test.png")
+	CheckNoError(t, resp)
+
+	info := fileResp.FileInfos[0]
+	if info.ScanStatus != model.FILE_SCAN_STATUS_QUARANTINED {
+		t.Fatal("file should have been quarantined")
+	}
+
+	_, resp = Client.GetFile(info.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.GetFileThumbnail(info.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.GetFilePreview(info.Id)
+	CheckForbiddenStatus(t, resp)
+}
+
 func TestGetFileThumbnail(t *testing.T) {
 	th := Setup().InitBasic().InitSystemAdmin()
 	defer TearDown()
@@ -470,3 +529,170 @@ func TestGetPublicFile(t *testing.T) {
 
 	cleanupTestFile(info)
 }
+
+func TestSearchFiles(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	fileResp, resp := Client.UploadFile([]byte("quarterly numbers for "+model.NewId()), th.BasicChannel.Id, "report.txt")
+	CheckNoError(t, resp)
+	fileId := fileResp.FileInfos[0].Id
+
+	// Hacky way to assign file to a post (usually would be done by CreatePost call)
+	store.Must(app.Srv.Store.FileInfo().AttachToPost(fileId, th.BasicPost.Id))
+
+	// Wait a bit for the content to be extracted
+	time.Sleep(2 * time.Second)
+
+	infos, resp := Client.SearchFiles(th.BasicTeam.Id, &model.FileSearch{Terms: "quarterly"})
+	CheckNoError(t, resp)
+	if len(infos) != 1 || infos[0].Id != fileId {
+		t.Fatal("should have found the file by its content", infos)
+	}
+
+	infos, resp = Client.SearchFiles(th.BasicTeam.Id, &model.FileSearch{Extensions: []string{"txt"}, FromUsers: []string{th.BasicUser.Username}})
+	CheckNoError(t, resp)
+	if len(infos) != 1 || infos[0].Id != fileId {
+		t.Fatal("should have found the file by its extension and uploader", infos)
+	}
+
+	infos, resp = Client.SearchFiles(th.BasicTeam.Id, &model.FileSearch{Name: "report", InChannels: []string{th.BasicChannel2.Name}})
+	CheckNoError(t, resp)
+	if len(infos) != 0 {
+		t.Fatal("shouldn't have found files in another channel", infos)
+	}
+
+	_, resp = Client.SearchFiles(th.BasicTeam.Id, &model.FileSearch{})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.SearchFiles(model.NewId(), &model.FileSearch{Terms: "quarterly"})
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.SearchFiles(th.BasicTeam.Id, &model.FileSearch{Terms: "quarterly"})
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestStorageUsage(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	maxStoragePerTeam := *utils.Cfg.FileSettings.MaxStoragePerTeam
+	maxStoragePerUser := *utils.Cfg.FileSettings.MaxStoragePerUser
+	defer func() {
+		*utils.Cfg.FileSettings.MaxStoragePerTeam = maxStoragePerTeam
+		*utils.Cfg.FileSettings.MaxStoragePerUser = maxStoragePerUser
+	}()
+
+	data := []byte("some data to take up space")
+
+	_, resp := Client.UploadFile(data, th.BasicChannel.Id, "file.txt")
+	CheckNoError(t, resp)
+
+	usage, resp := Client.GetTeamStorageUsage(th.BasicTeam.Id)
+	CheckNoError(t, resp)
+
+	if usage.Usage != int64(len(data)) {
+		t.Fatal("wrong team usage", usage.Usage)
+	} else if usage.Quota != 0 {
+		t.Fatal("team shouldn't have a quota")
+	}
+
+	usage, resp = Client.GetUserStorageUsage(th.BasicUser.Id)
+	CheckNoError(t, resp)
+
+	if usage.Usage != int64(len(data)) {
+		t.Fatal("wrong user usage", usage.Usage)
+	}
+
+	_, resp = Client.GetUserStorageUsage(th.BasicUser2.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetUserStorageUsage(th.BasicUser.Id)
+	CheckNoError(t, resp)
+
+	_, resp = Client.GetTeamStorageUsage(model.NewId())
+	CheckForbiddenStatus(t, resp)
+
+	*utils.Cfg.FileSettings.MaxStoragePerUser = int64(len(data)) * 2
+
+	_, resp = Client.UploadFile(data, th.BasicChannel.Id, "file.txt")
+	CheckNoError(t, resp)
+
+	_, resp = Client.UploadFile(data, th.BasicChannel.Id, "file.txt")
+	CheckErrorMessage(t, resp, "api.file.upload_file.user_quota.app_error")
+
+	*utils.Cfg.FileSettings.MaxStoragePerUser = 0
+	*utils.Cfg.FileSettings.MaxStoragePerTeam = int64(len(data)) * 2
+
+	_, resp = th.SystemAdminClient.UploadFile(data, th.BasicChannel.Id, "file.txt")
+	CheckErrorMessage(t, resp, "api.file.upload_file.team_quota.app_error")
+
+	usage, resp = Client.GetTeamStorageUsage(th.BasicTeam.Id)
+	CheckNoError(t, resp)
+
+	if usage.Quota != *utils.Cfg.FileSettings.MaxStoragePerTeam {
+		t.Fatal("should've returned team quota")
+	}
+}
+
+func TestSharedFileStorage(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	if utils.Cfg.FileSettings.DriverName == "" {
+		t.Skip("skipping because no file driver is enabled")
+	}
+
+	data := []byte("the same build artifact " + model.NewId())
+
+	fileResp, resp := Client.UploadFile(data, th.BasicChannel.Id, "artifact.zip")
+	CheckNoError(t, resp)
+	info1 := store.Must(app.Srv.Store.FileInfo().Get(fileResp.FileInfos[0].Id)).(*model.FileInfo)
+
+	fileResp, resp = Client.UploadFile(data, th.BasicChannel2.Id, "artifact.zip")
+	CheckNoError(t, resp)
+	info2 := store.Must(app.Srv.Store.FileInfo().Get(fileResp.FileInfos[0].Id)).(*model.FileInfo)
+
+	if info1.Path != info2.Path {
+		t.Fatal("files with the same content should be stored once")
+	}
+
+	post1, resp := Client.CreatePost(&model.Post{ChannelId: th.BasicChannel.Id, Message: "artifact", FileIds: []string{info1.Id}})
+	CheckNoError(t, resp)
+
+	post2, resp := Client.CreatePost(&model.Post{ChannelId: th.BasicChannel2.Id, Message: "artifact", FileIds: []string{info2.Id}})
+	CheckNoError(t, resp)
+
+	_, resp = Client.DeletePost(post1.Id)
+	CheckNoError(t, resp)
+
+	// Files are deleted in the background
+	time.Sleep(time.Second)
+
+	if received, resp := Client.GetFile(info2.Id); resp.Error != nil {
+		t.Fatal("file should still be available while it's referenced by another post", resp.Error)
+	} else if string(received) != string(data) {
+		t.Fatal("received file didn't match sent one")
+	}
+
+	_, resp = Client.DeletePost(post2.Id)
+	CheckNoError(t, resp)
+
+	time.Sleep(time.Second)
+
+	if _, err := app.ReadFile(info2.Path); err == nil {
+		t.Fatal("file should've been removed after its last reference was deleted")
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+	"strconv"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitImage() {
+	l4g.Debug(utils.T("api.image_proxy.init.debug"))
+
+	BaseRoutes.Image.Handle("", ApiSessionRequiredTrustRequester(getImage)).Methods("GET")
+}
+
+func getImage(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !app.IsImageProxyEnabled() {
+		c.Err = model.NewAppError("getImage", "api.image_proxy.disabled.app_error", nil, "", http.StatusNotImplemented)
+		return
+	}
+
+	query := r.URL.Query()
+
+	imageURL := query.Get("url")
+	if len(imageURL) == 0 {
+		c.SetInvalidUrlParam("url")
+		return
+	}
+
+	if !app.IsValidImageProxySignature(imageURL, query.Get("sig")) {
+		c.Err = model.NewAppError("getImage", "api.image_proxy.signature.app_error", nil, "", http.StatusForbidden)
+		return
+	}
+
+	width := 0
+	if widthStr := query.Get("width"); len(widthStr) > 0 {
+		var err error
+		if width, err = strconv.Atoi(widthStr); err != nil || width <= 0 {
+			c.SetInvalidUrlParam("width")
+			return
+		}
+	}
+
+	img, contentType, err := app.GetProxiedImage(imageURL, width)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(img)))
+	w.Header().Set("Cache-Control", "max-age=86400, private")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Content-Security-Policy", "default-src 'none'")
+	w.Write(img)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestGetProxiedImage(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	enable := *utils.Cfg.ImageProxySettings.Enable
+	defer func() {
+		*utils.Cfg.ImageProxySettings.Enable = enable
+	}()
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 30)))
+	imageData := buf.Bytes()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(imageData)
+	}))
+	defer ts.Close()
+
+	imageURL := ts.URL + "/image.png"
+	sig := app.GetImageProxySignature(imageURL)
+
+	*utils.Cfg.ImageProxySettings.Enable = false
+	_, resp := Client.GetProxiedImage(imageURL, sig, 0)
+	CheckNotImplementedStatus(t, resp)
+
+	*utils.Cfg.ImageProxySettings.Enable = true
+	data, resp := Client.GetProxiedImage(imageURL, sig, 0)
+	CheckNoError(t, resp)
+	if !bytes.Equal(data, imageData) {
+		t.Fatal("should have returned the image")
+	}
+
+	_, resp = Client.GetProxiedImage(imageURL, app.GetImageProxySignature(ts.URL+"/other.png"), 0)
+	CheckForbiddenStatus(t, resp)
+
+	post := &model.Post{ChannelId: th.BasicChannel.Id, Message: "![image](" + imageURL + ")"}
+	rpost, resp := Client.CreatePost(post)
+	CheckNoError(t, resp)
+	if !strings.Contains(rpost.Message, "/api/v4/image?url="+url.QueryEscape(imageURL)+"&sig="+sig) {
+		t.Fatal("should have rewritten the image url", rpost.Message)
+	}
+
+	Client.Logout()
+	_, resp = Client.GetProxiedImage(imageURL, sig, 0)
+	CheckUnauthorizedStatus(t, resp)
+}
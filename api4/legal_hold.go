@@ -0,0 +1,128 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitLegalHold() {
+	l4g.Debug(utils.T("api.legal_hold.init.debug"))
+
+	BaseRoutes.LegalHolds.Handle("", ApiSessionRequired(createLegalHold)).Methods("POST")
+	BaseRoutes.LegalHolds.Handle("", ApiSessionRequired(getLegalHolds)).Methods("GET")
+	BaseRoutes.LegalHold.Handle("", ApiSessionRequired(getLegalHold)).Methods("GET")
+	BaseRoutes.LegalHold.Handle("/release", ApiSessionRequired(releaseLegalHold)).Methods("POST")
+	BaseRoutes.LegalHold.Handle("/export", ApiSessionRequired(exportLegalHold)).Methods("GET")
+}
+
+func createLegalHold(c *Context, w http.ResponseWriter, r *http.Request) {
+	hold := model.LegalHoldFromJson(r.Body)
+	if hold == nil {
+		c.SetInvalidParam("legal_hold")
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	hold.CreatorId = c.Session.UserId
+
+	if rhold, err := app.CreateLegalHold(hold); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("legal_hold_id=" + rhold.Id + " name=" + rhold.Name)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(rhold.ToJson()))
+	}
+}
+
+func getLegalHolds(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	if holds, err := app.GetLegalHolds(c.Params.Page, c.Params.PerPage); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.LegalHoldListToJson(holds)))
+	}
+}
+
+func getLegalHold(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireLegalHoldId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	if hold, err := app.GetLegalHold(c.Params.LegalHoldId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(hold.ToJson()))
+	}
+}
+
+func releaseLegalHold(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireLegalHoldId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	if hold, err := app.ReleaseLegalHold(c.Params.LegalHoldId); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("legal_hold_id=" + hold.Id + " name=" + hold.Name)
+		w.Write([]byte(hold.ToJson()))
+	}
+}
+
+func exportLegalHold(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireLegalHoldId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	hold, err := app.GetLegalHold(c.Params.LegalHoldId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("legal_hold_id=" + hold.Id + " name=" + hold.Name)
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment;filename=\"legal-hold-"+hold.Id+".zip\"")
+
+	// The export is streamed, so errors after this point can only be logged
+	if err := app.ExportLegalHold(hold, w); err != nil {
+		l4g.Error(utils.T("api.legal_hold.export.error"), hold.Id, err.Error())
+	}
+}
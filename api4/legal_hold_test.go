@@ -0,0 +1,120 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
+)
+
+func TestLegalHolds(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	post := th.CreatePost()
+
+	hold := &model.LegalHold{
+		Name:    "hold",
+		UserIds: []string{th.BasicUser.Id},
+		StartAt: post.CreateAt,
+	}
+
+	_, resp := Client.CreateLegalHold(hold)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.CreateLegalHold(&model.LegalHold{Name: "hold"})
+	CheckBadRequestStatus(t, resp)
+
+	rhold, resp := th.SystemAdminClient.CreateLegalHold(hold)
+	CheckNoError(t, resp)
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("wrong status code", resp.StatusCode)
+	}
+
+	if rhold.CreatorId != th.SystemAdminUser.Id || !rhold.IsActive() {
+		t.Fatal("created wrong hold", rhold)
+	}
+
+	_, resp = Client.GetLegalHold(rhold.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetLegalHold(model.NewId())
+	CheckNotFoundStatus(t, resp)
+
+	if fetched, resp := th.SystemAdminClient.GetLegalHold(rhold.Id); resp.Error != nil {
+		t.Fatal(resp.Error)
+	} else if fetched.Name != hold.Name {
+		t.Fatal("got wrong hold")
+	}
+
+	_, resp = Client.GetLegalHolds(0, 10)
+	CheckForbiddenStatus(t, resp)
+
+	if holds, resp := th.SystemAdminClient.GetLegalHolds(0, 10); resp.Error != nil {
+		t.Fatal(resp.Error)
+	} else if len(holds) == 0 || holds[0].Id != rhold.Id {
+		t.Fatal("should've listed the hold")
+	}
+
+	if err := app.PermanentDeleteUser(th.BasicUser); err == nil || err.Id != "api.legal_hold.user_held.app_error" {
+		t.Fatal("shouldn't be able to delete a held user", err)
+	}
+
+	if err := app.PermanentDeleteChannel(th.BasicChannel); err == nil || err.Id != "api.legal_hold.channel_held.app_error" {
+		t.Fatal("shouldn't be able to delete a channel with held posts", err)
+	}
+
+	_, resp = Client.ExportLegalHold(rhold.Id)
+	CheckForbiddenStatus(t, resp)
+
+	data, resp := th.SystemAdminClient.ExportLegalHold(rhold.Id)
+	CheckNoError(t, resp)
+
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	foundPost := false
+	for _, file := range reader.File {
+		if file.Name != "posts.jsonl" {
+			continue
+		}
+
+		contents, _ := file.Open()
+		posts, _ := ioutil.ReadAll(contents)
+		contents.Close()
+
+		foundPost = strings.Contains(string(posts), post.Id)
+	}
+
+	if !foundPost {
+		t.Fatal("should've exported the held post")
+	}
+
+	_, resp = Client.ReleaseLegalHold(rhold.Id)
+	CheckForbiddenStatus(t, resp)
+
+	if released, resp := th.SystemAdminClient.ReleaseLegalHold(rhold.Id); resp.Error != nil {
+		t.Fatal(resp.Error)
+	} else if released.IsActive() {
+		t.Fatal("should've released the hold")
+	}
+
+	_, resp = th.SystemAdminClient.ReleaseLegalHold(rhold.Id)
+	CheckBadRequestStatus(t, resp)
+
+	if app.IsUnderLegalHold(post.UserId, post.ChannelId, post.CreateAt) {
+		t.Fatal("released hold shouldn't cover content")
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/utils"
+)
+
+func TestGetOldClientLicense(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	license, resp := Client.GetOldClientLicense("")
+	CheckNoError(t, resp)
+
+	if len(license["IsLicensed"]) == 0 {
+		t.Fatal("license not returned correctly")
+	}
+
+	Client.Logout()
+
+	_, resp = Client.GetOldClientLicense("")
+	CheckNoError(t, resp)
+
+	if _, err := Client.DoApiGet("/license/client", ""); err == nil || err.StatusCode != 501 {
+		t.Fatal("should have errored with 501")
+	}
+
+	if _, err := Client.DoApiGet("/license/client?format=junk", ""); err == nil || err.StatusCode != 400 {
+		t.Fatal("should have errored with 400")
+	}
+
+	license, resp = th.SystemAdminClient.GetOldClientLicense("")
+	CheckNoError(t, resp)
+
+	if len(license["IsLicensed"]) == 0 {
+		t.Fatal("license not returned correctly")
+	}
+}
+
+func TestUploadLicenseFile(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	ok, resp := Client.UploadLicenseFile([]byte{})
+	CheckForbiddenStatus(t, resp)
+	if ok {
+		t.Fatal("should fail")
+	}
+
+	ok, resp = th.SystemAdminClient.UploadLicenseFile([]byte{})
+	if ok || resp.Error == nil {
+		t.Fatal("should fail with an invalid license")
+	}
+}
+
+func TestRemoveLicenseFile(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	isLicensed := utils.IsLicensed
+	license := utils.License
+	defer func() {
+		utils.IsLicensed = isLicensed
+		utils.License = license
+	}()
+
+	ok, resp := Client.RemoveLicenseFile()
+	CheckForbiddenStatus(t, resp)
+	if ok {
+		t.Fatal("should fail")
+	}
+
+	ok, resp = th.SystemAdminClient.RemoveLicenseFile()
+	CheckNoError(t, resp)
+	if !ok {
+		t.Fatal("should pass")
+	}
+
+	if utils.IsLicensed {
+		t.Fatal("license should have been removed")
+	}
+
+	th.SystemAdminClient.Logout()
+
+	_, resp = th.SystemAdminClient.RemoveLicenseFile()
+	CheckUnauthorizedStatus(t, resp)
+
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitLoginLockout() {
+	l4g.Debug(utils.T("api.login_lockout.init.debug"))
+
+	BaseRoutes.LoginLockouts.Handle("", ApiSessionRequired(getLoginLockouts)).Methods("GET")
+	BaseRoutes.LoginLockout.Handle("", ApiSessionRequired(removeLoginLockout)).Methods("DELETE")
+}
+
+func getLoginLockouts(c *Context, w http.ResponseWriter, r *http.Request) {
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	if lockouts, err := app.GetLoginLockouts(); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.LoginLockoutListToJson(lockouts)))
+	}
+}
+
+func removeLoginLockout(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireLockoutId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	if lockout, err := app.RemoveLoginLockout(c.Params.LockoutId); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("lockout_id=" + lockout.Id + " key_type=" + lockout.KeyType + " key_value=" + lockout.KeyValue)
+	}
+
+	ReturnStatusOK(w)
+}
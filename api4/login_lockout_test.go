@@ -0,0 +1,113 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestLoginLockout(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	enableLockout := *utils.Cfg.ServiceSettings.EnableLoginLockout
+	maxPerLoginId := *utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerLoginId
+	defer func() {
+		*utils.Cfg.ServiceSettings.EnableLoginLockout = enableLockout
+		*utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerLoginId = maxPerLoginId
+	}()
+	*utils.Cfg.ServiceSettings.EnableLoginLockout = true
+	*utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerLoginId = 2
+
+	Client.Logout()
+
+	unknownLoginId := model.NewId() + "@simulator.amazonses.com"
+	_, resp := Client.Login(unknownLoginId, "badpwd")
+	CheckBadRequestStatus(t, resp)
+	_, resp = Client.Login(unknownLoginId, "badpwd")
+	CheckBadRequestStatus(t, resp)
+
+	// unknown login ids are locked out too so that they can't be enumerated
+	_, resp = Client.Login(unknownLoginId, "badpwd")
+	CheckErrorMessage(t, resp, "api.user.check_login_lockout.locked_out.app_error")
+	CheckForbiddenStatus(t, resp)
+
+	// login ids are not case sensitive
+	_, resp = Client.Login(" "+strings.ToUpper(unknownLoginId)+" ", "badpwd")
+	CheckForbiddenStatus(t, resp)
+
+	// failures are counted for the user no matter which login id was used
+	_, resp = Client.Login(th.BasicUser.Email, "badpwd")
+	CheckUnauthorizedStatus(t, resp)
+	_, resp = Client.Login(th.BasicUser.Username, "badpwd")
+	CheckUnauthorizedStatus(t, resp)
+
+	_, resp = Client.Login(th.BasicUser.Email, th.BasicUser.Password)
+	CheckErrorMessage(t, resp, "api.user.check_login_lockout.locked_out.app_error")
+
+	_, resp = Client.LoginById(th.BasicUser.Id, th.BasicUser.Password)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.GetLoginLockouts()
+	CheckUnauthorizedStatus(t, resp)
+
+	th.LoginBasic2()
+	_, resp = Client.GetLoginLockouts()
+	CheckForbiddenStatus(t, resp)
+
+	lockouts, resp := th.SystemAdminClient.GetLoginLockouts()
+	CheckNoError(t, resp)
+
+	var lockout *model.LoginLockout
+	for _, l := range lockouts {
+		if l.KeyType == model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID && l.KeyValue == th.BasicUser.Id {
+			lockout = l
+		}
+	}
+
+	if lockout == nil {
+		t.Fatal("should have listed the lockout")
+	} else if lockout.FailedAttempts != 2 {
+		t.Fatal("wrong number of failed attempts")
+	}
+
+	_, resp = Client.RemoveLoginLockout(lockout.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.RemoveLoginLockout("junk")
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.RemoveLoginLockout(model.NewId())
+	CheckNotFoundStatus(t, resp)
+
+	ok, resp := th.SystemAdminClient.RemoveLoginLockout(lockout.Id)
+	CheckNoError(t, resp)
+	if !ok {
+		t.Fatal("should have returned true")
+	}
+
+	Client.Logout()
+	_, resp = Client.Login(th.BasicUser.Email, th.BasicUser.Password)
+	CheckNoError(t, resp)
+
+	if audits, resp := th.SystemAdminClient.GetAudits(0, 100, ""); resp.Error != nil {
+		t.Fatal(resp.Error)
+	} else {
+		found := false
+		for _, audit := range audits {
+			if audit.Action == "login_lockout" {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("lockouts should have been audited")
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func InitMentionGroup() {
+	l4g.Debug(utils.T("api.mention_group.init.debug"))
+
+	BaseRoutes.MentionGroups.Handle("", ApiSessionRequired(createMentionGroup)).Methods("POST")
+	BaseRoutes.MentionGroupsForTeam.Handle("", ApiSessionRequired(getMentionGroupsForTeam)).Methods("GET")
+	BaseRoutes.MentionGroup.Handle("", ApiSessionRequired(getMentionGroup)).Methods("GET")
+	BaseRoutes.MentionGroup.Handle("/patch", ApiSessionRequired(patchMentionGroup)).Methods("PUT")
+	BaseRoutes.MentionGroup.Handle("", ApiSessionRequired(deleteMentionGroup)).Methods("DELETE")
+}
+
+func createMentionGroup(c *Context, w http.ResponseWriter, r *http.Request) {
+	group := model.MentionGroupFromJson(r.Body)
+	if group == nil {
+		c.SetInvalidParam("mention_group")
+		return
+	}
+
+	if !app.SessionHasPermissionToTeam(c.Session, group.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	group.CreatorId = c.Session.UserId
+
+	if rgroup, err := app.CreateMentionGroup(group); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("name=" + rgroup.Name)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(rgroup.ToJson()))
+	}
+}
+
+func getMentionGroupsForTeam(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireTeamId()
+	if c.Err != nil {
+		return
+	}
+
+	if !app.SessionHasPermissionToTeam(c.Session, c.Params.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	if groups, err := app.GetMentionGroupsForTeam(c.Params.TeamId); err != nil {
+		c.Err = err
+		return
+	} else {
+		w.Write([]byte(model.MentionGroupListToJson(groups)))
+	}
+}
+
+func getMentionGroup(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireMentionGroupId()
+	if c.Err != nil {
+		return
+	}
+
+	group, err := app.GetMentionGroup(c.Params.MentionGroupId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !app.SessionHasPermissionToTeam(c.Session, group.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return
+	}
+
+	w.Write([]byte(group.ToJson()))
+}
+
+func patchMentionGroup(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireMentionGroupId()
+	if c.Err != nil {
+		return
+	}
+
+	patch := model.MentionGroupPatchFromJson(r.Body)
+	if patch == nil {
+		c.SetInvalidParam("mention_group")
+		return
+	}
+
+	group, err := app.GetMentionGroup(c.Params.MentionGroupId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !canManageMentionGroup(c, group) {
+		return
+	}
+
+	if rgroup, err := app.PatchMentionGroup(group, patch); err != nil {
+		c.Err = err
+		return
+	} else {
+		c.LogAudit("name=" + rgroup.Name)
+		w.Write([]byte(rgroup.ToJson()))
+	}
+}
+
+func deleteMentionGroup(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireMentionGroupId()
+	if c.Err != nil {
+		return
+	}
+
+	group, err := app.GetMentionGroup(c.Params.MentionGroupId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if !canManageMentionGroup(c, group) {
+		return
+	}
+
+	if err := app.DeleteMentionGroup(group.Id); err != nil {
+		c.Err = err
+		return
+	}
+
+	c.LogAudit("name=" + group.Name)
+	ReturnStatusOK(w)
+}
+
+// canManageMentionGroup returns true if the session's user created the group or can manage its team. Otherwise it
+// sets a permission error on the context.
+func canManageMentionGroup(c *Context, group *model.MentionGroup) bool {
+	if !app.SessionHasPermissionToTeam(c.Session, group.TeamId, model.PERMISSION_VIEW_TEAM) {
+		c.SetPermissionError(model.PERMISSION_VIEW_TEAM)
+		return false
+	}
+
+	if c.Session.UserId != group.CreatorId && !app.SessionHasPermissionToTeam(c.Session, group.TeamId, model.PERMISSION_MANAGE_TEAM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_TEAM)
+		return false
+	}
+
+	return true
+}
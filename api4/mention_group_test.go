@@ -0,0 +1,95 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestMentionGroups(t *testing.T) {
+	th := Setup().InitBasic()
+	defer TearDown()
+	Client := th.Client
+
+	group := &model.MentionGroup{
+		TeamId:  th.BasicTeam.Id,
+		Name:    "group" + model.NewId()[:10],
+		UserIds: []string{th.BasicUser.Id, th.BasicUser2.Id},
+	}
+
+	rgroup, resp := Client.CreateMentionGroup(group)
+	CheckNoError(t, resp)
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatal("wrong status code", resp.StatusCode)
+	}
+
+	if rgroup.CreatorId != th.BasicUser.Id || rgroup.Name != group.Name || len(rgroup.UserIds) != 2 {
+		t.Fatal("created wrong group", rgroup)
+	}
+
+	_, resp = Client.CreateMentionGroup(group)
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.CreateMentionGroup(&model.MentionGroup{TeamId: th.BasicTeam.Id, Name: "here", UserIds: []string{th.BasicUser.Id}})
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.CreateMentionGroup(&model.MentionGroup{TeamId: model.NewId(), Name: "group" + model.NewId()[:10], UserIds: []string{th.BasicUser.Id}})
+	CheckForbiddenStatus(t, resp)
+
+	if fetched, resp := Client.GetMentionGroup(rgroup.Id); resp.Error != nil {
+		t.Fatal(resp.Error)
+	} else if fetched.Id != rgroup.Id {
+		t.Fatal("got wrong group")
+	}
+
+	_, resp = Client.GetMentionGroup(model.NewId())
+	CheckNotFoundStatus(t, resp)
+
+	_, resp = Client.GetMentionGroup("junk")
+	CheckBadRequestStatus(t, resp)
+
+	if groups, resp := Client.GetMentionGroupsForTeam(th.BasicTeam.Id); resp.Error != nil {
+		t.Fatal(resp.Error)
+	} else if len(groups) != 1 || groups[0].Id != rgroup.Id {
+		t.Fatal("should've listed the team's group")
+	}
+
+	_, resp = Client.GetMentionGroupsForTeam(model.NewId())
+	CheckForbiddenStatus(t, resp)
+
+	userIds := []string{th.BasicUser2.Id}
+	patch := &model.MentionGroupPatch{UserIds: &userIds}
+
+	th.LoginBasic2()
+
+	_, resp = Client.PatchMentionGroup(rgroup.Id, patch)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.DeleteMentionGroup(rgroup.Id)
+	CheckForbiddenStatus(t, resp)
+
+	th.LoginBasic()
+
+	if patched, resp := Client.PatchMentionGroup(rgroup.Id, patch); resp.Error != nil {
+		t.Fatal(resp.Error)
+	} else if len(patched.UserIds) != 1 || patched.UserIds[0] != th.BasicUser2.Id || patched.Name != rgroup.Name {
+		t.Fatal("should've patched the group's users")
+	}
+
+	th.LoginTeamAdmin()
+
+	_, resp = Client.DeleteMentionGroup(rgroup.Id)
+	CheckNoError(t, resp)
+
+	_, resp = Client.GetMentionGroup(rgroup.Id)
+	CheckNotFoundStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetMentionGroupsForTeam(th.BasicTeam.Id)
+	CheckUnauthorizedStatus(t, resp)
+}
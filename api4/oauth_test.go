@@ -0,0 +1,266 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestCreateOAuthApp(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+	AdminClient := th.SystemAdminClient
+
+	enableOAuth := utils.Cfg.ServiceSettings.EnableOAuthServiceProvider
+	adminOnly := *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = enableOAuth
+		*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = adminOnly
+		utils.SetDefaultRolesBasedOnConfig()
+	}()
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = true
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = true
+	utils.SetDefaultRolesBasedOnConfig()
+
+	oapp := &model.OAuthApp{Name: GenerateTestAppName(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+
+	rapp, resp := AdminClient.CreateOAuthApp(oapp)
+	CheckNoError(t, resp)
+
+	if rapp.Name != oapp.Name {
+		t.Fatal("names did not match")
+	}
+
+	if len(rapp.ClientSecret) == 0 {
+		t.Fatal("should have returned the client secret")
+	}
+
+	_, resp = Client.CreateOAuthApp(oapp)
+	CheckForbiddenStatus(t, resp)
+
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = false
+	utils.SetDefaultRolesBasedOnConfig()
+	_, resp = Client.CreateOAuthApp(oapp)
+	CheckNoError(t, resp)
+
+	oapp.Name = ""
+	_, resp = AdminClient.CreateOAuthApp(oapp)
+	CheckBadRequestStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.CreateOAuthApp(oapp)
+	CheckUnauthorizedStatus(t, resp)
+
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = false
+	oapp.Name = GenerateTestAppName()
+	_, resp = AdminClient.CreateOAuthApp(oapp)
+	CheckNotImplementedStatus(t, resp)
+}
+
+func TestGetOAuthApps(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+	AdminClient := th.SystemAdminClient
+
+	enableOAuth := utils.Cfg.ServiceSettings.EnableOAuthServiceProvider
+	adminOnly := *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = enableOAuth
+		*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = adminOnly
+		utils.SetDefaultRolesBasedOnConfig()
+	}()
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = true
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = false
+	utils.SetDefaultRolesBasedOnConfig()
+
+	oapp := &model.OAuthApp{Name: GenerateTestAppName(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+
+	rapp, resp := AdminClient.CreateOAuthApp(oapp)
+	CheckNoError(t, resp)
+
+	oapp.Name = GenerateTestAppName()
+	rapp2, resp := Client.CreateOAuthApp(oapp)
+	CheckNoError(t, resp)
+
+	apps, resp := AdminClient.GetOAuthApps(0, 1000)
+	CheckNoError(t, resp)
+
+	found1 := false
+	found2 := false
+	for _, a := range apps {
+		if a.Id == rapp.Id {
+			found1 = true
+		}
+		if a.Id == rapp2.Id {
+			found2 = true
+		}
+	}
+
+	if !found1 || !found2 {
+		t.Fatal("missing oauth app")
+	}
+
+	apps, resp = AdminClient.GetOAuthApps(1, 1)
+	CheckNoError(t, resp)
+
+	if len(apps) != 1 {
+		t.Fatal("paging failed")
+	}
+
+	apps, resp = Client.GetOAuthApps(0, 1000)
+	CheckNoError(t, resp)
+
+	if len(apps) != 1 || apps[0].Id != rapp2.Id {
+		t.Fatal("wrong apps returned")
+	}
+
+	rapp3, resp := AdminClient.GetOAuthApp(rapp2.Id)
+	CheckNoError(t, resp)
+	if rapp3.Id != rapp2.Id {
+		t.Fatal("wrong app")
+	}
+
+	_, resp = Client.GetOAuthApp(rapp.Id)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = Client.GetOAuthApp(model.NewId())
+	CheckNotFoundStatus(t, resp)
+
+	info, resp := Client.GetOAuthAppInfo(rapp.Id)
+	CheckNoError(t, resp)
+	if info.Id != rapp.Id {
+		t.Fatal("wrong app")
+	}
+	if len(info.ClientSecret) != 0 {
+		t.Fatal("info should be sanitized")
+	}
+
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = true
+	utils.SetDefaultRolesBasedOnConfig()
+
+	_, resp = Client.GetOAuthApps(0, 1000)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+
+	_, resp = Client.GetOAuthApps(0, 1000)
+	CheckUnauthorizedStatus(t, resp)
+
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = false
+	_, resp = AdminClient.GetOAuthApps(0, 1000)
+	CheckNotImplementedStatus(t, resp)
+}
+
+func TestDeleteOAuthApp(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+	AdminClient := th.SystemAdminClient
+
+	enableOAuth := utils.Cfg.ServiceSettings.EnableOAuthServiceProvider
+	adminOnly := *utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = enableOAuth
+		*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = adminOnly
+		utils.SetDefaultRolesBasedOnConfig()
+	}()
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = true
+	*utils.Cfg.ServiceSettings.EnableOnlyAdminIntegrations = false
+	utils.SetDefaultRolesBasedOnConfig()
+
+	oapp := &model.OAuthApp{Name: GenerateTestAppName(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+
+	rapp, resp := AdminClient.CreateOAuthApp(oapp)
+	CheckNoError(t, resp)
+
+	oapp.Name = GenerateTestAppName()
+	rapp2, resp := Client.CreateOAuthApp(oapp)
+	CheckNoError(t, resp)
+
+	_, resp = Client.DeleteOAuthApp(rapp.Id)
+	CheckForbiddenStatus(t, resp)
+
+	rapp3, resp := Client.RegenerateOAuthAppSecret(rapp2.Id)
+	CheckNoError(t, resp)
+	if rapp3.ClientSecret == rapp2.ClientSecret {
+		t.Fatal("secret should have changed")
+	}
+
+	pass, resp := Client.DeleteOAuthApp(rapp2.Id)
+	CheckNoError(t, resp)
+	if !pass {
+		t.Fatal("should have passed")
+	}
+
+	_, resp = AdminClient.DeleteOAuthApp(rapp.Id)
+	CheckNoError(t, resp)
+
+	_, resp = AdminClient.DeleteOAuthApp(rapp.Id)
+	CheckNotFoundStatus(t, resp)
+
+	_, resp = AdminClient.DeleteOAuthApp("junk")
+	CheckBadRequestStatus(t, resp)
+}
+
+func TestGetAuthorizedOAuthAppsForUser(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+	AdminClient := th.SystemAdminClient
+
+	enableOAuth := utils.Cfg.ServiceSettings.EnableOAuthServiceProvider
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = enableOAuth
+	}()
+	utils.Cfg.ServiceSettings.EnableOAuthServiceProvider = true
+
+	oapp := &model.OAuthApp{Name: GenerateTestAppName(), Homepage: "https://nowhere.com", Description: "test", CallbackUrls: []string{"https://nowhere.com"}}
+
+	rapp, resp := AdminClient.CreateOAuthApp(oapp)
+	CheckNoError(t, resp)
+
+	authorizedApp := model.Preference{
+		UserId:   th.BasicUser.Id,
+		Category: model.PREFERENCE_CATEGORY_AUTHORIZED_OAUTH_APP,
+		Name:     rapp.Id,
+		Value:    model.DEFAULT_SCOPE,
+	}
+	_, resp = Client.UpdatePreferences(th.BasicUser.Id, &model.Preferences{authorizedApp})
+	CheckNoError(t, resp)
+
+	apps, resp := Client.GetAuthorizedOAuthAppsForUser(th.BasicUser.Id, 0, 1000)
+	CheckNoError(t, resp)
+
+	if len(apps) != 1 || apps[0].Id != rapp.Id {
+		t.Fatal("missing authorized app")
+	}
+
+	if len(apps[0].ClientSecret) != 0 {
+		t.Fatal("apps should be sanitized")
+	}
+
+	_, resp = Client.GetAuthorizedOAuthAppsForUser(th.BasicUser2.Id, 0, 1000)
+	CheckForbiddenStatus(t, resp)
+
+	_, resp = AdminClient.GetAuthorizedOAuthAppsForUser(th.BasicUser.Id, 0, 1000)
+	CheckNoError(t, resp)
+
+	pass, resp := Client.DeauthorizeOAuthApp(th.BasicUser.Id, rapp.Id)
+	CheckNoError(t, resp)
+	if !pass {
+		t.Fatal("should have passed")
+	}
+
+	apps, resp = Client.GetAuthorizedOAuthAppsForUser(th.BasicUser.Id, 0, 1000)
+	CheckNoError(t, resp)
+
+	if len(apps) != 0 {
+		t.Fatal("app should have been deauthorized")
+	}
+}
@@ -26,6 +26,12 @@ type ApiParams struct {
 	HookId         string
 	ReportId       string
 	EmojiId        string
+	AppId          string
+	EmojiName      string
+	ActionId       string
+	LockoutId      string
+	LegalHoldId    string
+	MentionGroupId string
 	Email          string
 	Username       string
 	TeamName       string
@@ -77,6 +83,30 @@ func ApiParamsFromRequest(r *http.Request) *ApiParams {
 		params.EmojiId = val
 	}
 
+	if val, ok := props["app_id"]; ok {
+		params.AppId = val
+	}
+
+	if val, ok := props["emoji_name"]; ok {
+		params.EmojiName = val
+	}
+
+	if val, ok := props["action_id"]; ok {
+		params.ActionId = val
+	}
+
+	if val, ok := props["lockout_id"]; ok {
+		params.LockoutId = val
+	}
+
+	if val, ok := props["legal_hold_id"]; ok {
+		params.LegalHoldId = val
+	}
+
+	if val, ok := props["mention_group_id"]; ok {
+		params.MentionGroupId = val
+	}
+
 	if val, ok := props["email"]; ok {
 		params.Email = val
 	}
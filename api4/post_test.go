@@ -5,6 +5,7 @@ package api4
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"strconv"
 	"testing"
 	"time"
@@ -468,6 +469,54 @@ func TestGetPostThread(t *testing.T) {
 	CheckNoError(t, resp)
 }
 
+func TestGetEditHistoryForPost(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	post := th.CreateMessagePost("first")
+
+	list, resp := Client.GetEditHistoryForPost(post.Id)
+	CheckNoError(t, resp)
+
+	if len(list.Order) != 0 {
+		t.Fatal("unedited post shouldn't have history")
+	}
+
+	for _, message := range []string{"second", "third"} {
+		edited := &model.Post{Id: post.Id, UserId: post.UserId, Message: message}
+		if _, err := app.UpdatePost(edited, false); err != nil {
+			t.Fatal(err)
+		}
+
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	list, resp = Client.GetEditHistoryForPost(post.Id)
+	CheckNoError(t, resp)
+
+	if len(list.Order) != 2 || list.Posts[list.Order[0]].Message != "second" || list.Posts[list.Order[1]].Message != "first" {
+		t.Fatal("should've returned the previous versions with the most recent first")
+	}
+
+	if list.Posts[list.Order[0]].OriginalId != post.Id {
+		t.Fatal("previous version should point to the post")
+	}
+
+	_, resp = Client.GetEditHistoryForPost("junk")
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.GetEditHistoryForPost(model.NewId())
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.GetEditHistoryForPost(post.Id)
+	CheckUnauthorizedStatus(t, resp)
+
+	_, resp = th.SystemAdminClient.GetEditHistoryForPost(post.Id)
+	CheckNoError(t, resp)
+}
+
 func TestSearchPosts(t *testing.T) {
 	th := Setup().InitBasic()
 	defer TearDown()
@@ -744,3 +793,105 @@ func TestGetFileInfosForPost(t *testing.T) {
 	_, resp = th.SystemAdminClient.GetFileInfosForPost(th.BasicPost.Id, "")
 	CheckNoError(t, resp)
 }
+
+func TestDoPostAction(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	var request *model.PostActionIntegrationRequest
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request = model.PostActionIntegrationRequestFromJson(r.Body)
+		response := &model.PostActionIntegrationResponse{EphemeralText: "done"}
+		if request != nil && request.Type == model.POST_ACTION_TYPE_SELECT {
+			response.Update = &model.Post{Message: "updated"}
+		}
+		w.Write([]byte(response.ToJson()))
+	}))
+	defer ts.Close()
+
+	buttonId := model.NewId()
+	selectId := model.NewId()
+	post := &model.Post{
+		ChannelId: th.BasicChannel.Id,
+		UserId:    th.BasicUser.Id,
+		Message:   "a" + model.NewId() + "a",
+		Props: model.StringInterface{
+			"override_username": "bot",
+			"attachments": []*model.SlackAttachment{
+				{
+					Text: "hello",
+					Actions: []*model.PostAction{
+						{
+							Id:          buttonId,
+							Name:        "button",
+							Type:        model.POST_ACTION_TYPE_BUTTON,
+							Integration: &model.PostActionIntegration{URL: ts.URL, Context: model.StringInterface{"a": "b"}},
+						},
+						{
+							Id:          selectId,
+							Name:        "select",
+							Type:        model.POST_ACTION_TYPE_SELECT,
+							Options:     []*model.PostActionOptions{{Text: "One", Value: "one"}},
+							Integration: &model.PostActionIntegration{URL: ts.URL},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	post, err := app.CreatePost(post, th.BasicTeam.Id, false, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rpost, resp := Client.GetPost(post.Id, ""); resp.Error != nil {
+		t.Fatal(resp.Error)
+	} else if action := rpost.GetAction(buttonId); action == nil || action.Integration != nil {
+		t.Fatal("shouldn't have sent the action's integration to the client")
+	}
+
+	ok, resp := Client.DoPostAction(post.Id, buttonId)
+	CheckNoError(t, resp)
+
+	if !ok {
+		t.Fatal("should have returned ok")
+	}
+
+	if request == nil || request.UserId != th.BasicUser.Id || request.ChannelId != th.BasicChannel.Id ||
+		request.TeamId != th.BasicTeam.Id || request.PostId != post.Id || request.Context["a"] != "b" {
+		t.Fatal("integration should have received the action context")
+	}
+
+	_, resp = Client.DoPostActionWithOption(post.Id, selectId, "two")
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.DoPostActionWithOption(post.Id, selectId, "one")
+	CheckNoError(t, resp)
+
+	if request.Context[model.POST_ACTION_CONTEXT_SELECTED_OPTION] != "one" {
+		t.Fatal("integration should have received the selected option")
+	}
+
+	if updated, err := app.GetSinglePost(post.Id); err != nil {
+		t.Fatal(err)
+	} else if updated.Message != "updated" || updated.Props["from_webhook"] != "true" {
+		t.Fatal("post should have been updated by the integration")
+	} else if updated.Props["override_username"] != "bot" || updated.GetAction(buttonId) == nil {
+		t.Fatal("should've kept the props that the integration didn't return")
+	}
+
+	_, resp = Client.DoPostAction(post.Id, model.NewId())
+	CheckNotFoundStatus(t, resp)
+
+	_, resp = Client.DoPostAction(post.Id, "junk")
+	CheckBadRequestStatus(t, resp)
+
+	_, resp = Client.DoPostAction(model.NewId(), buttonId)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.DoPostAction(post.Id, buttonId)
+	CheckUnauthorizedStatus(t, resp)
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package api4
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestSaveReaction(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+	userId := th.BasicUser.Id
+	postId := th.BasicPost.Id
+
+	reaction := &model.Reaction{
+		UserId:    userId,
+		PostId:    postId,
+		EmojiName: "smile",
+	}
+
+	rr, resp := Client.SaveReaction(reaction)
+	CheckNoError(t, resp)
+
+	if rr.UserId != reaction.UserId || rr.PostId != reaction.PostId || rr.EmojiName != reaction.EmojiName {
+		t.Fatal("did not save the reaction")
+	}
+
+	reactions, resp := Client.GetReactions(postId)
+	CheckNoError(t, resp)
+	if len(reactions) != 1 {
+		t.Fatal("should have one reaction")
+	}
+
+	reaction.UserId = th.BasicUser2.Id
+	_, resp = Client.SaveReaction(reaction)
+	CheckForbiddenStatus(t, resp)
+
+	reaction.UserId = userId
+	reaction.PostId = "junk"
+	_, resp = Client.SaveReaction(reaction)
+	CheckBadRequestStatus(t, resp)
+
+	reaction.PostId = postId
+	reaction.EmojiName = ""
+	_, resp = Client.SaveReaction(reaction)
+	CheckBadRequestStatus(t, resp)
+
+	// can't react to posts in channels the user isn't a member of
+	privateChannel := th.CreateChannelWithClient(th.SystemAdminClient, model.CHANNEL_PRIVATE)
+	privatePost := th.CreatePostWithClient(th.SystemAdminClient, privateChannel)
+
+	reaction = &model.Reaction{
+		UserId:    userId,
+		PostId:    privatePost.Id,
+		EmojiName: "smile",
+	}
+	_, resp = Client.SaveReaction(reaction)
+	CheckForbiddenStatus(t, resp)
+
+	Client.Logout()
+	_, resp = Client.SaveReaction(reaction)
+	CheckUnauthorizedStatus(t, resp)
+}
+
+func TestDeleteReaction(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+	userId := th.BasicUser.Id
+	postId := th.BasicPost.Id
+
+	reaction := &model.Reaction{
+		UserId:    userId,
+		PostId:    postId,
+		EmojiName: "+1",
+	}
+
+	_, resp := Client.SaveReaction(reaction)
+	CheckNoError(t, resp)
+
+	// another user can't remove it
+	th.LoginBasic2()
+	_, resp = Client.DeleteReaction(reaction)
+	CheckForbiddenStatus(t, resp)
+
+	th.LoginBasic()
+	ok, resp := Client.DeleteReaction(reaction)
+	CheckNoError(t, resp)
+	if !ok {
+		t.Fatal("should have returned true")
+	}
+
+	reactions, resp := Client.GetReactions(postId)
+	CheckNoError(t, resp)
+	if len(reactions) != 0 {
+		t.Fatal("should have removed the reaction")
+	}
+
+	// a system admin can remove anyone's reaction
+	_, resp = Client.SaveReaction(reaction)
+	CheckNoError(t, resp)
+
+	_, resp = th.SystemAdminClient.DeleteReaction(reaction)
+	CheckNoError(t, resp)
+
+	reaction.PostId = "junk"
+	_, resp = Client.DeleteReaction(reaction)
+	CheckBadRequestStatus(t, resp)
+}
@@ -25,6 +25,8 @@ func InitSystem() {
 	BaseRoutes.ApiRoot.Handle("/caches/invalidate", ApiSessionRequired(invalidateCaches)).Methods("POST")
 
 	BaseRoutes.ApiRoot.Handle("/logs", ApiSessionRequired(getLogs)).Methods("GET")
+
+	BaseRoutes.ApiRoot.Handle("/analytics/old", ApiSessionRequired(getAnalytics)).Methods("GET")
 }
 
 func getSystemPing(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -155,3 +157,30 @@ func getLogs(c *Context, w http.ResponseWriter, r *http.Request) {
 
 	w.Write([]byte(model.ArrayToJson(lines)))
 }
+
+func getAnalytics(c *Context, w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	teamId := r.URL.Query().Get("team_id")
+
+	if name == "" {
+		name = "standard"
+	}
+
+	if !app.SessionHasPermissionTo(c.Session, model.PERMISSION_MANAGE_SYSTEM) {
+		c.SetPermissionError(model.PERMISSION_MANAGE_SYSTEM)
+		return
+	}
+
+	rows, err := app.GetAnalytics(name, teamId)
+	if err != nil {
+		c.Err = err
+		return
+	}
+
+	if rows == nil {
+		c.SetInvalidParam("name")
+		return
+	}
+
+	w.Write([]byte(rows.ToJson()))
+}
@@ -27,6 +27,8 @@ func InitWebhook() {
 	BaseRoutes.OutgoingHook.Handle("", ApiSessionRequired(updateOutgoingHook)).Methods("PUT")
 	BaseRoutes.OutgoingHook.Handle("", ApiSessionRequired(deleteOutgoingHook)).Methods("DELETE")
 	BaseRoutes.OutgoingHook.Handle("/regen_token", ApiSessionRequired(regenOutgoingHookToken)).Methods("POST")
+
+	BaseRoutes.Hooks.Handle("/commands/{hook_id:[A-Za-z0-9]+}", ApiHandler(commandWebhook)).Methods("POST")
 }
 
 func createIncomingHook(c *Context, w http.ResponseWriter, r *http.Request) {
@@ -431,3 +433,25 @@ func deleteOutgoingHook(c *Context, w http.ResponseWriter, r *http.Request) {
 	c.LogAudit("success")
 	ReturnStatusOK(w)
 }
+
+func commandWebhook(c *Context, w http.ResponseWriter, r *http.Request) {
+	c.RequireHookId()
+	if c.Err != nil {
+		return
+	}
+
+	// the token is part of the response_url, but integrations may also send it in a header
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		token = app.ParseAuthTokenFromRequest(r)
+	}
+
+	response := model.CommandResponseFromJson(r.Body)
+
+	if err := app.HandleCommandWebhook(c.Params.HookId, token, response, c.GetSiteURL()); err != nil {
+		c.Err = err
+		return
+	}
+
+	ReturnStatusOK(w)
+}
@@ -4,8 +4,13 @@
 package api4
 
 import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
+	"github.com/mattermost/platform/app"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 )
@@ -893,3 +898,67 @@ func TestDeleteOutgoingHook(t *testing.T) {
 		CheckForbiddenStatus(t, resp)
 	})
 }
+
+func TestCommandWebhooks(t *testing.T) {
+	th := Setup().InitBasic().InitSystemAdmin()
+	defer TearDown()
+	Client := th.Client
+
+	enableCommands := *utils.Cfg.ServiceSettings.EnableCommands
+	maxUses := *utils.Cfg.ServiceSettings.CommandWebhookMaxUses
+	defer func() {
+		utils.Cfg.ServiceSettings.EnableCommands = &enableCommands
+		utils.Cfg.ServiceSettings.CommandWebhookMaxUses = &maxUses
+	}()
+	*utils.Cfg.ServiceSettings.EnableCommands = true
+	*utils.Cfg.ServiceSettings.CommandWebhookMaxUses = 2
+
+	responseURL := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		responseURL = r.FormValue("response_url")
+		w.Write([]byte(`{"response_type": "in_channel", "text": "working on it"}`))
+	}))
+	defer ts.Close()
+
+	cmd := &model.Command{
+		CreatorId: th.SystemAdminUser.Id,
+		TeamId:    th.BasicTeam.Id,
+		URL:       ts.URL,
+		Method:    model.COMMAND_METHOD_POST,
+		Trigger:   "delayed",
+	}
+	if _, err := app.CreateCommand(cmd); err != nil {
+		t.Fatal(err)
+	}
+
+	_, resp := Client.ExecuteCommand(th.BasicChannel.Id, "/delayed")
+	CheckNoError(t, resp)
+
+	parsed, err := url.Parse(responseURL)
+	if err != nil {
+		t.Fatal(err)
+	} else if !strings.HasPrefix(parsed.Path, model.API_URL_SUFFIX+"/hooks/commands/") {
+		t.Fatal("should have sent a response url, got " + responseURL)
+	}
+
+	hookURI := strings.TrimPrefix(parsed.RequestURI(), model.API_URL_SUFFIX)
+
+	if _, err := Client.DoApiPost(strings.TrimPrefix(parsed.Path, model.API_URL_SUFFIX), `{"text": "done"}`); err == nil || err.StatusCode != http.StatusUnauthorized {
+		t.Fatal("should have failed - missing token")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := Client.DoApiPost(hookURI, `{"response_type": "in_channel", "text": "done"}`); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := Client.DoApiPost(hookURI, `{"response_type": "in_channel", "text": "done"}`); err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should have failed - use limit reached")
+	}
+
+	if _, err := Client.DoApiPost("/hooks/commands/"+model.NewId(), `{"text": "done"}`); err == nil || err.StatusCode != http.StatusNotFound {
+		t.Fatal("should have failed - bad hook id")
+	}
+}
@@ -118,9 +118,29 @@ func ReloadConfig() {
 
 	// start/restart email batching job if necessary
 	InitEmailBatching()
+
+	// start/restart the email reply server in case its settings have changed
+	RestartEmailReplyServer()
 }
 
 func SaveConfig(cfg *model.Config) *model.AppError {
+	oldCfg := utils.Cfg
+
+	if err := SaveConfigSkipClusterSend(cfg); err != nil {
+		return err
+	}
+
+	if einterfaces.GetClusterInterface() != nil {
+		err := einterfaces.GetClusterInterface().ConfigChanged(oldCfg, utils.Cfg, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func SaveConfigSkipClusterSend(cfg *model.Config) *model.AppError {
 	cfg.SetDefaults()
 	utils.Desanitize(cfg)
 
@@ -132,11 +152,6 @@ func SaveConfig(cfg *model.Config) *model.AppError {
 		return err
 	}
 
-	if *utils.Cfg.ClusterSettings.Enable {
-		return model.NewLocAppError("saveConfig", "ent.cluster.save_config.error", nil, "")
-	}
-
-	//oldCfg := utils.Cfg
 	utils.SaveConfig(utils.CfgFileName, cfg)
 	utils.LoadConfig(utils.CfgFileName)
 
@@ -148,17 +163,12 @@ func SaveConfig(cfg *model.Config) *model.AppError {
 		}
 	}
 
-	// Future feature is to sync the configuration files
-	// if einterfaces.GetClusterInterface() != nil {
-	// 	err := einterfaces.GetClusterInterface().ConfigChanged(cfg, oldCfg, true)
-	// 	if err != nil {
-	// 		return err
-	// 	}
-	// }
-
 	// start/restart email batching job if necessary
 	InitEmailBatching()
 
+	// start/restart the email reply server in case its settings have changed
+	RestartEmailReplyServer()
+
 	return nil
 }
 
@@ -159,6 +159,25 @@ func GetAnalytics(name string, teamId string) (model.AnalyticsRows, *model.AppEr
 		} else {
 			return r.Data.(model.AnalyticsRows), nil
 		}
+	} else if name == "storage_by_team" {
+		if skipIntensiveQueries {
+			rows := model.AnalyticsRows{&model.AnalyticsRow{Name: "", Value: -1}}
+			return rows, nil
+		}
+
+		if teamId != "" {
+			if r := <-Srv.Store.FileInfo().GetStorageUsageForTeam(teamId); r.Err != nil {
+				return nil, r.Err
+			} else {
+				return model.AnalyticsRows{&model.AnalyticsRow{Name: teamId, Value: float64(r.Data.(int64))}}, nil
+			}
+		}
+
+		if r := <-Srv.Store.FileInfo().AnalyticsStorageUsageByTeam(); r.Err != nil {
+			return nil, r.Err
+		} else {
+			return r.Data.(model.AnalyticsRows), nil
+		}
 	} else if name == "extra_counts" {
 		var rows model.AnalyticsRows = make([]*model.AnalyticsRow, 6)
 		rows[0] = &model.AnalyticsRow{Name: "file_post_count", Value: 0}
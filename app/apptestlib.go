@@ -27,6 +27,7 @@ func SetupEnterprise() *TestHelper {
 		utils.InitTranslations(utils.Cfg.LocalizationSettings)
 		utils.Cfg.TeamSettings.MaxUsersPerTeam = 50
 		*utils.Cfg.RateLimitSettings.Enable = false
+		*utils.Cfg.ServiceSettings.EnableLoginLockout = false
 		utils.DisableDebugLogForTest()
 		utils.License.Features.SetDefaults()
 		NewServer()
@@ -49,6 +50,7 @@ func Setup() *TestHelper {
 		utils.InitTranslations(utils.Cfg.LocalizationSettings)
 		utils.Cfg.TeamSettings.MaxUsersPerTeam = 50
 		*utils.Cfg.RateLimitSettings.Enable = false
+		*utils.Cfg.ServiceSettings.EnableLoginLockout = false
 		utils.DisableDebugLogForTest()
 		NewServer()
 		InitStores()
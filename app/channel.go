@@ -306,6 +306,14 @@ func UpdateChannelMemberNotifyProps(data map[string]string, channelId string, us
 		member.NotifyProps[model.PUSH_NOTIFY_PROP] = push
 	}
 
+	if keywords, exists := data[model.KEYWORDS_NOTIFY_PROP]; exists {
+		if !model.IsValidMentionKeywords(keywords) {
+			return nil, model.NewAppError("UpdateChannelMemberNotifyProps", "model.channel_member.is_valid.mention_keywords.app_error", nil, "user_id="+userId, http.StatusBadRequest)
+		}
+
+		member.NotifyProps[model.KEYWORDS_NOTIFY_PROP] = keywords
+	}
+
 	if result := <-Srv.Store.Channel().UpdateMember(member); result.Err != nil {
 		return nil, result.Err
 	} else {
@@ -1031,6 +1039,10 @@ func ViewChannel(view *model.ChannelView, userId string, clearPushNotifications
 }
 
 func PermanentDeleteChannel(channel *model.Channel) *model.AppError {
+	if err := CheckChannelNotUnderLegalHold(channel.Id); err != nil {
+		return err
+	}
+
 	if result := <-Srv.Store.Post().PermanentDeleteByChannel(channel.Id); result.Err != nil {
 		return result.Err
 	}
@@ -0,0 +1,47 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"strconv"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// claimClusterTask claims a task that should only be run by one server at a time for the given duration, after
+// which it can be claimed again in case the server that claimed it has gone away. It returns false if another
+// server has already claimed the task. Otherwise, the claim can be passed to releaseClusterTask once the task
+// is done.
+func claimClusterTask(name string, duration time.Duration) (string, bool) {
+	oldValue := ""
+	if result := <-Srv.Store.System().GetByName(name); result.Err == nil {
+		oldValue = result.Data.(*model.System).Value
+	}
+
+	now := model.GetMillis()
+	if expiresAt, _ := strconv.ParseInt(oldValue, 10, 64); expiresAt > now {
+		return "", false
+	}
+
+	claim := strconv.FormatInt(now+int64(duration/time.Millisecond), 10)
+
+	if result := <-Srv.Store.System().Claim(name, oldValue, claim); result.Err != nil {
+		l4g.Error(utils.T("app.cluster_task.claim.error"), name, result.Err.Error())
+		return "", false
+	} else if !result.Data.(bool) {
+		return "", false
+	}
+
+	return claim, true
+}
+
+// releaseClusterTask lets the task be claimed again before its claim would've expired.
+func releaseClusterTask(name string, claim string) {
+	if result := <-Srv.Store.System().Claim(name, claim, ""); result.Err != nil {
+		l4g.Error(utils.T("app.cluster_task.release.error"), name, result.Err.Error())
+	}
+}
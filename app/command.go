@@ -4,7 +4,7 @@
 package app
 
 import (
-	"crypto/tls"
+	"crypto/subtle"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -166,17 +166,23 @@ func ExecuteCommand(args *model.CommandArgs) (*model.CommandResponse, *model.App
 
 					p.Set("command", "/"+trigger)
 					p.Set("text", message)
-					p.Set("response_url", "not supported yet")
+
+					if hook, err := CreateCommandWebhook(cmd.Id, args); err != nil {
+						return nil, model.NewAppError("command", "api.command.execute_command.failed.app_error", map[string]interface{}{"Trigger": trigger}, err.Error(), http.StatusInternalServerError)
+					} else {
+						args.ResponseURL = hook.GetResponseURL(args.SiteURL)
+						args.ResponseToken = hook.Token
+
+						p.Set("response_url", args.ResponseURL)
+						p.Set("response_token", args.ResponseToken)
+					}
 
 					method := "POST"
 					if cmd.Method == model.COMMAND_METHOD_GET {
 						method = "GET"
 					}
 
-					tr := &http.Transport{
-						TLSClientConfig: &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
-					}
-					client := &http.Client{Transport: tr}
+					client := NewHTTPClient()
 
 					req, _ := http.NewRequest(method, cmd.URL, strings.NewReader(p.Encode()))
 					req.Header.Set("Accept", "application/json")
@@ -244,6 +250,82 @@ func HandleCommandResponse(command *model.Command, args *model.CommandArgs, resp
 	return response, nil
 }
 
+// CreateCommandWebhook creates the webhook that lets an integration send delayed responses to a command.
+func CreateCommandWebhook(commandId string, args *model.CommandArgs) (*model.CommandWebhook, *model.AppError) {
+	hook := &model.CommandWebhook{
+		CommandId: commandId,
+		UserId:    args.UserId,
+		ChannelId: args.ChannelId,
+		RootId:    args.RootId,
+		ParentId:  args.ParentId,
+	}
+
+	if result := <-Srv.Store.CommandWebhook().Save(hook); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.CommandWebhook), nil
+	}
+}
+
+// HandleCommandWebhook posts a delayed response to the channel the command was executed in. Each webhook
+// can only be used a limited number of times before it expires.
+func HandleCommandWebhook(hookId, token string, response *model.CommandResponse, siteURL string) *model.AppError {
+	if !*utils.Cfg.ServiceSettings.EnableCommands {
+		return model.NewAppError("HandleCommandWebhook", "api.command.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if response == nil {
+		return model.NewAppError("HandleCommandWebhook", "web.command_webhook.parse.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	expireTime := getCommandWebhookExpireTime()
+
+	var hook *model.CommandWebhook
+	if result := <-Srv.Store.CommandWebhook().Get(hookId, expireTime); result.Err != nil {
+		return model.NewAppError("HandleCommandWebhook", "web.command_webhook.invalid.app_error", nil, "err="+result.Err.Message, result.Err.StatusCode)
+	} else {
+		hook = result.Data.(*model.CommandWebhook)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), []byte(hook.Token)) != 1 {
+		return model.NewAppError("HandleCommandWebhook", "web.command_webhook.token.app_error", nil, "id="+hookId, http.StatusUnauthorized)
+	}
+
+	var cmd *model.Command
+	if result := <-Srv.Store.Command().Get(hook.CommandId); result.Err != nil {
+		return model.NewAppError("HandleCommandWebhook", "web.command_webhook.command.app_error", nil, "err="+result.Err.Message, http.StatusBadRequest)
+	} else {
+		cmd = result.Data.(*model.Command)
+	}
+
+	args := &model.CommandArgs{
+		UserId:    hook.UserId,
+		ChannelId: hook.ChannelId,
+		TeamId:    cmd.TeamId,
+		RootId:    hook.RootId,
+		ParentId:  hook.ParentId,
+		SiteURL:   siteURL,
+	}
+
+	if result := <-Srv.Store.CommandWebhook().TryUse(hook.Id, *utils.Cfg.ServiceSettings.CommandWebhookMaxUses, expireTime); result.Err != nil {
+		return model.NewAppError("HandleCommandWebhook", "web.command_webhook.invalid.app_error", nil, "err="+result.Err.Message, result.Err.StatusCode)
+	}
+
+	_, err := HandleCommandResponse(cmd, args, response, false)
+	return err
+}
+
+func CleanupCommandWebhooks() {
+	if result := <-Srv.Store.CommandWebhook().Cleanup(getCommandWebhookExpireTime()); result.Err != nil {
+		l4g.Error(utils.T("api.command.cleanup_command_webhooks.error"), result.Err.Error())
+	}
+}
+
+// getCommandWebhookExpireTime returns the time before which command webhooks were created can no longer be used.
+func getCommandWebhookExpireTime() int64 {
+	return model.GetMillis() - int64(*utils.Cfg.ServiceSettings.CommandWebhookLifetimeInMinutes)*60*1000
+}
+
 func CreateCommand(cmd *model.Command) (*model.Command, *model.AppError) {
 	if !*utils.Cfg.ServiceSettings.EnableCommands {
 		return nil, model.NewAppError("CreateCommand", "api.command.disabled.app_error", nil, "", http.StatusNotImplemented)
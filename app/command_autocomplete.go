@@ -0,0 +1,252 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	goi18n "github.com/nicksnyder/go-i18n/i18n"
+)
+
+// CommandAutocompleteProvider is implemented by built-in command providers that fill in their own
+// dynamic list arguments instead of fetching them from an integration.
+type CommandAutocompleteProvider interface {
+	GetAutocompleteListItems(args *model.CommandArgs, arg *model.AutocompleteArg, partial string) ([]*model.AutocompleteListItem, *model.AppError)
+}
+
+// GetCommandAutocompleteSuggestions returns suggestions for a partially typed command line. Triggers
+// are suggested until the first space, after which the command's argument definitions are used.
+// Suggestions that start with the text being typed are ranked ahead of those that only contain it.
+func GetCommandAutocompleteSuggestions(args *model.CommandArgs, userInput string) ([]*model.AutocompleteSuggestion, *model.AppError) {
+	if !strings.HasPrefix(userInput, "/") {
+		return []*model.AutocompleteSuggestion{}, nil
+	}
+
+	line := userInput[1:]
+	index := strings.Index(line, " ")
+	if index == -1 {
+		commands, err := ListCommands(args.TeamId, args.T)
+		if err != nil {
+			return nil, err
+		}
+
+		items := make([]*model.AutocompleteListItem, 0, len(commands))
+		for _, cmd := range commands {
+			items = append(items, &model.AutocompleteListItem{Item: "/" + cmd.Trigger, HelpText: cmd.AutoCompleteDesc, Hint: cmd.AutoCompleteHint})
+		}
+
+		return rankAutocompleteListItems("", userInput, items), nil
+	}
+
+	cmd, provider, err := getCommandForAutocomplete(args.TeamId, strings.ToLower(line[:index]), args.T)
+	if err != nil {
+		return nil, err
+	} else if cmd == nil {
+		return []*model.AutocompleteSuggestion{}, nil
+	}
+
+	arg, partial, usedFlags := parseAutocompleteInput(cmd.AutocompleteArgs, line[index+1:])
+	prefix := userInput[:len(userInput)-len(partial)]
+
+	if arg == nil {
+		items := []*model.AutocompleteListItem{}
+		for _, flag := range cmd.AutocompleteArgs {
+			if flag.IsFlag() && !usedFlags[flag.Name] {
+				items = append(items, &model.AutocompleteListItem{Item: model.AUTOCOMPLETE_ARG_FLAG_PREFIX + flag.Name, HelpText: flag.HelpText, Hint: flag.Hint})
+			}
+		}
+
+		return rankAutocompleteListItems(prefix, partial, items), nil
+	}
+
+	switch arg.Type {
+	case model.AUTOCOMPLETE_ARG_TYPE_STATIC_LIST:
+		return rankAutocompleteListItems(prefix, partial, arg.Choices), nil
+	case model.AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST:
+		items, err := getDynamicAutocompleteListItems(args, cmd, provider, arg, userInput, partial)
+		if err != nil {
+			return nil, err
+		}
+
+		return rankAutocompleteListItems(prefix, partial, items), nil
+	}
+
+	return []*model.AutocompleteSuggestion{{Complete: userInput, Hint: arg.Hint, Description: arg.HelpText}}, nil
+}
+
+// getCommandForAutocomplete returns the built-in or custom command with the given trigger along with
+// its provider for built-in commands. The command is nil if the team doesn't have one.
+func getCommandForAutocomplete(teamId string, trigger string, T goi18n.TranslateFunc) (*model.Command, CommandProvider, *model.AppError) {
+	if provider := GetCommandProvider(trigger); provider != nil {
+		return provider.GetCommand(T), provider, nil
+	}
+
+	if !*utils.Cfg.ServiceSettings.EnableCommands {
+		return nil, nil, nil
+	}
+
+	if result := <-Srv.Store.Command().GetByTeam(teamId); result.Err != nil {
+		return nil, nil, result.Err
+	} else {
+		for _, cmd := range result.Data.([]*model.Command) {
+			if cmd.Trigger == trigger {
+				return cmd, nil, nil
+			}
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// parseAutocompleteInput works out which argument the last word of the input is a value for. Words
+// starting with -- name a flag and the word following them is its value, every other word fills the
+// next positional argument. The argument is nil when a flag name should be suggested instead.
+func parseAutocompleteInput(args model.AutocompleteArgList, input string) (*model.AutocompleteArg, string, map[string]bool) {
+	words := strings.Fields(input)
+
+	partial := ""
+	if len(words) > 0 && !strings.HasSuffix(input, " ") {
+		partial = words[len(words)-1]
+		words = words[:len(words)-1]
+	}
+
+	usedFlags := make(map[string]bool)
+	position := 0
+
+	var flag *model.AutocompleteArg
+	for _, word := range words {
+		if flag != nil {
+			flag = nil
+		} else if strings.HasPrefix(word, model.AUTOCOMPLETE_ARG_FLAG_PREFIX) {
+			name := strings.TrimPrefix(word, model.AUTOCOMPLETE_ARG_FLAG_PREFIX)
+			usedFlags[name] = true
+			flag = args.Flag(name)
+		} else {
+			position++
+		}
+	}
+
+	if flag != nil {
+		return flag, partial, usedFlags
+	}
+
+	if strings.HasPrefix(partial, "-") {
+		return nil, partial, usedFlags
+	}
+
+	if positional := args.Positional(); position < len(positional) {
+		return positional[position], partial, usedFlags
+	}
+
+	return nil, partial, usedFlags
+}
+
+// rankAutocompleteListItems turns the items matching the partially typed word into suggestions that
+// complete the input. Items starting with the word come first followed by items that contain it.
+func rankAutocompleteListItems(prefix string, partial string, items []*model.AutocompleteListItem) []*model.AutocompleteSuggestion {
+	lowerPartial := strings.ToLower(partial)
+
+	type rankedSuggestion struct {
+		rank       int
+		suggestion *model.AutocompleteSuggestion
+	}
+
+	ranked := []rankedSuggestion{}
+	for _, item := range items {
+		lowerItem := strings.ToLower(item.Item)
+
+		var rank int
+		if strings.HasPrefix(lowerItem, lowerPartial) {
+			rank = 1
+		} else if strings.Contains(lowerItem, lowerPartial) {
+			rank = 2
+		} else {
+			continue
+		}
+
+		ranked = append(ranked, rankedSuggestion{
+			rank: rank,
+			suggestion: &model.AutocompleteSuggestion{
+				Complete:    prefix + item.Item,
+				Suggestion:  item.Item,
+				Hint:        item.Hint,
+				Description: item.HelpText,
+			},
+		})
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].rank != ranked[j].rank {
+			return ranked[i].rank < ranked[j].rank
+		}
+		return strings.ToLower(ranked[i].suggestion.Suggestion) < strings.ToLower(ranked[j].suggestion.Suggestion)
+	})
+
+	suggestions := make([]*model.AutocompleteSuggestion, len(ranked))
+	for i, r := range ranked {
+		suggestions[i] = r.suggestion
+	}
+
+	return suggestions
+}
+
+// getDynamicAutocompleteListItems asks a built-in provider for the values of a dynamic list argument or
+// fetches them from the integration. Integrations receive the same identifying fields as when the
+// command is executed and reply with a JSON array of list items.
+func getDynamicAutocompleteListItems(args *model.CommandArgs, cmd *model.Command, provider CommandProvider, arg *model.AutocompleteArg, userInput string, partial string) ([]*model.AutocompleteListItem, *model.AppError) {
+	if provider != nil {
+		if autocompleteProvider, ok := provider.(CommandAutocompleteProvider); ok {
+			return autocompleteProvider.GetAutocompleteListItems(args, arg, partial)
+		}
+
+		return []*model.AutocompleteListItem{}, nil
+	}
+
+	fetchURL := arg.FetchURL
+	if len(fetchURL) == 0 {
+		fetchURL = cmd.URL
+	}
+
+	p := url.Values{}
+	p.Set("token", cmd.Token)
+	p.Set("team_id", cmd.TeamId)
+	p.Set("channel_id", args.ChannelId)
+	p.Set("user_id", args.UserId)
+	p.Set("command", "/"+cmd.Trigger)
+	p.Set("user_input", userInput)
+	p.Set("arg_name", arg.Name)
+	p.Set("partial", partial)
+
+	separator := "?"
+	if strings.Contains(fetchURL, "?") {
+		separator = "&"
+	}
+
+	client := NewHTTPClient()
+
+	req, _ := http.NewRequest("GET", fetchURL+separator+p.Encode(), nil)
+	req.Header.Set("Accept", "application/json")
+
+	if resp, err := client.Do(req); err != nil {
+		return nil, model.NewAppError("getDynamicAutocompleteListItems", "api.command.autocomplete.fetch.app_error", map[string]interface{}{"Trigger": cmd.Trigger}, err.Error(), http.StatusInternalServerError)
+	} else {
+		defer CloseBody(resp)
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, model.NewAppError("getDynamicAutocompleteListItems", "api.command.autocomplete.fetch.app_error", map[string]interface{}{"Trigger": cmd.Trigger}, "status="+resp.Status, http.StatusInternalServerError)
+		}
+
+		items := model.AutocompleteListItemsFromJson(resp.Body)
+		if items == nil {
+			return nil, model.NewAppError("getDynamicAutocompleteListItems", "api.command.autocomplete.fetch.app_error", map[string]interface{}{"Trigger": cmd.Trigger}, "invalid response", http.StatusInternalServerError)
+		}
+
+		return items, nil
+	}
+}
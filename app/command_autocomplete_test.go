@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestParseAutocompleteInput(t *testing.T) {
+	args := model.AutocompleteArgList{
+		{HelpText: "first", Type: model.AUTOCOMPLETE_ARG_TYPE_TEXT},
+		{HelpText: "second", Type: model.AUTOCOMPLETE_ARG_TYPE_TEXT},
+		{Name: "flag", HelpText: "flag", Type: model.AUTOCOMPLETE_ARG_TYPE_TEXT},
+	}
+
+	for input, expected := range map[string]string{
+		"":                   "first",
+		"fir":                "first",
+		"one ":               "second",
+		"one sec":            "second",
+		"--flag ":            "flag",
+		"--flag val":         "flag",
+		"--flag value ":      "first",
+		"one --flag value t": "second",
+		"one two ":           "",
+		"one -":              "",
+	} {
+		arg, _, _ := parseAutocompleteInput(args, input)
+		if expected == "" && arg != nil {
+			t.Fatal("should have suggested flags for " + input)
+		} else if expected != "" && (arg == nil || arg.HelpText != expected) {
+			t.Fatal("wrong argument for " + input)
+		}
+	}
+
+	_, partial, usedFlags := parseAutocompleteInput(args, "--flag value sec")
+	if partial != "sec" {
+		t.Fatal("wrong partial " + partial)
+	} else if !usedFlags["flag"] {
+		t.Fatal("flag should have been used")
+	}
+}
+
+func TestRankAutocompleteListItems(t *testing.T) {
+	items := []*model.AutocompleteListItem{
+		{Item: "uptown"},
+		{Item: "Upper", HelpText: "help", Hint: "hint"},
+		{Item: "down"},
+		{Item: "setup"},
+	}
+
+	suggestions := rankAutocompleteListItems("/cmd ", "up", items)
+	if len(suggestions) != 3 {
+		t.Fatal("should have dropped items that don't match")
+	}
+
+	if suggestions[0].Suggestion != "Upper" || suggestions[1].Suggestion != "uptown" || suggestions[2].Suggestion != "setup" {
+		t.Fatal("prefix matches should be ranked ahead of other matches")
+	}
+
+	if suggestions[0].Complete != "/cmd Upper" || suggestions[0].Description != "help" || suggestions[0].Hint != "hint" {
+		t.Fatal("suggestion should complete the input")
+	}
+
+	if len(rankAutocompleteListItems("/cmd ", "", items)) != 4 {
+		t.Fatal("should have suggested every item")
+	}
+}
@@ -29,10 +29,42 @@ func (me *JoinProvider) GetCommand(T goi18n.TranslateFunc) *model.Command {
 		AutoComplete:     true,
 		AutoCompleteDesc: T("api.command_join.desc"),
 		AutoCompleteHint: T("api.command_join.hint"),
-		DisplayName:      T("api.command_join.name"),
+		AutocompleteArgs: model.AutocompleteArgList{
+			{
+				HelpText: T("api.command_join.autocomplete.channel"),
+				Hint:     "[channel-name]",
+				Type:     model.AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST,
+				Required: true,
+			},
+		},
+		DisplayName: T("api.command_join.name"),
 	}
 }
 
+func (me *JoinProvider) GetAutocompleteListItems(args *model.CommandArgs, arg *model.AutocompleteArg, partial string) ([]*model.AutocompleteListItem, *model.AppError) {
+	var channels *model.ChannelList
+	var err *model.AppError
+
+	if len(partial) > 0 {
+		channels, err = SearchChannels(args.TeamId, partial)
+	} else {
+		channels, err = GetPublicChannelsForTeam(args.TeamId, 0, 100)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*model.AutocompleteListItem, 0, len(*channels))
+	for _, channel := range *channels {
+		if channel.Type == model.CHANNEL_OPEN {
+			items = append(items, &model.AutocompleteListItem{Item: channel.Name, HelpText: channel.DisplayName})
+		}
+	}
+
+	return items, nil
+}
+
 func (me *JoinProvider) DoCommand(args *model.CommandArgs, message string) *model.CommandResponse {
 	if result := <-Srv.Store.Channel().GetByName(args.TeamId, message, true); result.Err != nil {
 		return &model.CommandResponse{Text: args.T("api.command_join.list.app_error"), ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL}
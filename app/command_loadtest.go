@@ -5,7 +5,6 @@ package app
 
 import (
 	"io"
-	"net/http"
 	"path"
 	"strconv"
 	"strings"
@@ -81,7 +80,29 @@ func (me *LoadTestProvider) GetCommand(T goi18n.TranslateFunc) *model.Command {
 		AutoComplete:     false,
 		AutoCompleteDesc: "Debug Load Testing",
 		AutoCompleteHint: "help",
-		DisplayName:      "loadtest",
+		AutocompleteArgs: model.AutocompleteArgList{
+			{
+				HelpText: "Load test command to run",
+				Hint:     "[command]",
+				Type:     model.AUTOCOMPLETE_ARG_TYPE_STATIC_LIST,
+				Required: true,
+				Choices: []*model.AutocompleteListItem{
+					{Item: "setup", HelpText: "Creates a testing environment in current team", Hint: "[teams] [fuzz] <Num Channels> <Num Users> <NumPosts>"},
+					{Item: "users", HelpText: "Add a specified number of random users to current team", Hint: "[fuzz] <Min Users> <Max Users>"},
+					{Item: "channels", HelpText: "Add a specified number of random channels to current team", Hint: "[fuzz] <Min Channels> <Max Channels>"},
+					{Item: "posts", HelpText: "Add some random posts to current channel", Hint: "[fuzz] <Min Posts> <Max Posts> <Max Images>"},
+					{Item: "url", HelpText: "Add a post containing the text from a given url to current channel", Hint: "<url>"},
+					{Item: "json", HelpText: "Add a post using the JSON file as payload to the current channel", Hint: "<url>"},
+					{Item: "help", HelpText: "Show the load testing commands"},
+				},
+			},
+			{
+				HelpText: "Arguments for the load test command",
+				Hint:     "[arguments]",
+				Type:     model.AUTOCOMPLETE_ARG_TYPE_TEXT,
+			},
+		},
+		DisplayName: "loadtest",
 	}
 }
 
@@ -331,7 +352,7 @@ func (me *LoadTestProvider) UrlCommand(args *model.CommandArgs, message string)
 	}
 
 	var contents io.ReadCloser
-	if r, err := http.Get(url); err != nil {
+	if r, err := NewHTTPClient().Get(url); err != nil {
 		return &model.CommandResponse{Text: "Unable to get file", ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL}
 	} else if r.StatusCode > 400 {
 		return &model.CommandResponse{Text: "Unable to get file", ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL}
@@ -381,7 +402,7 @@ func (me *LoadTestProvider) JsonCommand(args *model.CommandArgs, message string)
 	}
 
 	var contents io.ReadCloser
-	if r, err := http.Get(url); err != nil {
+	if r, err := NewHTTPClient().Get(url); err != nil {
 		return &model.CommandResponse{Text: "Unable to get file", ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL}
 	} else if r.StatusCode > 400 {
 		return &model.CommandResponse{Text: "Unable to get file", ResponseType: model.COMMAND_RESPONSE_TYPE_EPHEMERAL}
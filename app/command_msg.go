@@ -8,6 +8,7 @@ import (
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
 	goi18n "github.com/nicksnyder/go-i18n/i18n"
 )
 
@@ -32,10 +33,47 @@ func (me *msgProvider) GetCommand(T goi18n.TranslateFunc) *model.Command {
 		AutoComplete:     true,
 		AutoCompleteDesc: T("api.command_msg.desc"),
 		AutoCompleteHint: T("api.command_msg.hint"),
-		DisplayName:      T("api.command_msg.name"),
+		AutocompleteArgs: model.AutocompleteArgList{
+			{
+				HelpText: T("api.command_msg.autocomplete.username"),
+				Hint:     "@[username]",
+				Type:     model.AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST,
+				Required: true,
+			},
+			{
+				HelpText: T("api.command_msg.autocomplete.message"),
+				Hint:     "[message]",
+				Type:     model.AUTOCOMPLETE_ARG_TYPE_TEXT,
+			},
+		},
+		DisplayName: T("api.command_msg.name"),
 	}
 }
 
+func (me *msgProvider) GetAutocompleteListItems(args *model.CommandArgs, arg *model.AutocompleteArg, partial string) ([]*model.AutocompleteListItem, *model.AppError) {
+	var users []*model.User
+	var err *model.AppError
+
+	if term := strings.TrimPrefix(partial, "@"); len(term) > 0 {
+		users, err = SearchUsersInTeam(args.TeamId, term, map[string]bool{store.USER_SEARCH_OPTION_NAMES_ONLY: true}, false)
+	} else {
+		users, err = GetUsersInTeam(args.TeamId, 0, 100)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*model.AutocompleteListItem, 0, len(users))
+	for _, user := range users {
+		if user.Id != args.UserId {
+			items = append(items, &model.AutocompleteListItem{Item: "@" + user.Username, HelpText: user.GetFullName()})
+		}
+	}
+
+	return items, nil
+}
+
 func (me *msgProvider) DoCommand(args *model.CommandArgs, message string) *model.CommandResponse {
 
 	splitMessage := strings.SplitN(message, " ", 2)
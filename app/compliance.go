@@ -53,6 +53,10 @@ func GetComplianceReport(reportId string) (*model.Compliance, *model.AppError) {
 }
 
 func GetComplianceFile(job *model.Compliance) ([]byte, *model.AppError) {
+	if job.Type == model.COMPLIANCE_TYPE_EXPORT {
+		return ReadFile(getComplianceExportPath(job))
+	}
+
 	if f, err := ioutil.ReadFile(*utils.Cfg.ComplianceSettings.Directory + "compliance/" + job.JobName() + ".zip"); err != nil {
 		return nil, model.NewLocAppError("readFile", "api.file.read_file.reading_local.app_error", nil, err.Error())
 
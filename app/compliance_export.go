@@ -0,0 +1,287 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	COMPLIANCE_EXPORT_BATCH_SIZE = 30000
+
+	// Posts are only exported once they're this old so that ones which are still being saved with an earlier
+	// UpdateAt than the newest exported post aren't skipped
+	COMPLIANCE_EXPORT_DELAY = 60 * 1000
+
+	// An export that's been running for this long is assumed to have been abandoned by its server
+	COMPLIANCE_EXPORT_TIMEOUT = time.Hour
+
+	SYSTEM_COMPLIANCE_EXPORT_CLAIM = "ComplianceExportClaim"
+)
+
+// A ComplianceExportFormatter writes exported posts to the zip file that's downloaded by admins. Events are
+// in the order that the posts were updated.
+type ComplianceExportFormatter func(archive *zip.Writer, events []*model.ComplianceExportEvent) error
+
+var complianceExportFormatters = map[string]ComplianceExportFormatter{}
+
+var complianceExportLock sync.Mutex
+var complianceExportClaim string
+
+func init() {
+	RegisterComplianceExportFormatter(model.COMPLIANCE_EXPORT_FORMAT_CSV, writeCsvComplianceExport)
+	RegisterComplianceExportFormatter(model.COMPLIANCE_EXPORT_FORMAT_ACTIANCE, writeActianceComplianceExport)
+	RegisterComplianceExportFormatter(model.COMPLIANCE_EXPORT_FORMAT_GLOBALRELAY, writeGlobalRelayComplianceExport)
+}
+
+func RegisterComplianceExportFormatter(format string, formatter ComplianceExportFormatter) {
+	complianceExportFormatters[format] = formatter
+}
+
+func GetComplianceExportFormatter(format string) ComplianceExportFormatter {
+	return complianceExportFormatters[format]
+}
+
+func IsComplianceExportEnabled() bool {
+	return *utils.Cfg.ComplianceSettings.EnableExport
+}
+
+func GetComplianceExports(page, perPage int) (model.Compliances, *model.AppError) {
+	if result := <-Srv.Store.Compliance().GetAllByType(model.COMPLIANCE_TYPE_EXPORT, page*perPage, perPage); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(model.Compliances), nil
+	}
+}
+
+func GetComplianceExport(exportId string) (*model.Compliance, *model.AppError) {
+	if result := <-Srv.Store.Compliance().Get(exportId); result.Err != nil {
+		result.Err.StatusCode = http.StatusNotFound
+		return nil, result.Err
+	} else if job := result.Data.(*model.Compliance); job.Type != model.COMPLIANCE_TYPE_EXPORT {
+		return nil, model.NewAppError("GetComplianceExport", "api.compliance.export.not_found.app_error", nil, "id="+exportId, http.StatusNotFound)
+	} else {
+		return job, nil
+	}
+}
+
+// StartComplianceExport exports the posts that have changed since the last export in the background and
+// returns the export's job so that its status can be checked.
+func StartComplianceExport(userId string) (*model.Compliance, *model.AppError) {
+	job, err := createComplianceExport(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer finishComplianceExport()
+
+		if err := runComplianceExport(job); err != nil {
+			l4g.Error(utils.T("api.compliance.export.error"), job.Id, err.Error())
+		}
+	}()
+
+	return job, nil
+}
+
+// ScheduledComplianceExport is run periodically to export the posts that have changed since the last export.
+func ScheduledComplianceExport() {
+	if !IsComplianceExportEnabled() {
+		return
+	}
+
+	job, err := createComplianceExport("")
+	if err != nil {
+		if err.StatusCode != http.StatusConflict {
+			l4g.Error(utils.T("api.compliance.export.error"), "", err.Error())
+		}
+		return
+	}
+	defer finishComplianceExport()
+
+	if err := runComplianceExport(job); err != nil {
+		l4g.Error(utils.T("api.compliance.export.error"), job.Id, err.Error())
+	}
+}
+
+// createComplianceExport saves the job for an export starting after the last exported post. Only one export
+// runs at a time across the cluster, so finishComplianceExport must be called once the job has been run.
+func createComplianceExport(userId string) (*model.Compliance, *model.AppError) {
+	if !IsComplianceExportEnabled() {
+		return nil, model.NewAppError("createComplianceExport", "api.compliance.export.disabled.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	if GetComplianceExportFormatter(*utils.Cfg.ComplianceSettings.ExportFormat) == nil {
+		return nil, model.NewAppError("createComplianceExport", "api.compliance.export.format.app_error", nil, "format="+*utils.Cfg.ComplianceSettings.ExportFormat, http.StatusNotImplemented)
+	}
+
+	complianceExportLock.Lock()
+	defer complianceExportLock.Unlock()
+
+	if complianceExportClaim != "" {
+		return nil, model.NewAppError("createComplianceExport", "api.compliance.export.running.app_error", nil, "", http.StatusConflict)
+	}
+
+	// Another server may be exporting, in which case both would export the same posts and move the cursor
+	claim, ok := claimClusterTask(SYSTEM_COMPLIANCE_EXPORT_CLAIM, COMPLIANCE_EXPORT_TIMEOUT)
+	if !ok {
+		return nil, model.NewAppError("createComplianceExport", "api.compliance.export.running.app_error", nil, "", http.StatusConflict)
+	}
+
+	lastUpdateAt, _, err := getComplianceExportCursor()
+	if err != nil {
+		releaseClusterTask(SYSTEM_COMPLIANCE_EXPORT_CLAIM, claim)
+		return nil, err
+	}
+
+	job := &model.Compliance{
+		UserId: userId,
+		Status: model.COMPLIANCE_STATUS_RUNNING,
+		Desc:   *utils.Cfg.ComplianceSettings.ExportFormat,
+		Type:   model.COMPLIANCE_TYPE_EXPORT,
+		// A job can't start at 0, so the range starts just after the last exported post
+		StartAt: lastUpdateAt + 1,
+		EndAt:   model.GetMillis() - COMPLIANCE_EXPORT_DELAY,
+	}
+
+	if result := <-Srv.Store.Compliance().Save(job); result.Err != nil {
+		releaseClusterTask(SYSTEM_COMPLIANCE_EXPORT_CLAIM, claim)
+		return nil, result.Err
+	}
+
+	complianceExportClaim = claim
+
+	return job, nil
+}
+
+func finishComplianceExport() {
+	complianceExportLock.Lock()
+	defer complianceExportLock.Unlock()
+
+	releaseClusterTask(SYSTEM_COMPLIANCE_EXPORT_CLAIM, complianceExportClaim)
+	complianceExportClaim = ""
+}
+
+// runComplianceExport writes the posts updated after the last exported post to the job's zip file and
+// moves the cursor past them. At most COMPLIANCE_EXPORT_BATCH_SIZE posts are exported at once, and the rest
+// are left for the next export.
+func runComplianceExport(job *model.Compliance) *model.AppError {
+	lastUpdateAt, lastPostId, err := getComplianceExportCursor()
+	if err == nil {
+		err = writeComplianceExport(job, lastUpdateAt, lastPostId)
+	}
+
+	if err != nil {
+		job.Status = model.COMPLIANCE_STATUS_FAILED
+	} else {
+		job.Status = model.COMPLIANCE_STATUS_FINISHED
+	}
+
+	if result := <-Srv.Store.Compliance().Update(job); result.Err != nil && err == nil {
+		err = result.Err
+	}
+
+	return err
+}
+
+func writeComplianceExport(job *model.Compliance, lastUpdateAt int64, lastPostId string) *model.AppError {
+	var posts []*model.ComplianceExportPost
+	if result := <-Srv.Store.Compliance().MessageExport(lastUpdateAt, lastPostId, job.EndAt, COMPLIANCE_EXPORT_BATCH_SIZE); result.Err != nil {
+		return result.Err
+	} else {
+		posts = result.Data.([]*model.ComplianceExportPost)
+	}
+
+	events := []*model.ComplianceExportEvent{}
+	for _, post := range posts {
+		postEvents := post.Events(lastUpdateAt)
+		if len(postEvents) == 0 {
+			continue
+		}
+
+		if len(post.GetFileIds()) > 0 {
+			if result := <-Srv.Store.FileInfo().GetForPost(post.PostId, false, true); result.Err != nil {
+				l4g.Warn(utils.T("api.compliance.export.files.warn"), post.PostId, result.Err.Error())
+			} else {
+				post.Files = result.Data.([]*model.FileInfo)
+			}
+		}
+
+		events = append(events, postEvents...)
+	}
+
+	if err := writeComplianceExportFile(job, events); err != nil {
+		return err
+	}
+
+	job.Count = len(events)
+
+	if len(posts) == 0 {
+		return nil
+	}
+
+	last := posts[len(posts)-1]
+	if len(posts) == COMPLIANCE_EXPORT_BATCH_SIZE && last.PostUpdateAt > job.StartAt {
+		// The rest of the posts will be included in the next export
+		job.EndAt = last.PostUpdateAt
+	}
+
+	return saveComplianceExportCursor(last.PostUpdateAt, last.PostId)
+}
+
+// getComplianceExportPath returns where an export's zip file is kept in the file store.
+func getComplianceExportPath(job *model.Compliance) string {
+	return "compliance/" + job.JobName() + ".zip"
+}
+
+// writeComplianceExportFile saves the export to the file store so that it can be downloaded from any server.
+func writeComplianceExportFile(job *model.Compliance, events []*model.ComplianceExportEvent) *model.AppError {
+	var buf bytes.Buffer
+
+	archive := zip.NewWriter(&buf)
+	if err := GetComplianceExportFormatter(job.Desc)(archive, events); err != nil {
+		return model.NewAppError("writeComplianceExportFile", "api.compliance.export.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	if err := archive.Close(); err != nil {
+		return model.NewAppError("writeComplianceExportFile", "api.compliance.export.write.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return WriteFile(buf.Bytes(), getComplianceExportPath(job))
+}
+
+// getComplianceExportCursor returns the UpdateAt and id of the last exported post.
+func getComplianceExportCursor() (int64, string, *model.AppError) {
+	var props model.StringMap
+	if result := <-Srv.Store.System().Get(); result.Err != nil {
+		return 0, "", result.Err
+	} else {
+		props = result.Data.(model.StringMap)
+	}
+
+	lastUpdateAt, _ := strconv.ParseInt(props[model.SYSTEM_LAST_COMPLIANCE_EXPORT_UPDATE_AT], 10, 64)
+
+	return lastUpdateAt, props[model.SYSTEM_LAST_COMPLIANCE_EXPORT_POST_ID], nil
+}
+
+func saveComplianceExportCursor(lastUpdateAt int64, lastPostId string) *model.AppError {
+	if result := <-Srv.Store.System().SaveOrUpdate(&model.System{Name: model.SYSTEM_LAST_COMPLIANCE_EXPORT_UPDATE_AT, Value: strconv.FormatInt(lastUpdateAt, 10)}); result.Err != nil {
+		return result.Err
+	}
+
+	if result := <-Srv.Store.System().SaveOrUpdate(&model.System{Name: model.SYSTEM_LAST_COMPLIANCE_EXPORT_POST_ID, Value: lastPostId}); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/mattermost/platform/model"
+)
+
+const (
+	COMPLIANCE_EXPORT_CSV_FILE_NAME      = "posts.csv"
+	COMPLIANCE_EXPORT_ACTIANCE_FILE_NAME = "actiance_export.xml"
+)
+
+func writeCsvComplianceExport(archive *zip.Writer, events []*model.ComplianceExportEvent) error {
+	file, err := archive.Create(COMPLIANCE_EXPORT_CSV_FILE_NAME)
+	if err != nil {
+		return err
+	}
+
+	writer := csv.NewWriter(file)
+	writer.Write(model.ComplianceExportHeader())
+
+	for _, event := range events {
+		writer.Write(event.Row())
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// Actiance expects every channel's messages to be grouped into a conversation, with participants entering
+// and leaving it and files being transferred as separate elements.
+
+type actianceExport struct {
+	XMLName       xml.Name                `xml:"FileDump"`
+	Conversations []*actianceConversation `xml:"Conversation"`
+}
+
+type actianceConversation struct {
+	Perspective string        `xml:"Perspective,attr"`
+	RoomId      string        `xml:"RoomID"`
+	StartTime   int64         `xml:"StartTimeUTC"`
+	Events      []interface{} `xml:",any"`
+	EndTime     int64         `xml:"EndTimeUTC"`
+}
+
+type actianceParticipant struct {
+	XMLName   xml.Name
+	LoginName string `xml:"LoginName"`
+	UserType  string `xml:"UserType"`
+	Time      int64  `xml:"DateTimeUTC"`
+	Email     string `xml:"CorporateEmailID"`
+}
+
+type actianceMessage struct {
+	XMLName    xml.Name `xml:"Message"`
+	LoginName  string   `xml:"LoginName"`
+	UserType   string   `xml:"UserType"`
+	Time       int64    `xml:"DateTimeUTC"`
+	Content    string   `xml:"Content"`
+	EventType  string   `xml:"EventType,omitempty"`
+	PostId     string   `xml:"PostId"`
+	OriginalId string   `xml:"OriginalPostId,omitempty"`
+}
+
+type actianceFileTransfer struct {
+	XMLName      xml.Name
+	LoginName    string `xml:"LoginName"`
+	UserType     string `xml:"UserType"`
+	Time         int64  `xml:"DateTimeUTC"`
+	UserFileName string `xml:"UserFileName"`
+	FileName     string `xml:"FileName"`
+}
+
+func writeActianceComplianceExport(archive *zip.Writer, events []*model.ComplianceExportEvent) error {
+	export := &actianceExport{Conversations: []*actianceConversation{}}
+	conversations := map[string]*actianceConversation{}
+
+	for _, event := range events {
+		post := event.Post
+		eventTime := event.Time / 1000
+
+		conversation, ok := conversations[post.ChannelId]
+		if !ok {
+			conversation = &actianceConversation{
+				Perspective: post.ChannelDisplayName,
+				RoomId:      getActianceRoomId(post),
+				StartTime:   eventTime,
+			}
+
+			conversations[post.ChannelId] = conversation
+			export.Conversations = append(export.Conversations, conversation)
+		}
+
+		conversation.EndTime = eventTime
+
+		switch event.Type {
+		case model.COMPLIANCE_EXPORT_EVENT_JOIN, model.COMPLIANCE_EXPORT_EVENT_LEAVE:
+			name := "ParticipantEntered"
+			if event.Type == model.COMPLIANCE_EXPORT_EVENT_LEAVE {
+				name = "ParticipantLeft"
+			}
+
+			conversation.Events = append(conversation.Events, &actianceParticipant{
+				XMLName:   xml.Name{Local: name},
+				LoginName: post.UserUsername,
+				UserType:  "user",
+				Time:      eventTime,
+				Email:     post.UserEmail,
+			})
+		default:
+			message := &actianceMessage{
+				LoginName:  post.UserUsername,
+				UserType:   "user",
+				Time:       eventTime,
+				Content:    post.PostMessage,
+				PostId:     post.PostId,
+				OriginalId: post.PostOriginalId,
+			}
+
+			if event.Type != model.COMPLIANCE_EXPORT_EVENT_MESSAGE {
+				message.EventType = event.Type
+			}
+
+			conversation.Events = append(conversation.Events, message)
+
+			// Files are only transferred when the post is created
+			if event.Type == model.COMPLIANCE_EXPORT_EVENT_MESSAGE {
+				for _, info := range post.Files {
+					for _, name := range []string{"FileTransferStarted", "FileTransferEnded"} {
+						conversation.Events = append(conversation.Events, &actianceFileTransfer{
+							XMLName:      xml.Name{Local: name},
+							LoginName:    post.UserUsername,
+							UserType:     "user",
+							Time:         eventTime,
+							UserFileName: info.Name,
+							FileName:     info.Path,
+						})
+					}
+				}
+			}
+		}
+	}
+
+	file, err := archive.Create(COMPLIANCE_EXPORT_ACTIANCE_FILE_NAME)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.WriteString(file, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(file)
+	encoder.Indent("", "  ")
+	return encoder.Encode(export)
+}
+
+func getActianceRoomId(post *model.ComplianceExportPost) string {
+	if post.TeamName == "" {
+		return post.ChannelName + " - " + post.ChannelId
+	}
+
+	return post.TeamName + " - " + post.ChannelName + " - " + post.ChannelId
+}
+
+// GlobalRelay archives email, so every event is written as its own message with the details of the post in
+// X-Mattermost headers.
+func writeGlobalRelayComplianceExport(archive *zip.Writer, events []*model.ComplianceExportEvent) error {
+	for _, event := range events {
+		name := fmt.Sprintf("%v-%v-%v.eml", event.Time, event.Post.PostId, event.Type)
+
+		file, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+
+		if _, err := file.Write(getGlobalRelayEmail(event)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func getGlobalRelayEmail(event *model.ComplianceExportEvent) []byte {
+	post := event.Post
+
+	channelName := post.ChannelDisplayName
+	if channelName == "" {
+		channelName = post.ChannelName
+	}
+
+	from := &mail.Address{Name: post.UserUsername, Address: post.UserEmail}
+
+	headers := [][]string{
+		{"From", from.String()},
+		{"To", "undisclosed-recipients:;"},
+		{"Subject", mime.QEncoding.Encode("utf-8", fmt.Sprintf("Mattermost %v in %v", event.Type, channelName))},
+		{"Date", time.Unix(0, event.Time*int64(time.Millisecond)).UTC().Format(time.RFC1123Z)},
+		{"Message-ID", fmt.Sprintf("<%v.%v.%v@mattermost>", post.PostId, event.Type, event.Time)},
+		{"X-Mattermost-EventType", event.Type},
+		{"X-Mattermost-Team", mime.QEncoding.Encode("utf-8", post.TeamName)},
+		{"X-Mattermost-ChannelId", post.ChannelId},
+		{"X-Mattermost-Channel", mime.QEncoding.Encode("utf-8", post.ChannelName)},
+		{"X-Mattermost-ChannelType", post.ChannelType},
+		{"X-Mattermost-UserId", post.UserId},
+		{"X-Mattermost-PostId", post.PostId},
+		{"X-Mattermost-RootId", post.PostRootId},
+		{"X-Mattermost-OriginalId", post.PostOriginalId},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "text/plain; charset=UTF-8"},
+		{"Content-Transfer-Encoding", "quoted-printable"},
+	}
+
+	var buf bytes.Buffer
+	for _, header := range headers {
+		if header[1] != "" {
+			fmt.Fprintf(&buf, "%v: %v\r\n", header[0], header[1])
+		}
+	}
+	buf.WriteString("\r\n")
+
+	body := quotedprintable.NewWriter(&buf)
+	body.Write([]byte(post.PostMessage))
+
+	if len(post.Files) > 0 {
+		files := make([]string, len(post.Files))
+		for i, info := range post.Files {
+			files[i] = info.Name + " (" + info.Path + ")"
+		}
+
+		body.Write([]byte("\r\n\r\nAttachments:\r\n" + strings.Join(files, "\r\n")))
+	}
+
+	body.Close()
+
+	return buf.Bytes()
+}
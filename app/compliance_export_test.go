@@ -0,0 +1,189 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io/ioutil"
+	"net/mail"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func getComplianceExportTestEvents() []*model.ComplianceExportEvent {
+	post1 := &model.ComplianceExportPost{
+		TeamName:           "team",
+		ChannelId:          model.NewId(),
+		ChannelName:        "town-square",
+		ChannelDisplayName: "Town Square",
+		ChannelType:        model.CHANNEL_OPEN,
+		UserId:             model.NewId(),
+		UserUsername:       "user1",
+		UserEmail:          "user1@example.com",
+		PostId:             model.NewId(),
+		PostCreateAt:       1500000000000,
+		PostMessage:        "hello wörld",
+		Files:              []*model.FileInfo{{Name: "file.txt", Path: "data/file.txt"}},
+	}
+
+	post2 := &model.ComplianceExportPost{
+		ChannelId:    model.NewId(),
+		ChannelName:  model.NewId() + "__" + model.NewId(),
+		ChannelType:  model.CHANNEL_DIRECT,
+		UserId:       model.NewId(),
+		UserUsername: "user2",
+		UserEmail:    "user2@example.com",
+		PostId:       model.NewId(),
+		PostCreateAt: 1500000001000,
+		PostEditAt:   1500000002000,
+		PostMessage:  "edited",
+	}
+
+	return []*model.ComplianceExportEvent{
+		{Type: model.COMPLIANCE_EXPORT_EVENT_JOIN, Time: 1499999999000, Post: &model.ComplianceExportPost{ChannelId: post1.ChannelId, UserUsername: "user1", UserEmail: "user1@example.com"}},
+		{Type: model.COMPLIANCE_EXPORT_EVENT_MESSAGE, Time: post1.PostCreateAt, Post: post1},
+		{Type: model.COMPLIANCE_EXPORT_EVENT_EDITED, Time: post2.PostEditAt, Post: post2},
+	}
+}
+
+func writeTestComplianceExport(t *testing.T, format string, events []*model.ComplianceExportEvent) *zip.Reader {
+	var buf bytes.Buffer
+
+	archive := zip.NewWriter(&buf)
+	if err := GetComplianceExportFormatter(format)(archive, events); err != nil {
+		t.Fatal(err)
+	}
+	archive.Close()
+
+	reader, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return reader
+}
+
+func readTestComplianceExportFile(t *testing.T, file *zip.File) []byte {
+	reader, err := file.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+
+	data, err := ioutil.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return data
+}
+
+func TestCsvComplianceExport(t *testing.T) {
+	reader := writeTestComplianceExport(t, model.COMPLIANCE_EXPORT_FORMAT_CSV, getComplianceExportTestEvents())
+	if len(reader.File) != 1 || reader.File[0].Name != COMPLIANCE_EXPORT_CSV_FILE_NAME {
+		t.Fatal("should've written a single csv file")
+	}
+
+	rows, err := csv.NewReader(bytes.NewReader(readTestComplianceExportFile(t, reader.File[0]))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 4 {
+		t.Fatal("should've written a header and a row for each event", len(rows))
+	}
+
+	if rows[0][0] != "EventType" || rows[2][0] != model.COMPLIANCE_EXPORT_EVENT_MESSAGE || rows[3][0] != model.COMPLIANCE_EXPORT_EVENT_EDITED {
+		t.Fatal("wrote incorrect rows", rows)
+	}
+}
+
+func TestActianceComplianceExport(t *testing.T) {
+	events := getComplianceExportTestEvents()
+
+	reader := writeTestComplianceExport(t, model.COMPLIANCE_EXPORT_FORMAT_ACTIANCE, events)
+	if len(reader.File) != 1 || reader.File[0].Name != COMPLIANCE_EXPORT_ACTIANCE_FILE_NAME {
+		t.Fatal("should've written a single xml file")
+	}
+
+	var export struct {
+		Conversations []struct {
+			RoomId  string `xml:"RoomID"`
+			Entered []struct {
+				LoginName string `xml:"LoginName"`
+			} `xml:"ParticipantEntered"`
+			Messages []struct {
+				Content   string `xml:"Content"`
+				EventType string `xml:"EventType"`
+			} `xml:"Message"`
+			Files []struct {
+				UserFileName string `xml:"UserFileName"`
+			} `xml:"FileTransferStarted"`
+			StartTime int64 `xml:"StartTimeUTC"`
+			EndTime   int64 `xml:"EndTimeUTC"`
+		} `xml:"Conversation"`
+	}
+
+	if err := xml.Unmarshal(readTestComplianceExportFile(t, reader.File[0]), &export); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(export.Conversations) != 2 {
+		t.Fatal("should've written a conversation per channel", len(export.Conversations))
+	}
+
+	conversation := export.Conversations[0]
+	if len(conversation.Entered) != 1 || conversation.Entered[0].LoginName != "user1" {
+		t.Fatal("should've written the user joining")
+	} else if len(conversation.Messages) != 1 || conversation.Messages[0].Content != "hello wörld" || conversation.Messages[0].EventType != "" {
+		t.Fatal("should've written the message")
+	} else if len(conversation.Files) != 1 || conversation.Files[0].UserFileName != "file.txt" {
+		t.Fatal("should've written the file")
+	} else if conversation.StartTime != 1499999999 || conversation.EndTime != 1500000000 {
+		t.Fatal("wrote incorrect times", conversation.StartTime, conversation.EndTime)
+	}
+
+	conversation = export.Conversations[1]
+	if conversation.RoomId != events[2].Post.ChannelName+" - "+events[2].Post.ChannelId {
+		t.Fatal("wrote incorrect room id", conversation.RoomId)
+	} else if len(conversation.Messages) != 1 || conversation.Messages[0].EventType != model.COMPLIANCE_EXPORT_EVENT_EDITED {
+		t.Fatal("should've written the edit")
+	}
+}
+
+func TestGlobalRelayComplianceExport(t *testing.T) {
+	events := getComplianceExportTestEvents()
+
+	reader := writeTestComplianceExport(t, model.COMPLIANCE_EXPORT_FORMAT_GLOBALRELAY, events)
+	if len(reader.File) != len(events) {
+		t.Fatal("should've written an email per event")
+	}
+
+	message, err := mail.ReadMessage(bytes.NewReader(readTestComplianceExportFile(t, reader.File[1])))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if from, err := message.Header.AddressList("From"); err != nil || len(from) != 1 || from[0].Address != "user1@example.com" {
+		t.Fatal("wrote incorrect sender", err)
+	}
+
+	if message.Header.Get("X-Mattermost-PostId") != events[1].Post.PostId || message.Header.Get("X-Mattermost-EventType") != model.COMPLIANCE_EXPORT_EVENT_MESSAGE {
+		t.Fatal("wrote incorrect headers", message.Header)
+	}
+
+	if date, err := message.Header.Date(); err != nil || date.Unix() != 1500000000 {
+		t.Fatal("wrote incorrect date", date, err)
+	}
+
+	body, _ := ioutil.ReadAll(message.Body)
+	if !strings.Contains(string(body), "hello w=C3=B6rld") || !strings.Contains(string(body), "file.txt (data/file.txt)") {
+		t.Fatal("wrote incorrect body", string(body))
+	}
+}
@@ -164,6 +164,11 @@ func trackConfig() {
 		"uses_letsencrypt":                              *utils.Cfg.ServiceSettings.UseLetsEncrypt,
 		"forward_80_to_443":                             *utils.Cfg.ServiceSettings.Forward80To443,
 		"maximum_login_attempts":                        utils.Cfg.ServiceSettings.MaximumLoginAttempts,
+		"enable_login_lockout":                          *utils.Cfg.ServiceSettings.EnableLoginLockout,
+		"login_attempt_window_seconds":                  *utils.Cfg.ServiceSettings.LoginAttemptWindowSeconds,
+		"maximum_login_attempts_per_ip_address":         *utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerIpAddress,
+		"maximum_login_attempts_per_login_id":           *utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerLoginId,
+		"login_lockout_duration_seconds":                *utils.Cfg.ServiceSettings.LoginLockoutDurationSeconds,
 		"session_length_web_in_days":                    *utils.Cfg.ServiceSettings.SessionLengthWebInDays,
 		"session_length_mobile_in_days":                 *utils.Cfg.ServiceSettings.SessionLengthMobileInDays,
 		"session_length_sso_in_days":                    *utils.Cfg.ServiceSettings.SessionLengthSSOInDays,
@@ -256,6 +261,7 @@ func trackConfig() {
 		"enable_email_batching":           *utils.Cfg.EmailSettings.EnableEmailBatching,
 		"email_batching_buffer_size":      *utils.Cfg.EmailSettings.EmailBatchingBufferSize,
 		"email_batching_interval":         *utils.Cfg.EmailSettings.EmailBatchingInterval,
+		"enable_reply_by_email":           *utils.Cfg.EmailSettings.EnableReplyByEmail,
 		"isdefault_feedback_name":         isDefault(utils.Cfg.EmailSettings.FeedbackName, ""),
 		"isdefault_feedback_email":        isDefault(utils.Cfg.EmailSettings.FeedbackEmail, ""),
 		"isdefault_feedback_organization": isDefault(*utils.Cfg.EmailSettings.FeedbackOrganization, model.EMAIL_SETTINGS_DEFAULT_FEEDBACK_ORGANIZATION),
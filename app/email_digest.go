@@ -0,0 +1,308 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"database/sql"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	"github.com/nicksnyder/go-i18n/i18n"
+)
+
+const (
+	EMAIL_DIGEST_BATCH_SIZE = 100
+)
+
+func GetEmailDigest(userId string) (*model.EmailDigest, *model.AppError) {
+	if result := <-Srv.Store.EmailDigest().Get(userId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.EmailDigest), nil
+	}
+}
+
+// SetEmailDigest subscribes the user to a digest or changes their existing subscription. Changing it doesn't
+// affect which activity will be included in the next digest.
+func SetEmailDigest(digest *model.EmailDigest) (*model.EmailDigest, *model.AppError) {
+	result := <-Srv.Store.EmailDigest().Get(digest.UserId)
+	if result.Err != nil && result.Err.StatusCode != http.StatusNotFound {
+		return nil, result.Err
+	}
+
+	if result.Err != nil {
+		result = <-Srv.Store.EmailDigest().Save(digest)
+	} else {
+		oldDigest := result.Data.(*model.EmailDigest)
+		digest.CreateAt = oldDigest.CreateAt
+		digest.LastSentAt = oldDigest.LastSentAt
+
+		result = <-Srv.Store.EmailDigest().Update(digest)
+	}
+
+	if result.Err != nil {
+		result.Err.StatusCode = http.StatusBadRequest
+		return nil, result.Err
+	}
+
+	return result.Data.(*model.EmailDigest), nil
+}
+
+func DeleteEmailDigest(userId string) *model.AppError {
+	if _, err := GetEmailDigest(userId); err != nil {
+		return err
+	}
+
+	if result := <-Srv.Store.EmailDigest().Delete(userId); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// SendEmailDigests is run periodically to send the digests that are due. Subscriptions are stored in the
+// database, so digests that came due while the server was down are sent the next time that this runs.
+func SendEmailDigests() {
+	if !utils.Cfg.EmailSettings.SendEmailNotifications {
+		return
+	}
+
+	// like with email batching, the send function is passed through so that this can be tested without
+	// actually sending emails
+	sendDueEmailDigests(time.Now(), sendEmailDigest)
+}
+
+func sendDueEmailDigests(now time.Time, handler func(*model.EmailDigest, int64)) {
+	nowMillis := now.UnixNano() / int64(time.Millisecond)
+
+	for {
+		result := <-Srv.Store.EmailDigest().GetDue(nowMillis, EMAIL_DIGEST_BATCH_SIZE)
+		if result.Err != nil {
+			l4g.Error(utils.T("api.email_digest.send.get_due.error"), result.Err.Error())
+			return
+		}
+
+		digests := result.Data.([]*model.EmailDigest)
+
+		for _, digest := range digests {
+			since := digest.GetPeriodStart()
+
+			// another server may have claimed the digest first, in which case it'll send it instead
+			if result := <-Srv.Store.EmailDigest().Claim(digest, digest.GetNextSendAt(now), nowMillis); result.Err != nil {
+				l4g.Error(utils.T("api.email_digest.send.claim.error"), digest.UserId, result.Err.Error())
+				return
+			} else if result.Data.(bool) {
+				go handler(digest, since)
+			}
+		}
+
+		if len(digests) < EMAIL_DIGEST_BATCH_SIZE {
+			return
+		}
+	}
+}
+
+type emailDigestSection struct {
+	TeamId   string
+	Channels []*model.EmailDigestChannel
+}
+
+// groupEmailDigestChannels splits the unread channels into a section per team, keeping them in the order that
+// they were returned in. Direct and group messages don't belong to a team, so they're put in a section first.
+func groupEmailDigestChannels(channels []*model.EmailDigestChannel) []*emailDigestSection {
+	var directSection *emailDigestSection
+	var sections []*emailDigestSection
+	sectionsByTeam := make(map[string]*emailDigestSection)
+
+	for _, channel := range channels {
+		if channel.ChannelType == model.CHANNEL_DIRECT || channel.ChannelType == model.CHANNEL_GROUP {
+			if directSection == nil {
+				directSection = &emailDigestSection{}
+			}
+
+			directSection.Channels = append(directSection.Channels, channel)
+			continue
+		}
+
+		section, ok := sectionsByTeam[channel.TeamId]
+		if !ok {
+			section = &emailDigestSection{TeamId: channel.TeamId}
+			sectionsByTeam[channel.TeamId] = section
+			sections = append(sections, section)
+		}
+
+		section.Channels = append(section.Channels, channel)
+	}
+
+	if directSection != nil {
+		sections = append([]*emailDigestSection{directSection}, sections...)
+	}
+
+	return sections
+}
+
+func sendEmailDigest(digest *model.EmailDigest, since int64) {
+	uchan := Srv.Store.User().Get(digest.UserId)
+	cchan := Srv.Store.EmailDigest().GetUnreadChannels(digest.UserId, since)
+	tchan := Srv.Store.Team().GetTeamsByUserId(digest.UserId)
+	pchan := Srv.Store.Preference().Get(digest.UserId, model.PREFERENCE_CATEGORY_DISPLAY_SETTINGS, model.PREFERENCE_NAME_DISPLAY_NAME_FORMAT)
+
+	var user *model.User
+	if result := <-uchan; result.Err != nil {
+		l4g.Warn(utils.T("api.email_digest.send.user.warn"), digest.UserId, result.Err.Error())
+		return
+	} else {
+		user = result.Data.(*model.User)
+	}
+
+	if user.DeleteAt != 0 {
+		return
+	}
+
+	var channels []*model.EmailDigestChannel
+	if result := <-cchan; result.Err != nil {
+		l4g.Warn(utils.T("api.email_digest.send.channels.warn"), digest.UserId, result.Err.Error())
+		return
+	} else {
+		channels = result.Data.([]*model.EmailDigestChannel)
+	}
+
+	// don't send an empty digest
+	if len(channels) == 0 {
+		return
+	}
+
+	teams := make(map[string]*model.Team)
+	if result := <-tchan; result.Err != nil {
+		l4g.Warn(utils.T("api.email_digest.send.teams.warn"), digest.UserId, result.Err.Error())
+		return
+	} else {
+		for _, team := range result.Data.([]*model.Team) {
+			teams[team.Id] = team
+		}
+	}
+
+	var displayNameFormat string
+	if result := <-pchan; result.Err != nil && result.Err.DetailedError != sql.ErrNoRows.Error() {
+		l4g.Warn(utils.T("api.email_digest.send.preferences.warn"), digest.UserId, result.Err.Error())
+		return
+	} else if result.Err != nil {
+		// no display name format saved, so fall back to default
+		displayNameFormat = model.PREFERENCE_DEFAULT_DISPLAY_NAME_FORMAT
+	} else {
+		displayNameFormat = result.Data.(model.Preference).Value
+	}
+
+	translateFunc := utils.GetUserTranslations(user.Locale)
+
+	// direct messages are linked to through the first of the user's teams since they don't have one of their own
+	var defaultTeam *model.Team
+	for _, team := range teams {
+		if defaultTeam == nil || team.Name < defaultTeam.Name {
+			defaultTeam = team
+		}
+	}
+
+	var contents string
+	for _, section := range groupEmailDigestChannels(channels) {
+		team := defaultTeam
+		if section.TeamId != "" {
+			team = teams[section.TeamId]
+		}
+
+		if team == nil {
+			// the user has left the team since the posts were made
+			continue
+		}
+
+		contents += renderEmailDigestSection(user, section, team, displayNameFormat, translateFunc)
+	}
+
+	if contents == "" {
+		return
+	}
+
+	location, err := time.LoadLocation(digest.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	tm := time.Now().In(location)
+
+	subject := translateFunc("api.email_digest.send.subject."+digest.Frequency, map[string]interface{}{
+		"SiteName": utils.Cfg.TeamSettings.SiteName,
+		"Year":     tm.Year(),
+		"Month":    translateFunc(tm.Month().String()),
+		"Day":      tm.Day(),
+	})
+
+	body := utils.NewHTMLTemplate("email_digest_body", user.Locale)
+	body.Props["SiteURL"] = *utils.Cfg.ServiceSettings.SiteURL
+	body.Props["Sections"] = template.HTML(contents)
+	body.Props["BodyText"] = translateFunc("api.email_digest.send.body_text", len(channels))
+
+	if err := utils.SendMail(user.Email, subject, body.Render()); err != nil {
+		l4g.Warn(utils.T("api.email_digest.send.send.warn"), user.Email, err)
+	}
+}
+
+type emailDigestChannelSummary struct {
+	Name    string
+	Link    string
+	Summary string
+}
+
+func renderEmailDigestSection(user *model.User, section *emailDigestSection, team *model.Team, displayNameFormat string, translateFunc i18n.TranslateFunc) string {
+	teamURL := *utils.Cfg.ServiceSettings.SiteURL + "/" + team.Name
+
+	var summaries []*emailDigestChannelSummary
+	for _, channel := range section.Channels {
+		summary := &emailDigestChannelSummary{
+			Name:    channel.ChannelDisplayName,
+			Link:    teamURL + "/channels/" + channel.ChannelName,
+			Summary: translateFunc("api.email_digest.render_section.messages", int(channel.MsgCount)),
+		}
+
+		if channel.MentionCount > 0 {
+			summary.Summary += " " + translateFunc("api.email_digest.render_section.mentions", int(channel.MentionCount))
+		}
+
+		if channel.ChannelType == model.CHANNEL_DIRECT {
+			otherUserId := user.Id
+			if userIds := strings.Split(channel.ChannelName, "__"); userIds[0] != user.Id {
+				otherUserId = userIds[0]
+			} else if len(userIds) > 1 {
+				otherUserId = userIds[1]
+			}
+
+			if result := <-Srv.Store.User().Get(otherUserId); result.Err != nil {
+				l4g.Warn(utils.T("api.email_digest.render_section.direct_message.warn"), channel.ChannelId, result.Err.Error())
+				continue
+			} else {
+				summary.Name = result.Data.(*model.User).GetDisplayNameForPreference(displayNameFormat)
+			}
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	if len(summaries) == 0 {
+		return ""
+	}
+
+	sectionTemplate := utils.NewHTMLTemplate("email_digest_section", user.Locale)
+	sectionTemplate.Props["Channels"] = summaries
+
+	if section.TeamId == "" {
+		sectionTemplate.Props["Title"] = translateFunc("api.email_digest.render_section.direct_messages")
+	} else {
+		sectionTemplate.Props["Title"] = team.DisplayName
+	}
+
+	return sectionTemplate.Render()
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestGroupEmailDigestChannels(t *testing.T) {
+	teamId1 := model.NewId()
+	teamId2 := model.NewId()
+
+	channels := []*model.EmailDigestChannel{
+		{TeamId: teamId1, ChannelId: model.NewId(), ChannelType: model.CHANNEL_OPEN},
+		{TeamId: teamId2, ChannelId: model.NewId(), ChannelType: model.CHANNEL_PRIVATE},
+		{ChannelId: model.NewId(), ChannelType: model.CHANNEL_DIRECT},
+		{TeamId: teamId1, ChannelId: model.NewId(), ChannelType: model.CHANNEL_OPEN},
+		{ChannelId: model.NewId(), ChannelType: model.CHANNEL_GROUP},
+	}
+
+	sections := groupEmailDigestChannels(channels)
+	if len(sections) != 3 {
+		t.Fatal("should've made a section for direct messages and each team", len(sections))
+	}
+
+	if sections[0].TeamId != "" || len(sections[0].Channels) != 2 || sections[0].Channels[0] != channels[2] || sections[0].Channels[1] != channels[4] {
+		t.Fatal("should've put direct and group messages first", sections[0])
+	}
+
+	if sections[1].TeamId != teamId1 || len(sections[1].Channels) != 2 || sections[1].Channels[0] != channels[0] || sections[1].Channels[1] != channels[3] {
+		t.Fatal("should've grouped the first team's channels in order", sections[1])
+	}
+
+	if sections[2].TeamId != teamId2 || len(sections[2].Channels) != 1 || sections[2].Channels[0] != channels[1] {
+		t.Fatal("should've grouped the second team's channels", sections[2])
+	}
+
+	if sections := groupEmailDigestChannels(nil); len(sections) != 0 {
+		t.Fatal("shouldn't have any sections")
+	}
+}
+
+func TestSendDueEmailDigests(t *testing.T) {
+	Setup()
+
+	digest, err := SetEmailDigest(&model.EmailDigest{UserId: model.NewId(), Frequency: model.EMAIL_DIGEST_FREQUENCY_HOURLY})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sent := make(chan *model.EmailDigest, 10)
+	handler := func(digest *model.EmailDigest, since int64) {
+		if since != digest.CreateAt {
+			t.Error("should've included activity since the digest was created", since)
+		}
+
+		sent <- digest
+	}
+
+	// not due yet
+	sendDueEmailDigests(time.Now(), handler)
+
+	// due now, but it should only be sent once even if checked twice
+	now := time.Unix(0, digest.NextSendAt*int64(time.Millisecond))
+	sendDueEmailDigests(now, handler)
+	sendDueEmailDigests(now, handler)
+
+	count := 0
+	timeout := time.After(time.Second)
+	for done := false; !done; {
+		select {
+		case d := <-sent:
+			if d.UserId == digest.UserId {
+				count++
+			}
+		case <-timeout:
+			done = true
+		}
+	}
+
+	if count != 1 {
+		t.Fatal("should've sent the digest exactly once", count)
+	}
+
+	if rdigest, err := GetEmailDigest(digest.UserId); err != nil {
+		t.Fatal(err)
+	} else if rdigest.LastSentAt != digest.NextSendAt || rdigest.NextSendAt != digest.NextSendAt+int64(time.Hour/time.Millisecond) {
+		t.Fatal("should've scheduled the next digest", rdigest)
+	}
+
+	if err := DeleteEmailDigest(digest.UserId); err != nil {
+		t.Fatal(err)
+	} else if err := DeleteEmailDigest(digest.UserId); err == nil {
+		t.Fatal("shouldn't delete a digest twice")
+	}
+}
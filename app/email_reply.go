@@ -0,0 +1,396 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	EMAIL_REPLY_SIGNATURE_LENGTH = 16
+	EMAIL_REPLY_MAX_FILES        = 5
+
+	// Reply addresses stop working once the post they were made for is this old
+	EMAIL_REPLY_EXPIRY = 30 * 24 * time.Hour
+)
+
+var emailReplyHeaderPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`^-+ ?Original Message ?-+$`),
+	regexp.MustCompile(`^_{10,}$`),
+}
+
+var emailReplyBlockquotePattern = regexp.MustCompile(`(?is)<blockquote.*</blockquote>`)
+var emailReplyLineBreakPattern = regexp.MustCompile(`(?i)<br\s*/?>|</p>|</div>`)
+var emailReplyTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+type emailReply struct {
+	text        string
+	html        string
+	attachments []*emailReplyAttachment
+}
+
+type emailReplyAttachment struct {
+	name string
+	data []byte
+}
+
+// GetEmailReplyAddress returns the address that the user can reply to in order to respond to the post, or an
+// empty string if replying by email isn't enabled. The address contains the ids of the post and user along with
+// a signature so that it can't be forged to post as someone else, and it expires along with the post.
+func GetEmailReplyAddress(post *model.Post, userId string) string {
+	if !*utils.Cfg.EmailSettings.EnableReplyByEmail {
+		return ""
+	}
+
+	parts := strings.SplitN(*utils.Cfg.EmailSettings.ReplyByEmailAddress, "@", 2)
+	if len(parts) != 2 {
+		return ""
+	}
+
+	return parts[0] + "+" + post.Id + userId + signEmailReplyIds(post.Id, userId) + "@" + parts[1]
+}
+
+func signEmailReplyIds(postId, userId string) string {
+	mac := hmac.New(sha256.New, []byte(*utils.Cfg.EmailSettings.ReplyByEmailSalt))
+	mac.Write([]byte(postId + ":" + userId))
+
+	return hex.EncodeToString(mac.Sum(nil))[:EMAIL_REPLY_SIGNATURE_LENGTH]
+}
+
+// parseEmailReplyAddress returns the ids of the post and user that a reply address was made for after checking
+// that it was signed by this server.
+func parseEmailReplyAddress(address string) (string, string, *model.AppError) {
+	address = strings.ToLower(strings.Trim(strings.TrimSpace(address), "<>"))
+
+	localPart := address
+	if index := strings.LastIndex(address, "@"); index != -1 {
+		localPart = address[:index]
+	}
+
+	token := localPart
+	if index := strings.LastIndex(localPart, "+"); index != -1 {
+		token = localPart[index+1:]
+	}
+
+	if len(token) != 52+EMAIL_REPLY_SIGNATURE_LENGTH {
+		return "", "", model.NewAppError("parseEmailReplyAddress", "api.email_reply.address.app_error", nil, "address="+address, http.StatusBadRequest)
+	}
+
+	postId := token[:26]
+	userId := token[26:52]
+
+	if !hmac.Equal([]byte(token[52:]), []byte(signEmailReplyIds(postId, userId))) {
+		return "", "", model.NewAppError("parseEmailReplyAddress", "api.email_reply.address.app_error", nil, "address="+address, http.StatusBadRequest)
+	}
+
+	return postId, userId, nil
+}
+
+// HandleEmailReply posts the contents of an email sent to a reply address as a reply in the thread of the post
+// that the address was made for. The email must come from the user that the address was made for, and quoted
+// text from earlier in the conversation is removed.
+func HandleEmailReply(recipient string, data io.Reader) (*model.Post, *model.AppError) {
+	postId, userId, err := parseEmailReplyAddress(recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	message, readErr := mail.ReadMessage(data)
+	if readErr != nil {
+		return nil, model.NewAppError("HandleEmailReply", "api.email_reply.parse.app_error", nil, readErr.Error(), http.StatusBadRequest)
+	}
+
+	user, err := GetUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.DeleteAt != 0 {
+		return nil, model.NewAppError("HandleEmailReply", "api.email_reply.sender.app_error", nil, "user_id="+userId, http.StatusForbidden)
+	}
+
+	if from, fromErr := message.Header.AddressList("From"); fromErr != nil || len(from) != 1 || !strings.EqualFold(from[0].Address, user.Email) {
+		return nil, model.NewAppError("HandleEmailReply", "api.email_reply.sender.app_error", nil, "user_id="+userId, http.StatusForbidden)
+	}
+
+	reply := &emailReply{}
+	if readErr := readEmailReplyPart(message.Header.Get("Content-Type"), message.Header.Get("Content-Transfer-Encoding"), message.Header.Get("Content-Disposition"), message.Body, reply); readErr != nil {
+		return nil, model.NewAppError("HandleEmailReply", "api.email_reply.parse.app_error", nil, readErr.Error(), http.StatusBadRequest)
+	}
+
+	text := reply.text
+	if text == "" && reply.html != "" {
+		text = convertEmailReplyHtml(reply.html)
+	}
+	text = stripEmailReplyQuotes(text)
+
+	if text == "" && len(reply.attachments) == 0 {
+		return nil, model.NewAppError("HandleEmailReply", "api.email_reply.empty.app_error", nil, "user_id="+userId, http.StatusBadRequest)
+	}
+
+	parent, err := GetSinglePost(postId)
+	if err != nil {
+		return nil, err
+	}
+
+	if model.GetMillis()-parent.CreateAt > int64(EMAIL_REPLY_EXPIRY/time.Millisecond) {
+		return nil, model.NewAppError("HandleEmailReply", "api.email_reply.expired.app_error", nil, "post_id="+postId, http.StatusForbidden)
+	}
+
+	if !HasPermissionToChannel(userId, parent.ChannelId, model.PERMISSION_CREATE_POST) {
+		return nil, model.NewAppError("HandleEmailReply", "api.email_reply.permissions.app_error", nil, "user_id="+userId+", channel_id="+parent.ChannelId, http.StatusForbidden)
+	}
+
+	channel, err := GetChannel(parent.ChannelId)
+	if err != nil {
+		return nil, err
+	}
+
+	rootId := parent.RootId
+	if rootId == "" {
+		rootId = parent.Id
+	}
+
+	post := &model.Post{
+		ChannelId: parent.ChannelId,
+		UserId:    userId,
+		RootId:    rootId,
+		ParentId:  rootId,
+		Message:   text,
+	}
+
+	var infos []*model.FileInfo
+	if len(reply.attachments) > 0 {
+		infos = uploadEmailReplyAttachments(channel, userId, reply.attachments)
+		for _, info := range infos {
+			post.FileIds = append(post.FileIds, info.Id)
+		}
+	}
+
+	rpost, err := CreatePostAsUser(post, *utils.Cfg.ServiceSettings.SiteURL)
+	if err != nil {
+		deleteUploadedFiles(infos)
+		return nil, err
+	}
+
+	return rpost, nil
+}
+
+func uploadEmailReplyAttachments(channel *model.Channel, userId string, attachments []*emailReplyAttachment) []*model.FileInfo {
+	if len(utils.Cfg.FileSettings.DriverName) == 0 {
+		l4g.Warn(utils.T("api.email_reply.upload.warn"), userId, "file storage isn't configured")
+		return nil
+	}
+
+	infos := []*model.FileInfo{}
+	previewPathList := []string{}
+	thumbnailPathList := []string{}
+	imageDataList := [][]byte{}
+
+	for _, attachment := range attachments {
+		if len(infos) >= EMAIL_REPLY_MAX_FILES {
+			l4g.Warn(utils.T("api.email_reply.upload.warn"), userId, "too many attachments")
+			break
+		}
+
+		if int64(len(attachment.data)) > *utils.Cfg.FileSettings.MaxFileSize {
+			l4g.Warn(utils.T("api.email_reply.upload.warn"), userId, "attachment "+attachment.name+" is too large")
+			continue
+		}
+
+		info, err := DoUploadFile(channel.TeamId, channel.Id, userId, attachment.name, attachment.data)
+		if err != nil {
+			l4g.Warn(utils.T("api.email_reply.upload.warn"), userId, err.Error())
+			continue
+		}
+
+		if info.IsImage() && (info.PreviewPath != "" || info.ThumbnailPath != "") {
+			previewPathList = append(previewPathList, info.PreviewPath)
+			thumbnailPathList = append(thumbnailPathList, info.ThumbnailPath)
+			imageDataList = append(imageDataList, attachment.data)
+		}
+
+		infos = append(infos, info)
+	}
+
+	HandleImages(previewPathList, thumbnailPathList, imageDataList)
+
+	return infos
+}
+
+// readEmailReplyPart reads the text and attachments out of a part of an email, recursing into any multipart
+// parts. The first plain text and HTML parts are used as the text of the reply after being converted to UTF-8,
+// and anything with a file name is treated as an attachment.
+func readEmailReplyPart(contentType, transferEncoding, disposition string, body io.Reader, reply *emailReply) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+		params = map[string]string{}
+	}
+
+	switch strings.ToLower(strings.TrimSpace(transferEncoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(body, params["boundary"])
+
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				return nil
+			} else if err != nil {
+				return err
+			}
+
+			// the multipart reader has already decoded quoted-printable parts and removed their encoding header
+			if err := readEmailReplyPart(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part.Header.Get("Content-Disposition"), part, reply); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return err
+	}
+
+	filename := params["name"]
+	if _, dispositionParams, err := mime.ParseMediaType(disposition); err == nil && dispositionParams["filename"] != "" {
+		filename = dispositionParams["filename"]
+	}
+
+	if filename != "" {
+		reply.attachments = append(reply.attachments, &emailReplyAttachment{name: filename, data: data})
+	} else if mediaType == "text/plain" && reply.text == "" {
+		if reply.text, err = decodeEmailReplyCharset(params["charset"], data); err != nil {
+			return err
+		}
+	} else if mediaType == "text/html" && reply.html == "" {
+		if reply.html, err = decodeEmailReplyCharset(params["charset"], data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// decodeEmailReplyCharset converts text in the given charset to UTF-8. Only UTF-8 and the Latin-1 charsets used
+// by most mail clients are supported. Text without a charset is assumed to be UTF-8.
+func decodeEmailReplyCharset(charset string, data []byte) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return string(data), nil
+	case "iso-8859-1", "iso8859-1", "latin1":
+		return decodeEmailReplySingleByte(data, nil), nil
+	case "windows-1252", "cp1252":
+		return decodeEmailReplySingleByte(data, &windows1252Runes), nil
+	default:
+		return "", fmt.Errorf("unsupported charset %v", charset)
+	}
+}
+
+// windows1252Runes holds the characters that Windows-1252 puts in place of the control characters between
+// 0x80 and 0x9f in ISO-8859-1. The bytes that it leaves undefined are kept as those control characters.
+var windows1252Runes = [32]rune{
+	'\u20ac', '\u0081', '\u201a', '\u0192', '\u201e', '\u2026', '\u2020', '\u2021',
+	'\u02c6', '\u2030', '\u0160', '\u2039', '\u0152', '\u008d', '\u017d', '\u008f',
+	'\u0090', '\u2018', '\u2019', '\u201c', '\u201d', '\u2022', '\u2013', '\u2014',
+	'\u02dc', '\u2122', '\u0161', '\u203a', '\u0153', '\u009d', '\u017e', '\u0178',
+}
+
+// decodeEmailReplySingleByte converts text from ISO-8859-1, where every byte is the character with the same code,
+// or from Windows-1252 when given its characters for 0x80 to 0x9f.
+func decodeEmailReplySingleByte(data []byte, highControlRunes *[32]rune) string {
+	runes := make([]rune, len(data))
+	for i, b := range data {
+		if highControlRunes != nil && b >= 0x80 && b <= 0x9f {
+			runes[i] = highControlRunes[b-0x80]
+		} else {
+			runes[i] = rune(b)
+		}
+	}
+
+	return string(runes)
+}
+
+// convertEmailReplyHtml turns the HTML body of a reply into plain text, dropping any quoted messages.
+func convertEmailReplyHtml(body string) string {
+	body = emailReplyBlockquotePattern.ReplaceAllString(body, "")
+	body = emailReplyLineBreakPattern.ReplaceAllString(body, "\n")
+	body = emailReplyTagPattern.ReplaceAllString(body, "")
+
+	return html.UnescapeString(body)
+}
+
+// stripEmailReplyQuotes removes the parts of a reply that were quoted from earlier in the conversation along
+// with the sender's signature.
+func stripEmailReplyQuotes(text string) string {
+	lines := strings.Split(strings.Replace(text, "\r\n", "\n", -1), "\n")
+
+	var kept []string
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		next := ""
+		if i+1 < len(lines) {
+			next = strings.TrimSpace(lines[i+1])
+		}
+
+		if isEmailReplyHeader(trimmed, next) || line == "-- " {
+			break
+		}
+
+		if strings.HasPrefix(trimmed, ">") {
+			continue
+		}
+
+		kept = append(kept, line)
+	}
+
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// isEmailReplyHeader returns true if the line starts the quoted message in a reply, such as "On <date>, <name>
+// wrote:", which some clients wrap onto a second line.
+func isEmailReplyHeader(line, next string) bool {
+	if strings.HasPrefix(line, "On ") && (strings.HasSuffix(line, "wrote:") || next == "wrote:" || (strings.HasSuffix(next, "wrote:") && !strings.HasPrefix(next, ">"))) {
+		return true
+	}
+
+	// Outlook doesn't quote the original message, but starts it with its headers
+	if strings.HasPrefix(line, "From: ") && (strings.HasPrefix(next, "Sent: ") || strings.HasPrefix(next, "Date: ")) {
+		return true
+	}
+
+	for _, pattern := range emailReplyHeaderPatterns {
+		if pattern.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	EMAIL_REPLY_SERVER_TIMEOUT         = time.Minute * 5
+	EMAIL_REPLY_SERVER_MAX_RECIPIENTS  = 100
+	EMAIL_REPLY_SERVER_MAX_CONNECTIONS = 20
+)
+
+var emailReplyServer *EmailReplyServer
+var emailReplyServerLock sync.Mutex
+
+// EmailReplyServer is a minimal SMTP server that receives replies to notification emails. It only accepts mail
+// for valid reply addresses, so it's meant to be sent mail by the organisation's mail server rather than being
+// exposed as a general purpose one.
+type EmailReplyServer struct {
+	listener    net.Listener
+	handler     func(recipient string, data io.Reader) (*model.Post, *model.AppError)
+	connections chan bool
+}
+
+func StartEmailReplyServer() {
+	emailReplyServerLock.Lock()
+	defer emailReplyServerLock.Unlock()
+
+	if emailReplyServer != nil || !*utils.Cfg.EmailSettings.EnableReplyByEmail {
+		return
+	}
+
+	listener, err := net.Listen("tcp", *utils.Cfg.EmailSettings.ReplyByEmailListen)
+	if err != nil {
+		l4g.Error(utils.T("api.email_reply.server.listen.error"), *utils.Cfg.EmailSettings.ReplyByEmailListen, err.Error())
+		return
+	}
+
+	l4g.Info(utils.T("api.email_reply.server.starting.info"), listener.Addr().String())
+
+	emailReplyServer = &EmailReplyServer{
+		listener:    listener,
+		handler:     HandleEmailReply,
+		connections: make(chan bool, EMAIL_REPLY_SERVER_MAX_CONNECTIONS),
+	}
+
+	go emailReplyServer.Serve()
+}
+
+func StopEmailReplyServer() {
+	emailReplyServerLock.Lock()
+	defer emailReplyServerLock.Unlock()
+
+	if emailReplyServer != nil {
+		emailReplyServer.listener.Close()
+		emailReplyServer = nil
+	}
+}
+
+func RestartEmailReplyServer() {
+	StopEmailReplyServer()
+	StartEmailReplyServer()
+}
+
+func (s *EmailReplyServer) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+func (s *EmailReplyServer) Serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			// the listener has been closed
+			return
+		}
+
+		select {
+		case s.connections <- true:
+			go func() {
+				defer func() { <-s.connections }()
+				s.serveConn(conn)
+			}()
+		default:
+			conn.SetDeadline(time.Now().Add(time.Second))
+			textproto.NewConn(conn).PrintfLine("421 Too many connections, try again later")
+			conn.Close()
+		}
+	}
+}
+
+func (s *EmailReplyServer) serveConn(conn net.Conn) {
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+
+	hostname := "localhost"
+	if host, _, err := net.SplitHostPort(s.listener.Addr().String()); err == nil && host != "" && host != "::" && host != "0.0.0.0" {
+		hostname = host
+	}
+
+	conn.SetDeadline(time.Now().Add(EMAIL_REPLY_SERVER_TIMEOUT))
+	text.PrintfLine("220 %v ESMTP %v", hostname, utils.Cfg.TeamSettings.SiteName)
+
+	hasSender := false
+	var recipients []string
+
+	for {
+		conn.SetDeadline(time.Now().Add(EMAIL_REPLY_SERVER_TIMEOUT))
+
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb := strings.ToUpper(line)
+		arg := ""
+		if index := strings.Index(line, " "); index != -1 {
+			verb = strings.ToUpper(line[:index])
+			arg = strings.TrimSpace(line[index+1:])
+		}
+
+		switch verb {
+		case "HELO", "EHLO":
+			hasSender = false
+			recipients = nil
+			text.PrintfLine("250 %v", hostname)
+		case "MAIL":
+			if !strings.HasPrefix(strings.ToUpper(arg), "FROM:") {
+				text.PrintfLine("501 Syntax: MAIL FROM:<address>")
+				continue
+			}
+
+			// the sender isn't used since replies are authenticated by their recipient and From header
+			hasSender = true
+			recipients = nil
+			text.PrintfLine("250 OK")
+		case "RCPT":
+			if !hasSender {
+				text.PrintfLine("503 Need MAIL before RCPT")
+				continue
+			} else if !strings.HasPrefix(strings.ToUpper(arg), "TO:") {
+				text.PrintfLine("501 Syntax: RCPT TO:<address>")
+				continue
+			} else if len(recipients) >= EMAIL_REPLY_SERVER_MAX_RECIPIENTS {
+				text.PrintfLine("452 Too many recipients")
+				continue
+			}
+
+			recipient := getSmtpAddress(arg[len("TO:"):])
+			if _, _, err := parseEmailReplyAddress(recipient); err != nil {
+				text.PrintfLine("550 No such reply address")
+				continue
+			}
+
+			recipients = append(recipients, recipient)
+			text.PrintfLine("250 OK")
+		case "DATA":
+			if len(recipients) == 0 {
+				text.PrintfLine("503 Need RCPT before DATA")
+				continue
+			}
+
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+
+			maxSize := *utils.Cfg.EmailSettings.ReplyByEmailMaxMessageSize
+			dot := text.DotReader()
+
+			data, err := ioutil.ReadAll(io.LimitReader(dot, maxSize+1))
+			if err != nil {
+				return
+			}
+
+			if int64(len(data)) > maxSize {
+				// read the rest of the message so that the connection can be used again
+				io.Copy(ioutil.Discard, dot)
+				text.PrintfLine("552 Message too large")
+			} else if s.handleMessage(recipients, data) {
+				text.PrintfLine("250 OK")
+			} else {
+				text.PrintfLine("554 Reply could not be posted")
+			}
+
+			hasSender = false
+			recipients = nil
+		case "RSET":
+			hasSender = false
+			recipients = nil
+			text.PrintfLine("250 OK")
+		case "NOOP":
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+// handleMessage posts the message for each of its recipients, returning true if any of them succeeded.
+func (s *EmailReplyServer) handleMessage(recipients []string, data []byte) bool {
+	posted := false
+
+	for _, recipient := range recipients {
+		if _, err := s.handler(recipient, bytes.NewReader(data)); err != nil {
+			l4g.Warn(utils.T("api.email_reply.server.handle.warn"), recipient, err.Error())
+		} else {
+			posted = true
+		}
+	}
+
+	return posted
+}
+
+// getSmtpAddress returns the address from the argument of a MAIL or RCPT command, ignoring any parameters.
+func getSmtpAddress(arg string) string {
+	arg = strings.TrimSpace(arg)
+
+	if start := strings.Index(arg, "<"); start != -1 {
+		if end := strings.Index(arg[start:], ">"); end != -1 {
+			return arg[start+1 : start+end]
+		}
+	}
+
+	if fields := strings.Fields(arg); len(fields) > 0 {
+		return fields[0]
+	}
+
+	return ""
+}
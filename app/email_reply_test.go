@@ -0,0 +1,301 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"io"
+	"net"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestEmailReplyAddress(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+
+	enable := *utils.Cfg.EmailSettings.EnableReplyByEmail
+	address := *utils.Cfg.EmailSettings.ReplyByEmailAddress
+	defer func() {
+		*utils.Cfg.EmailSettings.EnableReplyByEmail = enable
+		*utils.Cfg.EmailSettings.ReplyByEmailAddress = address
+	}()
+
+	post := &model.Post{Id: model.NewId()}
+	userId := model.NewId()
+
+	*utils.Cfg.EmailSettings.EnableReplyByEmail = false
+	*utils.Cfg.EmailSettings.ReplyByEmailAddress = "reply@example.com"
+	if replyAddress := GetEmailReplyAddress(post, userId); replyAddress != "" {
+		t.Fatal("shouldn't have a reply address when disabled", replyAddress)
+	}
+
+	*utils.Cfg.EmailSettings.EnableReplyByEmail = true
+	replyAddress := GetEmailReplyAddress(post, userId)
+	if !strings.HasPrefix(replyAddress, "reply+"+post.Id+userId) || !strings.HasSuffix(replyAddress, "@example.com") {
+		t.Fatal("made wrong reply address", replyAddress)
+	}
+
+	if postId, ruserId, err := parseEmailReplyAddress("<" + strings.ToUpper(replyAddress) + ">"); err != nil {
+		t.Fatal(err)
+	} else if postId != post.Id || ruserId != userId {
+		t.Fatal("parsed wrong ids", postId, ruserId)
+	}
+
+	forged := strings.Replace(replyAddress, userId, model.NewId(), 1)
+	if _, _, err := parseEmailReplyAddress(forged); err == nil {
+		t.Fatal("shouldn't accept a reply address for another user")
+	}
+
+	if _, _, err := parseEmailReplyAddress("reply@example.com"); err == nil {
+		t.Fatal("shouldn't accept an address without a token")
+	}
+}
+
+func TestStripEmailReplyQuotes(t *testing.T) {
+	cases := map[string]string{
+		"a reply":                           "a reply",
+		"a reply\r\n\r\n> quoted\r\n> text": "a reply",
+		"first\n> quoted\nsecond":           "first\nsecond",
+		"a reply\n\nOn Mon, Jun 12, 2017 at 10:00 AM, Someone <a@example.com> wrote:\n> quoted":  "a reply",
+		"a reply\n\nOn Mon, Jun 12, 2017 at 10:00 AM, Someone\n<a@example.com> wrote:\n\nquoted": "a reply",
+		"a reply\n\n-----Original Message-----\nFrom: someone":                                   "a reply",
+		"a reply\n\nFrom: Someone <a@example.com>\nSent: Monday\nSubject: hi\n\noriginal":        "a reply",
+		"a reply\n-- \nmy signature": "a reply",
+		"On second thought, no":      "On second thought, no",
+		"> only quoted":              "",
+	}
+
+	for text, expected := range cases {
+		if actual := stripEmailReplyQuotes(text); actual != expected {
+			t.Fatalf("stripped %q to %q instead of %q", text, actual, expected)
+		}
+	}
+
+	if text := convertEmailReplyHtml("<div>a &amp; reply<br>second</div><blockquote><p>quoted</p></blockquote>"); strings.TrimSpace(text) != "a & reply\nsecond" {
+		t.Fatalf("converted html to %q", text)
+	}
+}
+
+func TestReadEmailReplyPart(t *testing.T) {
+	body := strings.Join([]string{
+		"--outer",
+		"Content-Type: multipart/alternative; boundary=inner",
+		"",
+		"--inner",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: quoted-printable",
+		"",
+		"h=C3=A9llo",
+		"--inner",
+		"Content-Type: text/html; charset=utf-8",
+		"",
+		"<p>hello</p>",
+		"--inner--",
+		"--outer",
+		"Content-Type: text/plain; name=\"notes.txt\"",
+		"Content-Disposition: attachment; filename=\"notes.txt\"",
+		"Content-Transfer-Encoding: base64",
+		"",
+		"c29tZSBu",
+		"b3Rlcw==",
+		"--outer--",
+		"",
+	}, "\r\n")
+
+	reply := &emailReply{}
+	if err := readEmailReplyPart("multipart/mixed; boundary=outer", "", "", strings.NewReader(body), reply); err != nil {
+		t.Fatal(err)
+	}
+
+	if reply.text != "héllo" {
+		t.Fatalf("read wrong text %q", reply.text)
+	} else if reply.html != "<p>hello</p>" {
+		t.Fatalf("read wrong html %q", reply.html)
+	} else if len(reply.attachments) != 1 || reply.attachments[0].name != "notes.txt" || string(reply.attachments[0].data) != "some notes" {
+		t.Fatal("read wrong attachments", reply.attachments)
+	}
+
+	reply = &emailReply{}
+	if err := readEmailReplyPart("text/plain; charset=ISO-8859-1", "quoted-printable", "", strings.NewReader("h=E9llo"), reply); err != nil {
+		t.Fatal(err)
+	} else if reply.text != "héllo" {
+		t.Fatalf("read wrong ISO-8859-1 text %q", reply.text)
+	}
+
+	reply = &emailReply{}
+	if err := readEmailReplyPart("text/html; charset=windows-1252", "quoted-printable", "", strings.NewReader("=93h=E9llo=94 =80"), reply); err != nil {
+		t.Fatal(err)
+	} else if reply.html != "\u201chéllo\u201d \u20ac" {
+		t.Fatalf("read wrong Windows-1252 html %q", reply.html)
+	}
+
+	if err := readEmailReplyPart("text/plain; charset=koi8-r", "", "", strings.NewReader("hello"), &emailReply{}); err == nil {
+		t.Fatal("should've rejected an unsupported charset")
+	}
+}
+
+func TestEmailReplyServer(t *testing.T) {
+	th := Setup().InitBasic()
+
+	enable := *utils.Cfg.EmailSettings.EnableReplyByEmail
+	address := *utils.Cfg.EmailSettings.ReplyByEmailAddress
+	listen := *utils.Cfg.EmailSettings.ReplyByEmailListen
+	defer func() {
+		StopEmailReplyServer()
+
+		*utils.Cfg.EmailSettings.EnableReplyByEmail = enable
+		*utils.Cfg.EmailSettings.ReplyByEmailAddress = address
+		*utils.Cfg.EmailSettings.ReplyByEmailListen = listen
+	}()
+
+	*utils.Cfg.EmailSettings.EnableReplyByEmail = true
+	*utils.Cfg.EmailSettings.ReplyByEmailAddress = "reply@example.com"
+	*utils.Cfg.EmailSettings.ReplyByEmailListen = "localhost:0"
+
+	StartEmailReplyServer()
+	if emailReplyServer == nil {
+		t.Fatal("should've started the server")
+	}
+	serverAddress := emailReplyServer.Addr().String()
+
+	replyAddress := GetEmailReplyAddress(th.BasicPost, th.BasicUser.Id)
+
+	message := strings.Join([]string{
+		"From: " + th.BasicUser.Email,
+		"To: " + replyAddress,
+		"Subject: Re: notification",
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/mixed; boundary=boundary",
+		"",
+		"--boundary",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		"replying by email",
+		"",
+		"On Mon, Jun 12, 2017 at 10:00 AM, Someone wrote:",
+		"> " + th.BasicPost.Message,
+		"--boundary",
+		"Content-Type: text/plain",
+		"Content-Disposition: attachment; filename=\"notes.txt\"",
+		"",
+		"some notes",
+		"--boundary--",
+		"",
+	}, "\r\n")
+
+	if err := smtp.SendMail(serverAddress, nil, th.BasicUser.Email, []string{replyAddress}, []byte(message)); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := GetPostThread(th.BasicPost.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var reply *model.Post
+	for _, post := range list.Posts {
+		if post.RootId == th.BasicPost.Id {
+			reply = post
+		}
+	}
+
+	if reply == nil {
+		t.Fatal("should've posted the reply")
+	} else if reply.UserId != th.BasicUser.Id || reply.Message != "replying by email" {
+		t.Fatal("posted the wrong reply", reply.UserId, reply.Message)
+	} else if len(reply.FileIds) != 1 {
+		t.Fatal("should've attached the file", reply.FileIds)
+	}
+
+	if infos, err := GetFileInfosForPost(reply.Id, true); err != nil {
+		t.Fatal(err)
+	} else if len(infos) != 1 || infos[0].Name != "notes.txt" {
+		t.Fatal("should've saved the attachment", infos)
+	}
+
+	// the From header has to match the user the address was made for
+	forged := strings.Replace(message, "From: "+th.BasicUser.Email, "From: "+th.BasicUser2.Email, 1)
+	if err := smtp.SendMail(serverAddress, nil, th.BasicUser2.Email, []string{replyAddress}, []byte(forged)); err == nil {
+		t.Fatal("shouldn't accept a reply from another sender")
+	}
+
+	if err := smtp.SendMail(serverAddress, nil, th.BasicUser.Email, []string{"reply+junk@example.com"}, []byte(message)); err == nil {
+		t.Fatal("shouldn't accept mail for an invalid address")
+	}
+
+	old := (<-Srv.Store.Post().Save(&model.Post{
+		ChannelId: th.BasicChannel.Id,
+		UserId:    th.BasicUser2.Id,
+		Message:   "an old post",
+		CreateAt:  model.GetMillis() - int64(EMAIL_REPLY_EXPIRY/time.Millisecond) - 1000,
+	})).Data.(*model.Post)
+
+	if _, err := HandleEmailReply(GetEmailReplyAddress(old, th.BasicUser.Id), strings.NewReader(message)); err == nil || err.Id != "api.email_reply.expired.app_error" {
+		t.Fatal("shouldn't accept a reply to an expired address", err)
+	}
+}
+
+func TestEmailReplyServerLimits(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+
+	enable := *utils.Cfg.EmailSettings.EnableReplyByEmail
+	address := *utils.Cfg.EmailSettings.ReplyByEmailAddress
+	maxMessageSize := *utils.Cfg.EmailSettings.ReplyByEmailMaxMessageSize
+	defer func() {
+		*utils.Cfg.EmailSettings.EnableReplyByEmail = enable
+		*utils.Cfg.EmailSettings.ReplyByEmailAddress = address
+		*utils.Cfg.EmailSettings.ReplyByEmailMaxMessageSize = maxMessageSize
+	}()
+
+	*utils.Cfg.EmailSettings.EnableReplyByEmail = true
+	*utils.Cfg.EmailSettings.ReplyByEmailAddress = "reply@example.com"
+	*utils.Cfg.EmailSettings.ReplyByEmailMaxMessageSize = 100
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+
+	server := &EmailReplyServer{
+		listener: listener,
+		handler: func(recipient string, data io.Reader) (*model.Post, *model.AppError) {
+			return &model.Post{}, nil
+		},
+		connections: make(chan bool, 1),
+	}
+	go server.Serve()
+
+	replyAddress := GetEmailReplyAddress(&model.Post{Id: model.NewId()}, model.NewId())
+
+	if err := smtp.SendMail(listener.Addr().String(), nil, "sender@example.com", []string{replyAddress}, []byte("Subject: hi\r\n\r\nshort")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := smtp.SendMail(listener.Addr().String(), nil, "sender@example.com", []string{replyAddress}, []byte("Subject: hi\r\n\r\n"+strings.Repeat("a", 100))); err == nil {
+		t.Fatal("shouldn't accept a message over the maximum size")
+	}
+
+	// wait for the earlier connections to be closed by the server
+	for i := 0; i < 100 && len(server.connections) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// the only connection is held open, so the next one is turned away
+	conn, err := smtp.Dial(listener.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	if _, err := smtp.Dial(listener.Addr().String()); err == nil || !strings.Contains(err.Error(), "421") {
+		t.Fatal("should've refused a connection over the limit", err)
+	}
+}
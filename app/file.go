@@ -5,8 +5,10 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
@@ -15,14 +17,19 @@ import (
 	"image/jpeg"
 	"io"
 	"io/ioutil"
+	"mime"
 	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"os/exec"
 	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
+	"unicode/utf8"
 
 	l4g "github.com/alecthomas/log4go"
 	"github.com/disintegration/imaging"
@@ -54,8 +61,97 @@ const (
 	RotatedCW          = 8
 
 	MaxImageSize = 6048 * 4032 // 24 megapixels, roughly 36MB as a raw image
+
+	PreviewConverterTimeout = 30 * time.Second
 )
 
+// A PreviewGenerator creates the preview of a file that isn't an image. Documents and videos are rendered
+// to an image that is saved as the file's thumbnail and preview in the same way as uploaded images, while
+// text files are previewed using the start of their text.
+type PreviewGenerator func(data []byte) (*FilePreview, error)
+
+type FilePreview struct {
+	Image image.Image
+	Text  []byte
+}
+
+var previewGenerators = map[string]PreviewGenerator{}
+
+func init() {
+	RegisterPreviewGenerator("application/pdf", generatePdfPreview)
+	RegisterPreviewGenerator("video/*", generateVideoPreview)
+	RegisterPreviewGenerator("text/*", generateTextPreview)
+
+	for _, mimeType := range textMimeTypes {
+		RegisterPreviewGenerator(mimeType, generateTextPreview)
+	}
+}
+
+// Types of text files that aren't under text/*
+var textMimeTypes = []string{"application/json", "application/javascript", "application/xml", "application/x-sh", "application/x-yaml"}
+
+// RegisterPreviewGenerator sets the generator used for files of a MIME type. A type ending in /* such as
+// video/* is used for any subtype that doesn't have its own generator.
+func RegisterPreviewGenerator(mimeType string, generator PreviewGenerator) {
+	previewGenerators[mimeType] = generator
+}
+
+func GetPreviewGenerator(mimeType string) PreviewGenerator {
+	for _, key := range mimeTypeRegistryKeys(mimeType) {
+		if generator, ok := previewGenerators[key]; ok {
+			return generator
+		}
+	}
+
+	return nil
+}
+
+// mimeTypeRegistryKeys returns the keys that a generator or extractor for a MIME type may be registered
+// under from the most to the least specific.
+func mimeTypeRegistryKeys(mimeType string) []string {
+	if mediaType, _, err := mime.ParseMediaType(mimeType); err == nil {
+		mimeType = mediaType
+	}
+
+	keys := []string{mimeType}
+	if index := strings.Index(mimeType, "/"); index > 0 {
+		keys = append(keys, mimeType[:index]+"/*")
+	}
+
+	return keys
+}
+
+// getFileMimeType returns the type of an uploaded file, falling back to detecting it from the file's
+// contents if its extension wasn't recognized.
+func getFileMimeType(info *model.FileInfo, data []byte) string {
+	if info.MimeType != "" {
+		return info.MimeType
+	}
+
+	return http.DetectContentType(data)
+}
+
+// runPreviewConverter writes the file to a temporary location, runs an external converter on it and returns
+// what the converter wrote to stdout. It's a variable so that tests don't need the converters installed.
+var runPreviewConverter = func(data []byte, command string, args func(inputPath string) []string) ([]byte, error) {
+	input, err := ioutil.TempFile("", "preview")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(input.Name())
+
+	_, err = input.Write(data)
+	input.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), PreviewConverterTimeout)
+	defer cancel()
+
+	return exec.CommandContext(ctx, command, args(input.Name())...).Output()
+}
+
 func ReadFile(path string) ([]byte, *model.AppError) {
 	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
 		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
@@ -122,6 +218,32 @@ func MoveFile(oldPath, newPath string) *model.AppError {
 	return nil
 }
 
+func RemoveFile(path string) *model.AppError {
+	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
+		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
+		accessKey := utils.Cfg.FileSettings.AmazonS3AccessKeyId
+		secretKey := utils.Cfg.FileSettings.AmazonS3SecretAccessKey
+		secure := *utils.Cfg.FileSettings.AmazonS3SSL
+		s3Clnt, err := s3.New(endpoint, accessKey, secretKey, secure)
+		if err != nil {
+			return model.NewLocAppError("RemoveFile", "api.file.remove_file.s3.app_error", nil, err.Error())
+		}
+		bucket := utils.Cfg.FileSettings.AmazonS3Bucket
+
+		if err = s3Clnt.RemoveObject(bucket, path); err != nil {
+			return model.NewLocAppError("RemoveFile", "api.file.remove_file.s3.app_error", nil, err.Error())
+		}
+	} else if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_LOCAL {
+		if err := os.Remove(utils.Cfg.FileSettings.Directory + path); err != nil {
+			return model.NewLocAppError("RemoveFile", "api.file.remove_file.local.app_error", nil, err.Error())
+		}
+	} else {
+		return model.NewLocAppError("RemoveFile", "api.file.remove_file.configured.app_error", nil, "")
+	}
+
+	return nil
+}
+
 func WriteFile(f []byte, path string) *model.AppError {
 	if utils.Cfg.FileSettings.DriverName == model.IMAGE_DRIVER_S3 {
 		endpoint := utils.Cfg.FileSettings.AmazonS3Endpoint
@@ -306,6 +428,8 @@ func MigrateFilenamesToFileInfos(post *model.Post) []*model.FileInfo {
 				continue
 			}
 
+			info.TeamId = channel.TeamId
+
 			infos = append(infos, info)
 		}
 	}
@@ -349,8 +473,8 @@ func MigrateFilenamesToFileInfos(post *model.Post) []*model.FileInfo {
 	newPost.Filenames = []string{}
 	newPost.FileIds = fileIds
 
-	// Update Posts to clear Filenames and set FileIds
-	if result := <-Srv.Store.Post().Update(newPost, post); result.Err != nil {
+	// Update Posts to clear Filenames and set FileIds without keeping the old version as an edit
+	if result := <-Srv.Store.Post().Overwrite(newPost); result.Err != nil {
 		l4g.Error(utils.T("api.file.migrate_filenames_to_file_infos.save_post.app_error"), post.Id, newPost.FileIds, post.Filenames, result.Err)
 		return []*model.FileInfo{}
 	} else {
@@ -403,10 +527,11 @@ func UploadFiles(teamId string, channelId string, userId string, fileHeaders []*
 
 		info, err := DoUploadFile(teamId, channelId, userId, fileHeader.Filename, data)
 		if err != nil {
+			deleteUploadedFiles(resStruct.FileInfos)
 			return nil, err
 		}
 
-		if info.PreviewPath != "" || info.ThumbnailPath != "" {
+		if info.IsImage() && (info.PreviewPath != "" || info.ThumbnailPath != "") {
 			previewPathList = append(previewPathList, info.PreviewPath)
 			thumbnailPathList = append(thumbnailPathList, info.ThumbnailPath)
 			imageDataList = append(imageDataList, data)
@@ -433,8 +558,20 @@ func DoUploadFile(teamId string, channelId string, userId string, rawFilename st
 		return nil, err
 	}
 
+	// Files are counted against the team of the channel that they're uploaded to rather than the team in
+	// their path, which isn't set for files uploaded through APIv4. Files in direct messages have no team.
+	channel, err := GetChannel(channelId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := CheckStorageQuota(channel.TeamId, userId, info.Size); err != nil {
+		return nil, err
+	}
+
 	info.Id = model.NewId()
 	info.CreatorId = userId
+	info.TeamId = channel.TeamId
 
 	pathPrefix := "teams/" + teamId + "/channels/" + channelId + "/users/" + userId + "/" + info.Id + "/"
 	info.Path = pathPrefix + filename
@@ -452,17 +589,62 @@ func DoUploadFile(teamId string, channelId string, userId string, rawFilename st
 		info.ThumbnailPath = pathPrefix + nameWithoutExtension + "_thumb.jpg"
 	}
 
-	if err := WriteFile(data, info.Path); err != nil {
+	if err := scanUploadedFile(info, data); err != nil {
 		return nil, err
 	}
 
-	if result := <-Srv.Store.FileInfo().Save(info); result.Err != nil {
-		return nil, result.Err
+	quarantined := info.ScanStatus == model.FILE_SCAN_STATUS_QUARANTINED
+
+	if info.CanBeServed() {
+		if err := saveFileBlob(info, data); err != nil {
+			return nil, err
+		}
+	} else {
+		if err := WriteFile(data, info.Path); err != nil {
+			return nil, err
+		}
+
+		if result := <-Srv.Store.FileInfo().Save(info); result.Err != nil {
+			return nil, result.Err
+		}
+	}
+
+	if !quarantined {
+		go ExtractFileContent(info, data)
+
+		// like image previews, these can take a while to generate so they're saved once they're ready
+		if !info.IsImage() {
+			go saveFilePreview(*info, data, pathPrefix+strings.TrimSuffix(filename, filepath.Ext(filename)))
+		}
 	}
 
 	return info, nil
 }
 
+// deleteUploadedFiles removes files that were uploaded for a post that couldn't be created along with their
+// stored data. Nothing else can reference them yet, so their infos are removed permanently.
+func deleteUploadedFiles(infos []*model.FileInfo) {
+	for _, info := range infos {
+		if result := <-Srv.Store.FileInfo().PermanentDelete(info.Id); result.Err != nil {
+			l4g.Warn(utils.T("api.file.delete_uploaded_files.warn"), info.Id, result.Err.Error())
+			continue
+		}
+
+		if info.ContentHash != "" {
+			releaseFileBlob(info)
+		} else if err := RemoveFile(info.Path); err != nil {
+			l4g.Warn(utils.T("api.file.delete_uploaded_files.warn"), info.Id, err.Error())
+		}
+
+		// previews may not have been generated yet, so failing to remove them isn't an error
+		for _, path := range []string{info.ThumbnailPath, info.PreviewPath} {
+			if path != "" {
+				RemoveFile(path)
+			}
+		}
+	}
+}
+
 func HandleImages(previewPathList []string, thumbnailPathList []string, fileData [][]byte) {
 	for i, data := range fileData {
 		go func(i int, data []byte) {
@@ -582,6 +764,154 @@ func generatePreviewImage(img image.Image, previewPath string, width int) {
 	}
 }
 
+// generateFilePreview creates the preview of a file that isn't an image using the generator registered for its
+// type. Failures are only logged since the file can still be used without a preview.
+func generateFilePreview(info *model.FileInfo, data []byte, pathWithoutExtension string) {
+	generator := GetPreviewGenerator(getFileMimeType(info, data))
+	if generator == nil {
+		return
+	}
+
+	preview, err := generator(data)
+	if err != nil {
+		l4g.Warn(utils.T("api.file.generate_preview.warn"), info.Name, err.Error())
+		return
+	}
+
+	if preview.Image != nil {
+		bounds := preview.Image.Bounds()
+
+		info.ThumbnailPath = pathWithoutExtension + "_thumb.jpg"
+		info.PreviewPath = pathWithoutExtension + "_preview.jpg"
+		info.HasPreviewImage = true
+
+		generateThumbnailImage(preview.Image, info.ThumbnailPath, bounds.Dx(), bounds.Dy())
+		generatePreviewImage(preview.Image, info.PreviewPath, bounds.Dx())
+	} else if len(preview.Text) > 0 {
+		if err := WriteFile(preview.Text, pathWithoutExtension+"_preview.txt"); err != nil {
+			l4g.Warn(utils.T("api.file.generate_preview.warn"), info.Name, err.Error())
+			return
+		}
+
+		info.PreviewPath = pathWithoutExtension + "_preview.txt"
+	}
+}
+
+// saveFilePreview generates the preview of a file that's already been saved and updates its info with it. The
+// info is passed by value since the uploader still has the original.
+func saveFilePreview(info model.FileInfo, data []byte, pathWithoutExtension string) {
+	generateFilePreview(&info, data, pathWithoutExtension)
+	if info.PreviewPath == "" {
+		return
+	}
+
+	if result := <-Srv.Store.FileInfo().UpdatePreview(&info); result.Err != nil {
+		l4g.Warn(utils.T("api.file.generate_preview.warn"), info.Name, result.Err.Error())
+		return
+	}
+
+	// the file may have been attached to a post while the preview was being generated
+	if result := <-Srv.Store.FileInfo().Get(info.Id); result.Err == nil && result.Data.(*model.FileInfo).PostId != "" {
+		Srv.Store.FileInfo().InvalidateFileInfosForPostCache(result.Data.(*model.FileInfo).PostId)
+	}
+}
+
+func generatePdfPreview(data []byte) (*FilePreview, error) {
+	converter := *utils.Cfg.FileSettings.PdfPreviewConverter
+	if converter == "" {
+		return nil, errors.New("no pdf converter is configured")
+	}
+
+	// Render the first page as a png written to stdout
+	output, err := runPreviewConverter(data, converter, func(inputPath string) []string {
+		return []string{"-png", "-f", "1", "-l", "1", "-singlefile", "-scale-to", strconv.Itoa(utils.Cfg.FileSettings.PreviewWidth), inputPath}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodePreviewImage(output)
+}
+
+func generateVideoPreview(data []byte) (*FilePreview, error) {
+	converter := *utils.Cfg.FileSettings.VideoPreviewConverter
+	if converter == "" {
+		return nil, errors.New("no video converter is configured")
+	}
+
+	// Extract the first frame as a png written to stdout
+	output, err := runPreviewConverter(data, converter, func(inputPath string) []string {
+		return []string{"-loglevel", "error", "-i", inputPath, "-frames:v", "1", "-f", "image2pipe", "-vcodec", "png", "-"}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return decodePreviewImage(output)
+}
+
+// GetPreviewContentType returns the content type of a thumbnail or preview created for a file.
+func GetPreviewContentType(previewPath string) string {
+	if strings.HasSuffix(previewPath, "_preview.txt") {
+		return "text/plain; charset=utf-8"
+	}
+
+	return "image/jpeg"
+}
+
+func decodePreviewImage(data []byte) (*FilePreview, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	} else if config.Width*config.Height > MaxImageSize {
+		return nil, errors.New("rendered preview is too large")
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return &FilePreview{Image: img}, nil
+}
+
+// generateTextPreview returns up to MaxTextPreviewSize bytes of a text file without splitting a character.
+func generateTextPreview(data []byte) (*FilePreview, error) {
+	text := truncateText(data, *utils.Cfg.FileSettings.MaxTextPreviewSize)
+	if !utf8.Valid(text) {
+		return nil, errors.New("file isn't utf-8 text")
+	}
+
+	return &FilePreview{Text: text}, nil
+}
+
+// truncateText shortens text to at most maxSize bytes without splitting a character.
+func truncateText(text []byte, maxSize int64) []byte {
+	if int64(len(text)) <= maxSize {
+		return text
+	}
+
+	text = text[:maxSize]
+	for i := 0; i < utf8.UTFMax && len(text) > 0 && !utf8.Valid(text); i++ {
+		text = text[:len(text)-1]
+	}
+
+	return text
+}
+
+func SearchFilesInTeam(params *model.FileSearch, userId string, teamId string) ([]*model.FileInfo, *model.AppError) {
+	// don't allow users to search for everything
+	if params.Terms == "*" {
+		return []*model.FileInfo{}, nil
+	}
+
+	if result := <-Srv.Store.FileInfo().Search(teamId, userId, params); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.FileInfo), nil
+	}
+}
+
 func GetFileInfo(fileId string) (*model.FileInfo, *model.AppError) {
 	if result := <-Srv.Store.FileInfo().Get(fileId); result.Err != nil {
 		return nil, result.Err
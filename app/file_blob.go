@@ -0,0 +1,193 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// Uploaded files are stored once for each distinct content under a path made from the hash of their content.
+// Every FileInfo for the same content points at the same blob, and the number of FileInfos referencing each
+// blob is kept in the database so that the blob is removed once the last of them is deleted, even when the
+// servers in a cluster upload and delete files with the same content at the same time. Files that are
+// quarantined or waiting to be scanned aren't shared since they may be moved by the scanner.
+
+const (
+	FILE_BLOB_PATH_PREFIX = "blobs/"
+
+	FILE_BLOB_MIGRATION_BATCH_SIZE = 100
+
+	// A blob that's been waiting this long to be removed is assumed to have been abandoned by its server
+	FILE_BLOB_REMOVAL_TIMEOUT = 10 * time.Minute
+
+	FILE_BLOB_ACQUIRE_ATTEMPTS     = 50
+	FILE_BLOB_ACQUIRE_RETRY_PERIOD = 100 * time.Millisecond
+)
+
+func GetContentHash(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+func getFileBlobPath(hash string) string {
+	return FILE_BLOB_PATH_PREFIX + hash[:2] + "/" + hash
+}
+
+// saveFileBlob stores the data for a newly uploaded file in shared storage and saves its FileInfo.
+func saveFileBlob(info *model.FileInfo, data []byte) *model.AppError {
+	if _, err := acquireFileBlob(info, GetContentHash(data), data); err != nil {
+		return err
+	}
+
+	if result := <-Srv.Store.FileInfo().Save(info); result.Err != nil {
+		releaseFileBlob(info)
+		return result.Err
+	}
+
+	return nil
+}
+
+// acquireFileBlob points a file at the shared storage for its content and adds a reference to it, storing the
+// data unless another file already references the same content, in which case it returns true. The reference
+// must be released if the FileInfo can't be saved.
+func acquireFileBlob(info *model.FileInfo, hash string, data []byte) (bool, *model.AppError) {
+	info.ContentHash = hash
+	info.Path = getFileBlobPath(hash)
+
+	for attempt := 1; ; attempt++ {
+		staleBefore := model.GetMillis() - int64(FILE_BLOB_REMOVAL_TIMEOUT/time.Millisecond)
+
+		result := <-Srv.Store.FileBlob().Acquire(info.Path, staleBefore)
+		if result.Err != nil {
+			// The blob is being removed by another file, so wait for it to be gone before storing it again
+			if result.Err.StatusCode == http.StatusConflict && attempt < FILE_BLOB_ACQUIRE_ATTEMPTS {
+				time.Sleep(FILE_BLOB_ACQUIRE_RETRY_PERIOD)
+				continue
+			}
+
+			return false, result.Err
+		}
+
+		if result.Data.(bool) {
+			return true, nil
+		}
+
+		if err := WriteFile(data, info.Path); err != nil {
+			releaseFileBlob(info)
+			return false, err
+		}
+
+		return false, nil
+	}
+}
+
+// releaseFileBlob removes a deleted file's reference to its stored data, removing the data if no other file
+// references it.
+func releaseFileBlob(info *model.FileInfo) {
+	if result := <-Srv.Store.FileBlob().Release(info.Path); result.Err != nil {
+		l4g.Warn(utils.T("api.file.release_file_blob.warn"), info.Path, result.Err.Error())
+	} else if result.Data.(bool) {
+		if err := RemoveFile(info.Path); err != nil {
+			l4g.Warn(utils.T("api.file.release_file_blob.warn"), info.Path, err.Error())
+		}
+
+		if result := <-Srv.Store.FileBlob().Delete(info.Path); result.Err != nil {
+			l4g.Warn(utils.T("api.file.release_file_blob.warn"), info.Path, result.Err.Error())
+		}
+	}
+}
+
+// releaseDeletedFileBlob releases a deleted file's reference to its stored data unless it's already been
+// released, which can happen when the file is no longer held by a legal hold.
+func releaseDeletedFileBlob(info *model.FileInfo) {
+	if result := <-Srv.Store.FileInfo().MarkBlobReleased(info.Id); result.Err != nil {
+		l4g.Warn(utils.T("api.file.release_file_blob.warn"), info.Path, result.Err.Error())
+	} else if result.Data.(bool) {
+		releaseFileBlob(info)
+	}
+}
+
+// MigrateFilesToBlobs moves files that were uploaded before files were shared into shared storage, removing
+// their old copies once nothing references them. Deleted files are moved too since their data is kept. It
+// returns the number of files that were moved and how many bytes of duplicate files were removed.
+func MigrateFilesToBlobs() (int, int64, *model.AppError) {
+	moved := 0
+	var saved int64
+
+	afterId := ""
+	for {
+		var infos []*model.FileInfo
+		if result := <-Srv.Store.FileInfo().GetWithoutContentHash(afterId, FILE_BLOB_MIGRATION_BATCH_SIZE); result.Err != nil {
+			return moved, saved, result.Err
+		} else {
+			infos = result.Data.([]*model.FileInfo)
+		}
+
+		if len(infos) == 0 {
+			return moved, saved, nil
+		}
+
+		for _, info := range infos {
+			afterId = info.Id
+
+			if !info.CanBeServed() {
+				continue
+			}
+
+			if duplicate, err := migrateFileToBlob(info); err != nil {
+				l4g.Warn(utils.T("api.file.migrate_file_to_blob.warn"), info.Id, err.Error())
+			} else {
+				moved++
+				if duplicate {
+					saved += info.Size
+				}
+			}
+		}
+	}
+}
+
+func migrateFileToBlob(info *model.FileInfo) (bool, *model.AppError) {
+	data, err := ReadFile(info.Path)
+	if err != nil {
+		return false, err
+	}
+
+	oldPath := info.Path
+
+	// The old copy is kept until the FileInfo has been updated so that the file can't go missing
+	duplicate, err := acquireFileBlob(info, GetContentHash(data), data)
+	if err != nil {
+		return false, err
+	}
+
+	if result := <-Srv.Store.FileInfo().UpdateContentHash(info); result.Err != nil {
+		releaseFileBlob(info)
+		return false, result.Err
+	}
+
+	if info.PostId != "" {
+		Srv.Store.FileInfo().InvalidateFileInfosForPostCache(info.PostId)
+	}
+
+	// Before files had ids, the same file could be attached to multiple posts, and deleted files may still
+	// point at the old copy if they haven't been migrated yet
+	if result := <-Srv.Store.FileInfo().CountByPath(oldPath); result.Err != nil {
+		return false, result.Err
+	} else if result.Data.(int64) > 0 {
+		return false, nil
+	}
+
+	if err := RemoveFile(oldPath); err != nil {
+		return false, err
+	}
+
+	return duplicate, nil
+}
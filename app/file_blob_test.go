@@ -0,0 +1,25 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"testing"
+)
+
+func TestGetFileBlobPath(t *testing.T) {
+	hash := GetContentHash([]byte("file contents"))
+	if len(hash) != 64 {
+		t.Fatal("hash should be a hex encoded sha256", hash)
+	}
+
+	if hash != GetContentHash([]byte("file contents")) {
+		t.Fatal("the same contents should have the same hash")
+	} else if hash == GetContentHash([]byte("other contents")) {
+		t.Fatal("different contents should have different hashes")
+	}
+
+	if path := getFileBlobPath(hash); path != FILE_BLOB_PATH_PREFIX+hash[:2]+"/"+hash {
+		t.Fatal("incorrect blob path", path)
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	DOCX_MIME_TYPE          = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+	DOCX_MAX_DOCUMENT_SIZE  = 20 * 1024 * 1024 // 20MB, since document.xml is compressed inside the docx
+	DOCX_DOCUMENT_FILE_NAME = "word/document.xml"
+)
+
+// A ContentExtractor returns the text of a file so that the file can be found by searching for the words
+// inside it.
+type ContentExtractor func(data []byte) (string, error)
+
+var contentExtractors = map[string]ContentExtractor{}
+
+func init() {
+	// Not all systems know the type of docx files
+	mime.AddExtensionType(".docx", DOCX_MIME_TYPE)
+
+	RegisterContentExtractor("text/*", extractTextContent)
+	RegisterContentExtractor("application/pdf", extractPdfContent)
+	RegisterContentExtractor(DOCX_MIME_TYPE, extractDocxContent)
+
+	for _, mimeType := range textMimeTypes {
+		RegisterContentExtractor(mimeType, extractTextContent)
+	}
+}
+
+// RegisterContentExtractor sets the extractor used for files of a MIME type. As with preview generators, a
+// type ending in /* is used for any subtype that doesn't have its own extractor.
+func RegisterContentExtractor(mimeType string, extractor ContentExtractor) {
+	contentExtractors[mimeType] = extractor
+}
+
+func GetContentExtractor(mimeType string) ContentExtractor {
+	for _, key := range mimeTypeRegistryKeys(mimeType) {
+		if extractor, ok := contentExtractors[key]; ok {
+			return extractor
+		}
+	}
+
+	return nil
+}
+
+// ExtractFileContent saves the text of an uploaded file so that it's included in file searches. It's run in
+// the background after the file is uploaded, and failures are only logged since the file can still be found
+// by its name.
+func ExtractFileContent(info *model.FileInfo, data []byte) {
+	if !*utils.Cfg.FileSettings.ExtractContent {
+		return
+	}
+
+	extractor := GetContentExtractor(getFileMimeType(info, data))
+	if extractor == nil {
+		return
+	}
+
+	text, err := extractor(data)
+	if err != nil {
+		l4g.Warn(utils.T("api.file.extract_content.warn"), info.Name, err.Error())
+		return
+	}
+
+	// Postgres doesn't allow null characters in text
+	text = strings.TrimSpace(strings.Replace(text, "\x00", "", -1))
+	if len(text) == 0 {
+		return
+	}
+
+	content := &model.FileInfoContent{
+		FileId:  info.Id,
+		Content: string(truncateText([]byte(text), model.FILE_INFO_CONTENT_MAX_SIZE)),
+	}
+
+	if result := <-Srv.Store.FileInfo().SaveContent(content); result.Err != nil {
+		l4g.Warn(utils.T("api.file.extract_content.warn"), info.Name, result.Err.Error())
+	}
+}
+
+func extractTextContent(data []byte) (string, error) {
+	text := truncateText(data, model.FILE_INFO_CONTENT_MAX_SIZE)
+	if !utf8.Valid(text) {
+		return "", errors.New("file isn't utf-8 text")
+	}
+
+	return string(text), nil
+}
+
+func extractPdfContent(data []byte) (string, error) {
+	converter := *utils.Cfg.FileSettings.PdfContentConverter
+	if converter == "" {
+		return "", errors.New("no pdf converter is configured")
+	}
+
+	// Write the text of every page to stdout
+	output, err := runPreviewConverter(data, converter, func(inputPath string) []string {
+		return []string{"-enc", "UTF-8", "-q", inputPath, "-"}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return extractTextContent(output)
+}
+
+// extractDocxContent reads the text of a Word document from the paragraphs in its document.xml.
+func extractDocxContent(data []byte) (string, error) {
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range reader.File {
+		if file.Name != DOCX_DOCUMENT_FILE_NAME {
+			continue
+		}
+
+		document, err := file.Open()
+		if err != nil {
+			return "", err
+		}
+		defer document.Close()
+
+		return extractDocxText(io.LimitReader(document, DOCX_MAX_DOCUMENT_SIZE))
+	}
+
+	return "", errors.New("docx doesn't contain " + DOCX_DOCUMENT_FILE_NAME)
+}
+
+func extractDocxText(document io.Reader) (string, error) {
+	var text bytes.Buffer
+	inText := false
+
+	decoder := xml.NewDecoder(document)
+	for text.Len() < model.FILE_INFO_CONTENT_MAX_SIZE {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return "", err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			switch element.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteString("\t")
+			case "br":
+				text.WriteString("\n")
+			}
+		case xml.EndElement:
+			switch element.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteString("\n")
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(element)
+			}
+		}
+	}
+
+	return text.String(), nil
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestGetContentExtractor(t *testing.T) {
+	for _, mimeType := range []string{"text/plain", "text/csv; charset=utf-8", "application/json", "application/pdf", DOCX_MIME_TYPE} {
+		if GetContentExtractor(mimeType) == nil {
+			t.Fatal("should have an extractor for " + mimeType)
+		}
+	}
+
+	if GetContentExtractor("image/png") != nil || GetContentExtractor("application/zip") != nil {
+		t.Fatal("shouldn't have an extractor for other types")
+	}
+
+	if info, _ := model.GetInfoForBytes("report.docx", nil); info.MimeType != DOCX_MIME_TYPE {
+		t.Fatal("should have recognized docx files", info.MimeType)
+	}
+}
+
+func TestExtractContent(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+
+	pdfConverter := *utils.Cfg.FileSettings.PdfContentConverter
+	converter := runPreviewConverter
+	defer func() {
+		*utils.Cfg.FileSettings.PdfContentConverter = pdfConverter
+		runPreviewConverter = converter
+	}()
+
+	if text, err := extractTextContent([]byte("plain text")); err != nil || text != "plain text" {
+		t.Fatal("should have returned the text", text, err)
+	}
+
+	if _, err := extractTextContent([]byte{0xff, 0xfe}); err == nil {
+		t.Fatal("shouldn't have extracted invalid text")
+	}
+
+	if text, _ := extractTextContent([]byte(strings.Repeat("a", model.FILE_INFO_CONTENT_MAX_SIZE+10))); len(text) != model.FILE_INFO_CONTENT_MAX_SIZE {
+		t.Fatal("should have limited the size of the text", len(text))
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	document, _ := archive.Create(DOCX_DOCUMENT_FILE_NAME)
+	document.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+		<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+		<w:p><w:r><w:t>Q3</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">numbers </w:t></w:r></w:p>
+		<w:p><w:r><w:t>revenue</w:t></w:r></w:p>
+		</w:body></w:document>`))
+	archive.Close()
+
+	if text, err := extractDocxContent(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	} else if text != "Q3\tnumbers \nrevenue\n" {
+		t.Fatalf("should have extracted the paragraphs, got %q", text)
+	}
+
+	if _, err := extractDocxContent([]byte("not a zip")); err == nil {
+		t.Fatal("shouldn't have extracted an invalid docx")
+	}
+
+	*utils.Cfg.FileSettings.PdfContentConverter = ""
+	if _, err := extractPdfContent([]byte("%PDF-1.4")); err == nil {
+		t.Fatal("shouldn't have extracted a pdf without a converter")
+	}
+
+	*utils.Cfg.FileSettings.PdfContentConverter = "pdftotext"
+	runPreviewConverter = func(data []byte, command string, args func(string) []string) ([]byte, error) {
+		return []byte("text of " + command), nil
+	}
+
+	if text, err := extractPdfContent([]byte("%PDF-1.4")); err != nil || text != "text of pdftotext" {
+		t.Fatal("should have extracted the text with the converter", text, err)
+	}
+}
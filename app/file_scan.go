@@ -0,0 +1,200 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	QUARANTINE_PATH_PREFIX = "quarantine/"
+
+	PENDING_FILE_SCAN_BATCH_SIZE = 100
+	PENDING_FILE_SCAN_INTERVAL   = 5 * time.Minute
+
+	// Files that still can't be scanned after this many attempts while the scanner is working are marked as
+	// failed so that they don't hold up the rest of the pending files
+	MAX_PENDING_FILE_SCAN_ATTEMPTS = 5
+
+	SYSTEM_PENDING_FILE_SCAN_CLAIM = "PendingFileScanClaim"
+)
+
+func IsFileScanningEnabled() bool {
+	return *utils.Cfg.FileSettings.EnableFileScanning
+}
+
+// scanUploadedFile scans a file before it's written to the file store and sets its scan status. Infected
+// files are either rejected or moved to the quarantine depending on the config. If the scanner can't be
+// reached, the file is saved as pending and can't be downloaded until ScanPendingFiles has scanned it. Files
+// that are larger than the scanner accepts are rejected since they could never be scanned.
+func scanUploadedFile(info *model.FileInfo, data []byte) *model.AppError {
+	if !IsFileScanningEnabled() {
+		return nil
+	}
+
+	if int64(len(data)) > *utils.Cfg.FileSettings.MaxFileScanSize {
+		return model.NewAppError("uploadFile", "api.file.upload_file.too_large_to_scan.app_error", map[string]interface{}{"Filename": info.Name}, "", http.StatusRequestEntityTooLarge)
+	}
+
+	threat, err := scanFile(data)
+	if err != nil {
+		l4g.Warn(utils.T("api.file.scan_file.pending.warn"), info.Name, err.Error())
+		info.ScanStatus = model.FILE_SCAN_STATUS_PENDING
+		return nil
+	}
+
+	if threat == "" {
+		info.ScanStatus = model.FILE_SCAN_STATUS_CLEAN
+		return nil
+	}
+
+	l4g.Warn(utils.T("api.file.scan_file.infected.warn"), info.Name, info.CreatorId, threat)
+
+	if *utils.Cfg.FileSettings.InfectedFileAction == model.INFECTED_FILE_ACTION_REJECT {
+		return model.NewAppError("uploadFile", "api.file.upload_file.infected.app_error", map[string]interface{}{"Filename": info.Name}, "threat="+threat, http.StatusBadRequest)
+	}
+
+	quarantineFileInfo(info)
+
+	return nil
+}
+
+func scanFile(data []byte) (string, *model.AppError) {
+	scanner := einterfaces.GetFileScannerInterface()
+	if scanner == nil {
+		return "", model.NewAppError("scanFile", "api.file.scan_file.no_scanner.app_error", nil, "", http.StatusNotImplemented)
+	}
+
+	return scanner.ScanFile(data)
+}
+
+// quarantineFileInfo marks a file as quarantined and moves its path outside of the team directories so that
+// it isn't found by anything reading files by their path. Quarantined files have no previews.
+func quarantineFileInfo(info *model.FileInfo) {
+	info.ScanStatus = model.FILE_SCAN_STATUS_QUARANTINED
+	info.Path = QUARANTINE_PATH_PREFIX + info.Id + "/" + info.Name
+	info.PreviewPath = ""
+	info.ThumbnailPath = ""
+	info.HasPreviewImage = false
+}
+
+// CheckFileCanBeServed returns an error if a file can't be downloaded because it's been quarantined or
+// hasn't been scanned yet.
+func CheckFileCanBeServed(info *model.FileInfo) *model.AppError {
+	if info.ScanStatus == model.FILE_SCAN_STATUS_QUARANTINED {
+		return model.NewAppError("CheckFileCanBeServed", "api.file.check_file_can_be_served.quarantined.app_error", nil, "file_id="+info.Id, http.StatusForbidden)
+	} else if info.ScanStatus == model.FILE_SCAN_STATUS_FAILED {
+		return model.NewAppError("CheckFileCanBeServed", "api.file.check_file_can_be_served.failed.app_error", nil, "file_id="+info.Id, http.StatusForbidden)
+	} else if !info.CanBeServed() {
+		return model.NewAppError("CheckFileCanBeServed", "api.file.check_file_can_be_served.pending.app_error", nil, "file_id="+info.Id, http.StatusForbidden)
+	}
+
+	return nil
+}
+
+// ScanPendingFiles retries scanning files that couldn't be scanned when they were uploaded. Infected files
+// are always quarantined here since they've already been accepted and may be attached to posts. Only one
+// server in a cluster scans the pending files at a time.
+func ScanPendingFiles() {
+	if !IsFileScanningEnabled() {
+		return
+	}
+
+	claim, ok := claimClusterTask(SYSTEM_PENDING_FILE_SCAN_CLAIM, PENDING_FILE_SCAN_INTERVAL)
+	if !ok {
+		return
+	}
+	defer releaseClusterTask(SYSTEM_PENDING_FILE_SCAN_CLAIM, claim)
+
+	var infos []*model.FileInfo
+	if result := <-Srv.Store.FileInfo().GetByScanStatus(model.FILE_SCAN_STATUS_PENDING, PENDING_FILE_SCAN_BATCH_SIZE); result.Err != nil {
+		l4g.Error(utils.T("api.file.scan_pending_files.error"), result.Err.Error())
+		return
+	} else {
+		infos = result.Data.([]*model.FileInfo)
+	}
+
+	for _, info := range infos {
+		// Files that were pending before the maximum scan size was lowered would be rejected by the scanner
+		if info.Size > *utils.Cfg.FileSettings.MaxFileScanSize {
+			l4g.Error(utils.T("api.file.scan_pending_files.too_large.error"), info.Name, info.Id)
+			info.ScanStatus = model.FILE_SCAN_STATUS_FAILED
+			saveFailedFileScan(info)
+			continue
+		}
+
+		if err := scanPendingFile(info); err != nil {
+			l4g.Warn(utils.T("api.file.scan_file.pending.warn"), info.Name, err.Error())
+
+			// The scanner is most likely down, so the remaining files will be retried next time
+			if err.Id == "api.file.scan_file.no_scanner.app_error" || err.StatusCode == http.StatusServiceUnavailable {
+				return
+			}
+
+			recordFailedFileScan(info)
+		}
+	}
+}
+
+// recordFailedFileScan counts an attempt to scan a file that failed for a reason other than the scanner being
+// down, giving up on the file once it's run out of attempts.
+func recordFailedFileScan(info *model.FileInfo) {
+	info.ScanAttempts++
+	if info.ScanAttempts >= MAX_PENDING_FILE_SCAN_ATTEMPTS {
+		l4g.Error(utils.T("api.file.scan_pending_files.failed.error"), info.Name, info.Id)
+		info.ScanStatus = model.FILE_SCAN_STATUS_FAILED
+	}
+
+	saveFailedFileScan(info)
+}
+
+func saveFailedFileScan(info *model.FileInfo) {
+	if result := <-Srv.Store.FileInfo().UpdateScanStatus(info); result.Err != nil {
+		l4g.Error(utils.T("api.file.scan_pending_files.update.error"), info.Name, result.Err.Error())
+	} else if info.PostId != "" {
+		Srv.Store.FileInfo().InvalidateFileInfosForPostCache(info.PostId)
+	}
+}
+
+func scanPendingFile(info *model.FileInfo) *model.AppError {
+	data, err := ReadFile(info.Path)
+	if err != nil {
+		return err
+	}
+
+	threat, err := scanFile(data)
+	if err != nil {
+		return err
+	}
+
+	if threat == "" {
+		info.ScanStatus = model.FILE_SCAN_STATUS_CLEAN
+	} else {
+		l4g.Warn(utils.T("api.file.scan_file.infected.warn"), info.Name, info.CreatorId, threat)
+
+		// Any preview that was generated is left behind, but it can no longer be reached
+		oldPath := info.Path
+		quarantineFileInfo(info)
+
+		if err := MoveFile(oldPath, info.Path); err != nil {
+			return err
+		}
+	}
+
+	if result := <-Srv.Store.FileInfo().UpdateScanStatus(info); result.Err != nil {
+		return result.Err
+	}
+
+	if info.PostId != "" {
+		Srv.Store.FileInfo().InvalidateFileInfosForPostCache(info.PostId)
+	}
+
+	return nil
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+type testFileScanner struct {
+	err *model.AppError
+}
+
+func (s *testFileScanner) ScanFile(data []byte) (string, *model.AppError) {
+	if s.err != nil {
+		return "", s.err
+	} else if bytes.Contains(data, []byte("virus")) {
+		return "Test-Virus", nil
+	}
+
+	return "", nil
+}
+
+func TestScanUploadedFile(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	enabled := *utils.Cfg.FileSettings.EnableFileScanning
+	action := *utils.Cfg.FileSettings.InfectedFileAction
+	maxScanSize := *utils.Cfg.FileSettings.MaxFileScanSize
+	scanner := einterfaces.GetFileScannerInterface()
+	defer func() {
+		*utils.Cfg.FileSettings.EnableFileScanning = enabled
+		*utils.Cfg.FileSettings.InfectedFileAction = action
+		*utils.Cfg.FileSettings.MaxFileScanSize = maxScanSize
+		einterfaces.RegisterFileScannerInterface(scanner)
+	}()
+
+	testScanner := &testFileScanner{}
+	einterfaces.RegisterFileScannerInterface(testScanner)
+
+	newInfo := func() *model.FileInfo {
+		return &model.FileInfo{
+			Id:            model.NewId(),
+			Name:          "file.png",
+			Path:          "teams/team/file.png",
+			PreviewPath:   "teams/team/file_preview.jpg",
+			ThumbnailPath: "teams/team/file_thumb.jpg",
+		}
+	}
+
+	*utils.Cfg.FileSettings.EnableFileScanning = false
+	info := newInfo()
+	if err := scanUploadedFile(info, []byte("virus")); err != nil {
+		t.Fatal(err)
+	} else if info.ScanStatus != "" {
+		t.Fatal("shouldn't have scanned file while scanning is disabled")
+	}
+
+	*utils.Cfg.FileSettings.EnableFileScanning = true
+	*utils.Cfg.FileSettings.InfectedFileAction = model.INFECTED_FILE_ACTION_REJECT
+
+	info = newInfo()
+	if err := scanUploadedFile(info, []byte("clean")); err != nil {
+		t.Fatal(err)
+	} else if info.ScanStatus != model.FILE_SCAN_STATUS_CLEAN {
+		t.Fatal("should've marked file as clean")
+	}
+
+	if err := scanUploadedFile(newInfo(), []byte("virus")); err == nil {
+		t.Fatal("should've rejected infected file")
+	} else if err.Id != "api.file.upload_file.infected.app_error" {
+		t.Fatal("wrong error", err.Id)
+	}
+
+	*utils.Cfg.FileSettings.InfectedFileAction = model.INFECTED_FILE_ACTION_QUARANTINE
+
+	info = newInfo()
+	if err := scanUploadedFile(info, []byte("virus")); err != nil {
+		t.Fatal(err)
+	} else if info.ScanStatus != model.FILE_SCAN_STATUS_QUARANTINED {
+		t.Fatal("should've quarantined infected file")
+	} else if info.Path != QUARANTINE_PATH_PREFIX+info.Id+"/file.png" {
+		t.Fatal("should've moved file to quarantine", info.Path)
+	} else if info.PreviewPath != "" || info.ThumbnailPath != "" {
+		t.Fatal("quarantined file shouldn't have previews")
+	}
+
+	*utils.Cfg.FileSettings.MaxFileScanSize = 4
+
+	if err := scanUploadedFile(newInfo(), []byte("clean")); err == nil {
+		t.Fatal("should've rejected file that's too large to scan")
+	} else if err.Id != "api.file.upload_file.too_large_to_scan.app_error" {
+		t.Fatal("wrong error", err.Id)
+	}
+
+	*utils.Cfg.FileSettings.MaxFileScanSize = maxScanSize
+
+	testScanner.err = model.NewAppError("ScanFile", "clamav.scan_file.connect.app_error", nil, "", http.StatusServiceUnavailable)
+
+	info = newInfo()
+	if err := scanUploadedFile(info, []byte("virus")); err != nil {
+		t.Fatal(err)
+	} else if info.ScanStatus != model.FILE_SCAN_STATUS_PENDING {
+		t.Fatal("should've left file pending when scanner fails")
+	}
+
+	einterfaces.RegisterFileScannerInterface(nil)
+
+	info = newInfo()
+	if err := scanUploadedFile(info, []byte("clean")); err != nil {
+		t.Fatal(err)
+	} else if info.ScanStatus != model.FILE_SCAN_STATUS_PENDING {
+		t.Fatal("should've left file pending without a scanner")
+	}
+}
+
+func TestCheckFileCanBeServed(t *testing.T) {
+	for _, status := range []string{"", model.FILE_SCAN_STATUS_CLEAN} {
+		if err := CheckFileCanBeServed(&model.FileInfo{ScanStatus: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, status := range []string{model.FILE_SCAN_STATUS_PENDING, model.FILE_SCAN_STATUS_QUARANTINED, model.FILE_SCAN_STATUS_FAILED} {
+		if err := CheckFileCanBeServed(&model.FileInfo{ScanStatus: status}); err == nil {
+			t.Fatal("shouldn't serve file with scan status " + status)
+		} else if err.StatusCode != http.StatusForbidden {
+			t.Fatal("wrong status code", err.StatusCode)
+		}
+	}
+}
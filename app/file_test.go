@@ -4,9 +4,17 @@
 package app
 
 import (
+	"bytes"
+	"errors"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"strings"
 	"testing"
 
 	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
 )
 
 func TestGeneratePublicLinkHash(t *testing.T) {
@@ -31,3 +39,119 @@ func TestGeneratePublicLinkHash(t *testing.T) {
 		t.Fatal("hashes for the same file with different salts should not be equal")
 	}
 }
+
+func TestGetPreviewGenerator(t *testing.T) {
+	if GetPreviewGenerator("application/pdf") == nil {
+		t.Fatal("should have a generator for pdfs")
+	}
+
+	if GetPreviewGenerator("video/mp4") == nil || GetPreviewGenerator("text/plain; charset=utf-8") == nil {
+		t.Fatal("should have fallen back to the generator for the top level type")
+	}
+
+	if GetPreviewGenerator("application/zip") != nil || GetPreviewGenerator("") != nil {
+		t.Fatal("shouldn't have a generator for other types")
+	}
+}
+
+func TestGenerateFilePreview(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	directory := utils.Cfg.FileSettings.Directory
+	pdfConverter := *utils.Cfg.FileSettings.PdfPreviewConverter
+	maxTextPreviewSize := *utils.Cfg.FileSettings.MaxTextPreviewSize
+	converter := runPreviewConverter
+	defer func() {
+		utils.Cfg.FileSettings.Directory = directory
+		*utils.Cfg.FileSettings.PdfPreviewConverter = pdfConverter
+		*utils.Cfg.FileSettings.MaxTextPreviewSize = maxTextPreviewSize
+		runPreviewConverter = converter
+	}()
+
+	dir, err := ioutil.TempDir("", "previews")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	utils.Cfg.FileSettings.Directory = dir + "/"
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 400, 300)))
+	rendered := buf.Bytes()
+
+	var ranCommand string
+	runPreviewConverter = func(data []byte, command string, args func(string) []string) ([]byte, error) {
+		ranCommand = command
+		if len(args("input")) == 0 {
+			return nil, errors.New("no arguments")
+		}
+		return rendered, nil
+	}
+
+	info := &model.FileInfo{Name: "test.pdf", MimeType: "application/pdf"}
+	*utils.Cfg.FileSettings.PdfPreviewConverter = ""
+	generateFilePreview(info, []byte("%PDF-1.4"), "test")
+	if info.ThumbnailPath != "" || info.PreviewPath != "" || ranCommand != "" {
+		t.Fatal("shouldn't have generated a preview without a converter")
+	}
+
+	*utils.Cfg.FileSettings.PdfPreviewConverter = "pdftoppm"
+	generateFilePreview(info, []byte("%PDF-1.4"), "test")
+	if ranCommand != "pdftoppm" || !info.HasPreviewImage || info.ThumbnailPath != "test_thumb.jpg" || info.PreviewPath != "test_preview.jpg" {
+		t.Fatal("should have rendered a preview of the pdf", info)
+	}
+
+	if data, err := ReadFile(info.ThumbnailPath); err != nil {
+		t.Fatal(err)
+	} else if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil || config.Height != utils.Cfg.FileSettings.ThumbnailHeight {
+		t.Fatal("should have saved the thumbnail", config.Height)
+	}
+
+	*utils.Cfg.FileSettings.MaxTextPreviewSize = 8
+	info = &model.FileInfo{Name: "notes"}
+	generateFilePreview(info, []byte("hello wörld, this is long"), "notes")
+	if info.PreviewPath != "notes_preview.txt" || info.ThumbnailPath != "" || info.HasPreviewImage {
+		t.Fatal("should have saved a text preview", info)
+	}
+
+	if data, err := ReadFile(info.PreviewPath); err != nil {
+		t.Fatal(err)
+	} else if string(data) != "hello w" {
+		t.Fatal("should have cut the text without splitting a character", string(data))
+	}
+
+	info = &model.FileInfo{Name: "binary.zip", MimeType: "application/zip"}
+	generateFilePreview(info, []byte("PK"), "binary")
+	if info.PreviewPath != "" {
+		t.Fatal("shouldn't have generated a preview for a zip file")
+	}
+
+	if preview, err := generateTextPreview([]byte{0xff, 0xfe, 0x00}); err == nil {
+		t.Fatal("shouldn't have previewed invalid text", preview)
+	}
+
+	if !strings.HasPrefix(GetPreviewContentType("notes_preview.txt"), "text/plain") || GetPreviewContentType("test_preview.jpg") != "image/jpeg" {
+		t.Fatal("should have returned the content type of the preview")
+	}
+}
+
+func TestDeleteUploadedFiles(t *testing.T) {
+	th := Setup().InitBasic()
+
+	info, err := DoUploadFile(th.BasicTeam.Id, th.BasicChannel.Id, th.BasicUser.Id, "file.txt", []byte("uploaded for a post that failed"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deleteUploadedFiles([]*model.FileInfo{info})
+
+	if _, err := GetFileInfo(info.Id); err == nil {
+		t.Fatal("should have deleted the file info")
+	}
+
+	if _, err := ReadFile(info.Path); err == nil {
+		t.Fatal("should have removed the stored file")
+	}
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/utils"
+)
+
+// Every outgoing request is made with one of the clients below so that the proxy, timeouts, TLS settings
+// and metrics are applied in one place. URLs supplied by users or integrations are fetched with
+// NewHTTPClient, which won't connect to internal addresses. Servers configured by an admin, such as the
+// push notification server, are reached with NewTrustedHTTPClient since they're often on the internal
+// network.
+
+const (
+	// URLs supplied by users could create any number of hosts, so their metrics are recorded under one label
+	OUTGOING_HTTP_UNTRUSTED_METRICS_HOST = "untrusted"
+)
+
+var reservedIPRanges []*net.IPNet
+
+func init() {
+	for _, cidr := range []string{
+		"0.0.0.0/8",      // "this" network
+		"10.0.0.0/8",     // private
+		"100.64.0.0/10",  // carrier-grade NAT
+		"127.0.0.0/8",    // loopback
+		"169.254.0.0/16", // link-local, including cloud metadata services
+		"172.16.0.0/12",  // private
+		"192.0.0.0/24",   // IETF protocol assignments
+		"192.168.0.0/16", // private
+		"198.18.0.0/15",  // benchmarking
+		"224.0.0.0/4",    // multicast
+		"240.0.0.0/4",    // reserved
+		"::/128",         // unspecified
+		"::1/128",        // loopback
+		"fc00::/7",       // unique local
+		"fe80::/10",      // link-local
+		"ff00::/8",       // multicast
+	} {
+		_, ipRange, _ := net.ParseCIDR(cidr)
+		reservedIPRanges = append(reservedIPRanges, ipRange)
+	}
+}
+
+// IsReservedIP returns true for loopback, private and other addresses that aren't reachable on the
+// public internet.
+func IsReservedIP(ip net.IP) bool {
+	for _, ipRange := range reservedIPRanges {
+		if ipRange.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// outgoingProxy returns the proxy set by OutgoingProxyURL, falling back to the one set in the environment.
+func outgoingProxy(req *http.Request) (*url.URL, error) {
+	if proxyURL := *utils.Cfg.ServiceSettings.OutgoingProxyURL; len(proxyURL) > 0 {
+		return url.Parse(proxyURL)
+	}
+
+	return http.ProxyFromEnvironment(req)
+}
+
+// isAllowedInternalConnection returns true if the host or address is listed in the space separated
+// hostnames, IP addresses and CIDR ranges of AllowedUntrustedInternalConnections or is the host of the
+// outgoing proxy.
+func isAllowedInternalConnection(host string, ip net.IP) bool {
+	allowed := strings.Fields(*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections)
+
+	for _, scheme := range []string{"http", "https"} {
+		if proxy, err := outgoingProxy(&http.Request{URL: &url.URL{Scheme: scheme, Host: "example.com"}}); err == nil && proxy != nil {
+			allowed = append(allowed, proxy.Hostname())
+		}
+	}
+
+	for _, entry := range allowed {
+		if strings.EqualFold(entry, host) {
+			return true
+		}
+
+		if ip == nil {
+			continue
+		}
+
+		if _, ipRange, err := net.ParseCIDR(entry); err == nil && ipRange.Contains(ip) {
+			return true
+		} else if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// resolveUntrustedHost returns the addresses of the host that may be connected to. It fails if the host
+// only resolves to internal addresses that aren't allowed.
+func resolveUntrustedHost(ctx context.Context, host string) ([]net.IP, error) {
+	if isAllowedInternalConnection(host, nil) {
+		return nil, nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+
+	ips := []net.IP{}
+	for _, addr := range addrs {
+		if !IsReservedIP(addr.IP) || isAllowedInternalConnection(host, addr.IP) {
+			ips = append(ips, addr.IP)
+		}
+	}
+
+	if len(ips) == 0 {
+		return nil, errors.New("connection to internal address of " + host + " is not allowed")
+	}
+
+	return ips, nil
+}
+
+// untrustedDialContext resolves the host itself so that every address that is connected to can be checked.
+// Checking the resolved address rather than the hostname prevents a hostname from being pointed at an
+// internal address after it has been checked.
+func untrustedDialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+
+		ips, err := resolveUntrustedHost(ctx, host)
+		if err != nil {
+			return nil, err
+		} else if ips == nil {
+			return dialer.DialContext(ctx, network, addr)
+		}
+
+		for _, ip := range ips {
+			var conn net.Conn
+			if conn, err = dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port)); err == nil {
+				return conn, nil
+			}
+		}
+
+		return nil, err
+	}
+}
+
+// outgoingTransport records metrics for each trusted destination and for all untrusted ones together. For
+// untrusted clients it also checks the destination of requests sent through a proxy since the proxy, rather
+// than the dialer, connects to it.
+type outgoingTransport struct {
+	transport       *http.Transport
+	enforceDenylist bool
+}
+
+func (t *outgoingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := OUTGOING_HTTP_UNTRUSTED_METRICS_HOST
+	if !t.enforceDenylist {
+		host = req.URL.Hostname()
+	}
+
+	start := time.Now()
+
+	metrics := einterfaces.GetMetricsInterface()
+	if metrics != nil {
+		metrics.IncrementOutgoingHttpRequest(host)
+	}
+
+	resp, err := t.roundTrip(req)
+
+	if metrics != nil {
+		if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+			metrics.IncrementOutgoingHttpError(host)
+		}
+		metrics.ObserveOutgoingHttpRequestDuration(host, time.Since(start).Seconds())
+	}
+
+	return resp, err
+}
+
+func (t *outgoingTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if t.enforceDenylist {
+		if proxy, err := t.transport.Proxy(req); err != nil {
+			return nil, err
+		} else if proxy != nil {
+			if _, err := resolveUntrustedHost(req.Context(), req.URL.Hostname()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return t.transport.RoundTrip(req)
+}
+
+func newHTTPClient(enforceDenylist bool) *http.Client {
+	connectTimeout := time.Duration(*utils.Cfg.ServiceSettings.OutgoingConnectTimeoutInSeconds) * time.Second
+
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}
+	dialContext := dialer.DialContext
+	if enforceDenylist {
+		dialContext = untrustedDialContext(dialer)
+	}
+
+	// Clients aren't reused, so keep-alives would only leave idle connections behind
+	transport := &http.Transport{
+		Proxy:               outgoingProxy,
+		DialContext:         dialContext,
+		TLSHandshakeTimeout: connectTimeout,
+		TLSClientConfig:     &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
+		DisableKeepAlives:   true,
+	}
+
+	return &http.Client{
+		Transport: &outgoingTransport{transport: transport, enforceDenylist: enforceDenylist},
+		Timeout:   time.Duration(*utils.Cfg.ServiceSettings.OutgoingRequestTimeoutInSeconds) * time.Second,
+	}
+}
+
+// NewHTTPClient returns a client for URLs supplied by users or integrations. It refuses to connect to
+// internal addresses unless they're allowed by AllowedUntrustedInternalConnections so that those URLs
+// can't be used to reach services behind the firewall.
+func NewHTTPClient() *http.Client {
+	return newHTTPClient(true)
+}
+
+// NewTrustedHTTPClient returns a client for servers configured by an admin, which may be internal.
+func NewTrustedHTTPClient() *http.Client {
+	return newHTTPClient(false)
+}
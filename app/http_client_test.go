@@ -0,0 +1,93 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/platform/utils"
+)
+
+func TestIsReservedIP(t *testing.T) {
+	for ip, reserved := range map[string]bool{
+		"127.0.0.1":       true,
+		"10.1.2.3":        true,
+		"172.16.0.1":      true,
+		"192.168.1.1":     true,
+		"169.254.169.254": true,
+		"0.0.0.0":         true,
+		"::1":             true,
+		"fd00::1":         true,
+		"8.8.8.8":         false,
+		"172.32.0.1":      false,
+		"2001:4860::8888": false,
+	} {
+		if IsReservedIP(net.ParseIP(ip)) != reserved {
+			t.Fatalf("%v should have reserved=%v", ip, reserved)
+		}
+	}
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+
+	allowed := *utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections
+	proxyURL := *utils.Cfg.ServiceSettings.OutgoingProxyURL
+	defer func() {
+		*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = allowed
+		*utils.Cfg.ServiceSettings.OutgoingProxyURL = proxyURL
+	}()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = ""
+	if _, err := NewHTTPClient().Get(ts.URL); err == nil {
+		t.Fatal("should have refused to connect to a loopback address")
+	}
+
+	if res, err := NewTrustedHTTPClient().Get(ts.URL); err != nil {
+		t.Fatal("trusted clients should connect to internal addresses", err)
+	} else {
+		CloseBody(res)
+	}
+
+	for _, entry := range []string{"127.0.0.1", "127.0.0.0/8", "example.com 127.0.0.1"} {
+		*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = entry
+		if res, err := NewHTTPClient().Get(ts.URL); err != nil {
+			t.Fatalf("should have allowed the connection with %v: %v", entry, err)
+		} else {
+			CloseBody(res)
+		}
+	}
+
+	proxyRequests := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxyRequests++
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = ""
+	*utils.Cfg.ServiceSettings.OutgoingProxyURL = proxy.URL
+
+	if _, err := NewHTTPClient().Get("http://10.1.2.3/"); err == nil || proxyRequests != 0 {
+		t.Fatal("should have refused to send a request for an internal address through the proxy")
+	}
+
+	if res, err := NewTrustedHTTPClient().Get("http://10.1.2.3/"); err != nil {
+		t.Fatal(err)
+	} else {
+		CloseBody(res)
+		if proxyRequests != 1 {
+			t.Fatal("should have sent the request through the proxy")
+		}
+	}
+}
@@ -0,0 +1,292 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// External images in posts are loaded through the image proxy so that clients never contact the servers
+// hosting them, which would reveal their IP addresses and cause mixed content warnings on HTTPS sites.
+// Image URLs are rewritten to signed proxy URLs as posts are sent to clients so that the proxy can't be
+// used to fetch arbitrary URLs. Clients send those URLs back when editing a post, so they're rewritten to
+// the original URLs again before posts are saved.
+
+const (
+	IMAGE_PROXY_CACHE_SIZE          = 200
+	IMAGE_PROXY_CACHE_SECONDS       = 60 * 60
+	IMAGE_PROXY_CACHE_MAX_ITEM_SIZE = 1024 * 1024      // 1MB
+	IMAGE_PROXY_MAX_RESIZE_PIXELS   = 24 * 1000 * 1000 // 24 megapixels
+)
+
+var imageProxyCache = utils.NewLru(IMAGE_PROXY_CACHE_SIZE)
+
+// Image types that browsers display inline. SVG is deliberately left out since it can contain scripts.
+var imageProxyContentTypes = map[string]bool{
+	"image/png":  true,
+	"image/jpeg": true,
+	"image/gif":  true,
+	"image/bmp":  true,
+	"image/webp": true,
+}
+
+var markdownImageRegex = regexp.MustCompile(`(!\[[^\]]*\]\()([^\s)]+)`)
+var markdownCodeRegex = regexp.MustCompile("(?s)```.*?```|`[^`\n]*`")
+
+type proxiedImage struct {
+	data        []byte
+	contentType string
+}
+
+func IsImageProxyEnabled() bool {
+	return *utils.Cfg.ImageProxySettings.Enable
+}
+
+// GetImageProxySignature returns the signature that a proxy URL must carry for the image to be fetched.
+func GetImageProxySignature(imageURL string) string {
+	mac := hmac.New(sha256.New, []byte(*utils.Cfg.ImageProxySettings.SigningKey))
+	mac.Write([]byte(imageURL))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func IsValidImageProxySignature(imageURL, signature string) bool {
+	return hmac.Equal([]byte(GetImageProxySignature(imageURL)), []byte(signature))
+}
+
+// GetProxiedImageURL returns the URL that an external image should be loaded from. Images on this server
+// and URLs that aren't http or https are returned unchanged.
+func GetProxiedImageURL(siteURL, imageURL string) string {
+	if !IsImageProxyEnabled() || len(imageURL) == 0 {
+		return imageURL
+	}
+
+	if len(siteURL) > 0 && (imageURL == siteURL || strings.HasPrefix(imageURL, siteURL+"/")) {
+		return imageURL
+	}
+
+	parsed, err := url.Parse(imageURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return imageURL
+	}
+
+	return siteURL + model.API_URL_SUFFIX + "/image?url=" + url.QueryEscape(imageURL) + "&sig=" + GetImageProxySignature(imageURL)
+}
+
+// GetUnproxiedImageURL returns the original URL of an image that was rewritten to be loaded through the proxy.
+// The site URL that the proxy URL was made with depends on the request, so any host is accepted, and the
+// signature isn't checked so that URLs signed with an old key are still recognised.
+func GetUnproxiedImageURL(imageURL string) string {
+	if !strings.Contains(imageURL, model.API_URL_SUFFIX+"/image?") {
+		return imageURL
+	}
+
+	parsed, err := url.Parse(imageURL)
+	if err != nil || !strings.HasSuffix(parsed.Path, model.API_URL_SUFFIX+"/image") {
+		return imageURL
+	}
+
+	query := parsed.Query()
+	if query.Get("url") == "" || query.Get("sig") == "" {
+		return imageURL
+	}
+
+	return query.Get("url")
+}
+
+// PostWithProxyAddedToImageURLs returns a copy of the post with the URLs of the images in its message and
+// attachments pointing at the image proxy. The post itself is left unchanged since it may be cached.
+func PostWithProxyAddedToImageURLs(post *model.Post, siteURL string) *model.Post {
+	if !IsImageProxyEnabled() || post == nil {
+		return post
+	}
+
+	return postWithImageURLsRewritten(post, func(imageURL string) string {
+		return GetProxiedImageURL(siteURL, imageURL)
+	})
+}
+
+// PostWithProxyRemovedFromImageURLs returns a copy of the post with any image URLs that point at the image
+// proxy changed back to the original URLs. This is done even when the proxy is disabled since clients may
+// still have posts that were loaded while it was enabled.
+func PostWithProxyRemovedFromImageURLs(post *model.Post) *model.Post {
+	if post == nil {
+		return post
+	}
+
+	return postWithImageURLsRewritten(post, GetUnproxiedImageURL)
+}
+
+func postWithImageURLsRewritten(post *model.Post, rewrite func(string) string) *model.Post {
+	rewritten := *post
+	rewritten.Message = rewriteMarkdownImageURLs(post.Message, rewrite)
+
+	attachments := post.Attachments()
+	if len(attachments) == 0 {
+		return &rewritten
+	}
+
+	changed := false
+	rewrittenAttachments := make([]*model.SlackAttachment, len(attachments))
+	for i, attachment := range attachments {
+		rewrittenAttachment := *attachment
+		rewrittenAttachment.AuthorIcon = rewrite(attachment.AuthorIcon)
+		rewrittenAttachment.ImageURL = rewrite(attachment.ImageURL)
+		rewrittenAttachment.ThumbURL = rewrite(attachment.ThumbURL)
+		rewrittenAttachment.FooterIcon = rewrite(attachment.FooterIcon)
+		rewrittenAttachments[i] = &rewrittenAttachment
+
+		changed = changed || rewrittenAttachment.AuthorIcon != attachment.AuthorIcon || rewrittenAttachment.ImageURL != attachment.ImageURL ||
+			rewrittenAttachment.ThumbURL != attachment.ThumbURL || rewrittenAttachment.FooterIcon != attachment.FooterIcon
+	}
+
+	// the attachments are only replaced when needed since decoding them drops any fields they don't define
+	if changed {
+		rewritten.Props = make(model.StringInterface, len(post.Props))
+		for key, value := range post.Props {
+			rewritten.Props[key] = value
+		}
+
+		rewritten.Props["attachments"] = rewrittenAttachments
+	}
+
+	return &rewritten
+}
+
+func PostListWithProxyAddedToImageURLs(list *model.PostList, siteURL string) *model.PostList {
+	if !IsImageProxyEnabled() || list == nil {
+		return list
+	}
+
+	proxied := &model.PostList{Order: list.Order, Posts: make(map[string]*model.Post, len(list.Posts))}
+	for id, post := range list.Posts {
+		proxied.Posts[id] = PostWithProxyAddedToImageURLs(post, siteURL)
+	}
+
+	return proxied
+}
+
+// rewriteMarkdownImageURLs rewrites the URLs of markdown images outside of code spans and blocks.
+func rewriteMarkdownImageURLs(message string, rewrite func(string) string) string {
+	if !strings.Contains(message, "![") {
+		return message
+	}
+
+	var buf bytes.Buffer
+	last := 0
+	for _, code := range markdownCodeRegex.FindAllStringIndex(message, -1) {
+		buf.WriteString(rewriteMarkdownImageURLsInText(message[last:code[0]], rewrite))
+		buf.WriteString(message[code[0]:code[1]])
+		last = code[1]
+	}
+	buf.WriteString(rewriteMarkdownImageURLsInText(message[last:], rewrite))
+
+	return buf.String()
+}
+
+func rewriteMarkdownImageURLsInText(text string, rewrite func(string) string) string {
+	return markdownImageRegex.ReplaceAllStringFunc(text, func(match string) string {
+		parts := markdownImageRegex.FindStringSubmatch(match)
+		return parts[1] + rewrite(parts[2])
+	})
+}
+
+// GetProxiedImage fetches an external image for the image proxy along with its content type. Images wider
+// than width are scaled down to it, and recently fetched images are served from memory.
+func GetProxiedImage(imageURL string, width int) ([]byte, string, *model.AppError) {
+	cacheKey := strconv.Itoa(width) + ":" + imageURL
+	if cached, ok := imageProxyCache.Get(cacheKey); ok {
+		img := cached.(*proxiedImage)
+		return img.data, img.contentType, nil
+	}
+
+	data, contentType, err := fetchProxiedImage(imageURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if width > 0 {
+		data, contentType = resizeProxiedImage(data, contentType, width)
+	}
+
+	if len(data) <= IMAGE_PROXY_CACHE_MAX_ITEM_SIZE {
+		imageProxyCache.AddWithExpiresInSecs(cacheKey, &proxiedImage{data: data, contentType: contentType}, IMAGE_PROXY_CACHE_SECONDS)
+	}
+
+	return data, contentType, nil
+}
+
+func fetchProxiedImage(imageURL string) ([]byte, string, *model.AppError) {
+	parsed, err := url.Parse(imageURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return nil, "", model.NewAppError("GetProxiedImage", "api.image_proxy.invalid_url.app_error", nil, "url="+imageURL, http.StatusBadRequest)
+	}
+
+	res, err := NewHTTPClient().Get(imageURL)
+	if err != nil {
+		return nil, "", model.NewAppError("GetProxiedImage", "api.image_proxy.fetch.app_error", nil, "url="+imageURL+", err="+err.Error(), http.StatusBadGateway)
+	}
+	defer CloseBody(res)
+
+	if res.StatusCode != http.StatusOK {
+		return nil, "", model.NewAppError("GetProxiedImage", "api.image_proxy.fetch.app_error", nil, "url="+imageURL+", status="+res.Status, http.StatusBadGateway)
+	}
+
+	maxSize := *utils.Cfg.ImageProxySettings.MaxImageSize
+	if res.ContentLength > maxSize {
+		return nil, "", model.NewAppError("GetProxiedImage", "api.image_proxy.too_large.app_error", nil, "url="+imageURL, http.StatusRequestEntityTooLarge)
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, maxSize+1))
+	if err != nil {
+		return nil, "", model.NewAppError("GetProxiedImage", "api.image_proxy.fetch.app_error", nil, "url="+imageURL+", err="+err.Error(), http.StatusBadGateway)
+	} else if int64(len(data)) > maxSize {
+		return nil, "", model.NewAppError("GetProxiedImage", "api.image_proxy.too_large.app_error", nil, "url="+imageURL, http.StatusRequestEntityTooLarge)
+	}
+
+	// The content type is sniffed rather than trusted from the response so that other files can't be
+	// served from this server by labelling them as images
+	contentType := http.DetectContentType(data)
+	if !imageProxyContentTypes[contentType] {
+		return nil, "", model.NewAppError("GetProxiedImage", "api.image_proxy.content_type.app_error", nil, "url="+imageURL+", content_type="+contentType, http.StatusUnsupportedMediaType)
+	}
+
+	return data, contentType, nil
+}
+
+// resizeProxiedImage scales an image down to the given width using the same pipeline as file previews. The
+// original is returned if it's already narrow enough, can't be decoded or is too large to decode safely.
+func resizeProxiedImage(data []byte, contentType string, width int) ([]byte, string) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || config.Width <= width || config.Width*config.Height > IMAGE_PROXY_MAX_RESIZE_PIXELS {
+		return data, contentType
+	}
+
+	img, _, _ := prepareImage(data)
+	if img == nil {
+		return data, contentType
+	}
+
+	buf := new(bytes.Buffer)
+	if err := jpeg.Encode(buf, imaging.Resize(*img, width, 0, imaging.Lanczos), &jpeg.Options{Quality: 90}); err != nil {
+		return data, contentType
+	}
+
+	return buf.Bytes(), "image/jpeg"
+}
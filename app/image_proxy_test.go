@@ -0,0 +1,182 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestPostWithProxyAddedToImageURLs(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+
+	enable := *utils.Cfg.ImageProxySettings.Enable
+	defer func() {
+		*utils.Cfg.ImageProxySettings.Enable = enable
+	}()
+
+	siteURL := "https://mattermost.example.com"
+	imageURL := "http://images.example.com/image.png"
+	proxiedURL := siteURL + "/api/v4/image?url=" + url.QueryEscape(imageURL) + "&sig=" + GetImageProxySignature(imageURL)
+
+	post := &model.Post{
+		Message: "![image](" + imageURL + ") ![local](" + siteURL + "/static/logo.png) `![code](" + imageURL + ")`",
+		Props: model.StringInterface{
+			"attachments": []*model.SlackAttachment{{ImageURL: imageURL, AuthorIcon: "data:image/png;base64,abc"}},
+		},
+	}
+
+	*utils.Cfg.ImageProxySettings.Enable = false
+	if proxied := PostWithProxyAddedToImageURLs(post, siteURL); proxied != post {
+		t.Fatal("shouldn't have changed the post when the proxy is disabled")
+	}
+
+	*utils.Cfg.ImageProxySettings.Enable = true
+	proxied := PostWithProxyAddedToImageURLs(post, siteURL)
+
+	expected := "![image](" + proxiedURL + ") ![local](" + siteURL + "/static/logo.png) `![code](" + imageURL + ")`"
+	if proxied.Message != expected {
+		t.Fatal("should have only rewritten external images outside of code", proxied.Message)
+	}
+
+	if attachment := proxied.Attachments()[0]; attachment.ImageURL != proxiedURL {
+		t.Fatal("should have rewritten the attachment image", attachment.ImageURL)
+	} else if attachment.AuthorIcon != "data:image/png;base64,abc" {
+		t.Fatal("shouldn't have rewritten a data url", attachment.AuthorIcon)
+	}
+
+	if post.Attachments()[0].ImageURL != imageURL || !strings.Contains(post.Message, "![image]("+imageURL) {
+		t.Fatal("shouldn't have modified the original post")
+	}
+
+	list := &model.PostList{Order: []string{"a"}, Posts: map[string]*model.Post{"a": post}}
+	if proxiedList := PostListWithProxyAddedToImageURLs(list, siteURL); proxiedList.Posts["a"].Message != expected {
+		t.Fatal("should have rewritten the posts in the list")
+	}
+
+	if !IsValidImageProxySignature(imageURL, GetImageProxySignature(imageURL)) || IsValidImageProxySignature(imageURL+"x", GetImageProxySignature(imageURL)) {
+		t.Fatal("should only accept the signature of the same url")
+	}
+}
+
+func TestPostWithProxyRemovedFromImageURLs(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+
+	enable := *utils.Cfg.ImageProxySettings.Enable
+	defer func() {
+		*utils.Cfg.ImageProxySettings.Enable = enable
+	}()
+	*utils.Cfg.ImageProxySettings.Enable = true
+
+	siteURL := "https://mattermost.example.com"
+	imageURL := "http://images.example.com/image.png?size=large"
+
+	post := &model.Post{
+		Message: "![image](" + imageURL + ") ![link](http://example.com/api/v4/image?url=abc)",
+		Props: model.StringInterface{
+			"attachments": []*model.SlackAttachment{{ImageURL: imageURL, ThumbURL: imageURL}},
+		},
+	}
+
+	proxied := PostWithProxyAddedToImageURLs(post, siteURL)
+
+	// clients will still send back posts with proxied urls after the proxy has been disabled
+	*utils.Cfg.ImageProxySettings.Enable = false
+
+	unproxied := PostWithProxyRemovedFromImageURLs(proxied)
+	if unproxied.Message != post.Message {
+		t.Fatal("should have restored the original urls", unproxied.Message)
+	}
+
+	if attachment := unproxied.Attachments()[0]; attachment.ImageURL != imageURL || attachment.ThumbURL != imageURL {
+		t.Fatal("should have restored the attachment urls", attachment.ImageURL, attachment.ThumbURL)
+	}
+
+	if proxied.Attachments()[0].ImageURL == imageURL {
+		t.Fatal("shouldn't have modified the proxied post")
+	}
+
+	// attachments are left alone when there's nothing to change so that fields they don't define are kept
+	raw := &model.Post{Props: model.StringInterface{"attachments": []interface{}{map[string]interface{}{"image_url": imageURL, "custom": "value"}}}}
+	if unproxied := PostWithProxyRemovedFromImageURLs(raw); unproxied.Props["attachments"].([]interface{})[0].(map[string]interface{})["custom"] != "value" {
+		t.Fatal("shouldn't have replaced the attachments")
+	}
+
+	if original := GetUnproxiedImageURL("http://other.example.com/api/v4/image?url=" + url.QueryEscape(imageURL) + "&sig=old"); original != imageURL {
+		t.Fatal("should have accepted a url from another site url signed with another key", original)
+	}
+}
+
+func TestGetProxiedImage(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	allowed := *utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections
+	maxImageSize := *utils.Cfg.ImageProxySettings.MaxImageSize
+	defer func() {
+		*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = allowed
+		*utils.Cfg.ImageProxySettings.MaxImageSize = maxImageSize
+	}()
+	*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.1"
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 400, 300)))
+	imageData := buf.Bytes()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/image.png", "/other.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(imageData)
+		case "/image.svg":
+			w.Header().Set("Content-Type", "image/svg+xml")
+			w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`))
+		case "/disguised.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("<html><body>not an image</body></html>"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	if data, contentType, err := GetProxiedImage(ts.URL+"/image.png", 0); err != nil {
+		t.Fatal(err)
+	} else if contentType != "image/png" || !bytes.Equal(data, imageData) {
+		t.Fatal("should have returned the original image", contentType)
+	}
+
+	if data, contentType, err := GetProxiedImage(ts.URL+"/image.png", 100); err != nil {
+		t.Fatal(err)
+	} else if config, _, err := image.DecodeConfig(bytes.NewReader(data)); err != nil || contentType != "image/jpeg" || config.Width != 100 || config.Height != 75 {
+		t.Fatal("should have scaled the image down", contentType, config.Width, config.Height)
+	}
+
+	for _, path := range []string{"/image.svg", "/disguised.png", "/missing.png"} {
+		if _, _, err := GetProxiedImage(ts.URL+path, 0); err == nil {
+			t.Fatal("should have refused to proxy " + path)
+		}
+	}
+
+	*utils.Cfg.ImageProxySettings.MaxImageSize = int64(len(imageData) - 1)
+	if _, _, err := GetProxiedImage(ts.URL+"/other.png", 0); err == nil || err.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatal("should have refused an image over the maximum size")
+	}
+
+	if _, _, err := GetProxiedImage("file:///etc/passwd", 0); err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should only proxy http urls")
+	}
+}
@@ -893,10 +893,12 @@ func OldImportFile(file io.Reader, teamId string, channelId string, userId strin
 		return nil, err
 	}
 
-	img, width, height := prepareImage(data)
-	if img != nil {
-		generateThumbnailImage(*img, fileInfo.ThumbnailPath, width, height)
-		generatePreviewImage(*img, fileInfo.PreviewPath, width)
+	if fileInfo.PreviewPath != "" {
+		img, width, height := prepareImage(data)
+		if img != nil {
+			generateThumbnailImage(*img, fileInfo.ThumbnailPath, width, height)
+			generatePreviewImage(*img, fileInfo.PreviewPath, width)
+		}
 	}
 
 	return fileInfo, nil
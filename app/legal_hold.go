@@ -0,0 +1,296 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"archive/zip"
+	"io"
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	LEGAL_HOLD_EXPORT_BATCH_SIZE  = 1000
+	LEGAL_HOLD_RELEASE_BATCH_SIZE = 100
+)
+
+func CreateLegalHold(hold *model.LegalHold) (*model.LegalHold, *model.AppError) {
+	if result := <-Srv.Store.LegalHold().Save(hold); result.Err != nil {
+		result.Err.StatusCode = http.StatusBadRequest
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.LegalHold), nil
+	}
+}
+
+func GetLegalHold(holdId string) (*model.LegalHold, *model.AppError) {
+	if result := <-Srv.Store.LegalHold().Get(holdId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.LegalHold), nil
+	}
+}
+
+func GetLegalHolds(page, perPage int) ([]*model.LegalHold, *model.AppError) {
+	if result := <-Srv.Store.LegalHold().GetAll(page*perPage, perPage); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.LegalHold), nil
+	}
+}
+
+// ReleaseLegalHold ends a hold so that the content it covered can be deleted again. Content that was deleted
+// by users while it was held stays hidden from them, and the stored data of deleted files is removed unless
+// another hold still covers them.
+func ReleaseLegalHold(holdId string) (*model.LegalHold, *model.AppError) {
+	hold, err := GetLegalHold(holdId)
+	if err != nil {
+		return nil, err
+	}
+
+	if !hold.IsActive() {
+		return nil, model.NewAppError("ReleaseLegalHold", "api.legal_hold.release.released.app_error", nil, "id="+holdId, http.StatusBadRequest)
+	}
+
+	hold.ReleaseAt = model.GetMillis()
+
+	if result := <-Srv.Store.LegalHold().Update(hold); result.Err != nil {
+		return nil, result.Err
+	} else {
+		hold = result.Data.(*model.LegalHold)
+	}
+
+	go releaseLegalHoldFileBlobs(hold)
+
+	return hold, nil
+}
+
+// releaseLegalHoldFileBlobs releases the references to stored data that deleted files kept while they were
+// covered by a hold that has been released.
+func releaseLegalHoldFileBlobs(hold *model.LegalHold) {
+	activeHolds, err := getActiveLegalHolds()
+	if err != nil {
+		l4g.Error(utils.T("api.legal_hold.release_file_blobs.error"), hold.Id, err.Error())
+		return
+	}
+
+	for {
+		var infos []*model.FileInfo
+		if result := <-Srv.Store.LegalHold().GetUnreleasedFileInfos(hold, activeHolds, LEGAL_HOLD_RELEASE_BATCH_SIZE); result.Err != nil {
+			l4g.Error(utils.T("api.legal_hold.release_file_blobs.error"), hold.Id, result.Err.Error())
+			return
+		} else {
+			infos = result.Data.([]*model.FileInfo)
+		}
+
+		for _, info := range infos {
+			if result := <-Srv.Store.FileInfo().MarkBlobReleased(info.Id); result.Err != nil {
+				// Stop rather than fetching the same files again forever
+				l4g.Error(utils.T("api.legal_hold.release_file_blobs.error"), hold.Id, result.Err.Error())
+				return
+			} else if result.Data.(bool) {
+				releaseFileBlob(info)
+			}
+		}
+
+		if len(infos) < LEGAL_HOLD_RELEASE_BATCH_SIZE {
+			return
+		}
+	}
+}
+
+func getActiveLegalHolds() ([]*model.LegalHold, *model.AppError) {
+	if result := <-Srv.Store.LegalHold().GetActive(); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.LegalHold), nil
+	}
+}
+
+// IsUnderLegalHold returns true if content created by the user in the channel at the given time is covered by
+// an active hold and mustn't be destroyed. If the holds can't be loaded, the content is treated as held.
+func IsUnderLegalHold(userId, channelId string, createAt int64) bool {
+	holds, err := getActiveLegalHolds()
+	if err != nil {
+		l4g.Error(utils.T("api.legal_hold.get_active.error"), err.Error())
+		return true
+	}
+
+	for _, hold := range holds {
+		if hold.Covers(userId, channelId, createAt) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckUserNotUnderLegalHold returns an error if any of the user's content is covered by an active hold, either
+// because they're named by it or because they posted in one of its channels.
+func CheckUserNotUnderLegalHold(userId string) *model.AppError {
+	holds, err := getActiveLegalHolds()
+	if err != nil {
+		return err
+	}
+
+	for _, hold := range holds {
+		if hold.HasUser(userId) {
+			return model.NewAppError("CheckUserNotUnderLegalHold", "api.legal_hold.user_held.app_error", nil, "user_id="+userId+", legal_hold_id="+hold.Id, http.StatusForbidden)
+		}
+
+		if len(hold.ChannelIds) == 0 {
+			continue
+		}
+
+		if result := <-Srv.Store.LegalHold().HasPosts(hold, userId, ""); result.Err != nil {
+			return result.Err
+		} else if result.Data.(bool) {
+			return model.NewAppError("CheckUserNotUnderLegalHold", "api.legal_hold.user_held.app_error", nil, "user_id="+userId+", legal_hold_id="+hold.Id, http.StatusForbidden)
+		}
+	}
+
+	return nil
+}
+
+// CheckChannelNotUnderLegalHold returns an error if any of the channel's content is covered by an active hold,
+// either because it's named by it or because one of its users posted in it.
+func CheckChannelNotUnderLegalHold(channelId string) *model.AppError {
+	holds, err := getActiveLegalHolds()
+	if err != nil {
+		return err
+	}
+
+	for _, hold := range holds {
+		if hold.HasChannel(channelId) {
+			return model.NewAppError("CheckChannelNotUnderLegalHold", "api.legal_hold.channel_held.app_error", nil, "channel_id="+channelId+", legal_hold_id="+hold.Id, http.StatusForbidden)
+		}
+
+		if len(hold.UserIds) == 0 {
+			continue
+		}
+
+		if result := <-Srv.Store.LegalHold().HasPosts(hold, "", channelId); result.Err != nil {
+			return result.Err
+		} else if result.Data.(bool) {
+			return model.NewAppError("CheckChannelNotUnderLegalHold", "api.legal_hold.channel_held.app_error", nil, "channel_id="+channelId+", legal_hold_id="+hold.Id, http.StatusForbidden)
+		}
+	}
+
+	return nil
+}
+
+// ExportLegalHold writes a zip file containing the hold along with all of the posts, files and reactions that
+// it covers. Posts, files and reactions are written as one JSON object per line, and the contents of each file
+// are included under files/.
+func ExportLegalHold(hold *model.LegalHold, w io.Writer) *model.AppError {
+	archive := zip.NewWriter(w)
+
+	if err := writeLegalHoldExportFile(archive, "legal_hold.json", []byte(hold.ToJson())); err != nil {
+		return err
+	}
+
+	posts, err := archive.Create("posts.jsonl")
+	if err != nil {
+		return model.NewAppError("ExportLegalHold", "api.legal_hold.export.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	for offset := 0; ; offset += LEGAL_HOLD_EXPORT_BATCH_SIZE {
+		result := <-Srv.Store.LegalHold().GetPosts(hold, offset, LEGAL_HOLD_EXPORT_BATCH_SIZE)
+		if result.Err != nil {
+			return result.Err
+		}
+
+		batch := result.Data.([]*model.Post)
+		for _, post := range batch {
+			if _, err := io.WriteString(posts, post.ToJson()+"\n"); err != nil {
+				return model.NewAppError("ExportLegalHold", "api.legal_hold.export.app_error", nil, err.Error(), http.StatusInternalServerError)
+			}
+		}
+
+		if len(batch) < LEGAL_HOLD_EXPORT_BATCH_SIZE {
+			break
+		}
+	}
+
+	reactions, err := archive.Create("reactions.jsonl")
+	if err != nil {
+		return model.NewAppError("ExportLegalHold", "api.legal_hold.export.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	for offset := 0; ; offset += LEGAL_HOLD_EXPORT_BATCH_SIZE {
+		result := <-Srv.Store.LegalHold().GetReactions(hold, offset, LEGAL_HOLD_EXPORT_BATCH_SIZE)
+		if result.Err != nil {
+			return result.Err
+		}
+
+		batch := result.Data.([]*model.Reaction)
+		for _, reaction := range batch {
+			if _, err := io.WriteString(reactions, reaction.ToJson()+"\n"); err != nil {
+				return model.NewAppError("ExportLegalHold", "api.legal_hold.export.app_error", nil, err.Error(), http.StatusInternalServerError)
+			}
+		}
+
+		if len(batch) < LEGAL_HOLD_EXPORT_BATCH_SIZE {
+			break
+		}
+	}
+
+	// File contents are written after the list of files since a zip file can only be written one file at a time
+	var infos []*model.FileInfo
+	for offset := 0; ; offset += LEGAL_HOLD_EXPORT_BATCH_SIZE {
+		result := <-Srv.Store.LegalHold().GetFileInfos(hold, offset, LEGAL_HOLD_EXPORT_BATCH_SIZE)
+		if result.Err != nil {
+			return result.Err
+		}
+
+		batch := result.Data.([]*model.FileInfo)
+		infos = append(infos, batch...)
+
+		if len(batch) < LEGAL_HOLD_EXPORT_BATCH_SIZE {
+			break
+		}
+	}
+
+	files, err := archive.Create("files.jsonl")
+	if err != nil {
+		return model.NewAppError("ExportLegalHold", "api.legal_hold.export.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	for _, info := range infos {
+		if _, err := io.WriteString(files, info.ToJson()+"\n"); err != nil {
+			return model.NewAppError("ExportLegalHold", "api.legal_hold.export.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+	}
+
+	for _, info := range infos {
+		data, err := ReadFile(info.Path)
+		if err != nil {
+			l4g.Warn(utils.T("api.legal_hold.export.read_file.warn"), info.Id, err.Error())
+			continue
+		}
+
+		if err := writeLegalHoldExportFile(archive, "files/"+info.Id+"/"+info.Name, data); err != nil {
+			return err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return model.NewAppError("ExportLegalHold", "api.legal_hold.export.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
+
+func writeLegalHoldExportFile(archive *zip.Writer, name string, data []byte) *model.AppError {
+	if file, err := archive.Create(name); err != nil {
+		return model.NewAppError("ExportLegalHold", "api.legal_hold.export.app_error", nil, err.Error(), http.StatusInternalServerError)
+	} else if _, err := file.Write(data); err != nil {
+		return model.NewAppError("ExportLegalHold", "api.legal_hold.export.app_error", nil, err.Error(), http.StatusInternalServerError)
+	}
+
+	return nil
+}
@@ -0,0 +1,320 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/dyatlov/go-opengraph/opengraph"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	LINK_METADATA_MAX_RESPONSE_SIZE = 1024 * 1024 // 1MB
+	LINK_METADATA_OEMBED_TYPE       = "application/json+oembed"
+
+	LINK_METADATA_DESCRIPTION_MAX_RUNES = 300
+)
+
+// oEmbedResponse holds the fields of an oEmbed response that are used in link previews.
+type oEmbedResponse struct {
+	Type            string `json:"type"`
+	Title           string `json:"title"`
+	ProviderName    string `json:"provider_name"`
+	URL             string `json:"url"`
+	Width           uint64 `json:"width"`
+	Height          uint64 `json:"height"`
+	ThumbnailURL    string `json:"thumbnail_url"`
+	ThumbnailWidth  uint64 `json:"thumbnail_width"`
+	ThumbnailHeight uint64 `json:"thumbnail_height"`
+}
+
+// GetOpenGraphMetadata returns the preview for a link. Previews are kept in the store for
+// LinkPreviewCacheInMinutes so that popular links aren't fetched each time they're viewed.
+func GetOpenGraphMetadata(requestURL string) *opengraph.OpenGraph {
+	ttl := int64(*utils.Cfg.ServiceSettings.LinkPreviewCacheInMinutes) * 60 * 1000
+
+	if result := <-Srv.Store.LinkMetadata().Get(requestURL); result.Err == nil {
+		if metadata := result.Data.(*model.LinkMetadata); !metadata.IsExpired(ttl) {
+			return linkMetadataToOpenGraph(metadata)
+		}
+	}
+
+	og, metadataType := fetchOpenGraphMetadata(requestURL)
+
+	metadata := &model.LinkMetadata{URL: requestURL, Type: metadataType}
+	if metadataType != model.LINK_METADATA_TYPE_NONE {
+		if data, ok := getLinkMetadataData(og); ok {
+			metadata.Data = data
+		} else {
+			l4g.Warn(utils.T("api.post.link_metadata.too_large.warn"), requestURL)
+			return og
+		}
+	}
+
+	if result := <-Srv.Store.LinkMetadata().Save(metadata); result.Err != nil {
+		l4g.Warn(utils.T("api.post.link_metadata.save.warn"), requestURL, result.Err.Error())
+	}
+
+	return og
+}
+
+// getLinkMetadataData returns the preview as JSON that fits in the store. Optional fields are dropped or
+// shortened one at a time until it fits, working on a copy so the caller's preview is left complete. It returns
+// false if even the trimmed preview is too large.
+func getLinkMetadataData(og *opengraph.OpenGraph) (string, bool) {
+	trimmed := *og
+
+	steps := []func(){
+		func() {
+			trimmed.LocalesAlternate = nil
+			trimmed.Audios = nil
+			trimmed.Videos = nil
+		},
+		func() {
+			trimmed.Article = nil
+			trimmed.Book = nil
+			trimmed.Profile = nil
+		},
+		func() {
+			if len(trimmed.Images) > 1 {
+				trimmed.Images = trimmed.Images[:1]
+			}
+		},
+		func() {
+			if description := []rune(trimmed.Description); len(description) > LINK_METADATA_DESCRIPTION_MAX_RUNES {
+				trimmed.Description = string(description[:LINK_METADATA_DESCRIPTION_MAX_RUNES])
+			}
+		},
+		func() {
+			trimmed.Description = ""
+			trimmed.Images = nil
+		},
+	}
+
+	for i := 0; ; i++ {
+		if data, err := json.Marshal(&trimmed); err != nil {
+			return "", false
+		} else if utf8.RuneCount(data) <= model.LINK_METADATA_DATA_MAX_LENGTH {
+			return string(data), true
+		}
+
+		if i == len(steps) {
+			return "", false
+		}
+
+		steps[i]()
+	}
+}
+
+func linkMetadataToOpenGraph(metadata *model.LinkMetadata) *opengraph.OpenGraph {
+	og := opengraph.NewOpenGraph()
+
+	if metadata.Type != model.LINK_METADATA_TYPE_NONE {
+		if err := json.Unmarshal([]byte(metadata.Data), og); err != nil {
+			return opengraph.NewOpenGraph()
+		}
+	}
+
+	return og
+}
+
+// fetchOpenGraphMetadata builds the preview for a link along with the type of metadata that was
+// found. Pages are described by their OpenGraph tags and any oEmbed endpoint they link to while
+// images are described by their dimensions.
+func fetchOpenGraphMetadata(requestURL string) (*opengraph.OpenGraph, string) {
+	og := opengraph.NewOpenGraph()
+
+	pageURL, err := url.Parse(requestURL)
+	if err != nil || (pageURL.Scheme != "http" && pageURL.Scheme != "https") {
+		return og, model.LINK_METADATA_TYPE_NONE
+	}
+
+	res, err := getLinkMetadataResponse(requestURL)
+	if err != nil {
+		l4g.Warn(utils.T("api.post.link_metadata.fetch.warn"), requestURL, err.Error())
+		return og, model.LINK_METADATA_TYPE_NONE
+	}
+	defer CloseBody(res)
+
+	body := io.LimitReader(res.Body, LINK_METADATA_MAX_RESPONSE_SIZE)
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+
+	if strings.HasPrefix(mediaType, "image/") {
+		img := &opengraph.Image{URL: requestURL, Type: mediaType}
+		if config, _, err := image.DecodeConfig(body); err == nil {
+			img.Width = uint64(config.Width)
+			img.Height = uint64(config.Height)
+		}
+
+		og.URL = requestURL
+		og.Images = []*opengraph.Image{img}
+
+		return og, model.LINK_METADATA_TYPE_IMAGE
+	}
+
+	if len(mediaType) > 0 && mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return og, model.LINK_METADATA_TYPE_NONE
+	}
+
+	page, err := ioutil.ReadAll(body)
+	if err != nil {
+		l4g.Warn(utils.T("api.post.link_metadata.fetch.warn"), requestURL, err.Error())
+		return og, model.LINK_METADATA_TYPE_NONE
+	}
+
+	if err := og.ProcessHTML(bytes.NewReader(page)); err != nil {
+		l4g.Warn(utils.T("api.post.link_metadata.fetch.warn"), requestURL, err.Error())
+	}
+
+	if oEmbedURL := findOEmbedURL(page); len(oEmbedURL) > 0 {
+		applyOEmbed(og, resolveLinkMetadataURL(pageURL, oEmbedURL))
+	}
+
+	for _, img := range og.Images {
+		img.URL = resolveLinkMetadataURL(pageURL, img.URL)
+	}
+
+	if len(og.Images) > 0 && (og.Images[0].Width == 0 || og.Images[0].Height == 0) {
+		if width, height, ok := getImageDimensions(og.Images[0].URL); ok {
+			og.Images[0].Width = width
+			og.Images[0].Height = height
+		}
+	}
+
+	if len(og.Title) == 0 && len(og.Description) == 0 && len(og.Images) == 0 {
+		return og, model.LINK_METADATA_TYPE_NONE
+	}
+
+	return og, model.LINK_METADATA_TYPE_OPENGRAPH
+}
+
+func getLinkMetadataResponse(requestURL string) (*http.Response, error) {
+	client := NewHTTPClient()
+
+	res, err := client.Get(requestURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.StatusCode != http.StatusOK {
+		CloseBody(res)
+		return nil, errors.New("unexpected status " + res.Status)
+	}
+
+	return res, nil
+}
+
+// findOEmbedURL returns the oEmbed endpoint a page advertises in its head or an empty string if
+// it doesn't have one.
+func findOEmbedURL(page []byte) string {
+	z := html.NewTokenizer(bytes.NewReader(page))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if atom.Lookup(name) == atom.Body {
+				return ""
+			}
+			if atom.Lookup(name) != atom.Link || !hasAttr {
+				continue
+			}
+
+			attrs := make(map[string]string)
+			var key, val []byte
+			for hasAttr {
+				key, val, hasAttr = z.TagAttr()
+				attrs[string(key)] = string(val)
+			}
+
+			if attrs["type"] == LINK_METADATA_OEMBED_TYPE && len(attrs["href"]) > 0 {
+				return attrs["href"]
+			}
+		}
+	}
+}
+
+// applyOEmbed fills in the parts of the preview that the page's OpenGraph tags didn't provide using
+// its oEmbed endpoint.
+func applyOEmbed(og *opengraph.OpenGraph, oEmbedURL string) {
+	res, err := getLinkMetadataResponse(oEmbedURL)
+	if err != nil {
+		l4g.Warn(utils.T("api.post.link_metadata.fetch.warn"), oEmbedURL, err.Error())
+		return
+	}
+	defer CloseBody(res)
+
+	var oEmbed oEmbedResponse
+	if err := json.NewDecoder(io.LimitReader(res.Body, LINK_METADATA_MAX_RESPONSE_SIZE)).Decode(&oEmbed); err != nil {
+		l4g.Warn(utils.T("api.post.link_metadata.fetch.warn"), oEmbedURL, err.Error())
+		return
+	}
+
+	if len(og.Type) == 0 {
+		og.Type = oEmbed.Type
+	}
+
+	if len(og.Title) == 0 {
+		og.Title = oEmbed.Title
+	}
+
+	if len(og.SiteName) == 0 {
+		og.SiteName = oEmbed.ProviderName
+	}
+
+	if len(og.Images) == 0 {
+		if oEmbed.Type == "photo" && len(oEmbed.URL) > 0 {
+			og.Images = append(og.Images, &opengraph.Image{URL: oEmbed.URL, Width: oEmbed.Width, Height: oEmbed.Height})
+		} else if len(oEmbed.ThumbnailURL) > 0 {
+			og.Images = append(og.Images, &opengraph.Image{URL: oEmbed.ThumbnailURL, Width: oEmbed.ThumbnailWidth, Height: oEmbed.ThumbnailHeight})
+		}
+	}
+}
+
+// getImageDimensions reads just enough of an image to find its width and height.
+func getImageDimensions(imageURL string) (uint64, uint64, bool) {
+	res, err := getLinkMetadataResponse(imageURL)
+	if err != nil {
+		return 0, 0, false
+	}
+	defer CloseBody(res)
+
+	config, _, err := image.DecodeConfig(io.LimitReader(res.Body, LINK_METADATA_MAX_RESPONSE_SIZE))
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return uint64(config.Width), uint64(config.Height), true
+}
+
+func resolveLinkMetadataURL(base *url.URL, ref string) string {
+	if refURL, err := url.Parse(ref); err == nil {
+		return base.ResolveReference(refURL).String()
+	}
+
+	return ref
+}
+
+func CleanupLinkMetadata() {
+	expireTime := model.GetMillis() - int64(*utils.Cfg.ServiceSettings.LinkPreviewCacheInMinutes)*60*1000
+	if result := <-Srv.Store.LinkMetadata().Cleanup(expireTime); result.Err != nil {
+		l4g.Error(utils.T("api.post.link_metadata.cleanup.error"), result.Err.Error())
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dyatlov/go-opengraph/opengraph"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func TestFetchOpenGraphMetadata(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	allowed := *utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections
+	defer func() {
+		*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = allowed
+	}()
+	*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = "127.0.0.1"
+
+	var buf bytes.Buffer
+	png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 40, 30)))
+	imageData := buf.Bytes()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/og-data/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			fmt.Fprintln(w, `<html><head><meta property="og:title" content="Test Title" />
+				<meta property="og:image" content="/image.png" />
+				</head><body></body></html>`)
+		case "/oembed-page/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintln(w, `<html><head><link rel="alternate" type="application/json+oembed" href="/oembed.json" /></head><body></body></html>`)
+		case "/oembed.json":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintln(w, `{"type": "video", "title": "Embedded Title", "provider_name": "Provider", "thumbnail_url": "/image.png", "thumbnail_width": 400, "thumbnail_height": 300}`)
+		case "/no-og-data/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprintln(w, `<html><head></head><body></body></html>`)
+		case "/image.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(imageData)
+		case "/large/":
+			w.Header().Set("Content-Type", "text/html")
+			fmt.Fprint(w, "<html><head>"+strings.Repeat(" ", LINK_METADATA_MAX_RESPONSE_SIZE))
+			fmt.Fprint(w, `<meta property="og:title" content="Too Far" /></head></html>`)
+		case "/file.zip":
+			w.Header().Set("Content-Type", "application/zip")
+			w.Write([]byte("PK"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	og, metadataType := fetchOpenGraphMetadata(ts.URL + "/og-data/")
+	if metadataType != model.LINK_METADATA_TYPE_OPENGRAPH || og.Title != "Test Title" {
+		t.Fatal("should have read the opengraph tags", metadataType, og.Title)
+	} else if len(og.Images) != 1 || og.Images[0].URL != ts.URL+"/image.png" {
+		t.Fatal("should have resolved the image url", og.Images)
+	} else if og.Images[0].Width != 40 || og.Images[0].Height != 30 {
+		t.Fatal("should have found the image dimensions", og.Images[0].Width, og.Images[0].Height)
+	}
+
+	og, metadataType = fetchOpenGraphMetadata(ts.URL + "/oembed-page/")
+	if metadataType != model.LINK_METADATA_TYPE_OPENGRAPH || og.Title != "Embedded Title" || og.SiteName != "Provider" || og.Type != "video" {
+		t.Fatal("should have used the oembed response", og.Title, og.SiteName, og.Type)
+	} else if len(og.Images) != 1 || og.Images[0].Width != 400 || og.Images[0].Height != 300 {
+		t.Fatal("should have used the oembed thumbnail", og.Images)
+	}
+
+	og, metadataType = fetchOpenGraphMetadata(ts.URL + "/image.png")
+	if metadataType != model.LINK_METADATA_TYPE_IMAGE || len(og.Images) != 1 || og.Images[0].Width != 40 || og.Images[0].Height != 30 {
+		t.Fatal("should have described the image", metadataType, og.Images)
+	}
+
+	for _, path := range []string{"/no-og-data/", "/large/", "/file.zip", "/missing/"} {
+		if og, metadataType := fetchOpenGraphMetadata(ts.URL + path); metadataType != model.LINK_METADATA_TYPE_NONE || len(og.Title) != 0 {
+			t.Fatal("shouldn't have found a preview for "+path, metadataType, og.Title)
+		}
+	}
+
+	if _, metadataType := fetchOpenGraphMetadata("file:///etc/passwd"); metadataType != model.LINK_METADATA_TYPE_NONE {
+		t.Fatal("should only fetch http urls")
+	}
+
+	*utils.Cfg.ServiceSettings.AllowedUntrustedInternalConnections = ""
+	if _, metadataType := fetchOpenGraphMetadata(ts.URL + "/og-data/"); metadataType != model.LINK_METADATA_TYPE_NONE {
+		t.Fatal("shouldn't have fetched from an internal address")
+	}
+}
+
+func TestGetLinkMetadataData(t *testing.T) {
+	og := opengraph.NewOpenGraph()
+	og.Title = "Title"
+	og.Description = "Description"
+	og.Images = []*opengraph.Image{{URL: "http://example.com/image.png"}}
+
+	if data, ok := getLinkMetadataData(og); !ok || !strings.Contains(data, "Description") || !strings.Contains(data, "image.png") {
+		t.Fatal("should have kept a small preview intact", data)
+	}
+
+	for i := 0; i < 100; i++ {
+		og.Images = append(og.Images, &opengraph.Image{URL: "http://example.com/" + model.NewId() + ".png"})
+	}
+
+	if data, ok := getLinkMetadataData(og); !ok || !strings.Contains(data, "Description") || strings.Count(data, ".png") != 1 {
+		t.Fatal("should have dropped the extra images", data)
+	} else if len(og.Images) != 101 {
+		t.Fatal("shouldn't have modified the preview")
+	}
+
+	og.Description = strings.Repeat("a", model.LINK_METADATA_DATA_MAX_LENGTH)
+	if data, ok := getLinkMetadataData(og); !ok || !strings.Contains(data, strings.Repeat("a", LINK_METADATA_DESCRIPTION_MAX_RUNES)) || strings.Contains(data, strings.Repeat("a", LINK_METADATA_DESCRIPTION_MAX_RUNES+1)) {
+		t.Fatal("should have shortened the description", data)
+	}
+
+	og.Title = strings.Repeat("a", model.LINK_METADATA_DATA_MAX_LENGTH)
+	if _, ok := getLinkMetadataData(og); ok {
+		t.Fatal("shouldn't have fit")
+	}
+}
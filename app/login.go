@@ -14,31 +14,46 @@ import (
 	"github.com/mssola/user_agent"
 )
 
-func AuthenticateUserForLogin(id, loginId, password, mfaToken, deviceId string, ldapOnly bool) (*model.User, *model.AppError) {
+func AuthenticateUserForLogin(id, loginId, password, mfaToken, deviceId, ipAddress string, ldapOnly bool) (*model.User, *model.AppError) {
 	if len(password) == 0 {
 		err := model.NewLocAppError("AuthenticateUserForLogin", "api.user.login.blank_pwd.app_error", nil, "")
 		err.StatusCode = http.StatusBadRequest
 		return nil, err
 	}
 
+	attemptLoginId := loginId
+	if len(id) != 0 {
+		attemptLoginId = id
+	}
+
 	var user *model.User
 	var err *model.AppError
 
 	if len(id) != 0 {
 		if user, err = GetUser(id); err != nil {
 			err.StatusCode = http.StatusBadRequest
-			if einterfaces.GetMetricsInterface() != nil {
-				einterfaces.GetMetricsInterface().IncrementLoginFail()
-			}
-			return nil, err
 		}
 	} else {
-		if user, err = GetUserForLogin(loginId, ldapOnly); err != nil {
-			if einterfaces.GetMetricsInterface() != nil {
-				einterfaces.GetMetricsInterface().IncrementLoginFail()
-			}
-			return nil, err
+		user, err = GetUserForLogin(loginId, ldapOnly)
+	}
+
+	attemptLoginId = getLoginAttemptId(user, attemptLoginId)
+
+	// check for a lockout before returning an error for a missing user so that locked out clients can't
+	// enumerate login ids
+	if lockoutErr := CheckLoginLockout(ipAddress, attemptLoginId); lockoutErr != nil {
+		if einterfaces.GetMetricsInterface() != nil {
+			einterfaces.GetMetricsInterface().IncrementLoginFail()
+		}
+		return nil, lockoutErr
+	}
+
+	if err != nil {
+		if einterfaces.GetMetricsInterface() != nil {
+			einterfaces.GetMetricsInterface().IncrementLoginFail()
 		}
+		RecordFailedLogin(ipAddress, attemptLoginId)
+		return nil, err
 	}
 
 	// and then authenticate them
@@ -46,9 +61,12 @@ func AuthenticateUserForLogin(id, loginId, password, mfaToken, deviceId string,
 		if einterfaces.GetMetricsInterface() != nil {
 			einterfaces.GetMetricsInterface().IncrementLoginFail()
 		}
+		RecordFailedLogin(ipAddress, attemptLoginId)
 		return nil, err
 	}
 
+	RecordSuccessfulLogin(attemptLoginId)
+
 	if einterfaces.GetMetricsInterface() != nil {
 		einterfaces.GetMetricsInterface().IncrementLogin()
 	}
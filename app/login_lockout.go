@@ -0,0 +1,141 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"fmt"
+	"net/http"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	LOGIN_LOCKOUT_AUDIT_ACTION = "login_lockout"
+)
+
+// getLoginAttemptId returns the id that failed logins are counted under, which is the id of the user when one
+// matched so that logging in with the username, email and id all count together.
+func getLoginAttemptId(user *model.User, loginId string) string {
+	if user != nil && len(user.Id) > 0 {
+		return user.Id
+	}
+
+	return loginId
+}
+
+func loginAttemptKeys(ipAddress, loginId string) map[string]string {
+	keys := map[string]string{}
+
+	if len(ipAddress) > 0 {
+		keys[model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS] = ipAddress
+	}
+
+	if len(loginId) > 0 {
+		keys[model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID] = loginId
+	}
+
+	return keys
+}
+
+func maximumLoginAttemptsFor(keyType string) int64 {
+	if keyType == model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS {
+		return int64(*utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerIpAddress)
+	}
+
+	return int64(*utils.Cfg.ServiceSettings.MaximumLoginAttemptsPerLoginId)
+}
+
+// CheckLoginLockout returns an error if logins from the IP address or for the login ID are currently locked out.
+func CheckLoginLockout(ipAddress, loginId string) *model.AppError {
+	if !*utils.Cfg.ServiceSettings.EnableLoginLockout {
+		return nil
+	}
+
+	for keyType, keyValue := range loginAttemptKeys(ipAddress, loginId) {
+		if result := <-Srv.Store.LoginAttempt().GetLockout(keyType, keyValue); result.Err != nil {
+			if result.Err.Id != store.MISSING_LOGIN_LOCKOUT_ERROR {
+				return result.Err
+			}
+		} else {
+			lockout := result.Data.(*model.LoginLockout)
+			minutes := (lockout.ExpireAt-model.GetMillis())/(60*1000) + 1
+			return model.NewAppError("CheckLoginLockout", "api.user.check_login_lockout.locked_out.app_error", map[string]interface{}{"Minutes": minutes}, "key_type="+keyType, http.StatusForbidden)
+		}
+	}
+
+	return nil
+}
+
+// RecordFailedLogin counts a failed login against the IP address and login ID, locking either of them out once
+// they have failed too many times within the configured window. The failure is saved and counted atomically so
+// that concurrent failures can't get past the limit.
+func RecordFailedLogin(ipAddress, loginId string) {
+	if !*utils.Cfg.ServiceSettings.EnableLoginLockout {
+		return
+	}
+
+	since := model.GetMillis() - int64(*utils.Cfg.ServiceSettings.LoginAttemptWindowSeconds)*1000
+
+	for keyType, keyValue := range loginAttemptKeys(ipAddress, loginId) {
+		lockout := &model.LoginLockout{CreateAt: model.GetMillis()}
+		lockout.ExpireAt = lockout.CreateAt + int64(*utils.Cfg.ServiceSettings.LoginLockoutDurationSeconds)*1000
+
+		attempt := &model.LoginAttempt{KeyType: keyType, KeyValue: keyValue}
+		if result := <-Srv.Store.LoginAttempt().SaveAttemptAndLockout(attempt, since, maximumLoginAttemptsFor(keyType), lockout); result.Err != nil {
+			l4g.Error(utils.T("api.user.record_failed_login.save.error"), keyType, result.Err.Error())
+			continue
+		} else if result.Data.(*model.LoginLockout) == nil {
+			continue
+		}
+
+		l4g.Warn(utils.T("api.user.record_failed_login.locked_out.warn"), keyType, lockout.KeyValue, lockout.FailedAttempts)
+
+		audit := &model.Audit{
+			IpAddress: ipAddress,
+			Action:    LOGIN_LOCKOUT_AUDIT_ACTION,
+			ExtraInfo: fmt.Sprintf("lockout_id=%v key_type=%v key_value=%v failed_attempts=%v", lockout.Id, keyType, lockout.KeyValue, lockout.FailedAttempts),
+		}
+		if result := <-Srv.Store.Audit().Save(audit); result.Err != nil {
+			l4g.Error(utils.T("api.user.record_failed_login.audit.error"), result.Err.Error())
+		}
+	}
+}
+
+// RecordSuccessfulLogin forgets the failed logins for the login ID so that they don't count towards a later lockout.
+func RecordSuccessfulLogin(loginId string) {
+	if !*utils.Cfg.ServiceSettings.EnableLoginLockout || len(loginId) == 0 {
+		return
+	}
+
+	if result := <-Srv.Store.LoginAttempt().DeleteAttempts(model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, loginId); result.Err != nil {
+		l4g.Error(utils.T("api.user.record_successful_login.error"), result.Err.Error())
+	}
+}
+
+func GetLoginLockouts() ([]*model.LoginLockout, *model.AppError) {
+	if result := <-Srv.Store.LoginAttempt().GetLockouts(); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.LoginLockout), nil
+	}
+}
+
+func RemoveLoginLockout(lockoutId string) (*model.LoginLockout, *model.AppError) {
+	if result := <-Srv.Store.LoginAttempt().DeleteLockout(lockoutId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.LoginLockout), nil
+	}
+}
+
+func CleanupLoginAttempts() {
+	before := model.GetMillis() - int64(*utils.Cfg.ServiceSettings.LoginAttemptWindowSeconds)*1000
+
+	if result := <-Srv.Store.LoginAttempt().Cleanup(before); result.Err != nil {
+		l4g.Error(utils.T("api.user.cleanup_login_attempts.error"), result.Err.Error())
+	}
+}
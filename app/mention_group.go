@@ -0,0 +1,132 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func CreateMentionGroup(group *model.MentionGroup) (*model.MentionGroup, *model.AppError) {
+	if err := checkMentionGroup(group); err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.MentionGroup().Save(group); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.MentionGroup), nil
+	}
+}
+
+func GetMentionGroup(groupId string) (*model.MentionGroup, *model.AppError) {
+	if result := <-Srv.Store.MentionGroup().Get(groupId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.MentionGroup), nil
+	}
+}
+
+func GetMentionGroupsForTeam(teamId string) ([]*model.MentionGroup, *model.AppError) {
+	if result := <-Srv.Store.MentionGroup().GetForTeam(teamId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.([]*model.MentionGroup), nil
+	}
+}
+
+func PatchMentionGroup(group *model.MentionGroup, patch *model.MentionGroupPatch) (*model.MentionGroup, *model.AppError) {
+	group.Patch(patch)
+
+	if err := checkMentionGroup(group); err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.MentionGroup().Update(group); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.MentionGroup), nil
+	}
+}
+
+func DeleteMentionGroup(groupId string) *model.AppError {
+	if result := <-Srv.Store.MentionGroup().Delete(groupId, model.GetMillis()); result.Err != nil {
+		return result.Err
+	}
+
+	return nil
+}
+
+// checkMentionGroup makes sure that the group won't stop a user with the same name from being mentioned and that
+// all of its users are on its team.
+func checkMentionGroup(group *model.MentionGroup) *model.AppError {
+	if !model.IsValidMentionGroupName(group.Name) {
+		return model.NewAppError("checkMentionGroup", "model.mention_group.is_valid.name.app_error", nil, "name="+group.Name, http.StatusBadRequest)
+	}
+
+	if result := <-Srv.Store.User().GetByUsername(group.Name); result.Err == nil {
+		return model.NewAppError("checkMentionGroup", "api.mention_group.name_taken.app_error", nil, "name="+group.Name, http.StatusBadRequest)
+	}
+
+	userIds := map[string]bool{}
+	for _, userId := range group.UserIds {
+		userIds[userId] = true
+	}
+
+	if len(userIds) == 0 || len(userIds) > model.MENTION_GROUP_MAX_USERS {
+		return model.NewAppError("checkMentionGroup", "model.mention_group.is_valid.user_ids.app_error", nil, "", http.StatusBadRequest)
+	}
+
+	group.UserIds = make(model.StringArray, 0, len(userIds))
+	for userId := range userIds {
+		group.UserIds = append(group.UserIds, userId)
+	}
+
+	if result := <-Srv.Store.Team().GetMembersByIds(group.TeamId, group.UserIds); result.Err != nil {
+		return result.Err
+	} else if members := result.Data.([]*model.TeamMember); len(members) != len(group.UserIds) {
+		return model.NewAppError("checkMentionGroup", "api.mention_group.not_team_member.app_error", nil, "team_id="+group.TeamId, http.StatusBadRequest)
+	}
+
+	return nil
+}
+
+// getMentionGroupUserIds returns the users mentioned by any of the team's mention groups that appear in the given
+// potential mentions, along with the potential mentions that weren't mention groups. Like @channel, a group with
+// at least MaxNotificationsPerChannel users doesn't notify anyone.
+func getMentionGroupUserIds(teamId string, potentialMentions []string) (map[string]bool, []string, *model.AppError) {
+	groups, err := GetMentionGroupsForTeam(teamId)
+	if err != nil {
+		return nil, potentialMentions, err
+	}
+
+	groupsByName := make(map[string]*model.MentionGroup, len(groups))
+	for _, group := range groups {
+		groupsByName[group.Name] = group
+	}
+
+	mentioned := make(map[string]bool)
+	remaining := make([]string, 0, len(potentialMentions))
+
+	for _, name := range potentialMentions {
+		group, ok := groupsByName[strings.ToLower(name)]
+		if !ok {
+			remaining = append(remaining, name)
+			continue
+		}
+
+		if int64(len(group.UserIds)) >= *utils.Cfg.TeamSettings.MaxNotificationsPerChannel {
+			continue
+		}
+
+		for _, userId := range group.UserIds {
+			mentioned[userId] = true
+		}
+	}
+
+	return mentioned, remaining, nil
+}
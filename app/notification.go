@@ -4,7 +4,7 @@
 package app
 
 import (
-	"crypto/tls"
+	"bytes"
 	"fmt"
 	"html"
 	"html/template"
@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"time"
@@ -24,6 +25,12 @@ import (
 	"github.com/nicksnyder/go-i18n/i18n"
 )
 
+const (
+	MENTION_KEYWORD_CACHE_SIZE = 10000
+)
+
+var mentionKeywordCache = utils.NewLru(MENTION_KEYWORD_CACHE_SIZE)
+
 func SendNotifications(post *model.Post, team *model.Team, channel *model.Channel, sender *model.User, siteURL string) ([]string, *model.AppError) {
 	pchan := Srv.Store.User().GetAllProfilesInChannel(channel.Id, true)
 	cmnchan := Srv.Store.Channel().GetAllChannelMembersNotifyPropsForChannel(channel.Id, true)
@@ -72,6 +79,24 @@ func SendNotifications(post *model.Post, team *model.Team, channel *model.Channe
 		var potentialOtherMentions []string
 		mentionedUserIds, potentialOtherMentions, hereNotification, channelNotification, allNotification = GetExplicitMentions(post.Message, keywords)
 
+		// mention groups notify their users in the channel and are treated like out of channel mentions for the rest
+		outOfChannelGroupUserIds := []string{}
+		if len(potentialOtherMentions) > 0 {
+			if groupUserIds, remaining, err := getMentionGroupUserIds(team.Id, potentialOtherMentions); err != nil {
+				l4g.Error(utils.T("api.post.send_notifications.mention_groups.error"), post.Id, err.Error())
+			} else {
+				potentialOtherMentions = remaining
+
+				for id := range groupUserIds {
+					if _, ok := profileMap[id]; ok {
+						mentionedUserIds[id] = true
+					} else if id != post.UserId {
+						outOfChannelGroupUserIds = append(outOfChannelGroupUserIds, id)
+					}
+				}
+			}
+		}
+
 		// get users that have comment thread mentions enabled
 		if len(post.RootId) > 0 {
 			if result := <-Srv.Store.Post().Get(post.RootId); result.Err != nil {
@@ -88,18 +113,34 @@ func SendNotifications(post *model.Post, team *model.Team, channel *model.Channe
 			}
 		}
 
+		for id := range GetKeywordMentions(post.Message, profileMap, channelMemberNotifyPropsMap) {
+			mentionedUserIds[id] = true
+		}
+
 		// prevent the user from mentioning themselves
 		if post.Props["from_webhook"] != "true" {
 			delete(mentionedUserIds, post.UserId)
 		}
 
+		outOfChannelMentions := make(map[string]*model.User)
 		if len(potentialOtherMentions) > 0 {
 			if result := <-Srv.Store.User().GetProfilesByUsernames(potentialOtherMentions, team.Id); result.Err == nil {
-				outOfChannelMentions := result.Data.(map[string]*model.User)
-				go sendOutOfChannelMentions(sender, post, team.Id, outOfChannelMentions)
+				outOfChannelMentions = result.Data.(map[string]*model.User)
+			}
+		}
+
+		if len(outOfChannelGroupUserIds) > 0 {
+			if result := <-Srv.Store.User().GetProfileByIds(outOfChannelGroupUserIds, true); result.Err == nil {
+				for _, profile := range result.Data.([]*model.User) {
+					outOfChannelMentions[profile.Id] = profile
+				}
 			}
 		}
 
+		if len(outOfChannelMentions) > 0 {
+			go sendOutOfChannelMentions(sender, post, team.Id, outOfChannelMentions)
+		}
+
 		// find which users in the channel are set up to always receive mobile notifications
 		for _, profile := range profileMap {
 			if (profile.NotifyProps[model.PUSH_NOTIFY_PROP] == model.USER_NOTIFY_ALL ||
@@ -284,7 +325,7 @@ func SendNotifications(post *model.Post, team *model.Team, channel *model.Channe
 	}
 
 	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POSTED, "", post.ChannelId, "", nil)
-	message.Add("post", post.ToJson())
+	message.Add("post", PreparePostForClient(post, siteURL).ToJson())
 	message.Add("channel_type", channel.Type)
 	message.Add("channel_display_name", channelName)
 	message.Add("channel_name", channel.Name)
@@ -421,7 +462,7 @@ func sendNotificationEmail(post *model.Post, user *model.User, channel *model.Ch
 			"TimeZone": zone, "Month": month, "Day": day}))
 
 	go func() {
-		if err := utils.SendMail(user.Email, html.UnescapeString(subject), bodyPage.Render()); err != nil {
+		if err := utils.SendMailWithReplyTo(user.Email, GetEmailReplyAddress(post, user.Id), html.UnescapeString(subject), bodyPage.Render()); err != nil {
 			l4g.Error(utils.T("api.post.send_notifications_and_forget.send.error"), user.Email, err)
 		}
 	}()
@@ -556,11 +597,7 @@ func ClearPushNotification(userId string, channelId string) *model.AppError {
 func sendToPushProxy(msg model.PushNotification, session *model.Session) {
 	msg.ServerId = utils.CfgDiagnosticId
 
-	tr := &http.Transport{
-		TLSClientConfig:   &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
-		DisableKeepAlives: true,
-	}
-	httpClient := &http.Client{Transport: tr}
+	httpClient := NewTrustedHTTPClient()
 	request, _ := http.NewRequest("POST", *utils.Cfg.EmailSettings.PushNotificationServer+model.API_URL_SUFFIX_V1+"/send_push", strings.NewReader(msg.ToJson()))
 
 	if resp, err := httpClient.Do(request); err != nil {
@@ -632,7 +669,10 @@ func sendOutOfChannelMentions(sender *model.User, post *model.Post, teamId strin
 
 // Given a message and a map mapping mention keywords to the users who use them, returns a map of mentioned
 // users and a slice of potential mention users not in the channel and whether or not @here was mentioned.
+// Anything inside of a code block or inline code span is ignored.
 func GetExplicitMentions(message string, keywords map[string][]string) (map[string]bool, []string, bool, bool, bool) {
+	message = removeCodeFromMessage(message)
+
 	mentioned := make(map[string]bool)
 	potentialOthersMentioned := make([]string, 0)
 	systemMentions := map[string]bool{"@here": true, "@channel": true, "@all": true}
@@ -712,6 +752,180 @@ func GetExplicitMentions(message string, keywords map[string][]string) (map[stri
 	return mentioned, potentialOthersMentioned, hereMentioned, channelMentioned, allMentioned
 }
 
+// removeCodeFromMessage removes Markdown code blocks and inline code spans from a message. Each line of a code
+// block is replaced by an empty line and each code span by a space so that the surrounding words stay apart.
+func removeCodeFromMessage(message string) string {
+	lines := strings.Split(message, "\n")
+
+	// A message that starts out indented is more likely to be a mistake than code, so indented code is only
+	// found after a blank line
+	fence := ""
+	inIndentedCode := false
+	previousBlank := false
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(line, " ")
+		isFenceIndent := len(line)-len(trimmed) < 4
+		isBlank := strings.TrimSpace(line) == ""
+
+		if fence != "" {
+			// A fence is closed by a line containing only a fence at least as long as the one that opened it
+			if isFenceIndent && len(getCodeFence(trimmed, fence[0])) >= len(fence) && strings.TrimSpace(strings.TrimLeft(trimmed, fence[:1])) == "" {
+				fence = ""
+			}
+
+			lines[i] = ""
+			previousBlank = true
+			continue
+		}
+
+		if isFenceIndent {
+			if fence = getCodeFence(trimmed, '`'); fence == "" {
+				fence = getCodeFence(trimmed, '~')
+			}
+
+			// Backtick fences can't contain backticks in their info string
+			if fence != "" && fence[0] == '`' && strings.Contains(trimmed[len(fence):], "`") {
+				fence = ""
+			}
+
+			if fence != "" {
+				lines[i] = ""
+				inIndentedCode = false
+				continue
+			}
+		}
+
+		// Indented code can't interrupt a paragraph, so it has to follow a blank line or more indented code
+		if !isBlank && (strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t")) && (previousBlank || inIndentedCode) {
+			inIndentedCode = true
+			lines[i] = ""
+			continue
+		}
+
+		if !isBlank {
+			inIndentedCode = false
+		}
+
+		previousBlank = isBlank
+	}
+
+	return removeCodeSpans(strings.Join(lines, "\n"))
+}
+
+// getCodeFence returns the run of at least 3 of the given character at the start of a line or an empty string
+// if there isn't one.
+func getCodeFence(line string, c byte) string {
+	length := 0
+	for length < len(line) && line[length] == c {
+		length++
+	}
+
+	if length < 3 {
+		return ""
+	}
+
+	return line[:length]
+}
+
+// removeCodeSpans replaces inline code spans with spaces. A code span starts with a run of backticks and ends
+// with the next run of the same length. Backticks that aren't closed are left as they are.
+func removeCodeSpans(message string) string {
+	var result bytes.Buffer
+
+	for i := 0; i < len(message); {
+		if message[i] != '`' {
+			result.WriteByte(message[i])
+			i++
+			continue
+		}
+
+		length := strings.IndexFunc(message[i:], func(r rune) bool { return r != '`' })
+		if length == -1 {
+			length = len(message) - i
+		}
+
+		end := -1
+		for j := i + length; j < len(message); {
+			if message[j] != '`' {
+				j++
+				continue
+			}
+
+			closing := strings.IndexFunc(message[j:], func(r rune) bool { return r != '`' })
+			if closing == -1 {
+				closing = len(message) - j
+			}
+
+			if closing == length {
+				end = j + closing
+				break
+			}
+
+			j += closing
+		}
+
+		if end == -1 {
+			result.WriteString(message[i : i+length])
+			i += length
+		} else {
+			result.WriteByte(' ')
+			i = end
+		}
+	}
+
+	return result.String()
+}
+
+// GetKeywordMentions returns the users in the channel who are mentioned by one of the keywords in their notify
+// props or in their notify props for the channel. Like other mentions, keywords inside of code are ignored.
+func GetKeywordMentions(message string, profiles map[string]*model.User, channelMemberNotifyProps map[string]model.StringMap) map[string]bool {
+	message = removeCodeFromMessage(message)
+	mentioned := make(map[string]bool)
+
+	for id, profile := range profiles {
+		var keywords []*model.MentionKeyword
+		if data := profile.NotifyProps[model.KEYWORDS_NOTIFY_PROP]; data != "" {
+			keywords = append(keywords, model.MentionKeywordsFromJson(data)...)
+		}
+
+		if data := channelMemberNotifyProps[id][model.KEYWORDS_NOTIFY_PROP]; data != "" {
+			keywords = append(keywords, model.MentionKeywordsFromJson(data)...)
+		}
+
+		for _, keyword := range keywords {
+			if matchesMentionKeyword(keyword, message) {
+				mentioned[id] = true
+				break
+			}
+		}
+	}
+
+	return mentioned
+}
+
+// matchesMentionKeyword returns true if the keyword appears anywhere in the message. Since Go's regular
+// expressions run in linear time, keywords are matched against the whole message without a time limit.
+// Keywords that are too long to be saved are ignored in case they were stored before they were validated.
+func matchesMentionKeyword(keyword *model.MentionKeyword, message string) bool {
+	if keyword == nil || len(keyword.Keyword) > model.MENTION_KEYWORD_MAX_LENGTH {
+		return false
+	}
+
+	key := fmt.Sprintf("%v:%v:%v:%v", keyword.CaseSensitive, keyword.WholeWord, keyword.Regex, keyword.Keyword)
+
+	var re *regexp.Regexp
+	if cached, ok := mentionKeywordCache.Get(key); ok {
+		re = cached.(*regexp.Regexp)
+	} else if compiled, err := keyword.Compile(); err != nil {
+		return false
+	} else {
+		re = compiled
+		mentionKeywordCache.Add(key, re)
+	}
+
+	return re.MatchString(message)
+}
+
 // Given a map of user IDs to profiles, returns a list of mention
 // keywords for all users in the channel.
 func GetMentionKeywordsInChannel(profiles map[string]*model.User) map[string][]string {
@@ -4,6 +4,7 @@
 package app
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/mattermost/platform/model"
@@ -116,6 +117,161 @@ func TestGetExplicitMentions(t *testing.T) {
 	}
 }
 
+func TestSendNotificationsWithMentionGroup(t *testing.T) {
+	th := Setup().InitBasic()
+
+	AddUserToChannel(th.BasicUser2, th.BasicChannel)
+
+	outOfChannelUser := th.CreateUser()
+	LinkUserToTeam(outOfChannelUser, th.BasicTeam)
+
+	group, err := CreateMentionGroup(&model.MentionGroup{
+		TeamId:    th.BasicTeam.Id,
+		CreatorId: th.BasicUser.Id,
+		Name:      "group" + model.NewId()[:10],
+		UserIds:   []string{th.BasicUser.Id, th.BasicUser2.Id, outOfChannelUser.Id},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post, err := CreatePost(&model.Post{
+		UserId:    th.BasicUser.Id,
+		ChannelId: th.BasicChannel.Id,
+		Message:   "hello @" + group.Name + "!",
+	}, th.BasicTeam.Id, true, utils.GetSiteURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mentions, err := SendNotifications(post, th.BasicTeam, th.BasicChannel, th.BasicUser, utils.GetSiteURL()); err != nil {
+		t.Fatal(err)
+	} else if len(mentions) != 1 || mentions[0] != th.BasicUser2.Id {
+		t.Fatal("should've only mentioned the group's other user in the channel", mentions)
+	}
+
+	maxNotifications := *utils.Cfg.TeamSettings.MaxNotificationsPerChannel
+	defer func() {
+		*utils.Cfg.TeamSettings.MaxNotificationsPerChannel = maxNotifications
+	}()
+	*utils.Cfg.TeamSettings.MaxNotificationsPerChannel = 3
+
+	if mentions, err := SendNotifications(post, th.BasicTeam, th.BasicChannel, th.BasicUser, utils.GetSiteURL()); err != nil {
+		t.Fatal(err)
+	} else if len(mentions) != 0 {
+		t.Fatal("shouldn't have mentioned a group with too many users", mentions)
+	}
+}
+
+func TestCreateMentionGroup(t *testing.T) {
+	th := Setup().InitBasic()
+
+	if _, err := CreateMentionGroup(&model.MentionGroup{TeamId: th.BasicTeam.Id, CreatorId: th.BasicUser.Id, Name: th.BasicUser2.Username, UserIds: []string{th.BasicUser.Id}}); err == nil {
+		t.Fatal("shouldn't be able to use a username as a group name")
+	}
+
+	if _, err := CreateMentionGroup(&model.MentionGroup{TeamId: th.BasicTeam.Id, CreatorId: th.BasicUser.Id, Name: "group" + model.NewId()[:10], UserIds: []string{th.CreateUser().Id}}); err == nil {
+		t.Fatal("shouldn't be able to add a user who isn't on the team")
+	}
+
+	if group, err := CreateMentionGroup(&model.MentionGroup{TeamId: th.BasicTeam.Id, CreatorId: th.BasicUser.Id, Name: "group" + model.NewId()[:10], UserIds: []string{th.BasicUser.Id, th.BasicUser.Id}}); err != nil {
+		t.Fatal(err)
+	} else if len(group.UserIds) != 1 {
+		t.Fatal("should've removed duplicate users")
+	}
+}
+
+func TestGetExplicitMentionsInCode(t *testing.T) {
+	id := model.NewId()
+	keywords := map[string][]string{"@user": {id}}
+
+	cases := map[string]bool{
+		"@user":                                    true,
+		"`@user`":                                  false,
+		"this is `code` for @user":                 true,
+		"``with a ` backtick @user``":              false,
+		"`unclosed @user":                          true,
+		"```\n@user\n```":                          false,
+		"```go\n@user\n```\n@user":                 true,
+		"~~~~\n@user\n~~~\nstill code\n~~~~":       false,
+		"```\nnever closed\n@user":                 false,
+		"```not` a fence @user":                    true,
+		"    @user":                                true,
+		"paragraph\n    @user":                     true,
+		"paragraph\n\n    @user":                   false,
+		"\tcode\n\n\tmore code @user\nafter @user": true,
+	}
+
+	for message, shouldMention := range cases {
+		if mentions, _, _, _, _ := GetExplicitMentions(message, keywords); mentions[id] && !shouldMention {
+			t.Fatalf("shouldn't have mentioned @user with %q", message)
+		} else if !mentions[id] && shouldMention {
+			t.Fatalf("should've mentioned @user with %q", message)
+		}
+	}
+
+	if _, potential, _, _, _ := GetExplicitMentions("`@potential` @other", keywords); len(potential) != 1 || potential[0] != "other" {
+		t.Fatal("shouldn't have potential mentions in code", potential)
+	}
+}
+
+func TestGetKeywordMentions(t *testing.T) {
+	user1 := &model.User{Id: model.NewId(), NotifyProps: model.StringMap{
+		model.KEYWORDS_NOTIFY_PROP: model.MentionKeywordsToJson([]*model.MentionKeyword{
+			{Keyword: "deploy", WholeWord: true},
+			{Keyword: `ticket-\d+`, Regex: true},
+		}),
+	}}
+	user2 := &model.User{Id: model.NewId(), NotifyProps: model.StringMap{
+		model.KEYWORDS_NOTIFY_PROP: model.MentionKeywordsToJson([]*model.MentionKeyword{
+			{Keyword: "Outage", CaseSensitive: true},
+		}),
+	}}
+	user3 := &model.User{Id: model.NewId(), NotifyProps: model.StringMap{}}
+	user4 := &model.User{Id: model.NewId(), NotifyProps: model.StringMap{
+		model.KEYWORDS_NOTIFY_PROP: model.MentionKeywordsToJson([]*model.MentionKeyword{
+			{Keyword: "deploy" + strings.Repeat(`\s?`, model.MENTION_KEYWORD_MAX_LENGTH/3), Regex: true},
+		}),
+	}}
+
+	profiles := map[string]*model.User{user1.Id: user1, user2.Id: user2, user3.Id: user3, user4.Id: user4}
+	channelProps := map[string]model.StringMap{
+		user3.Id: {
+			model.KEYWORDS_NOTIFY_PROP: model.MentionKeywordsToJson([]*model.MentionKeyword{{Keyword: "release"}}),
+		},
+	}
+
+	cases := map[string][]string{
+		"time to DEPLOY":           {user1.Id},
+		"redeploy the server":      {},
+		"see ticket-123":           {user1.Id},
+		"see ticket-abc":           {},
+		"there's an Outage":        {user2.Id},
+		"there's an outage":        {},
+		"the new release is out":   {user3.Id},
+		"`deploy` the `release`":   {},
+		"```\ndeploy release\n```": {},
+	}
+
+	for message, expected := range cases {
+		mentions := GetKeywordMentions(message, profiles, channelProps)
+		if len(mentions) != len(expected) {
+			t.Fatalf("mentioned wrong users with %q: %v", message, mentions)
+		}
+
+		for _, id := range expected {
+			if !mentions[id] {
+				t.Fatalf("should've mentioned %v with %q", id, message)
+			}
+		}
+	}
+
+	long := strings.Repeat("a", model.POST_MESSAGE_MAX_RUNES-11) + " ticket-123"
+	if mentions := GetKeywordMentions(long, profiles, channelProps); len(mentions) != 1 || !mentions[user1.Id] {
+		t.Fatal("should've matched regular expressions against the whole message", mentions)
+	}
+}
+
 func TestGetExplicitMentionsAtHere(t *testing.T) {
 	// test all the boundary cases that we know can break up terms (and those that we know won't)
 	cases := map[string]bool{
@@ -155,7 +311,7 @@ func TestGetExplicitMentionsAtHere(t *testing.T) {
 		">@here>": true,
 		"/@here/": true,
 		"?@here?": true,
-		// "`@here`": true,
+		"`@here`": false,
 		// "~@here~": true,
 	}
 
@@ -4,15 +4,11 @@
 package app
 
 import (
-	"net"
 	"net/http"
-	"net/url"
-	"os"
 	"regexp"
-	"time"
+	"strings"
 
 	l4g "github.com/alecthomas/log4go"
-	"github.com/dyatlov/go-opengraph/opengraph"
 	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/store"
@@ -20,35 +16,9 @@ import (
 )
 
 var (
-	httpClient *http.Client
-
-	httpTimeout       = time.Duration(5 * time.Second)
 	linkWithTextRegex = regexp.MustCompile(`<([^<\|]+)\|([^>]+)>`)
 )
 
-func dialTimeout(network, addr string) (net.Conn, error) {
-	return net.DialTimeout(network, addr, httpTimeout)
-}
-
-func init() {
-	p, ok := os.LookupEnv("HTTP_PROXY")
-	if ok {
-		if u, err := url.Parse(p); err == nil {
-			httpClient = &http.Client{
-				Transport: &http.Transport{
-					Proxy: http.ProxyURL(u),
-					Dial:  dialTimeout,
-				},
-			}
-			return
-		}
-	}
-
-	httpClient = &http.Client{
-		Timeout: httpTimeout,
-	}
-}
-
 func CreatePostAsUser(post *model.Post, siteURL string) (*model.Post, *model.AppError) {
 	// Check that channel has not been deleted
 	var channel *model.Channel
@@ -116,6 +86,9 @@ func CreatePost(post *model.Post, teamId string, triggerWebhooks bool, siteURL s
 		}
 	}
 
+	// clients may send back the proxied image URLs that they were given
+	*post = *PostWithProxyRemovedFromImageURLs(post)
+
 	post.Hashtags, _ = model.ParseHashtags(post.Message)
 
 	var rpost *model.Post
@@ -219,6 +192,7 @@ func parseSlackAttachment(post *model.Post, attachments []*model.SlackAttachment
 		}
 	}
 	post.AddProp("attachments", attachments)
+	post.GenerateActionIds()
 }
 
 func parseSlackLinksToMarkdown(text string) string {
@@ -240,15 +214,18 @@ func SendEphemeralPost(teamId, userId string, post *model.Post) *model.Post {
 	}
 
 	message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_EPHEMERAL_MESSAGE, "", post.ChannelId, userId, nil)
-	message.Add("post", post.ToJson())
+	message.Add("post", PreparePostForClient(post, utils.GetSiteURL()).ToJson())
 
 	go Publish(message)
 
 	return post
 }
 
-func UpdatePost(post *model.Post) (*model.Post, *model.AppError) {
-	if utils.IsLicensed {
+// UpdatePost edits the message of an existing post. When safeUpdate is false the post's props and
+// attachments are replaced as well and the edit policy isn't enforced, which is only allowed for
+// updates coming from integrations.
+func UpdatePost(post *model.Post, safeUpdate bool) (*model.Post, *model.AppError) {
+	if utils.IsLicensed && safeUpdate {
 		if *utils.Cfg.ServiceSettings.AllowEditPost == model.ALLOW_EDIT_POST_NEVER {
 			err := model.NewLocAppError("updatePost", "api.post.update_post.permissions_denied.app_error", nil, "")
 			err.StatusCode = http.StatusForbidden
@@ -286,7 +263,7 @@ func UpdatePost(post *model.Post) (*model.Post, *model.AppError) {
 			return nil, err
 		}
 
-		if utils.IsLicensed {
+		if utils.IsLicensed && safeUpdate {
 			if *utils.Cfg.ServiceSettings.AllowEditPost == model.ALLOW_EDIT_POST_TIME_LIMIT && model.GetMillis() > oldPost.CreateAt+int64(*utils.Cfg.ServiceSettings.PostEditTimeLimit*1000) {
 				err := model.NewLocAppError("updatePost", "api.post.update_post.permissions_time_limit.app_error", map[string]interface{}{"timeLimit": *utils.Cfg.ServiceSettings.PostEditTimeLimit}, "")
 				err.StatusCode = http.StatusBadRequest
@@ -295,6 +272,9 @@ func UpdatePost(post *model.Post) (*model.Post, *model.AppError) {
 		}
 	}
 
+	// clients send back the proxied image URLs that they were given when editing a post
+	post = PostWithProxyRemovedFromImageURLs(post)
+
 	newPost := &model.Post{}
 	*newPost = *oldPost
 
@@ -302,13 +282,17 @@ func UpdatePost(post *model.Post) (*model.Post, *model.AppError) {
 	newPost.EditAt = model.GetMillis()
 	newPost.Hashtags, _ = model.ParseHashtags(post.Message)
 
+	if !safeUpdate {
+		newPost.Props = post.Props
+	}
+
 	if result := <-Srv.Store.Post().Update(newPost, oldPost); result.Err != nil {
 		return nil, result.Err
 	} else {
 		rpost := result.Data.(*model.Post)
 
 		message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POST_EDITED, "", rpost.ChannelId, "", nil)
-		message.Add("post", rpost.ToJson())
+		message.Add("post", PreparePostForClient(rpost, utils.GetSiteURL()).ToJson())
 
 		go Publish(message)
 
@@ -354,6 +338,20 @@ func GetSinglePost(postId string) (*model.Post, *model.AppError) {
 	}
 }
 
+// GetEditHistoryForPost returns the versions of a post from before each time it was edited, with the most
+// recent first.
+func GetEditHistoryForPost(postId string) (*model.PostList, *model.AppError) {
+	if _, err := GetSinglePost(postId); err != nil {
+		return nil, err
+	}
+
+	if result := <-Srv.Store.Post().GetEditHistoryForPost(postId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return result.Data.(*model.PostList), nil
+	}
+}
+
 func GetPostThread(postId string) (*model.PostList, *model.AppError) {
 	if result := <-Srv.Store.Post().Get(postId); result.Err != nil {
 		return nil, result.Err
@@ -438,7 +436,7 @@ func DeletePost(postId string) (*model.Post, *model.AppError) {
 		}
 
 		message := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POST_DELETED, "", post.ChannelId, "", nil)
-		message.Add("post", post.ToJson())
+		message.Add("post", PreparePostForClient(post, utils.GetSiteURL()).ToJson())
 
 		go Publish(message)
 		go DeletePostFiles(post)
@@ -458,12 +456,35 @@ func DeleteFlaggedPosts(postId string) {
 }
 
 func DeletePostFiles(post *model.Post) {
-	if len(post.FileIds) != 0 {
+	if len(post.FileIds) == 0 {
+		return
+	}
+
+	var infos []*model.FileInfo
+	if result := <-Srv.Store.FileInfo().GetForPost(post.Id, true, false); result.Err != nil {
+		l4g.Warn(utils.T("api.post.delete_post_files.app_error.warn"), post.Id, result.Err)
 		return
+	} else {
+		infos = result.Data.([]*model.FileInfo)
 	}
 
 	if result := <-Srv.Store.FileInfo().DeleteForPost(post.Id); result.Err != nil {
 		l4g.Warn(utils.T("api.post.delete_post_files.app_error.warn"), post.Id, result.Err)
+		return
+	}
+
+	Srv.Store.FileInfo().InvalidateFileInfosForPostCache(post.Id)
+
+	// Held files keep their references to their stored data so that it's kept even though they're no longer
+	// shown, including when other files with the same content are deleted. ReleaseLegalHold releases them.
+	if IsUnderLegalHold(post.UserId, post.ChannelId, post.CreateAt) {
+		return
+	}
+
+	for _, info := range infos {
+		if info.ContentHash != "" {
+			releaseDeletedFileBlob(info)
+		}
 	}
 }
 
@@ -522,19 +543,170 @@ func GetFileInfosForPost(postId string, readFromMaster bool) ([]*model.FileInfo,
 	return infos, nil
 }
 
-func GetOpenGraphMetadata(url string) *opengraph.OpenGraph {
-	og := opengraph.NewOpenGraph()
+// PreparePostForClient returns a copy of the post that can be sent to clients. The integrations of its
+// attachment actions are removed since their contexts may hold secrets shared with the integration, and the
+// URLs of its images are changed to go through the image proxy.
+func PreparePostForClient(post *model.Post, siteURL string) *model.Post {
+	if post == nil {
+		return post
+	}
+
+	return PostWithProxyAddedToImageURLs(postWithoutActionIntegrations(post), siteURL)
+}
+
+func PreparePostListForClient(list *model.PostList, siteURL string) *model.PostList {
+	if list == nil {
+		return list
+	}
+
+	prepared := &model.PostList{Order: list.Order, Posts: make(map[string]*model.Post, len(list.Posts))}
+	for id, post := range list.Posts {
+		prepared.Posts[id] = PreparePostForClient(post, siteURL)
+	}
+
+	return prepared
+}
+
+// postWithoutActionIntegrations returns a copy of the post without the integrations of its attachment actions.
+// DoPostAction reads them from the stored post instead.
+func postWithoutActionIntegrations(post *model.Post) *model.Post {
+	attachments := post.Attachments()
+
+	hasIntegrations := false
+	for _, attachment := range attachments {
+		for _, action := range attachment.Actions {
+			hasIntegrations = hasIntegrations || action.Integration != nil
+		}
+	}
+
+	if !hasIntegrations {
+		return post
+	}
+
+	// the attachments may be shared with the original post, so they're copied before being changed
+	strippedAttachments := make([]*model.SlackAttachment, len(attachments))
+	for i, attachment := range attachments {
+		strippedAttachment := *attachment
+		strippedAttachment.Actions = make([]*model.PostAction, len(attachment.Actions))
+		for j, action := range attachment.Actions {
+			strippedAction := *action
+			strippedAction.Integration = nil
+			strippedAttachment.Actions[j] = &strippedAction
+		}
+		strippedAttachments[i] = &strippedAttachment
+	}
+
+	stripped := *post
+	stripped.Props = make(model.StringInterface, len(post.Props))
+	for key, value := range post.Props {
+		stripped.Props[key] = value
+	}
+	stripped.Props["attachments"] = strippedAttachments
+
+	return &stripped
+}
+
+// DoPostAction triggers an attachment action on behalf of the user by calling the integration's URL.
+// The integration can respond with an update to the original post or a message shown only to the user.
+func DoPostAction(postId, actionId, userId, selectedOption string) *model.AppError {
+	post, err := GetSinglePost(postId)
+	if err != nil {
+		return err
+	}
+
+	action := post.GetAction(actionId)
+	if action == nil {
+		return model.NewAppError("DoPostAction", "api.post.do_action.action_id.app_error", nil, "action="+actionId, http.StatusNotFound)
+	}
+
+	if err := action.IsValid(); err != nil {
+		return err
+	}
+
+	if action.Type == model.POST_ACTION_TYPE_SELECT && !action.HasOption(selectedOption) {
+		return model.NewAppError("DoPostAction", "api.post.do_action.selected_option.app_error", nil, "action="+actionId, http.StatusBadRequest)
+	}
 
-	res, err := httpClient.Get(url)
+	channel, err := GetChannel(post.ChannelId)
 	if err != nil {
-		l4g.Error(err.Error())
-		return og
+		return err
+	}
+
+	request := &model.PostActionIntegrationRequest{
+		UserId:    userId,
+		ChannelId: post.ChannelId,
+		TeamId:    channel.TeamId,
+		PostId:    postId,
+		Type:      action.Type,
+		Context:   model.StringInterface{},
 	}
-	defer CloseBody(res)
 
-	if err := og.ProcessHTML(res.Body); err != nil {
-		l4g.Error(err.Error())
+	for key, value := range action.Integration.Context {
+		request.Context[key] = value
 	}
 
-	return og
+	if action.Type == model.POST_ACTION_TYPE_SELECT {
+		request.Context[model.POST_ACTION_CONTEXT_SELECTED_OPTION] = selectedOption
+	}
+
+	client := NewHTTPClient()
+
+	req, _ := http.NewRequest("POST", action.Integration.URL, strings.NewReader(request.ToJson()))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	var response *model.PostActionIntegrationResponse
+	if resp, err := client.Do(req); err != nil {
+		return model.NewAppError("DoPostAction", "api.post.do_action.action_integration.app_error", nil, "err="+err.Error(), http.StatusBadRequest)
+	} else {
+		defer CloseBody(resp)
+
+		if resp.StatusCode != http.StatusOK {
+			return model.NewAppError("DoPostAction", "api.post.do_action.action_integration.app_error", nil, "status="+resp.Status, http.StatusBadRequest)
+		}
+
+		if response = model.PostActionIntegrationResponseFromJson(resp.Body); response == nil {
+			return model.NewAppError("DoPostAction", "api.post.do_action.action_integration.app_error", nil, "invalid response", http.StatusBadRequest)
+		}
+	}
+
+	if response.Update != nil {
+		response.Update.Id = postId
+		response.Update.UserId = post.UserId
+
+		// the returned props are added to the post's so that ones like the overridden username are kept
+		props := model.StringInterface{}
+		for key, value := range post.Props {
+			props[key] = value
+		}
+		for key, value := range response.Update.Props {
+			props[key] = value
+		}
+		response.Update.Props = props
+		response.Update.AddProp("from_webhook", "true")
+		response.Update.GenerateActionIds()
+
+		if _, err := UpdatePost(response.Update, false); err != nil {
+			return err
+		}
+	}
+
+	if len(response.EphemeralText) > 0 {
+		ephemeralPost := &model.Post{
+			ChannelId: post.ChannelId,
+			RootId:    post.RootId,
+			Message:   parseSlackLinksToMarkdown(response.EphemeralText),
+			Props: model.StringInterface{
+				"from_webhook": "true",
+			},
+		}
+
+		if len(ephemeralPost.RootId) == 0 {
+			ephemeralPost.RootId = post.Id
+		}
+
+		SendEphemeralPost(channel.TeamId, userId, ephemeralPost)
+	}
+
+	return nil
 }
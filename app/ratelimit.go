@@ -0,0 +1,292 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+	"gopkg.in/throttled/throttled.v2"
+	"gopkg.in/throttled/throttled.v2/store/memstore"
+)
+
+const (
+	RATE_LIMIT_CLEANUP_TASK_NAME = "RateLimitCleanup"
+
+	// Incoming webhooks are counted in the same store as API requests, so their keys are kept apart from the
+	// API's route classes
+	INCOMING_WEBHOOK_RATE_LIMIT_KEY_PREFIX = "incoming_webhook:"
+)
+
+// RateLimiter throttles requests with a separate quota for each route class. Requests are keyed by the
+// session's user when VaryByUser is enabled and the request is authenticated, otherwise by the remote
+// address or the configured headers.
+type RateLimiter struct {
+	limiters         map[string]*throttled.GCRARateLimiter
+	varyByUser       bool
+	varyByRemoteAddr bool
+	varyByHeaders    []string
+}
+
+func NewRateLimiter(settings *model.RateLimitSettings, gcraStore throttled.GCRAStore) (*RateLimiter, error) {
+	quotas := map[string]throttled.RateQuota{
+		model.RATE_LIMIT_CLASS_DEFAULT:     {MaxRate: throttled.PerSec(settings.PerSec), MaxBurst: *settings.MaxBurst},
+		model.RATE_LIMIT_CLASS_LOGIN:       {MaxRate: throttled.PerSec(*settings.LoginPerSec), MaxBurst: *settings.LoginMaxBurst},
+		model.RATE_LIMIT_CLASS_POST_CREATE: {MaxRate: throttled.PerSec(*settings.PostCreatePerSec), MaxBurst: *settings.PostCreateMaxBurst},
+		model.RATE_LIMIT_CLASS_SEARCH:      {MaxRate: throttled.PerSec(*settings.SearchPerSec), MaxBurst: *settings.SearchMaxBurst},
+		model.RATE_LIMIT_CLASS_FILE_UPLOAD: {MaxRate: throttled.PerSec(*settings.FileUploadPerSec), MaxBurst: *settings.FileUploadMaxBurst},
+	}
+
+	rl := &RateLimiter{
+		limiters:         make(map[string]*throttled.GCRARateLimiter, len(quotas)),
+		varyByUser:       *settings.VaryByUser,
+		varyByRemoteAddr: settings.VaryByRemoteAddr,
+	}
+
+	for class, quota := range quotas {
+		if limiter, err := throttled.NewGCRARateLimiter(gcraStore, quota); err != nil {
+			return nil, err
+		} else {
+			rl.limiters[class] = limiter
+		}
+	}
+
+	if len(settings.VaryByHeader) > 0 {
+		rl.varyByHeaders = strings.Fields(settings.VaryByHeader)
+
+		if rl.varyByRemoteAddr {
+			l4g.Warn(utils.T("api.server.start_server.rate.warn"))
+			rl.varyByRemoteAddr = false
+		}
+	}
+
+	return rl, nil
+}
+
+// GetRateLimitClass returns the route class whose quota applies to the request.
+func GetRateLimitClass(r *http.Request) string {
+	if r.Method != http.MethodPost {
+		return model.RATE_LIMIT_CLASS_DEFAULT
+	}
+
+	path := strings.TrimRight(r.URL.Path, "/")
+
+	switch {
+	case strings.HasSuffix(path, "/users/login"), strings.HasSuffix(path, "/users/login/switch"):
+		return model.RATE_LIMIT_CLASS_LOGIN
+	case path == model.API_URL_SUFFIX_V4+"/posts", strings.HasSuffix(path, "/posts/create"):
+		return model.RATE_LIMIT_CLASS_POST_CREATE
+	case strings.HasSuffix(path, "/posts/search"):
+		return model.RATE_LIMIT_CLASS_SEARCH
+	case path == model.API_URL_SUFFIX_V4+"/files", strings.HasSuffix(path, "/files/upload"):
+		return model.RATE_LIMIT_CLASS_FILE_UPLOAD
+	}
+
+	return model.RATE_LIMIT_CLASS_DEFAULT
+}
+
+// ParseAuthTokenFromRequest returns the session token sent with the request in the same order of
+// precedence used by the API handlers, or an empty string if there isn't one.
+func ParseAuthTokenFromRequest(r *http.Request) string {
+	authHeader := r.Header.Get(model.HEADER_AUTH)
+	if len(authHeader) > 6 && strings.ToUpper(authHeader[0:6]) == model.HEADER_BEARER {
+		return authHeader[7:]
+	} else if len(authHeader) > 5 && strings.ToLower(authHeader[0:5]) == model.HEADER_TOKEN {
+		return authHeader[6:]
+	}
+
+	if cookie, err := r.Cookie(model.SESSION_COOKIE_TOKEN); err == nil {
+		return cookie.Value
+	}
+
+	return r.URL.Query().Get("access_token")
+}
+
+// GenerateKey returns the key that the request is counted against for the given route class.
+func (rl *RateLimiter) GenerateKey(r *http.Request, class string) string {
+	if rl.varyByUser {
+		// only cached sessions are used so that made up tokens can't be used to cause a database lookup for every
+		// request, and a session that isn't cached yet is counted by address until the request loads it
+		if token := ParseAuthTokenFromRequest(r); len(token) > 0 {
+			if ts, ok := sessionCache.Get(token); ok {
+				if session := ts.(*model.Session); !session.IsExpired() {
+					return class + ":user:" + session.UserId
+				}
+			}
+		}
+	}
+
+	key := class + ":"
+
+	if rl.varyByRemoteAddr {
+		key += utils.GetIpAddress(r)
+	}
+
+	for _, header := range rl.varyByHeaders {
+		key += "\n" + r.Header.Get(header)
+	}
+
+	return key
+}
+
+func (rl *RateLimiter) RateLimitHandler(wrapped http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		class := GetRateLimitClass(r)
+
+		limited, result, err := rl.limiters[class].RateLimit(rl.GenerateKey(r, class), 1)
+		if err != nil {
+			l4g.Error(utils.T("api.server.rate_limit.error"), r.URL.Path, err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		setRateLimitHeaders(w, result)
+
+		if limited {
+			l4g.Error("%v: Denied due to throttling settings code=429 ip=%v class=%v", r.URL.Path, utils.GetIpAddress(r), class)
+			http.Error(w, "limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+
+		wrapped.ServeHTTP(w, r)
+	})
+}
+
+func setRateLimitHeaders(w http.ResponseWriter, result throttled.RateLimitResult) {
+	if result.Limit >= 0 {
+		w.Header().Set(model.HEADER_RATE_LIMIT_LIMIT, strconv.Itoa(result.Limit))
+	}
+
+	if result.Remaining >= 0 {
+		w.Header().Set(model.HEADER_RATE_LIMIT_REMAINING, strconv.Itoa(result.Remaining))
+	}
+
+	if result.ResetAfter >= 0 {
+		w.Header().Set(model.HEADER_RATE_LIMIT_RESET, strconv.Itoa(int(math.Ceil(result.ResetAfter.Seconds()))))
+	}
+
+	if result.RetryAfter >= 0 {
+		w.Header().Set(model.HEADER_RETRY_AFTER, strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+	}
+}
+
+// incomingWebhookLimiters throttles incoming webhooks that have their own rate limit. Hooks are keyed by
+// id in a single store, which is shared across the cluster like the API's when configured to be, and share a
+// limiter for each rate in use.
+var incomingWebhookLimiters = struct {
+	sync.Mutex
+	store  throttled.GCRAStore
+	byRate map[int]*throttled.GCRARateLimiter
+}{
+	byRate: make(map[int]*throttled.GCRARateLimiter),
+}
+
+// RateLimitIncomingWebhook counts a post against the hook's per-minute rate limit and reports whether it
+// should be rejected. Hooks without a rate limit are never limited.
+func RateLimitIncomingWebhook(hook *model.IncomingWebhook) (bool, throttled.RateLimitResult, error) {
+	if hook.RateLimit <= 0 {
+		return false, throttled.RateLimitResult{Limit: -1, Remaining: -1, ResetAfter: -1, RetryAfter: -1}, nil
+	}
+
+	incomingWebhookLimiters.Lock()
+
+	if incomingWebhookLimiters.store == nil {
+		if gcraStore, err := NewRateLimitStore(); err != nil {
+			incomingWebhookLimiters.Unlock()
+			return false, throttled.RateLimitResult{}, err
+		} else {
+			incomingWebhookLimiters.store = gcraStore
+		}
+	}
+
+	limiter, ok := incomingWebhookLimiters.byRate[hook.RateLimit]
+	if !ok {
+		quota := throttled.RateQuota{MaxRate: throttled.PerMin(hook.RateLimit), MaxBurst: hook.RateLimit - 1}
+
+		var err error
+		if limiter, err = throttled.NewGCRARateLimiter(incomingWebhookLimiters.store, quota); err != nil {
+			incomingWebhookLimiters.Unlock()
+			return false, throttled.RateLimitResult{}, err
+		}
+
+		incomingWebhookLimiters.byRate[hook.RateLimit] = limiter
+	}
+
+	incomingWebhookLimiters.Unlock()
+
+	return limiter.RateLimit(INCOMING_WEBHOOK_RATE_LIMIT_KEY_PREFIX+hook.Id, 1)
+}
+
+// rateLimitCleanupOnce makes sure that the shared state is only cleaned up by one task no matter how many stores
+// are created
+var rateLimitCleanupOnce sync.Once
+
+// NewRateLimitStore returns the store holding the rate limiter state. The state lives in the database
+// when it is shared across a cluster and in memory otherwise.
+func NewRateLimitStore() (throttled.GCRAStore, error) {
+	if *utils.Cfg.RateLimitSettings.ShareAcrossCluster && *utils.Cfg.ClusterSettings.Enable {
+		rateLimitCleanupOnce.Do(func() {
+			model.CreateRecurringTask(RATE_LIMIT_CLEANUP_TASK_NAME, func() {
+				if result := <-Srv.Store.RateLimit().Cleanup(); result.Err != nil {
+					l4g.Error(utils.T("api.server.rate_limit.cleanup.error"), result.Err.Error())
+				}
+			}, time.Minute*10)
+		})
+
+		return &sqlRateLimitStore{}, nil
+	}
+
+	return memstore.New(utils.Cfg.RateLimitSettings.MemoryStoreSize)
+}
+
+// sqlRateLimitStore adapts the RateLimit store so that every node in a cluster counts against the same limits
+type sqlRateLimitStore struct{}
+
+func (s *sqlRateLimitStore) GetWithTime(key string) (int64, time.Time, error) {
+	now := time.Now()
+
+	if result := <-Srv.Store.RateLimit().Get(key); result.Err != nil {
+		if result.Err.Id == store.MISSING_RATE_LIMIT_ERROR {
+			return -1, now, nil
+		}
+		return 0, now, result.Err
+	} else {
+		return result.Data.(*model.RateLimit).Value, now, nil
+	}
+}
+
+func (s *sqlRateLimitStore) SetIfNotExistsWithTTL(key string, value int64, ttl time.Duration) (bool, error) {
+	rateLimit := &model.RateLimit{Id: key, Value: value, ExpireAt: rateLimitExpireAt(ttl)}
+
+	if result := <-Srv.Store.RateLimit().SetIfNotExists(rateLimit); result.Err != nil {
+		return false, result.Err
+	} else {
+		return result.Data.(bool), nil
+	}
+}
+
+func (s *sqlRateLimitStore) CompareAndSwapWithTTL(key string, old, new int64, ttl time.Duration) (bool, error) {
+	if result := <-Srv.Store.RateLimit().CompareAndSwap(key, old, new, rateLimitExpireAt(ttl)); result.Err != nil {
+		return false, result.Err
+	} else {
+		return result.Data.(bool), nil
+	}
+}
+
+func rateLimitExpireAt(ttl time.Duration) int64 {
+	// the store only has millisecond precision so keep keys around for at least a second
+	if ttl < time.Second {
+		ttl = time.Second
+	}
+
+	return model.GetMillis() + int64(ttl/time.Millisecond)
+}
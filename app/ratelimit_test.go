@@ -0,0 +1,208 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+	"gopkg.in/throttled/throttled.v2/store/memstore"
+)
+
+func newTestRateLimitSettings(varyByUser bool) model.RateLimitSettings {
+	maxBurst, loginPerSec, loginMaxBurst, otherPerSec, otherMaxBurst := 100, 1, 1, 1, 10
+
+	return model.RateLimitSettings{
+		PerSec:             1,
+		MaxBurst:           &maxBurst,
+		VaryByRemoteAddr:   true,
+		VaryByUser:         &varyByUser,
+		LoginPerSec:        &loginPerSec,
+		LoginMaxBurst:      &loginMaxBurst,
+		PostCreatePerSec:   &otherPerSec,
+		PostCreateMaxBurst: &otherMaxBurst,
+		SearchPerSec:       &otherPerSec,
+		SearchMaxBurst:     &otherMaxBurst,
+		FileUploadPerSec:   &otherPerSec,
+		FileUploadMaxBurst: &otherMaxBurst,
+	}
+}
+
+func newTestRateLimiter(t *testing.T, settings model.RateLimitSettings) *RateLimiter {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	store, err := memstore.New(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rl, err := NewRateLimiter(&settings, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rl
+}
+
+func TestGetRateLimitClass(t *testing.T) {
+	for path, class := range map[string]string{
+		"/api/v4/users/login":                     model.RATE_LIMIT_CLASS_LOGIN,
+		"/api/v4/users/login/switch":              model.RATE_LIMIT_CLASS_LOGIN,
+		"/api/v3/users/login":                     model.RATE_LIMIT_CLASS_LOGIN,
+		"/api/v4/posts":                           model.RATE_LIMIT_CLASS_POST_CREATE,
+		"/api/v3/teams/t/channels/c/posts/create": model.RATE_LIMIT_CLASS_POST_CREATE,
+		"/api/v4/teams/t/posts/search":            model.RATE_LIMIT_CLASS_SEARCH,
+		"/api/v3/teams/t/posts/search":            model.RATE_LIMIT_CLASS_SEARCH,
+		"/api/v4/users/search":                    model.RATE_LIMIT_CLASS_DEFAULT,
+		"/api/v4/teams/t/channels/search":         model.RATE_LIMIT_CLASS_DEFAULT,
+		"/api/v4/files":                           model.RATE_LIMIT_CLASS_FILE_UPLOAD,
+		"/api/v3/teams/t/files/upload":            model.RATE_LIMIT_CLASS_FILE_UPLOAD,
+		"/api/v4/channels":                        model.RATE_LIMIT_CLASS_DEFAULT,
+	} {
+		if actual := GetRateLimitClass(httptest.NewRequest("POST", path, nil)); actual != class {
+			t.Fatal("wrong class for " + path + ": " + actual)
+		}
+	}
+
+	if GetRateLimitClass(httptest.NewRequest("GET", "/api/v4/posts", nil)) != model.RATE_LIMIT_CLASS_DEFAULT {
+		t.Fatal("only writes should use the route class quotas")
+	}
+}
+
+func TestParseAuthTokenFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v4/users/me?access_token=query", nil)
+	if ParseAuthTokenFromRequest(r) != "query" {
+		t.Fatal("should have read the token from the query string")
+	}
+
+	r.AddCookie(&http.Cookie{Name: model.SESSION_COOKIE_TOKEN, Value: "cookie"})
+	if ParseAuthTokenFromRequest(r) != "cookie" {
+		t.Fatal("cookie should take precedence over the query string")
+	}
+
+	r.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" header")
+	if ParseAuthTokenFromRequest(r) != "header" {
+		t.Fatal("header should take precedence over the cookie")
+	}
+}
+
+func TestRateLimiterGenerateKey(t *testing.T) {
+	rl := newTestRateLimiter(t, newTestRateLimitSettings(true))
+
+	session := &model.Session{Token: model.NewId(), UserId: model.NewId()}
+	AddSessionToCache(session)
+	defer ClearSessionCacheForUserSkipClusterSend(session.UserId)
+
+	r := httptest.NewRequest("GET", "/api/v4/users/me", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	if key := rl.GenerateKey(r, model.RATE_LIMIT_CLASS_DEFAULT); key != "default:10.0.0.1" {
+		t.Fatal("unauthenticated requests should be keyed by address: " + key)
+	}
+
+	r.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+session.Token)
+	if key := rl.GenerateKey(r, model.RATE_LIMIT_CLASS_SEARCH); key != "search:user:"+session.UserId {
+		t.Fatal("authenticated requests should be keyed by user: " + key)
+	}
+
+	r.Header.Set(model.HEADER_AUTH, model.HEADER_BEARER+" "+model.NewId())
+	if key := rl.GenerateKey(r, model.RATE_LIMIT_CLASS_DEFAULT); key != "default:10.0.0.1" {
+		t.Fatal("requests without a cached session should be keyed by address: " + key)
+	}
+
+	settings := newTestRateLimitSettings(false)
+	settings.VaryByRemoteAddr = false
+	rl = newTestRateLimiter(t, settings)
+
+	if key := rl.GenerateKey(r, model.RATE_LIMIT_CLASS_DEFAULT); key != "default:" {
+		t.Fatal("shouldn't use the address unless configured to: " + key)
+	}
+
+	settings.VaryByHeader = "X-Forwarded-For"
+	rl = newTestRateLimiter(t, settings)
+
+	r.Header.Set("X-Forwarded-For", "10.0.0.2")
+	if key := rl.GenerateKey(r, model.RATE_LIMIT_CLASS_DEFAULT); key != "default:\n10.0.0.2" {
+		t.Fatal("should have been keyed by the header: " + key)
+	}
+}
+
+func TestRateLimitHandler(t *testing.T) {
+	rl := newTestRateLimiter(t, newTestRateLimitSettings(false))
+
+	handler := rl.RateLimitHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(method, path, nil)
+		r.RemoteAddr = "10.0.0.2:1234"
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("POST", "/api/v4/users/login")
+	if w.Code != http.StatusOK {
+		t.Fatal("first login should be allowed")
+	} else if w.Header().Get(model.HEADER_RATE_LIMIT_LIMIT) != "2" || w.Header().Get(model.HEADER_RATE_LIMIT_REMAINING) != "1" {
+		t.Fatal("should have set the rate limit headers")
+	}
+
+	serve("POST", "/api/v4/users/login")
+
+	w = serve("POST", "/api/v4/users/login")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatal("login burst should have been exhausted")
+	} else if w.Header().Get(model.HEADER_RETRY_AFTER) == "" {
+		t.Fatal("should have set the retry after header")
+	}
+
+	if w = serve("GET", "/api/v4/users/me"); w.Code != http.StatusOK {
+		t.Fatal("other routes should have their own quota")
+	} else if w.Header().Get(model.HEADER_RATE_LIMIT_LIMIT) != "101" {
+		t.Fatal("other routes should use the default quota")
+	}
+}
+
+func TestRateLimitIncomingWebhook(t *testing.T) {
+	hook := &model.IncomingWebhook{Id: model.NewId()}
+
+	for i := 0; i < 5; i++ {
+		if limited, _, err := RateLimitIncomingWebhook(hook); err != nil {
+			t.Fatal(err)
+		} else if limited {
+			t.Fatal("hooks without a rate limit shouldn't be limited")
+		}
+	}
+
+	hook.RateLimit = 2
+
+	for i := 0; i < 2; i++ {
+		if limited, _, err := RateLimitIncomingWebhook(hook); err != nil {
+			t.Fatal(err)
+		} else if limited {
+			t.Fatal("should have allowed posts within the limit")
+		}
+	}
+
+	if limited, result, err := RateLimitIncomingWebhook(hook); err != nil {
+		t.Fatal(err)
+	} else if !limited {
+		t.Fatal("should have limited the hook")
+	} else if result.RetryAfter <= 0 {
+		t.Fatal("should have said when to retry")
+	}
+
+	otherHook := &model.IncomingWebhook{Id: model.NewId(), RateLimit: 2}
+	if limited, _, err := RateLimitIncomingWebhook(otherHook); err != nil {
+		t.Fatal(err)
+	} else if limited {
+		t.Fatal("hooks should be limited separately")
+	}
+}
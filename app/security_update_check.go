@@ -5,7 +5,6 @@ package app
 
 import (
 	"io/ioutil"
-	"net/http"
 	"net/url"
 	"runtime"
 	"strconv"
@@ -73,7 +72,7 @@ func DoSecurityUpdateCheck() {
 					v.Set(PROP_SECURITY_TEAM_COUNT, strconv.FormatInt(tcr.Data.(int64), 10))
 				}
 
-				res, err := http.Get(SECURITY_URL + "/security?" + v.Encode())
+				res, err := NewTrustedHTTPClient().Get(SECURITY_URL + "/security?" + v.Encode())
 				if err != nil {
 					l4g.Error(utils.T("mattermost.security_info.error"))
 					return
@@ -92,7 +91,7 @@ func DoSecurityUpdateCheck() {
 							} else {
 								users := results.Data.(map[string]*model.User)
 
-								resBody, err := http.Get(SECURITY_URL + "/bulletins/" + bulletin.Id)
+								resBody, err := NewTrustedHTTPClient().Get(SECURITY_URL + "/bulletins/" + bulletin.Id)
 								if err != nil {
 									l4g.Error(utils.T("mattermost.security_bulletin.error"))
 									return
@@ -18,8 +18,6 @@ import (
 	"github.com/mattermost/platform/utils"
 	"github.com/rsc/letsencrypt"
 	"github.com/tylerb/graceful"
-	"gopkg.in/throttled/throttled.v2"
-	"gopkg.in/throttled/throttled.v2/store/memstore"
 )
 
 type Server struct {
@@ -81,31 +79,6 @@ func InitStores() {
 	Srv.Store = store.NewSqlStore()
 }
 
-type VaryBy struct{}
-
-func (m *VaryBy) Key(r *http.Request) string {
-	return utils.GetIpAddress(r)
-}
-
-func initalizeThrottledVaryBy() *throttled.VaryBy {
-	vary := throttled.VaryBy{}
-
-	if utils.Cfg.RateLimitSettings.VaryByRemoteAddr {
-		vary.RemoteAddr = true
-	}
-
-	if len(utils.Cfg.RateLimitSettings.VaryByHeader) > 0 {
-		vary.Headers = strings.Fields(utils.Cfg.RateLimitSettings.VaryByHeader)
-
-		if utils.Cfg.RateLimitSettings.VaryByRemoteAddr {
-			l4g.Warn(utils.T("api.server.start_server.rate.warn"))
-			vary.RemoteAddr = false
-		}
-	}
-
-	return &vary
-}
-
 func redirectHTTPToHTTPS(w http.ResponseWriter, r *http.Request) {
 	if r.Host == "" {
 		http.Error(w, "Not Found", http.StatusNotFound)
@@ -125,33 +98,19 @@ func StartServer() {
 	if *utils.Cfg.RateLimitSettings.Enable {
 		l4g.Info(utils.T("api.server.start_server.rate.info"))
 
-		store, err := memstore.New(utils.Cfg.RateLimitSettings.MemoryStoreSize)
+		rateLimitStore, err := NewRateLimitStore()
 		if err != nil {
 			l4g.Critical(utils.T("api.server.start_server.rate_limiting_memory_store"))
 			return
 		}
 
-		quota := throttled.RateQuota{
-			MaxRate:  throttled.PerSec(utils.Cfg.RateLimitSettings.PerSec),
-			MaxBurst: *utils.Cfg.RateLimitSettings.MaxBurst,
-		}
-
-		rateLimiter, err := throttled.NewGCRARateLimiter(store, quota)
+		rateLimiter, err := NewRateLimiter(&utils.Cfg.RateLimitSettings, rateLimitStore)
 		if err != nil {
 			l4g.Critical(utils.T("api.server.start_server.rate_limiting_rate_limiter"))
 			return
 		}
 
-		httpRateLimiter := throttled.HTTPRateLimiter{
-			RateLimiter: rateLimiter,
-			VaryBy:      &VaryBy{},
-			DeniedHandler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				l4g.Error("%v: Denied due to throttling settings code=429 ip=%v", r.URL.Path, utils.GetIpAddress(r))
-				throttled.DefaultDeniedHandler.ServeHTTP(w, r)
-			}),
-		}
-
-		handler = httpRateLimiter.RateLimit(handler)
+		handler = rateLimiter.RateLimitHandler(handler)
 	}
 
 	Srv.GracefulServer = &graceful.Server{
@@ -0,0 +1,49 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func GetStorageUsageForTeam(teamId string) (*model.StorageUsage, *model.AppError) {
+	if result := <-Srv.Store.FileInfo().GetStorageUsageForTeam(teamId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return &model.StorageUsage{Usage: result.Data.(int64), Quota: *utils.Cfg.FileSettings.MaxStoragePerTeam}, nil
+	}
+}
+
+func GetStorageUsageForUser(userId string) (*model.StorageUsage, *model.AppError) {
+	if result := <-Srv.Store.FileInfo().GetStorageUsageForUser(userId); result.Err != nil {
+		return nil, result.Err
+	} else {
+		return &model.StorageUsage{Usage: result.Data.(int64), Quota: *utils.Cfg.FileSettings.MaxStoragePerUser}, nil
+	}
+}
+
+// CheckStorageQuota returns an error if uploading a file of the given size would take the team or the user
+// over their storage quota. Files that don't belong to a team are only counted against the user's quota.
+func CheckStorageQuota(teamId string, userId string, size int64) *model.AppError {
+	if *utils.Cfg.FileSettings.MaxStoragePerTeam > 0 && teamId != "" {
+		if usage, err := GetStorageUsageForTeam(teamId); err != nil {
+			return err
+		} else if usage.IsOverQuota(size) {
+			return model.NewAppError("CheckStorageQuota", "api.file.upload_file.team_quota.app_error", nil, "team_id="+teamId, http.StatusRequestEntityTooLarge)
+		}
+	}
+
+	if *utils.Cfg.FileSettings.MaxStoragePerUser > 0 {
+		if usage, err := GetStorageUsageForUser(userId); err != nil {
+			return err
+		} else if usage.IsOverQuota(size) {
+			return model.NewAppError("CheckStorageQuota", "api.file.upload_file.user_quota.app_error", nil, "user_id="+userId, http.StatusRequestEntityTooLarge)
+		}
+	}
+
+	return nil
+}
@@ -641,18 +641,27 @@ func GetTeamsUnreadForUser(excludeTeamId string, userId string) ([]*model.TeamUn
 }
 
 func PermanentDeleteTeam(team *model.Team) *model.AppError {
+	var channels *model.ChannelList
+	if result := <-Srv.Store.Channel().GetTeamChannels(team.Id); result.Err != nil {
+		return result.Err
+	} else {
+		channels = result.Data.(*model.ChannelList)
+	}
+
+	// Check every channel first so that the team isn't left partially deleted
+	for _, c := range *channels {
+		if err := CheckChannelNotUnderLegalHold(c.Id); err != nil {
+			return err
+		}
+	}
+
 	team.DeleteAt = model.GetMillis()
 	if result := <-Srv.Store.Team().Update(team); result.Err != nil {
 		return result.Err
 	}
 
-	if result := <-Srv.Store.Channel().GetTeamChannels(team.Id); result.Err != nil {
-		return result.Err
-	} else {
-		channels := result.Data.(*model.ChannelList)
-		for _, c := range *channels {
-			PermanentDeleteChannel(c)
-		}
+	for _, c := range *channels {
+		PermanentDeleteChannel(c)
 	}
 
 	if result := <-Srv.Store.Team().RemoveAllMembersByTeam(team.Id); result.Err != nil {
@@ -1133,6 +1133,10 @@ func PermanentDeleteUser(user *model.User) *model.AppError {
 		l4g.Warn(utils.T("api.user.permanent_delete_user.system_admin.warn"), user.Email)
 	}
 
+	if err := CheckUserNotUnderLegalHold(user.Id); err != nil {
+		return err
+	}
+
 	if _, err := UpdateActive(user, false); err != nil {
 		return err
 	}
@@ -1161,6 +1165,10 @@ func PermanentDeleteUser(user *model.User) *model.AppError {
 		return result.Err
 	}
 
+	if result := <-Srv.Store.EmailDigest().Delete(user.Id); result.Err != nil {
+		return result.Err
+	}
+
 	if result := <-Srv.Store.Channel().PermanentDeleteMembersByUser(user.Id); result.Err != nil {
 		return result.Err
 	}
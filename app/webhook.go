@@ -4,9 +4,9 @@
 package app
 
 import (
-	"crypto/tls"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"regexp"
 	"strings"
@@ -87,10 +87,7 @@ func handleWebhookEvents(post *model.Post, team *model.Team, channel *model.Chan
 				body = strings.NewReader(payload.ToFormValues())
 				contentType = "application/x-www-form-urlencoded"
 			}
-			tr := &http.Transport{
-				TLSClientConfig: &tls.Config{InsecureSkipVerify: *utils.Cfg.ServiceSettings.EnableInsecureOutgoingConnections},
-			}
-			client := &http.Client{Transport: tr}
+			client := NewHTTPClient()
 
 			for _, url := range hook.CallbackURLs {
 				go func(url string) {
@@ -107,7 +104,7 @@ func handleWebhookEvents(post *model.Post, team *model.Team, channel *model.Chan
 						respProps := model.MapFromJson(resp.Body)
 
 						if text, ok := respProps["text"]; ok {
-							if _, err := CreateWebhookPost(hook.CreatorId, hook.TeamId, post.ChannelId, text, respProps["username"], respProps["icon_url"], post.Props, post.Type, siteURL); err != nil {
+							if _, err := CreateWebhookPost(hook.CreatorId, hook.TeamId, post.ChannelId, text, respProps["username"], respProps["icon_url"], post.Props, post.Type, "", nil, siteURL); err != nil {
 								l4g.Error(utils.T("api.post.handle_webhook_events_and_forget.create_post.error"), err)
 							}
 						}
@@ -121,12 +118,12 @@ func handleWebhookEvents(post *model.Post, team *model.Team, channel *model.Chan
 	return nil
 }
 
-func CreateWebhookPost(userId, teamId, channelId, text, overrideUsername, overrideIconUrl string, props model.StringInterface, postType string, siteURL string) (*model.Post, *model.AppError) {
+func CreateWebhookPost(userId, teamId, channelId, text, overrideUsername, overrideIconUrl string, props model.StringInterface, postType string, rootId string, fileIds []string, siteURL string) (*model.Post, *model.AppError) {
 	// parse links into Markdown format
 	linkWithTextRegex := regexp.MustCompile(`<([^<\|]+)\|([^>]+)>`)
 	text = linkWithTextRegex.ReplaceAllString(text, "[${2}](${1})")
 
-	post := &model.Post{UserId: userId, ChannelId: channelId, Message: text, Type: postType}
+	post := &model.Post{UserId: userId, ChannelId: channelId, Message: text, Type: postType, RootId: rootId, FileIds: fileIds}
 	post.AddProp("from_webhook", "true")
 
 	if metrics := einterfaces.GetMetricsInterface(); metrics != nil {
@@ -159,11 +156,11 @@ func CreateWebhookPost(userId, teamId, channelId, text, overrideUsername, overri
 		}
 	}
 
-	if _, err := CreatePost(post, teamId, false, siteURL); err != nil {
+	if rpost, err := CreatePost(post, teamId, false, siteURL); err != nil {
 		return nil, model.NewLocAppError("CreateWebhookPost", "api.post.create_webhook_post.creating.app_error", nil, "err="+err.Message)
+	} else {
+		return rpost, nil
 	}
-
-	return post, nil
 }
 
 func CreateIncomingWebhookForChannel(creatorId string, channel *model.Channel, hook *model.IncomingWebhook) (*model.IncomingWebhook, *model.AppError) {
@@ -192,10 +189,13 @@ func UpdateIncomingWebhook(oldHook, updatedHook *model.IncomingWebhook) (*model.
 	updatedHook.UpdateAt = model.GetMillis()
 	updatedHook.TeamId = oldHook.TeamId
 	updatedHook.DeleteAt = oldHook.DeleteAt
+	updatedHook.PostCount = oldHook.PostCount
+	updatedHook.LastUsedAt = oldHook.LastUsedAt
 
 	if result := <-Srv.Store.Webhook().UpdateIncoming(updatedHook); result.Err != nil {
 		return nil, result.Err
 	} else {
+		InvalidateCacheForWebhook(updatedHook.Id)
 		return result.Data.(*model.IncomingWebhook), nil
 	}
 }
@@ -219,7 +219,8 @@ func GetIncomingWebhook(hookId string) (*model.IncomingWebhook, *model.AppError)
 		return nil, model.NewAppError("GetIncomingWebhook", "api.incoming_webhook.disabled.app_error", nil, "", http.StatusNotImplemented)
 	}
 
-	if result := <-Srv.Store.Webhook().GetIncoming(hookId, true); result.Err != nil {
+	// the cached hook is only refreshed when the hook is edited, so its usage would be out of date
+	if result := <-Srv.Store.Webhook().GetIncoming(hookId, false); result.Err != nil {
 		return nil, result.Err
 	} else {
 		return result.Data.(*model.IncomingWebhook), nil
@@ -423,25 +424,35 @@ func RegenOutgoingWebhookToken(hook *model.OutgoingWebhook) (*model.OutgoingWebh
 	}
 }
 
-func HandleIncomingWebhook(hookId string, req *model.IncomingWebhookRequest, siteURL string) *model.AppError {
+// HandleIncomingWebhook creates the post sent to an incoming webhook. Files uploaded with the request are
+// attached to the post and a root id makes it a reply to an existing thread in the channel.
+func HandleIncomingWebhook(hookId string, req *model.IncomingWebhookRequest, files []*multipart.FileHeader, siteURL string) (*model.Post, *model.AppError) {
 	if !utils.Cfg.ServiceSettings.EnableIncomingWebhooks {
-		return model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.disabled.app_error", nil, "", http.StatusNotImplemented)
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.disabled.app_error", nil, "", http.StatusNotImplemented)
 	}
 
 	hchan := Srv.Store.Webhook().GetIncoming(hookId, true)
 
 	if req == nil {
-		return model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.parse.app_error", nil, "", http.StatusBadRequest)
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.parse.app_error", nil, "", http.StatusBadRequest)
 	}
 
 	text := req.Text
-	if len(text) == 0 && req.Attachments == nil {
-		return model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.text.app_error", nil, "", http.StatusBadRequest)
+	if len(text) == 0 && req.Attachments == nil && len(files) == 0 {
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.text.app_error", nil, "", http.StatusBadRequest)
 	}
 
 	textSize := utf8.RuneCountInString(text)
 	if textSize > model.POST_MESSAGE_MAX_RUNES {
-		return model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.text.length.app_error", map[string]interface{}{"Max": model.POST_MESSAGE_MAX_RUNES, "Actual": textSize}, "", http.StatusBadRequest)
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.text.length.app_error", map[string]interface{}{"Max": model.POST_MESSAGE_MAX_RUNES, "Actual": textSize}, "", http.StatusBadRequest)
+	}
+
+	if len(files) > model.INCOMING_WEBHOOK_MAX_FILES {
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.files.app_error", map[string]interface{}{"Max": model.INCOMING_WEBHOOK_MAX_FILES}, "", http.StatusBadRequest)
+	}
+
+	if len(req.RootId) != 0 && len(req.RootId) != 26 {
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.root_id.app_error", nil, "", http.StatusBadRequest)
 	}
 
 	channelName := req.ChannelName
@@ -457,7 +468,7 @@ func HandleIncomingWebhook(hookId string, req *model.IncomingWebhookRequest, sit
 		attachmentSize := utf8.RuneCountInString(model.StringInterfaceToJson(req.Props))
 		// Minus 100 to leave room for setting post type in the Props
 		if attachmentSize > model.POST_PROPS_MAX_RUNES-100 {
-			return model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.attachment.app_error", map[string]interface{}{"Max": model.POST_PROPS_MAX_RUNES - 100, "Actual": attachmentSize}, "", http.StatusBadRequest)
+			return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.attachment.app_error", map[string]interface{}{"Max": model.POST_PROPS_MAX_RUNES - 100, "Actual": attachmentSize}, "", http.StatusBadRequest)
 		}
 
 		webhookType = model.POST_SLACK_ATTACHMENT
@@ -465,19 +476,29 @@ func HandleIncomingWebhook(hookId string, req *model.IncomingWebhookRequest, sit
 
 	var hook *model.IncomingWebhook
 	if result := <-hchan; result.Err != nil {
-		return model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.invalid.app_error", nil, "err="+result.Err.Message, http.StatusBadRequest)
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.invalid.app_error", nil, "err="+result.Err.Message, http.StatusBadRequest)
 	} else {
 		hook = result.Data.(*model.IncomingWebhook)
 	}
 
+	if limited, rateLimitResult, err := RateLimitIncomingWebhook(hook); err != nil {
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.rate_limit.app_error", nil, "err="+err.Error(), http.StatusInternalServerError)
+	} else if limited {
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.rate_limited.app_error", nil, "retry_after="+rateLimitResult.RetryAfter.String(), http.StatusTooManyRequests)
+	}
+
 	var channel *model.Channel
 	var cchan store.StoreChannel
 	var directUserId string
 
 	if len(channelName) != 0 {
+		if hook.ChannelLocked && channelName[0] == '@' {
+			return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.channel_locked.app_error", nil, "", http.StatusForbidden)
+		}
+
 		if channelName[0] == '@' {
 			if result := <-Srv.Store.User().GetByUsername(channelName[1:]); result.Err != nil {
-				return model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.user.app_error", nil, "err="+result.Err.Message, http.StatusBadRequest)
+				return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.user.app_error", nil, "err="+result.Err.Message, http.StatusBadRequest)
 			} else {
 				directUserId = result.Data.(*model.User).Id
 				channelName = model.GetDMNameFromIds(directUserId, hook.UserId)
@@ -492,31 +513,72 @@ func HandleIncomingWebhook(hookId string, req *model.IncomingWebhookRequest, sit
 	}
 
 	overrideUsername := req.Username
+	if hook.DisableUsernameOverride {
+		overrideUsername = ""
+	}
+
 	overrideIconUrl := req.IconURL
+	if hook.DisableIconOverride {
+		overrideIconUrl = ""
+	}
 
 	result := <-cchan
 	if result.Err != nil && result.Err.Id == store.MISSING_CHANNEL_ERROR && directUserId != "" {
 		newChanResult := <-Srv.Store.Channel().CreateDirectChannel(directUserId, hook.UserId)
 		if newChanResult.Err != nil {
-			return model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.channel.app_error", nil, "err="+newChanResult.Err.Message, http.StatusBadRequest)
+			return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.channel.app_error", nil, "err="+newChanResult.Err.Message, http.StatusBadRequest)
 		} else {
 			channel = newChanResult.Data.(*model.Channel)
 			InvalidateCacheForUser(directUserId)
 			InvalidateCacheForUser(hook.UserId)
 		}
 	} else if result.Err != nil {
-		return model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.channel.app_error", nil, "err="+result.Err.Message, result.Err.StatusCode)
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.channel.app_error", nil, "err="+result.Err.Message, result.Err.StatusCode)
 	} else {
 		channel = result.Data.(*model.Channel)
 	}
 
+	if hook.ChannelLocked && channel.Id != hook.ChannelId {
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.channel_locked.app_error", nil, "", http.StatusForbidden)
+	}
+
 	if channel.Type != model.CHANNEL_OPEN && !HasPermissionToChannel(hook.UserId, channel.Id, model.PERMISSION_READ_CHANNEL) {
-		return model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.permissions.app_error", nil, "", http.StatusForbidden)
+		return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.permissions.app_error", nil, "", http.StatusForbidden)
 	}
 
-	if _, err := CreateWebhookPost(hook.UserId, hook.TeamId, channel.Id, text, overrideUsername, overrideIconUrl, req.Props, webhookType, siteURL); err != nil {
-		return err
+	rootId := ""
+	if len(req.RootId) > 0 {
+		if root, err := GetSinglePost(req.RootId); err != nil || root.ChannelId != channel.Id {
+			return nil, model.NewAppError("HandleIncomingWebhook", "web.incoming_webhook.root_id.app_error", nil, "root_id="+req.RootId, http.StatusBadRequest)
+		} else if len(root.RootId) > 0 {
+			rootId = root.RootId
+		} else {
+			rootId = root.Id
+		}
 	}
 
-	return nil
+	var fileInfos []*model.FileInfo
+	var fileIds []string
+	if len(files) > 0 {
+		if resp, err := UploadFiles(hook.TeamId, channel.Id, hook.UserId, files, nil); err != nil {
+			return nil, err
+		} else {
+			fileInfos = resp.FileInfos
+			for _, info := range fileInfos {
+				fileIds = append(fileIds, info.Id)
+			}
+		}
+	}
+
+	post, err := CreateWebhookPost(hook.UserId, hook.TeamId, channel.Id, text, overrideUsername, overrideIconUrl, req.Props, webhookType, rootId, fileIds, siteURL)
+	if err != nil {
+		deleteUploadedFiles(fileInfos)
+		return nil, err
+	}
+
+	if result := <-Srv.Store.Webhook().UpdateIncomingUsage(hook.Id, post.CreateAt); result.Err != nil {
+		l4g.Error(utils.T("web.incoming_webhook.usage.error"), hook.Id, result.Err.Error())
+	}
+
+	return post, nil
 }
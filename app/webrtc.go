@@ -0,0 +1,98 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+func GetWebrtcInfoForSession(sessionId string) (map[string]string, *model.AppError) {
+	token, err := RegisterWebrtcToken(sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+	result["token"] = token
+	result["gateway_url"] = *utils.Cfg.WebrtcSettings.GatewayWebsocketUrl
+
+	if len(*utils.Cfg.WebrtcSettings.StunURI) > 0 {
+		result["stun_uri"] = *utils.Cfg.WebrtcSettings.StunURI
+	}
+
+	if len(*utils.Cfg.WebrtcSettings.TurnURI) > 0 {
+		timestamp := strconv.FormatInt(utils.EndOfDay(time.Now().AddDate(0, 0, 1)).Unix(), 10)
+		username := timestamp + ":" + *utils.Cfg.WebrtcSettings.TurnUsername
+
+		result["turn_uri"] = *utils.Cfg.WebrtcSettings.TurnURI
+		result["turn_password"] = GenerateTurnPassword(username, *utils.Cfg.WebrtcSettings.TurnSharedKey)
+		result["turn_username"] = username
+	}
+
+	return result, nil
+}
+
+func RegisterWebrtcToken(sessionId string) (string, *model.AppError) {
+	if !*utils.Cfg.WebrtcSettings.Enable {
+		return "", model.NewLocAppError("WebRTC.registerWebrtcToken", "api.webrtc.disabled.app_error", nil, "")
+	}
+
+	token := base64.StdEncoding.EncodeToString([]byte(sessionId))
+
+	data := make(map[string]string)
+	data["janus"] = "add_token"
+	data["token"] = token
+	data["transaction"] = model.NewId()
+	data["admin_secret"] = *utils.Cfg.WebrtcSettings.GatewayAdminSecret
+
+	rq, _ := http.NewRequest("POST", *utils.Cfg.WebrtcSettings.GatewayAdminUrl, strings.NewReader(model.MapToJson(data)))
+	rq.Header.Set("Content-Type", "application/json")
+
+	httpClient := NewTrustedHTTPClient()
+	if rp, err := httpClient.Do(rq); err != nil {
+		return "", model.NewLocAppError("WebRTC.Token", "model.client.connecting.app_error", nil, err.Error())
+	} else if rp.StatusCode >= 300 {
+		defer CloseBody(rp)
+		return "", model.AppErrorFromJson(rp.Body)
+	} else {
+		janusResponse := model.GatewayResponseFromJson(rp.Body)
+		if janusResponse.Status != "success" {
+			return "", model.NewLocAppError("registerWebrtcToken", "api.webrtc.register_token.app_error", nil, "")
+		}
+	}
+
+	return token, nil
+}
+
+func GenerateTurnPassword(username string, secret string) string {
+	key := []byte(secret)
+	h := hmac.New(sha1.New, key)
+	h.Write([]byte(username))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+func RevokeWebrtcToken(sessionId string) {
+	token := base64.StdEncoding.EncodeToString([]byte(sessionId))
+	data := make(map[string]string)
+	data["janus"] = "remove_token"
+	data["token"] = token
+	data["transaction"] = model.NewId()
+	data["admin_secret"] = *utils.Cfg.WebrtcSettings.GatewayAdminSecret
+
+	rq, _ := http.NewRequest("POST", *utils.Cfg.WebrtcSettings.GatewayAdminUrl, strings.NewReader(model.MapToJson(data)))
+	rq.Header.Set("Content-Type", "application/json")
+
+	// we do not care about the response
+	httpClient := NewTrustedHTTPClient()
+	httpClient.Do(rq)
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package clamav
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	// Files are streamed in chunks of this size. clamd still rejects files larger than its StreamMaxLength,
+	// which defaults to 25MB, no matter how they're chunked
+	CLAMAV_CHUNK_SIZE = 64 * 1024
+
+	CLAMAV_RESPONSE_OK    = "OK"
+	CLAMAV_RESPONSE_FOUND = " FOUND"
+	CLAMAV_RESPONSE_ERROR = " ERROR"
+)
+
+// ClamAVScanner scans files by streaming them to a clamd daemon using its INSTREAM command. The address of the
+// daemon is read from the config for each file so that changes to it take effect without a restart.
+type ClamAVScanner struct {
+}
+
+// InitFileScanner registers the ClamAV scanner unless another scanner has already been registered.
+func InitFileScanner() {
+	if einterfaces.GetFileScannerInterface() != nil {
+		return
+	}
+
+	einterfaces.RegisterFileScannerInterface(&ClamAVScanner{})
+}
+
+func (s *ClamAVScanner) ScanFile(data []byte) (string, *model.AppError) {
+	return scanFile(*utils.Cfg.FileSettings.ClamAVAddress, time.Duration(*utils.Cfg.FileSettings.FileScanTimeout)*time.Second, data)
+}
+
+func scanFile(address string, timeout time.Duration, data []byte) (string, *model.AppError) {
+	network := "tcp"
+	if strings.HasPrefix(address, "/") {
+		network = "unix"
+	}
+
+	conn, err := net.DialTimeout(network, address, timeout)
+	if err != nil {
+		return "", model.NewAppError("ScanFile", "clamav.scan_file.connect.app_error", nil, "address="+address+", err="+err.Error(), http.StatusServiceUnavailable)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	if err := writeStream(conn, data); err != nil {
+		return "", model.NewAppError("ScanFile", "clamav.scan_file.send.app_error", nil, "address="+address+", err="+err.Error(), http.StatusServiceUnavailable)
+	}
+
+	response, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && !(err == io.EOF && len(response) > 0) {
+		return "", model.NewAppError("ScanFile", "clamav.scan_file.receive.app_error", nil, "address="+address+", err="+err.Error(), http.StatusServiceUnavailable)
+	}
+
+	return parseResponse(strings.TrimRight(response, "\x00\n"))
+}
+
+// writeStream sends a file to clamd as a series of chunks, each prefixed with its length, followed by an
+// empty chunk to mark the end of the file.
+func writeStream(conn io.Writer, data []byte) error {
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return err
+	}
+
+	length := make([]byte, 4)
+	for start := 0; start < len(data); start += CLAMAV_CHUNK_SIZE {
+		end := start + CLAMAV_CHUNK_SIZE
+		if end > len(data) {
+			end = len(data)
+		}
+
+		binary.BigEndian.PutUint32(length, uint32(end-start))
+		if _, err := conn.Write(length); err != nil {
+			return err
+		}
+
+		if _, err := conn.Write(data[start:end]); err != nil {
+			return err
+		}
+	}
+
+	binary.BigEndian.PutUint32(length, 0)
+	_, err := conn.Write(length)
+	return err
+}
+
+// parseResponse reads a response like "stream: OK" or "stream: Eicar-Signature FOUND". Responses ending in
+// ERROR mean that clamd couldn't scan this file, like when it's larger than the StreamMaxLength, so retrying
+// it won't help.
+func parseResponse(response string) (string, *model.AppError) {
+	result := response
+	if index := strings.LastIndex(response, ": "); index != -1 {
+		result = response[index+2:]
+	}
+
+	if result == CLAMAV_RESPONSE_OK {
+		return "", nil
+	} else if strings.HasSuffix(result, CLAMAV_RESPONSE_FOUND) {
+		return strings.TrimSuffix(result, CLAMAV_RESPONSE_FOUND), nil
+	} else if strings.HasSuffix(result, CLAMAV_RESPONSE_ERROR) {
+		return "", model.NewAppError("ScanFile", "clamav.scan_file.error.app_error", nil, "response="+response, http.StatusBadRequest)
+	} else {
+		return "", model.NewAppError("ScanFile", "clamav.scan_file.response.app_error", nil, "response="+response, http.StatusServiceUnavailable)
+	}
+}
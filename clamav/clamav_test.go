@@ -0,0 +1,151 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package clamav
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/utils"
+)
+
+const EICAR_SIGNATURE = "EICAR-STANDARD-ANTIVIRUS-TEST-FILE"
+
+// startFakeClamd starts a server that speaks enough of the clamd protocol to receive a single file with
+// INSTREAM and reply to it.
+func startFakeClamd(t *testing.T, response func(data []byte) string) (string, chan []byte) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan []byte, 1)
+
+	go func() {
+		defer listener.Close()
+
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		if command, err := reader.ReadString(0); err != nil || command != "zINSTREAM\x00" {
+			conn.Write([]byte("UNKNOWN COMMAND\x00"))
+			return
+		}
+
+		var data bytes.Buffer
+		for {
+			var length uint32
+			if err := binary.Read(reader, binary.BigEndian, &length); err != nil {
+				return
+			} else if length == 0 {
+				break
+			}
+
+			if _, err := io.CopyN(&data, reader, int64(length)); err != nil {
+				return
+			}
+		}
+
+		received <- data.Bytes()
+		conn.Write([]byte(response(data.Bytes()) + "\x00"))
+	}()
+
+	return listener.Addr().String(), received
+}
+
+func eicarResponse(data []byte) string {
+	if bytes.Contains(data, []byte(EICAR_SIGNATURE)) {
+		return "stream: Eicar-Test-Signature FOUND"
+	}
+
+	return "stream: OK"
+}
+
+func TestScanFile(t *testing.T) {
+	address, received := startFakeClamd(t, eicarResponse)
+
+	data := bytes.Repeat([]byte("clean "), CLAMAV_CHUNK_SIZE/2)
+	if threat, err := scanFile(address, time.Second, data); err != nil {
+		t.Fatal(err)
+	} else if threat != "" {
+		t.Fatal("clean file should not have a threat, got " + threat)
+	}
+
+	if !bytes.Equal(<-received, data) {
+		t.Fatal("scanner received a different file than was sent over multiple chunks")
+	}
+}
+
+func TestScanFileInfected(t *testing.T) {
+	address, _ := startFakeClamd(t, eicarResponse)
+
+	if threat, err := scanFile(address, time.Second, []byte("X5O!P%@AP[4\\PZX54(P^)7CC)7}$"+EICAR_SIGNATURE+"!$H+H*")); err != nil {
+		t.Fatal(err)
+	} else if threat != "Eicar-Test-Signature" {
+		t.Fatal("should have found test signature, got " + threat)
+	}
+}
+
+func TestScanFileError(t *testing.T) {
+	address, _ := startFakeClamd(t, func(data []byte) string {
+		return "INSTREAM size limit exceeded. ERROR"
+	})
+
+	if _, err := scanFile(address, time.Second, []byte("data")); err == nil {
+		t.Fatal("should have failed on an error response")
+	} else if err.StatusCode == http.StatusServiceUnavailable {
+		t.Fatal("shouldn't treat an error scanning the file as the scanner being unavailable")
+	}
+
+	listener, _ := net.Listen("tcp", "127.0.0.1:0")
+	listener.Close()
+
+	if _, err := scanFile(listener.Addr().String(), time.Second, []byte("data")); err == nil {
+		t.Fatal("should have failed to connect")
+	}
+}
+
+func TestClamAVScanner(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+
+	address, _ := startFakeClamd(t, eicarResponse)
+	*utils.Cfg.FileSettings.ClamAVAddress = address
+
+	var scanner einterfaces.FileScannerInterface = &ClamAVScanner{}
+	if threat, err := scanner.ScanFile([]byte("clean")); err != nil {
+		t.Fatal(err)
+	} else if threat != "" {
+		t.Fatal("clean file should not have a threat, got " + threat)
+	}
+}
+
+func TestParseResponse(t *testing.T) {
+	for response, expected := range map[string]string{
+		"stream: OK":                         "",
+		"stream: Win.Test.EICAR_HDB-1 FOUND": "Win.Test.EICAR_HDB-1",
+		"1: stream: OK":                      "",
+	} {
+		if threat, err := parseResponse(response); err != nil {
+			t.Fatal(err)
+		} else if threat != expected {
+			t.Fatalf("expected %q from %q, got %q", expected, response, threat)
+		}
+	}
+
+	if _, err := parseResponse("stream: Can't allocate memory ERROR"); err == nil {
+		t.Fatal("should have failed on error")
+	}
+}
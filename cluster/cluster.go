@@ -0,0 +1,505 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package cluster
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	HEADER_CLUSTER_SIGNATURE = "X-Cluster-Signature"
+	HEADER_CLUSTER_TIMESTAMP = "X-Cluster-Timestamp"
+
+	// Signed requests older than this are rejected so that captured requests can't be replayed later. It
+	// also allows for some difference between the clocks of the nodes.
+	SIGNATURE_MAX_AGE = time.Second * 30
+
+	// The v3 API reads up to this many lines of logs at once
+	LOGS_MAX_PER_PAGE = 100000
+
+	PING_FREQUENCY   = time.Second * 15
+	REQUEST_TIMEOUT  = time.Second * 5
+	SEND_QUEUE_SIZE  = 4096
+	SEND_RETRY_COUNT = 3
+
+	ROUTE_MESSAGE = "/cluster/message"
+	ROUTE_INFO    = "/cluster/info"
+	ROUTE_STATS   = "/cluster/stats"
+	ROUTE_LOGS    = "/cluster/logs"
+)
+
+type ClusterMessageHandler func(msg *model.ClusterMessage)
+
+type InterNodeCluster struct {
+	discovery      *model.ClusterDiscovery
+	discoveryStore store.ClusterDiscoveryStore
+	listenAddress  string
+	staticUrls     []string
+	listener       net.Listener
+	server         *http.Server
+	client         *http.Client
+	handlers       map[string]ClusterMessageHandler
+	peers          map[string]*interNodePeer
+	peersMutex     sync.RWMutex
+	stop           chan bool
+	stopped        chan bool
+}
+
+// NewInterNodeCluster creates a cluster node that listens on listenAddress, talks to the static
+// peerUrls and, when discoveryStore is not nil, advertises itself to and discovers other nodes
+// through the ClusterDiscovery table.
+func NewInterNodeCluster(listenAddress string, peerUrls []string, discoveryStore store.ClusterDiscoveryStore) *InterNodeCluster {
+	c := &InterNodeCluster{
+		discovery: &model.ClusterDiscovery{
+			Id:      model.NewId(),
+			Type:    model.CDS_TYPE_APP,
+			Version: model.CurrentVersion,
+		},
+		discoveryStore: discoveryStore,
+		listenAddress:  listenAddress,
+		staticUrls:     peerUrls,
+		client:         &http.Client{Timeout: REQUEST_TIMEOUT},
+		peers:          make(map[string]*interNodePeer),
+	}
+
+	c.discovery.AutoFillHostname()
+	c.handlers = defaultMessageHandlers()
+
+	return c
+}
+
+// InitInterNodeCluster registers the built-in cluster implementation from the current configuration
+// unless another implementation has already been registered.
+func InitInterNodeCluster() {
+	if einterfaces.GetClusterInterface() != nil || !*utils.Cfg.ClusterSettings.Enable {
+		return
+	}
+
+	einterfaces.RegisterClusterInterface(NewInterNodeCluster(
+		*utils.Cfg.ClusterSettings.InterNodeListenAddress,
+		utils.Cfg.ClusterSettings.InterNodeUrls,
+		app.Srv.Store.ClusterDiscovery(),
+	))
+}
+
+func (c *InterNodeCluster) RegisterMessageHandler(event string, handler ClusterMessageHandler) {
+	c.handlers[event] = handler
+}
+
+func (c *InterNodeCluster) StartInterNodeCommunication() {
+	listener, err := net.Listen("tcp", c.listenAddress)
+	if err != nil {
+		l4g.Error(utils.T("cluster.start.listen.error"), c.listenAddress, err.Error())
+		return
+	}
+
+	c.listener = listener
+	c.discovery.InterNodeUrl = c.advertisedUrl()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(ROUTE_MESSAGE, c.handleMessage)
+	mux.HandleFunc(ROUTE_INFO, c.handleInfo)
+	mux.HandleFunc(ROUTE_STATS, c.handleStats)
+	mux.HandleFunc(ROUTE_LOGS, c.handleLogs)
+
+	c.server = &http.Server{Handler: mux}
+	go c.server.Serve(listener)
+
+	if c.discoveryStore != nil {
+		if result := <-c.discoveryStore.Save(c.discovery); result.Err != nil {
+			l4g.Error(utils.T("cluster.start.discovery.error"), result.Err.Error())
+		}
+	}
+
+	l4g.Info(utils.T("cluster.start.listening.info"), c.discovery.InterNodeUrl, c.discovery.Hostname, c.discovery.Id)
+
+	c.stop = make(chan bool)
+	c.stopped = make(chan bool)
+	c.refreshPeers()
+
+	go func() {
+		ticker := time.NewTicker(PING_FREQUENCY)
+		defer func() {
+			ticker.Stop()
+			close(c.stopped)
+		}()
+
+		for {
+			select {
+			case <-ticker.C:
+				c.refreshPeers()
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+func (c *InterNodeCluster) StopInterNodeCommunication() {
+	if c.listener == nil {
+		return
+	}
+
+	close(c.stop)
+	<-c.stopped
+
+	c.server.Close()
+	c.listener = nil
+
+	c.peersMutex.Lock()
+	for url, peer := range c.peers {
+		peer.close()
+		delete(c.peers, url)
+	}
+	c.peersMutex.Unlock()
+
+	if c.discoveryStore != nil {
+		<-c.discoveryStore.Delete(c.discovery.Id)
+	}
+
+	l4g.Info(utils.T("cluster.stop.info"), c.discovery.Id)
+}
+
+func (c *InterNodeCluster) advertisedUrl() string {
+	host, port, _ := net.SplitHostPort(c.listener.Addr().String())
+
+	if configuredHost, _, err := net.SplitHostPort(c.listenAddress); err == nil && len(configuredHost) > 0 {
+		host = configuredHost
+	} else if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() {
+		host = c.discovery.Hostname
+	}
+
+	return "http://" + net.JoinHostPort(host, port)
+}
+
+// refreshPeers records that this node is alive and reconciles the peer list against the static
+// InterNodeUrls and the nodes currently advertised in the ClusterDiscovery table.
+func (c *InterNodeCluster) refreshPeers() {
+	urls := make(map[string]bool)
+	for _, url := range c.staticUrls {
+		urls[strings.TrimRight(url, "/")] = true
+	}
+
+	if c.discoveryStore != nil {
+		if result := <-c.discoveryStore.SetLastPingAt(c.discovery.Id); result.Err != nil {
+			// Our entry was likely cleaned up by another node while we were unreachable
+			c.discovery.LastPingAt = model.GetMillis()
+			<-c.discoveryStore.Save(c.discovery)
+		}
+
+		if result := <-c.discoveryStore.GetAll(model.CDS_TYPE_APP); result.Err != nil {
+			l4g.Error(utils.T("cluster.refresh_peers.discovery.error"), result.Err.Error())
+		} else {
+			for _, discovery := range result.Data.([]*model.ClusterDiscovery) {
+				if discovery.Id != c.discovery.Id && discovery.IsAlive() {
+					urls[discovery.InterNodeUrl] = true
+				}
+			}
+		}
+
+		<-c.discoveryStore.Cleanup()
+	}
+
+	delete(urls, c.discovery.InterNodeUrl)
+
+	c.peersMutex.Lock()
+	for url := range urls {
+		if _, ok := c.peers[url]; !ok {
+			c.peers[url] = newInterNodePeer(c, url)
+		}
+	}
+
+	for url, peer := range c.peers {
+		if !urls[url] {
+			peer.close()
+			delete(c.peers, url)
+		}
+	}
+	c.peersMutex.Unlock()
+
+	for _, peer := range c.getPeers() {
+		go peer.ping()
+	}
+}
+
+func (c *InterNodeCluster) getPeers() []*interNodePeer {
+	c.peersMutex.RLock()
+	defer c.peersMutex.RUnlock()
+
+	peers := make([]*interNodePeer, 0, len(c.peers))
+	for _, peer := range c.peers {
+		peers = append(peers, peer)
+	}
+
+	return peers
+}
+
+func (c *InterNodeCluster) getInfo() *model.ClusterInfo {
+	return &model.ClusterInfo{
+		Id:                 c.discovery.Id,
+		Version:            model.CurrentVersion,
+		ConfigHash:         utils.CfgHash,
+		InterNodeUrl:       c.discovery.InterNodeUrl,
+		Hostname:           c.discovery.Hostname,
+		LastSuccessfulPing: model.GetMillis(),
+		IsAlive:            true,
+	}
+}
+
+func (c *InterNodeCluster) sendToAll(msg *model.ClusterMessage) {
+	for _, peer := range c.getPeers() {
+		peer.send(msg)
+	}
+}
+
+func (c *InterNodeCluster) GetClusterInfos() []*model.ClusterInfo {
+	infos := []*model.ClusterInfo{c.getInfo()}
+
+	for _, peer := range c.getPeers() {
+		infos = append(infos, peer.getInfo())
+	}
+
+	return infos
+}
+
+func (c *InterNodeCluster) GetClusterStats() ([]*model.ClusterStats, *model.AppError) {
+	stats := make([]*model.ClusterStats, 0)
+
+	for _, peer := range c.getPeers() {
+		if body, err := peer.request("GET", ROUTE_STATS, ""); err != nil {
+			return nil, err
+		} else if stat := model.ClusterStatsFromJson(bytes.NewReader(body)); stat != nil {
+			stats = append(stats, stat)
+		}
+	}
+
+	return stats, nil
+}
+
+func (c *InterNodeCluster) GetLogs(page, perPage int) ([]string, *model.AppError) {
+	lines := make([]string, 0)
+
+	for _, peer := range c.getPeers() {
+		body, err := peer.request("GET", ROUTE_LOGS+"?page="+strconv.Itoa(page)+"&per_page="+strconv.Itoa(perPage), "")
+		if err != nil {
+			return nil, err
+		}
+
+		var peerLines []string
+		if err := json.Unmarshal(body, &peerLines); err != nil {
+			return nil, model.NewLocAppError("GetLogs", "cluster.request.decode.app_error", nil, "url="+peer.url+", "+err.Error())
+		}
+
+		lines = append(lines, peerLines...)
+	}
+
+	return lines, nil
+}
+
+func (c *InterNodeCluster) GetClusterId() string {
+	return c.discovery.Id
+}
+
+func (c *InterNodeCluster) ClearSessionCacheForUser(userId string) {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_CLEAR_SESSION_CACHE_FOR_USER, SendType: model.CLUSTER_SEND_RELIABLE, Data: userId})
+}
+
+func (c *InterNodeCluster) InvalidateCacheForUser(userId string) {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER, SendType: model.CLUSTER_SEND_RELIABLE, Data: userId})
+}
+
+func (c *InterNodeCluster) InvalidateCacheForChannel(channelId string) {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL, SendType: model.CLUSTER_SEND_RELIABLE, Data: channelId})
+}
+
+func (c *InterNodeCluster) InvalidateCacheForChannelByName(teamId, name string) {
+	c.sendToAll(&model.ClusterMessage{
+		Event:    model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_BY_NAME,
+		SendType: model.CLUSTER_SEND_RELIABLE,
+		Props:    map[string]string{"team_id": teamId, "name": name},
+	})
+}
+
+func (c *InterNodeCluster) InvalidateCacheForChannelMembers(channelId string) {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_MEMBERS, SendType: model.CLUSTER_SEND_RELIABLE, Data: channelId})
+}
+
+func (c *InterNodeCluster) InvalidateCacheForChannelMembersNotifyProps(channelId string) {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_MEMBERS_NOTIFY_PROPS, SendType: model.CLUSTER_SEND_RELIABLE, Data: channelId})
+}
+
+func (c *InterNodeCluster) InvalidateCacheForChannelPosts(channelId string) {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_POSTS, SendType: model.CLUSTER_SEND_RELIABLE, Data: channelId})
+}
+
+func (c *InterNodeCluster) InvalidateCacheForWebhook(webhookId string) {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_WEBHOOK, SendType: model.CLUSTER_SEND_RELIABLE, Data: webhookId})
+}
+
+func (c *InterNodeCluster) InvalidateCacheForReactions(postId string) {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_REACTIONS, SendType: model.CLUSTER_SEND_RELIABLE, Data: postId})
+}
+
+func (c *InterNodeCluster) InvalidateAllCaches() *model.AppError {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_ALL_CACHES, SendType: model.CLUSTER_SEND_RELIABLE})
+	return nil
+}
+
+func (c *InterNodeCluster) Publish(event *model.WebSocketEvent) {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_PUBLISH, SendType: model.CLUSTER_SEND_BEST_EFFORT, Data: event.ToJson()})
+}
+
+func (c *InterNodeCluster) UpdateStatus(status *model.Status) {
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_UPDATE_STATUS, SendType: model.CLUSTER_SEND_BEST_EFFORT, Data: status.ToJson()})
+}
+
+func (c *InterNodeCluster) ConfigChanged(previousConfig *model.Config, newConfig *model.Config, sendToOtherServer bool) *model.AppError {
+	if !sendToOtherServer {
+		return nil
+	}
+
+	if previousConfig.ClusterSettings.InterNodeListenAddress != nil && newConfig.ClusterSettings.InterNodeListenAddress != nil &&
+		*previousConfig.ClusterSettings.InterNodeListenAddress != *newConfig.ClusterSettings.InterNodeListenAddress {
+		l4g.Warn(utils.T("cluster.config_changed.listen_address.warn"), c.discovery.Id)
+	}
+
+	c.sendToAll(&model.ClusterMessage{Event: model.CLUSTER_EVENT_CONFIG_CHANGED, SendType: model.CLUSTER_SEND_RELIABLE, Data: newConfig.ToJson()})
+	return nil
+}
+
+// sign returns the signature of a request, which covers its method, path and query, the time it was sent and
+// its body so that a signature can't be reused for a different request.
+func (c *InterNodeCluster) sign(method, uri, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(utils.Cfg.SqlSettings.AtRestEncryptKey))
+	mac.Write([]byte(method + "\n" + uri + "\n" + timestamp + "\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// signRequest sets the headers of a request to another node with the current time and the request's signature.
+func (c *InterNodeCluster) signRequest(req *http.Request, body []byte) {
+	timestamp := strconv.FormatInt(model.GetMillis(), 10)
+	req.Header.Set(HEADER_CLUSTER_TIMESTAMP, timestamp)
+	req.Header.Set(HEADER_CLUSTER_SIGNATURE, c.sign(req.Method, req.URL.RequestURI(), timestamp, body))
+}
+
+// verify reads the request body and checks it was recently signed by a node sharing our configuration
+func (c *InterNodeCluster) verify(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	timestamp := r.Header.Get(HEADER_CLUSTER_TIMESTAMP)
+	if sentAt, err := strconv.ParseInt(timestamp, 10, 64); err != nil || math.Abs(float64(model.GetMillis()-sentAt)) > float64(SIGNATURE_MAX_AGE/time.Millisecond) {
+		l4g.Warn(utils.T("cluster.verify.timestamp.warn"), utils.GetIpAddress(r))
+		http.Error(w, "invalid timestamp", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	signature, _ := hex.DecodeString(r.Header.Get(HEADER_CLUSTER_SIGNATURE))
+	expected, _ := hex.DecodeString(c.sign(r.Method, r.URL.RequestURI(), timestamp, body))
+	if !hmac.Equal(signature, expected) {
+		l4g.Warn(utils.T("cluster.verify.signature.warn"), utils.GetIpAddress(r))
+		http.Error(w, "invalid signature", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return body, true
+}
+
+func (c *InterNodeCluster) handleMessage(w http.ResponseWriter, r *http.Request) {
+	body, ok := c.verify(w, r)
+	if !ok {
+		return
+	}
+
+	msg := model.ClusterMessageFromJson(bytes.NewReader(body))
+	if msg == nil {
+		http.Error(w, "invalid message", http.StatusBadRequest)
+		return
+	}
+
+	if handler, ok := c.handlers[msg.Event]; ok {
+		handler(msg)
+	} else {
+		l4g.Warn(utils.T("cluster.handle_message.unknown_event.warn"), msg.Event)
+	}
+
+	w.Write([]byte(model.MapToJson(map[string]string{model.STATUS: model.STATUS_OK})))
+}
+
+func (c *InterNodeCluster) handleInfo(w http.ResponseWriter, r *http.Request) {
+	if _, ok := c.verify(w, r); !ok {
+		return
+	}
+
+	w.Write([]byte(c.getInfo().ToJson()))
+}
+
+func (c *InterNodeCluster) handleStats(w http.ResponseWriter, r *http.Request) {
+	if _, ok := c.verify(w, r); !ok {
+		return
+	}
+
+	stats := &model.ClusterStats{
+		Id:                        c.discovery.Id,
+		TotalWebsocketConnections: app.TotalWebsocketConnections(),
+	}
+
+	if app.Srv != nil && app.Srv.Store != nil {
+		stats.TotalMasterDbConnections = app.Srv.Store.TotalMasterDbConnections()
+		stats.TotalReadDbConnections = app.Srv.Store.TotalReadDbConnections()
+	}
+
+	w.Write([]byte(stats.ToJson()))
+}
+
+func (c *InterNodeCluster) handleLogs(w http.ResponseWriter, r *http.Request) {
+	if _, ok := c.verify(w, r); !ok {
+		return
+	}
+
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 0 {
+		http.Error(w, "invalid page", http.StatusBadRequest)
+		return
+	}
+
+	perPage, err := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if err != nil || perPage <= 0 || perPage > LOGS_MAX_PER_PAGE || page > math.MaxInt32/perPage {
+		http.Error(w, "invalid per_page", http.StatusBadRequest)
+		return
+	}
+
+	lines, appErr := app.GetLogsSkipSend(page, perPage)
+	if appErr != nil {
+		http.Error(w, appErr.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	b, _ := json.Marshal(lines)
+	w.Write(b)
+}
@@ -0,0 +1,388 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package cluster
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/store"
+	"github.com/mattermost/platform/utils"
+)
+
+// memoryDiscoveryStore lets several nodes in one process discover each other without a database
+type memoryDiscoveryStore struct {
+	sync.Mutex
+	discoveries map[string]*model.ClusterDiscovery
+}
+
+func newMemoryDiscoveryStore() *memoryDiscoveryStore {
+	return &memoryDiscoveryStore{discoveries: make(map[string]*model.ClusterDiscovery)}
+}
+
+func (s *memoryDiscoveryStore) result(data interface{}, err *model.AppError) store.StoreChannel {
+	storeChannel := make(store.StoreChannel, 1)
+	storeChannel <- store.StoreResult{Data: data, Err: err}
+	close(storeChannel)
+	return storeChannel
+}
+
+func (s *memoryDiscoveryStore) Save(discovery *model.ClusterDiscovery) store.StoreChannel {
+	s.Lock()
+	defer s.Unlock()
+
+	discovery.PreSave()
+	if err := discovery.IsValid(); err != nil {
+		return s.result(nil, err)
+	}
+
+	copy := *discovery
+	s.discoveries[discovery.Id] = &copy
+	return s.result(discovery, nil)
+}
+
+func (s *memoryDiscoveryStore) Delete(id string) store.StoreChannel {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.discoveries, id)
+	return s.result(nil, nil)
+}
+
+func (s *memoryDiscoveryStore) GetAll(discoveryType string) store.StoreChannel {
+	s.Lock()
+	defer s.Unlock()
+
+	discoveries := []*model.ClusterDiscovery{}
+	for _, discovery := range s.discoveries {
+		if discovery.Type == discoveryType && discovery.IsAlive() {
+			copy := *discovery
+			discoveries = append(discoveries, &copy)
+		}
+	}
+
+	return s.result(discoveries, nil)
+}
+
+func (s *memoryDiscoveryStore) SetLastPingAt(id string) store.StoreChannel {
+	s.Lock()
+	defer s.Unlock()
+
+	if discovery, ok := s.discoveries[id]; ok {
+		discovery.LastPingAt = model.GetMillis()
+		return s.result(nil, nil)
+	}
+
+	return s.result(nil, model.NewLocAppError("SetLastPingAt", "store.sql_cluster_discovery.set_last_ping_at.app_error", nil, ""))
+}
+
+func (s *memoryDiscoveryStore) Cleanup() store.StoreChannel {
+	s.Lock()
+	defer s.Unlock()
+
+	for id, discovery := range s.discoveries {
+		if !discovery.IsAlive() {
+			delete(s.discoveries, id)
+		}
+	}
+
+	return s.result(nil, nil)
+}
+
+type receivedMessages struct {
+	sync.Mutex
+	messages []*model.ClusterMessage
+}
+
+func (r *receivedMessages) handler(msg *model.ClusterMessage) {
+	r.Lock()
+	defer r.Unlock()
+	r.messages = append(r.messages, msg)
+}
+
+func (r *receivedMessages) waitFor(t *testing.T, count int) []*model.ClusterMessage {
+	for i := 0; i < 50; i++ {
+		r.Lock()
+		if len(r.messages) >= count {
+			messages := r.messages
+			r.Unlock()
+			return messages
+		}
+		r.Unlock()
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	t.Fatalf("timed out waiting for %v messages", count)
+	return nil
+}
+
+func setupTestCluster(t *testing.T, size int) ([]*InterNodeCluster, *memoryDiscoveryStore) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	discoveryStore := newMemoryDiscoveryStore()
+
+	nodes := make([]*InterNodeCluster, size)
+	for i := range nodes {
+		nodes[i] = NewInterNodeCluster("127.0.0.1:0", []string{}, discoveryStore)
+		nodes[i].StartInterNodeCommunication()
+		if nodes[i].listener == nil {
+			t.Fatal("node should have started listening")
+		}
+	}
+
+	// let every node pick up the nodes started after it
+	for _, node := range nodes {
+		node.refreshPeers()
+	}
+
+	return nodes, discoveryStore
+}
+
+func stopTestCluster(nodes []*InterNodeCluster) {
+	for _, node := range nodes {
+		node.StopInterNodeCommunication()
+	}
+}
+
+func TestClusterDiscovery(t *testing.T) {
+	nodes, discoveryStore := setupTestCluster(t, 3)
+	defer stopTestCluster(nodes)
+
+	for _, node := range nodes {
+		if peers := node.getPeers(); len(peers) != 2 {
+			t.Fatal("each node should have discovered the other two nodes")
+		}
+
+		if node.GetClusterId() != node.discovery.Id {
+			t.Fatal("cluster id should be the node's discovery id")
+		}
+	}
+
+	nodes[2].StopInterNodeCommunication()
+
+	if result := <-discoveryStore.GetAll(model.CDS_TYPE_APP); len(result.Data.([]*model.ClusterDiscovery)) != 2 {
+		t.Fatal("stopped node should have removed its discovery entry")
+	}
+
+	nodes[0].refreshPeers()
+	if peers := nodes[0].getPeers(); len(peers) != 1 || peers[0].url != nodes[1].discovery.InterNodeUrl {
+		t.Fatal("stopped node should have been dropped from the peer list")
+	}
+}
+
+func TestClusterStaticPeers(t *testing.T) {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+
+	node1 := NewInterNodeCluster("127.0.0.1:0", []string{}, nil)
+	node1.StartInterNodeCommunication()
+	defer node1.StopInterNodeCommunication()
+
+	node2 := NewInterNodeCluster("127.0.0.1:0", []string{node1.discovery.InterNodeUrl + "/"}, nil)
+	node2.StartInterNodeCommunication()
+	defer node2.StopInterNodeCommunication()
+
+	received := &receivedMessages{}
+	node1.RegisterMessageHandler(model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER, received.handler)
+
+	node2.InvalidateCacheForUser("user1")
+
+	if messages := received.waitFor(t, 1); messages[0].Data != "user1" {
+		t.Fatal("wrong user id received")
+	}
+}
+
+func TestClusterMessagePassing(t *testing.T) {
+	nodes, _ := setupTestCluster(t, 3)
+	defer stopTestCluster(nodes)
+
+	received := make([]*receivedMessages, len(nodes))
+	for i, node := range nodes {
+		received[i] = &receivedMessages{}
+		node.RegisterMessageHandler(model.CLUSTER_EVENT_PUBLISH, received[i].handler)
+		node.RegisterMessageHandler(model.CLUSTER_EVENT_UPDATE_STATUS, received[i].handler)
+		node.RegisterMessageHandler(model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_BY_NAME, received[i].handler)
+		node.RegisterMessageHandler(model.CLUSTER_EVENT_CONFIG_CHANGED, received[i].handler)
+	}
+
+	event := model.NewWebSocketEvent(model.WEBSOCKET_EVENT_POSTED, "team", "channel", "user", nil)
+	nodes[0].Publish(event)
+	nodes[0].UpdateStatus(&model.Status{UserId: "user", Status: model.STATUS_ONLINE})
+	nodes[0].InvalidateCacheForChannelByName("team", "town-square")
+
+	for i := 1; i < len(nodes); i++ {
+		messages := received[i].waitFor(t, 3)
+
+		if messages[0].Event != model.CLUSTER_EVENT_PUBLISH {
+			t.Fatal("messages should arrive in order")
+		} else if published := model.WebSocketEventFromJson(bytes.NewReader([]byte(messages[0].Data))); published == nil || published.Event != model.WEBSOCKET_EVENT_POSTED {
+			t.Fatal("published event should have been received intact")
+		}
+
+		if messages[1].Event != model.CLUSTER_EVENT_UPDATE_STATUS {
+			t.Fatal("status update should have been received")
+		}
+
+		if messages[2].Props["team_id"] != "team" || messages[2].Props["name"] != "town-square" {
+			t.Fatal("channel name invalidation should carry the team and name")
+		}
+	}
+
+	received[0].Lock()
+	if len(received[0].messages) != 0 {
+		t.Fatal("the sending node should not receive its own messages")
+	}
+	received[0].Unlock()
+
+	if err := nodes[1].ConfigChanged(utils.Cfg, utils.Cfg, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := nodes[1].ConfigChanged(utils.Cfg, utils.Cfg, true); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, expected := range map[int]int{0: 1, 2: 4} {
+		messages := received[i].waitFor(t, expected)
+		if last := messages[len(messages)-1]; len(messages) != expected || last.Event != model.CLUSTER_EVENT_CONFIG_CHANGED {
+			t.Fatal("config should have been sent exactly once")
+		} else if cfg := model.ConfigFromJson(bytes.NewReader([]byte(last.Data))); cfg == nil {
+			t.Fatal("config should have been received intact")
+		}
+	}
+}
+
+func TestClusterInfosAndStats(t *testing.T) {
+	nodes, _ := setupTestCluster(t, 3)
+	defer stopTestCluster(nodes)
+
+	// pings happen asynchronously after the peers are refreshed
+	for _, peer := range nodes[0].getPeers() {
+		peer.ping()
+	}
+
+	infos := nodes[0].GetClusterInfos()
+	if len(infos) != 3 {
+		t.Fatal("should have returned info for every node")
+	}
+
+	ids := map[string]bool{}
+	for _, info := range infos {
+		if !info.IsAlive {
+			t.Fatal("every node should be alive")
+		}
+
+		if info.Version != model.CurrentVersion {
+			t.Fatal("wrong version")
+		}
+
+		ids[info.Id] = true
+	}
+
+	for _, node := range nodes {
+		if !ids[node.discovery.Id] {
+			t.Fatal("missing info for node " + node.discovery.Id)
+		}
+	}
+
+	if stats, err := nodes[0].GetClusterStats(); err != nil {
+		t.Fatal(err)
+	} else if len(stats) != 2 {
+		t.Fatal("should have returned stats for the other nodes")
+	}
+
+	nodes[2].StopInterNodeCommunication()
+
+	for _, peer := range nodes[0].getPeers() {
+		peer.ping()
+	}
+
+	alive := 0
+	for _, info := range nodes[0].GetClusterInfos() {
+		if info.IsAlive {
+			alive++
+		}
+	}
+
+	if alive != 2 {
+		t.Fatal("stopped node should not be reported as alive")
+	}
+}
+
+func TestClusterRejectsUnsignedRequests(t *testing.T) {
+	nodes, _ := setupTestCluster(t, 1)
+	defer stopTestCluster(nodes)
+
+	received := &receivedMessages{}
+	nodes[0].RegisterMessageHandler(model.CLUSTER_EVENT_INVALIDATE_ALL_CACHES, received.handler)
+
+	body := (&model.ClusterMessage{Event: model.CLUSTER_EVENT_INVALIDATE_ALL_CACHES}).ToJson()
+
+	if resp, err := http.Post(nodes[0].discovery.InterNodeUrl+ROUTE_MESSAGE, "application/json", bytes.NewReader([]byte(body))); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("unsigned request should have been rejected")
+	}
+
+	req, _ := http.NewRequest("POST", nodes[0].discovery.InterNodeUrl+ROUTE_MESSAGE, bytes.NewReader([]byte(body)))
+	nodes[0].signRequest(req, []byte(body+" "))
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("request with a mismatched signature should have been rejected")
+	}
+
+	// A signature for one route can't be used for another
+	req, _ = http.NewRequest("POST", nodes[0].discovery.InterNodeUrl+ROUTE_MESSAGE, bytes.NewReader([]byte(body)))
+	nodes[0].signRequest(req, []byte(body))
+	req.URL.Path = ROUTE_STATS
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("request signed for a different route should have been rejected")
+	}
+
+	timestamp := strconv.FormatInt(model.GetMillis()-int64(2*SIGNATURE_MAX_AGE/time.Millisecond), 10)
+	req, _ = http.NewRequest("POST", nodes[0].discovery.InterNodeUrl+ROUTE_MESSAGE, bytes.NewReader([]byte(body)))
+	req.Header.Set(HEADER_CLUSTER_TIMESTAMP, timestamp)
+	req.Header.Set(HEADER_CLUSTER_SIGNATURE, nodes[0].sign("POST", ROUTE_MESSAGE, timestamp, []byte(body)))
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatal("request signed too long ago should have been rejected")
+	}
+
+	req, _ = http.NewRequest("POST", nodes[0].discovery.InterNodeUrl+ROUTE_MESSAGE, bytes.NewReader([]byte(body)))
+	nodes[0].signRequest(req, []byte(body))
+	if resp, err := http.DefaultClient.Do(req); err != nil {
+		t.Fatal(err)
+	} else if resp.StatusCode != http.StatusOK {
+		t.Fatal("signed request should have been accepted")
+	}
+
+	received.waitFor(t, 1)
+}
+
+func TestClusterLogsValidatesPaging(t *testing.T) {
+	nodes, _ := setupTestCluster(t, 1)
+	defer stopTestCluster(nodes)
+
+	for _, query := range []string{"", "?page=-1&per_page=10", "?page=0&per_page=0", "?page=0&per_page=1000000", "?page=2147483647&per_page=10"} {
+		req, _ := http.NewRequest("GET", nodes[0].discovery.InterNodeUrl+ROUTE_LOGS+query, nil)
+		nodes[0].signRequest(req, nil)
+		if resp, err := http.DefaultClient.Do(req); err != nil {
+			t.Fatal(err)
+		} else if resp.StatusCode != http.StatusBadRequest {
+			t.Fatal("should have rejected invalid paging " + query)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package cluster
+
+import (
+	"strings"
+
+	l4g "github.com/alecthomas/log4go"
+
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+// defaultMessageHandlers applies messages received from other nodes to this node only, using the
+// SkipClusterSend variants so that a message is never echoed back into the cluster.
+func defaultMessageHandlers() map[string]ClusterMessageHandler {
+	return map[string]ClusterMessageHandler{
+		model.CLUSTER_EVENT_PUBLISH: func(msg *model.ClusterMessage) {
+			if event := model.WebSocketEventFromJson(strings.NewReader(msg.Data)); event != nil {
+				app.PublishSkipClusterSend(event)
+			}
+		},
+		model.CLUSTER_EVENT_UPDATE_STATUS: func(msg *model.ClusterMessage) {
+			if status := model.StatusFromJson(strings.NewReader(msg.Data)); status != nil {
+				app.AddStatusCacheSkipClusterSend(status)
+			}
+		},
+		model.CLUSTER_EVENT_INVALIDATE_ALL_CACHES: func(msg *model.ClusterMessage) {
+			app.InvalidateAllCachesSkipSend()
+		},
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_REACTIONS: func(msg *model.ClusterMessage) {
+			app.InvalidateCacheForReactionsSkipClusterSend(msg.Data)
+		},
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_WEBHOOK: func(msg *model.ClusterMessage) {
+			app.InvalidateCacheForWebhookSkipClusterSend(msg.Data)
+		},
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_POSTS: func(msg *model.ClusterMessage) {
+			app.InvalidateCacheForChannelPostsSkipClusterSend(msg.Data)
+		},
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_MEMBERS_NOTIFY_PROPS: func(msg *model.ClusterMessage) {
+			app.InvalidateCacheForChannelMembersNotifyPropsSkipClusterSend(msg.Data)
+		},
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_MEMBERS: func(msg *model.ClusterMessage) {
+			app.InvalidateCacheForChannelMembersSkipClusterSend(msg.Data)
+		},
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL_BY_NAME: func(msg *model.ClusterMessage) {
+			app.InvalidateCacheForChannelByNameSkipClusterSend(msg.Props["team_id"], msg.Props["name"])
+		},
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_CHANNEL: func(msg *model.ClusterMessage) {
+			app.InvalidateCacheForChannelSkipClusterSend(msg.Data)
+		},
+		model.CLUSTER_EVENT_INVALIDATE_CACHE_FOR_USER: func(msg *model.ClusterMessage) {
+			app.InvalidateCacheForUserSkipClusterSend(msg.Data)
+		},
+		model.CLUSTER_EVENT_CLEAR_SESSION_CACHE_FOR_USER: func(msg *model.ClusterMessage) {
+			app.ClearSessionCacheForUserSkipClusterSend(msg.Data)
+		},
+		model.CLUSTER_EVENT_CONFIG_CHANGED: func(msg *model.ClusterMessage) {
+			cfg := model.ConfigFromJson(strings.NewReader(msg.Data))
+			if cfg == nil {
+				return
+			}
+
+			l4g.Info(utils.T("cluster.config_changed.info"))
+			if err := app.SaveConfigSkipClusterSend(cfg); err != nil {
+				l4g.Error(utils.T("cluster.config_changed.save.error"), err.Error())
+			}
+		},
+	}
+}
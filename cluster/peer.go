@@ -0,0 +1,158 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package cluster
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/model"
+	"github.com/mattermost/platform/utils"
+)
+
+type interNodePeer struct {
+	cluster   *InterNodeCluster
+	url       string
+	queue     chan *model.ClusterMessage
+	done      chan bool
+	info      *model.ClusterInfo
+	infoMutex sync.RWMutex
+}
+
+func newInterNodePeer(c *InterNodeCluster, url string) *interNodePeer {
+	p := &interNodePeer{
+		cluster: c,
+		url:     url,
+		queue:   make(chan *model.ClusterMessage, SEND_QUEUE_SIZE),
+		done:    make(chan bool),
+		info:    &model.ClusterInfo{InterNodeUrl: url},
+	}
+
+	go p.sendLoop()
+
+	return p
+}
+
+func (p *interNodePeer) close() {
+	close(p.done)
+}
+
+// send queues a message for delivery so that callers such as Publish never block on the network.
+// Messages are delivered in order by a single goroutine per peer.
+func (p *interNodePeer) send(msg *model.ClusterMessage) {
+	select {
+	case p.queue <- msg:
+	default:
+		l4g.Error(utils.T("cluster.send.queue_full.error"), p.url, msg.Event)
+	}
+}
+
+func (p *interNodePeer) sendLoop() {
+	for {
+		select {
+		case msg := <-p.queue:
+			p.deliver(msg)
+		case <-p.done:
+			return
+		}
+	}
+}
+
+func (p *interNodePeer) deliver(msg *model.ClusterMessage) {
+	body := msg.ToJson()
+
+	retries := 1
+	if msg.SendType == model.CLUSTER_SEND_RELIABLE {
+		retries = SEND_RETRY_COUNT
+	}
+
+	for i := 1; i <= retries; i++ {
+		if _, err := p.request("POST", ROUTE_MESSAGE, body); err == nil {
+			return
+		} else if i < retries {
+			l4g.Debug(utils.T("cluster.send.retry.debug"), p.url, msg.Event, err.Error(), i)
+			time.Sleep(time.Duration(i*100) * time.Millisecond)
+		} else {
+			l4g.Error(utils.T("cluster.send.final_fail.error"), p.url, msg.Event, err.Error(), i)
+		}
+	}
+}
+
+func (p *interNodePeer) request(method, route, body string) ([]byte, *model.AppError) {
+	start := time.Now()
+	metrics := einterfaces.GetMetricsInterface()
+	if metrics != nil {
+		metrics.IncrementClusterRequest()
+		defer func() {
+			metrics.ObserveClusterRequestDuration(time.Since(start).Seconds())
+		}()
+	}
+
+	req, _ := http.NewRequest(method, strings.TrimRight(p.url, "/")+route, bytes.NewReader([]byte(body)))
+	p.cluster.signRequest(req, []byte(body))
+
+	resp, err := p.cluster.client.Do(req)
+	if err != nil {
+		return nil, model.NewLocAppError("request", "cluster.request.failed.app_error", nil, "url="+p.url+route+", "+err.Error())
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, model.NewLocAppError("request", "cluster.request.failed.app_error", nil, "url="+p.url+route+", "+err.Error())
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, model.NewLocAppError("request", "cluster.request.failed.app_error", nil, "url="+p.url+route+", status="+resp.Status)
+	}
+
+	return data, nil
+}
+
+func (p *interNodePeer) ping() {
+	body, err := p.request("GET", ROUTE_INFO, "")
+
+	p.infoMutex.Lock()
+	defer p.infoMutex.Unlock()
+
+	if err != nil {
+		p.info.IsAlive = false
+		l4g.Warn(utils.T("cluster.ping.failed.warn"), p.info.Hostname, p.url, p.info.Id)
+		return
+	}
+
+	info := model.ClusterInfoFromJson(bytes.NewReader(body))
+	if info == nil {
+		p.info.IsAlive = false
+		return
+	}
+
+	if info.Version != model.CurrentVersion {
+		l4g.Warn(utils.T("cluster.ping.incompatible_version.warn"), p.url, info.Version)
+	}
+
+	if info.ConfigHash != utils.CfgHash {
+		l4g.Warn(utils.T("cluster.ping.incompatible_config.warn"), p.url)
+	}
+
+	info.InterNodeUrl = p.url
+	info.LastSuccessfulPing = model.GetMillis()
+	info.IsAlive = true
+	p.info = info
+}
+
+func (p *interNodePeer) getInfo() *model.ClusterInfo {
+	p.infoMutex.RLock()
+	defer p.infoMutex.RUnlock()
+
+	info := *p.info
+	return &info
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+package main
+
+import (
+	"fmt"
+
+	"github.com/mattermost/platform/app"
+	"github.com/spf13/cobra"
+)
+
+var fileCmd = &cobra.Command{
+	Use:   "file",
+	Short: "Management of uploaded files",
+}
+
+var dedupeFilesCmd = &cobra.Command{
+	Use:     "dedupe",
+	Short:   "Deduplicate uploaded files",
+	Long:    "Move files uploaded before files were deduplicated into shared storage so that identical files are only stored once.",
+	Example: "  file dedupe",
+	RunE:    dedupeFilesCmdF,
+}
+
+func init() {
+	fileCmd.AddCommand(
+		dedupeFilesCmd,
+	)
+}
+
+func dedupeFilesCmdF(cmd *cobra.Command, args []string) error {
+	initDBCommandContextCobra(cmd)
+
+	moved, saved, err := app.MigrateFilesToBlobs()
+	if err != nil {
+		return err
+	}
+
+	CommandPrettyPrintln(fmt.Sprintf("Moved %v files to shared storage, removing %v bytes of duplicate files", moved, saved))
+
+	return nil
+}
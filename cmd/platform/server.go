@@ -13,8 +13,11 @@ import (
 	"github.com/mattermost/platform/api"
 	"github.com/mattermost/platform/api4"
 	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/clamav"
+	"github.com/mattermost/platform/cluster"
 	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/manualtesting"
+	"github.com/mattermost/platform/metrics"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
 	"github.com/mattermost/platform/web"
@@ -81,6 +84,10 @@ func runServer(configFileLocation string) {
 
 	resetStatuses()
 
+	cluster.InitInterNodeCluster()
+	metrics.InitMetrics()
+	clamav.InitFileScanner()
+
 	app.StartServer()
 
 	// If we allow testing then listen for manual testing URL hits
@@ -92,6 +99,12 @@ func runServer(configFileLocation string) {
 	utils.RegenerateClientConfig()
 	go runSecurityJob()
 	go runDiagnosticsJob()
+	go runLoginAttemptsCleanupJob()
+	go runCommandWebhookCleanupJob()
+	go runLinkMetadataCleanupJob()
+	go runPendingFileScanJob()
+	go runComplianceExportJob()
+	go runEmailDigestJob()
 
 	if complianceI := einterfaces.GetComplianceInterface(); complianceI != nil {
 		complianceI.StartComplianceDailyJob()
@@ -105,6 +118,8 @@ func runServer(configFileLocation string) {
 		einterfaces.GetMetricsInterface().StartServer()
 	}
 
+	app.StartEmailReplyServer()
+
 	// wait for kill signal before attempting to gracefully shutdown
 	// the running service
 	c := make(chan os.Signal)
@@ -119,6 +134,7 @@ func runServer(configFileLocation string) {
 		einterfaces.GetMetricsInterface().StopServer()
 	}
 
+	app.StopEmailReplyServer()
 	app.StopServer()
 }
 
@@ -132,6 +148,31 @@ func runDiagnosticsJob() {
 	model.CreateRecurringTask("Diagnostics", doDiagnostics, time.Hour*24)
 }
 
+func runLoginAttemptsCleanupJob() {
+	app.CleanupLoginAttempts()
+	model.CreateRecurringTask("LoginAttemptsCleanup", app.CleanupLoginAttempts, time.Minute*10)
+}
+
+func runCommandWebhookCleanupJob() {
+	model.CreateRecurringTask("CommandWebhookCleanup", app.CleanupCommandWebhooks, time.Hour*1)
+}
+
+func runLinkMetadataCleanupJob() {
+	model.CreateRecurringTask("LinkMetadataCleanup", app.CleanupLinkMetadata, time.Hour*1)
+}
+
+func runPendingFileScanJob() {
+	model.CreateRecurringTask("PendingFileScan", app.ScanPendingFiles, app.PENDING_FILE_SCAN_INTERVAL)
+}
+
+func runComplianceExportJob() {
+	model.CreateRecurringTask("ComplianceExport", app.ScheduledComplianceExport, time.Hour*1)
+}
+
+func runEmailDigestJob() {
+	model.CreateRecurringTask("EmailDigest", app.SendEmailDigests, time.Minute*1)
+}
+
 func resetStatuses() {
 	if result := <-app.Srv.Store.Status().ResetAll(); result.Err != nil {
 		l4g.Error(utils.T("mattermost.reset_status.error"), result.Err.Error())
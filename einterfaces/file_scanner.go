@@ -0,0 +1,23 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package einterfaces
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type FileScannerInterface interface {
+	// ScanFile returns the name of the threat found in a file, or an empty string if the file is clean.
+	ScanFile(data []byte) (string, *model.AppError)
+}
+
+var theFileScannerInterface FileScannerInterface
+
+func RegisterFileScannerInterface(newInterface FileScannerInterface) {
+	theFileScannerInterface = newInterface
+}
+
+func GetFileScannerInterface() FileScannerInterface {
+	return theFileScannerInterface
+}
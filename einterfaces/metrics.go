@@ -18,6 +18,10 @@ type MetricsInterface interface {
 	IncrementHttpError()
 	ObserveHttpRequestDuration(elapsed float64)
 
+	IncrementOutgoingHttpRequest(host string)
+	IncrementOutgoingHttpError(host string)
+	ObserveOutgoingHttpRequestDuration(host string, elapsed float64)
+
 	IncrementClusterRequest()
 	ObserveClusterRequestDuration(elapsed float64)
 
@@ -0,0 +1,352 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package metrics
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+
+	l4g "github.com/alecthomas/log4go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/mattermost/platform/app"
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/utils"
+)
+
+const (
+	METRICS_NAMESPACE = "mattermost"
+
+	METRICS_SUBSYSTEM_DB            = "db"
+	METRICS_SUBSYSTEM_POST          = "post"
+	METRICS_SUBSYSTEM_HTTP          = "http"
+	METRICS_SUBSYSTEM_OUTGOING_HTTP = "outgoing_http"
+	METRICS_SUBSYSTEM_CLUSTER       = "cluster"
+	METRICS_SUBSYSTEM_LOGIN         = "login"
+	METRICS_SUBSYSTEM_CACHE         = "cache"
+	METRICS_SUBSYSTEM_WEBSOCKET     = "websocket"
+
+	METRICS_SESSION_CACHE_NAME = "Session"
+)
+
+type PrometheusMetrics struct {
+	registry *prometheus.Registry
+	server   *http.Server
+	lock     sync.Mutex
+
+	postCreate         prometheus.Counter
+	webhookPost        prometheus.Counter
+	postSentEmail      prometheus.Counter
+	postSentPush       prometheus.Counter
+	postBroadcast      prometheus.Counter
+	postFileAttachment prometheus.Counter
+
+	httpRequest         prometheus.Counter
+	httpError           prometheus.Counter
+	httpRequestDuration prometheus.Histogram
+
+	outgoingHttpRequest         *prometheus.CounterVec
+	outgoingHttpError           *prometheus.CounterVec
+	outgoingHttpRequestDuration *prometheus.HistogramVec
+
+	clusterRequest         prometheus.Counter
+	clusterRequestDuration prometheus.Histogram
+
+	login     prometheus.Counter
+	loginFail prometheus.Counter
+
+	etagHit      *prometheus.CounterVec
+	etagMiss     *prometheus.CounterVec
+	memCacheHit  *prometheus.CounterVec
+	memCacheMiss *prometheus.CounterVec
+
+	websocketEvent *prometheus.CounterVec
+}
+
+// NewPrometheusMetrics creates the collectors for every metric in einterfaces.MetricsInterface on a private
+// registry, together with gauges that sample the database pools and websocket hubs when scraped.
+func NewPrometheusMetrics() *PrometheusMetrics {
+	m := &PrometheusMetrics{registry: prometheus.NewRegistry()}
+
+	m.registry.MustRegister(prometheus.NewGoCollector())
+	m.registry.MustRegister(prometheus.NewProcessCollector(os.Getpid(), ""))
+
+	m.postCreate = m.newCounter(METRICS_SUBSYSTEM_POST, "total", "The total number of posts created.")
+	m.webhookPost = m.newCounter(METRICS_SUBSYSTEM_POST, "webhooks_total", "Total number of webhook posts.")
+	m.postSentEmail = m.newCounter(METRICS_SUBSYSTEM_POST, "emails_sent_total", "The total number of emails sent because a post was made.")
+	m.postSentPush = m.newCounter(METRICS_SUBSYSTEM_POST, "pushes_sent_total", "The total number of mobile push notifications sent because a post was made.")
+	m.postBroadcast = m.newCounter(METRICS_SUBSYSTEM_POST, "broadcasts_total", "The total number of websocket broadcasts sent because a post was made.")
+	m.postFileAttachment = m.newCounter(METRICS_SUBSYSTEM_POST, "file_attachments_total", "The total number of file attachments created because a post was made.")
+
+	m.httpRequest = m.newCounter(METRICS_SUBSYSTEM_HTTP, "requests_total", "The total number of http API requests.")
+	m.httpError = m.newCounter(METRICS_SUBSYSTEM_HTTP, "errors_total", "The total number of http API errors.")
+	m.httpRequestDuration = m.newHistogram(METRICS_SUBSYSTEM_HTTP, "request_duration_seconds", "The time in seconds to execute API handlers.")
+
+	m.outgoingHttpRequest = m.newCounterVec(METRICS_SUBSYSTEM_OUTGOING_HTTP, "requests_total", "The total number of requests made to other servers.", "host")
+	m.outgoingHttpError = m.newCounterVec(METRICS_SUBSYSTEM_OUTGOING_HTTP, "errors_total", "The total number of requests to other servers that failed.", "host")
+	m.outgoingHttpRequestDuration = m.newHistogramVec(METRICS_SUBSYSTEM_OUTGOING_HTTP, "request_duration_seconds", "The time in seconds taken by requests to other servers.", "host")
+
+	m.clusterRequest = m.newCounter(METRICS_SUBSYSTEM_CLUSTER, "requests_total", "The total number of inter-node requests.")
+	m.clusterRequestDuration = m.newHistogram(METRICS_SUBSYSTEM_CLUSTER, "request_duration_seconds", "The total duration in seconds of the inter-node cluster requests.")
+
+	m.login = m.newCounter(METRICS_SUBSYSTEM_LOGIN, "total", "The total number of successful logins.")
+	m.loginFail = m.newCounter(METRICS_SUBSYSTEM_LOGIN, "fail_total", "The total number of failed logins.")
+
+	m.etagHit = m.newCounterVec(METRICS_SUBSYSTEM_CACHE, "etag_hit_total", "Total number of etag cache hits for a specific cache.", "route")
+	m.etagMiss = m.newCounterVec(METRICS_SUBSYSTEM_CACHE, "etag_miss_total", "Total number of etag cache misses for a specific cache.", "route")
+	m.memCacheHit = m.newCounterVec(METRICS_SUBSYSTEM_CACHE, "mem_hit_total", "Total number of memory cache hits for a specific cache.", "name")
+	m.memCacheMiss = m.newCounterVec(METRICS_SUBSYSTEM_CACHE, "mem_miss_total", "Total number of memory cache misses for a specific cache.", "name")
+
+	m.websocketEvent = m.newCounterVec(METRICS_SUBSYSTEM_WEBSOCKET, "event_total", "Total number of websocket events.", "type")
+
+	m.newGaugeFunc(METRICS_SUBSYSTEM_DB, "master_connections_total", "The total number of connections to the master database.", func() float64 {
+		if app.Srv == nil || app.Srv.Store == nil {
+			return 0
+		}
+		return float64(app.Srv.Store.TotalMasterDbConnections())
+	})
+
+	m.newGaugeFunc(METRICS_SUBSYSTEM_DB, "read_replica_connections_total", "The total number of connections to all the read replica databases.", func() float64 {
+		if app.Srv == nil || app.Srv.Store == nil {
+			return 0
+		}
+		return float64(app.Srv.Store.TotalReadDbConnections())
+	})
+
+	m.newGaugeFunc(METRICS_SUBSYSTEM_HTTP, "websockets_total", "The total number of websocket connections to this server.", func() float64 {
+		return float64(app.TotalWebsocketConnections())
+	})
+
+	return m
+}
+
+// InitMetrics registers the built-in Prometheus exporter unless another implementation has already been registered.
+func InitMetrics() {
+	if einterfaces.GetMetricsInterface() != nil {
+		return
+	}
+
+	einterfaces.RegisterMetricsInterface(NewPrometheusMetrics())
+}
+
+func (m *PrometheusMetrics) newCounter(subsystem, name, help string) prometheus.Counter {
+	counter := prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	})
+	m.registry.MustRegister(counter)
+	return counter
+}
+
+func (m *PrometheusMetrics) newCounterVec(subsystem, name, help string, label string) *prometheus.CounterVec {
+	counter := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, []string{label})
+	m.registry.MustRegister(counter)
+	return counter
+}
+
+func (m *PrometheusMetrics) newHistogram(subsystem, name, help string) prometheus.Histogram {
+	histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	})
+	m.registry.MustRegister(histogram)
+	return histogram
+}
+
+func (m *PrometheusMetrics) newHistogramVec(subsystem, name, help string, label string) *prometheus.HistogramVec {
+	histogram := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, []string{label})
+	m.registry.MustRegister(histogram)
+	return histogram
+}
+
+func (m *PrometheusMetrics) newGaugeFunc(subsystem, name, help string, function func() float64) {
+	m.registry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: METRICS_NAMESPACE,
+		Subsystem: subsystem,
+		Name:      name,
+		Help:      help,
+	}, function))
+}
+
+func (m *PrometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// StartServer (re)starts the exporter on MetricsSettings.ListenAddress. It is called on startup and whenever the
+// configuration is saved, so a changed listen address or profile rate takes effect without a restart.
+func (m *PrometheusMetrics) StartServer() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stopServer()
+
+	if !*utils.Cfg.MetricsSettings.Enable {
+		return
+	}
+
+	runtime.SetBlockProfileRate(*utils.Cfg.MetricsSettings.BlockProfileRate)
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", m.Handler())
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+
+	listener, err := net.Listen("tcp", *utils.Cfg.MetricsSettings.ListenAddress)
+	if err != nil {
+		l4g.Error(utils.T("metrics.server.start.error"), *utils.Cfg.MetricsSettings.ListenAddress, err.Error())
+		return
+	}
+
+	m.server = &http.Server{
+		Addr:         listener.Addr().String(),
+		Handler:      mux,
+		ReadTimeout:  time.Duration(*utils.Cfg.ServiceSettings.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(*utils.Cfg.ServiceSettings.WriteTimeout) * time.Second,
+	}
+
+	go m.server.Serve(listener)
+
+	l4g.Info(utils.T("metrics.server.start.info"), m.server.Addr)
+}
+
+func (m *PrometheusMetrics) StopServer() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.stopServer()
+}
+
+func (m *PrometheusMetrics) stopServer() {
+	if m.server == nil {
+		return
+	}
+
+	m.server.Close()
+	m.server = nil
+
+	l4g.Info(utils.T("metrics.server.stop.info"))
+}
+
+func (m *PrometheusMetrics) IncrementPostCreate() {
+	m.postCreate.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementWebhookPost() {
+	m.webhookPost.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementPostSentEmail() {
+	m.postSentEmail.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementPostSentPush() {
+	m.postSentPush.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementPostBroadcast() {
+	m.postBroadcast.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementPostFileAttachment(count int) {
+	m.postFileAttachment.Add(float64(count))
+}
+
+func (m *PrometheusMetrics) IncrementHttpRequest() {
+	m.httpRequest.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementHttpError() {
+	m.httpError.Inc()
+}
+
+func (m *PrometheusMetrics) ObserveHttpRequestDuration(elapsed float64) {
+	m.httpRequestDuration.Observe(elapsed)
+}
+
+func (m *PrometheusMetrics) IncrementOutgoingHttpRequest(host string) {
+	m.outgoingHttpRequest.WithLabelValues(host).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementOutgoingHttpError(host string) {
+	m.outgoingHttpError.WithLabelValues(host).Inc()
+}
+
+func (m *PrometheusMetrics) ObserveOutgoingHttpRequestDuration(host string, elapsed float64) {
+	m.outgoingHttpRequestDuration.WithLabelValues(host).Observe(elapsed)
+}
+
+func (m *PrometheusMetrics) IncrementClusterRequest() {
+	m.clusterRequest.Inc()
+}
+
+func (m *PrometheusMetrics) ObserveClusterRequestDuration(elapsed float64) {
+	m.clusterRequestDuration.Observe(elapsed)
+}
+
+func (m *PrometheusMetrics) IncrementLogin() {
+	m.login.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementLoginFail() {
+	m.loginFail.Inc()
+}
+
+func (m *PrometheusMetrics) IncrementEtagHitCounter(route string) {
+	m.etagHit.WithLabelValues(route).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementEtagMissCounter(route string) {
+	m.etagMiss.WithLabelValues(route).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementMemCacheHitCounter(cacheName string) {
+	m.memCacheHit.WithLabelValues(cacheName).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementMemCacheMissCounter(cacheName string) {
+	m.memCacheMiss.WithLabelValues(cacheName).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementMemCacheMissCounterSession() {
+	m.memCacheMiss.WithLabelValues(METRICS_SESSION_CACHE_NAME).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementMemCacheHitCounterSession() {
+	m.memCacheHit.WithLabelValues(METRICS_SESSION_CACHE_NAME).Inc()
+}
+
+func (m *PrometheusMetrics) IncrementWebsocketEvent(eventType string) {
+	m.websocketEvent.WithLabelValues(eventType).Inc()
+}
+
+func (m *PrometheusMetrics) AddMemCacheHitCounter(cacheName string, amount float64) {
+	m.memCacheHit.WithLabelValues(cacheName).Add(amount)
+}
+
+func (m *PrometheusMetrics) AddMemCacheMissCounter(cacheName string, amount float64) {
+	m.memCacheMiss.WithLabelValues(cacheName).Add(amount)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mattermost/platform/einterfaces"
+	"github.com/mattermost/platform/utils"
+)
+
+func setup() {
+	utils.TranslationsPreInit()
+	utils.LoadConfig("config.json")
+	utils.InitTranslations(utils.Cfg.LocalizationSettings)
+}
+
+func scrape(t *testing.T, url string) string {
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal("bad status " + resp.Status)
+	}
+
+	body, _ := ioutil.ReadAll(resp.Body)
+	return string(body)
+}
+
+func TestPrometheusMetricsImplementsInterface(t *testing.T) {
+	var _ einterfaces.MetricsInterface = NewPrometheusMetrics()
+}
+
+func TestPrometheusMetricsServer(t *testing.T) {
+	setup()
+
+	enable := *utils.Cfg.MetricsSettings.Enable
+	listenAddress := *utils.Cfg.MetricsSettings.ListenAddress
+	defer func() {
+		*utils.Cfg.MetricsSettings.Enable = enable
+		*utils.Cfg.MetricsSettings.ListenAddress = listenAddress
+	}()
+
+	m := NewPrometheusMetrics()
+
+	*utils.Cfg.MetricsSettings.Enable = false
+	m.StartServer()
+	if m.server != nil {
+		t.Fatal("server should not start when metrics are disabled")
+	}
+
+	*utils.Cfg.MetricsSettings.Enable = true
+	*utils.Cfg.MetricsSettings.ListenAddress = "127.0.0.1:0"
+	m.StartServer()
+	defer m.StopServer()
+
+	if m.server == nil {
+		t.Fatal("server should have started")
+	}
+
+	m.IncrementPostCreate()
+	m.IncrementPostCreate()
+	m.IncrementPostFileAttachment(3)
+	m.IncrementHttpRequest()
+	m.ObserveHttpRequestDuration(0.25)
+	m.IncrementLoginFail()
+	m.IncrementEtagHitCounter("/api/v4/users")
+	m.IncrementMemCacheHitCounter("Channel")
+	m.AddMemCacheHitCounter("Channel", 4)
+	m.IncrementMemCacheMissCounterSession()
+	m.IncrementWebsocketEvent("posted")
+	m.IncrementOutgoingHttpRequest("push.example.com")
+	m.IncrementOutgoingHttpError("push.example.com")
+	m.ObserveOutgoingHttpRequestDuration("push.example.com", 0.5)
+
+	body := scrape(t, "http://"+m.server.Addr+"/metrics")
+
+	for _, expected := range []string{
+		"mattermost_post_total 2",
+		"mattermost_post_file_attachments_total 3",
+		"mattermost_http_requests_total 1",
+		"mattermost_http_request_duration_seconds_count 1",
+		"mattermost_login_fail_total 1",
+		`mattermost_cache_etag_hit_total{route="/api/v4/users"} 1`,
+		`mattermost_cache_mem_hit_total{name="Channel"} 5`,
+		`mattermost_cache_mem_miss_total{name="Session"} 1`,
+		`mattermost_websocket_event_total{type="posted"} 1`,
+		`mattermost_outgoing_http_requests_total{host="push.example.com"} 1`,
+		`mattermost_outgoing_http_errors_total{host="push.example.com"} 1`,
+		`mattermost_outgoing_http_request_duration_seconds_count{host="push.example.com"} 1`,
+		"mattermost_db_master_connections_total",
+		"mattermost_db_read_replica_connections_total",
+		"mattermost_http_websockets_total 0",
+		"go_goroutines",
+	} {
+		if !strings.Contains(body, expected) {
+			t.Fatal("missing " + expected + " in\n" + body)
+		}
+	}
+
+	addr := m.server.Addr
+	m.StopServer()
+	if m.server != nil {
+		t.Fatal("server should have stopped")
+	}
+
+	if _, err := http.Get("http://" + addr + "/metrics"); err == nil {
+		t.Fatal("server should no longer be listening")
+	}
+}
@@ -134,6 +134,10 @@ func (o *ChannelMember) IsValid() *AppError {
 		}
 	}
 
+	if keywords, ok := o.NotifyProps[KEYWORDS_NOTIFY_PROP]; ok && !IsValidMentionKeywords(keywords) {
+		return NewLocAppError("ChannelMember.IsValid", "model.channel_member.is_valid.mention_keywords.app_error", nil, "")
+	}
+
 	return nil
 }
 
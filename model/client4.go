@@ -178,6 +178,14 @@ func (c *Client4) GetComplianceReportRoute(reportId string) string {
 	return fmt.Sprintf("/compliance/reports/%v", reportId)
 }
 
+func (c *Client4) GetComplianceExportsRoute() string {
+	return fmt.Sprintf("/compliance/exports")
+}
+
+func (c *Client4) GetComplianceExportRoute(exportId string) string {
+	return fmt.Sprintf("/compliance/exports/%v", exportId)
+}
+
 func (c *Client4) GetOutgoingWebhooksRoute() string {
 	return fmt.Sprintf("/hooks/outgoing")
 }
@@ -210,6 +218,70 @@ func (c *Client4) GetCommandsRoute() string {
 	return fmt.Sprintf("/commands")
 }
 
+func (c *Client4) GetCommandRoute(commandId string) string {
+	return fmt.Sprintf(c.GetCommandsRoute()+"/%v", commandId)
+}
+
+func (c *Client4) GetEmojisRoute() string {
+	return fmt.Sprintf("/emoji")
+}
+
+func (c *Client4) GetEmojiRoute(emojiId string) string {
+	return fmt.Sprintf(c.GetEmojisRoute()+"/%v", emojiId)
+}
+
+func (c *Client4) GetReactionsRoute() string {
+	return fmt.Sprintf("/reactions")
+}
+
+func (c *Client4) GetImageRoute() string {
+	return fmt.Sprintf("/image")
+}
+
+func (c *Client4) GetOAuthAppsRoute() string {
+	return fmt.Sprintf("/oauth/apps")
+}
+
+func (c *Client4) GetOAuthAppRoute(appId string) string {
+	return fmt.Sprintf("/oauth/apps/%v", appId)
+}
+
+func (c *Client4) GetLicenseRoute() string {
+	return fmt.Sprintf("/license")
+}
+
+func (c *Client4) GetAnalyticsRoute() string {
+	return fmt.Sprintf("/analytics")
+}
+
+func (c *Client4) GetWebrtcRoute() string {
+	return fmt.Sprintf("/webrtc")
+}
+
+func (c *Client4) GetLoginLockoutsRoute() string {
+	return fmt.Sprintf("/login_lockouts")
+}
+
+func (c *Client4) GetLoginLockoutRoute(lockoutId string) string {
+	return fmt.Sprintf(c.GetLoginLockoutsRoute()+"/%v", lockoutId)
+}
+
+func (c *Client4) GetLegalHoldsRoute() string {
+	return fmt.Sprintf("/legal_holds")
+}
+
+func (c *Client4) GetLegalHoldRoute(holdId string) string {
+	return fmt.Sprintf(c.GetLegalHoldsRoute()+"/%v", holdId)
+}
+
+func (c *Client4) GetMentionGroupsRoute() string {
+	return fmt.Sprintf("/mention_groups")
+}
+
+func (c *Client4) GetMentionGroupRoute(groupId string) string {
+	return fmt.Sprintf(c.GetMentionGroupsRoute()+"/%v", groupId)
+}
+
 func (c *Client4) DoApiGet(url string, etag string) (*http.Response, *AppError) {
 	return c.DoApiRequest(http.MethodGet, url, "", etag)
 }
@@ -645,6 +717,36 @@ func (c *Client4) GetUserAudits(userId string, page int, perPage int, etag strin
 	}
 }
 
+// GetEmailDigest returns the email digest subscription for a user.
+func (c *Client4) GetEmailDigest(userId string) (*EmailDigest, *Response) {
+	if r, err := c.DoApiGet(c.GetUserRoute(userId)+"/email_digest", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return EmailDigestFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// UpdateEmailDigest subscribes a user to an email digest or changes their existing subscription.
+func (c *Client4) UpdateEmailDigest(userId string, digest *EmailDigest) (*EmailDigest, *Response) {
+	if r, err := c.DoApiPut(c.GetUserRoute(userId)+"/email_digest", digest.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return EmailDigestFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// DeleteEmailDigest unsubscribes a user from their email digest.
+func (c *Client4) DeleteEmailDigest(userId string) (bool, *Response) {
+	if r, err := c.DoApiDelete(c.GetUserRoute(userId) + "/email_digest"); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
 // VerifyUserEmail will verify a user's email using user id and hash strings.
 func (c *Client4) VerifyUserEmail(userId, hashId string) (bool, *Response) {
 	requestBody := map[string]string{"user_id": userId, "hash_id": hashId}
@@ -1075,6 +1177,16 @@ func (c *Client4) UpdateChannelRoles(channelId, userId, roles string) (bool, *Re
 	}
 }
 
+// UpdateChannelNotifyProps will update the notification properties on a channel for a user.
+func (c *Client4) UpdateChannelNotifyProps(channelId, userId string, props map[string]string) (bool, *Response) {
+	if r, err := c.DoApiPut(c.GetChannelMemberRoute(channelId, userId)+"/notify_props", MapToJson(props)); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
 // AddChannelMember adds user to channel and return a channel member.
 func (c *Client4) AddChannelMember(channelId, userId string) (*ChannelMember, *Response) {
 	requestBody := map[string]string{"user_id": userId}
@@ -1148,6 +1260,16 @@ func (c *Client4) GetPostThread(postId string, etag string) (*PostList, *Respons
 	}
 }
 
+// GetEditHistoryForPost gets the previous versions of a post, with the most recently replaced first.
+func (c *Client4) GetEditHistoryForPost(postId string) (*PostList, *Response) {
+	if r, err := c.DoApiGet(c.GetPostRoute(postId)+"/edit_history", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return PostListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
 // GetPostsForChannel gets a page of posts with an array for ordering for a channel.
 func (c *Client4) GetPostsForChannel(channelId string, page, perPage int, etag string) (*PostList, *Response) {
 	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
@@ -1203,6 +1325,25 @@ func (c *Client4) SearchPosts(teamId string, terms string, isOrSearch bool) (*Po
 	}
 }
 
+// DoPostAction performs a post action.
+func (c *Client4) DoPostAction(postId, actionId string) (bool, *Response) {
+	return c.DoPostActionWithOption(postId, actionId, "")
+}
+
+// DoPostActionWithOption performs a post action with the option picked from a select menu.
+func (c *Client4) DoPostActionWithOption(postId, actionId, selectedOption string) (bool, *Response) {
+	data := ""
+	if len(selectedOption) > 0 {
+		data = MapToJson(map[string]string{POST_ACTION_CONTEXT_SELECTED_OPTION: selectedOption})
+	}
+
+	if r, err := c.DoApiPost(c.GetPostRoute(postId)+"/actions/"+actionId, data); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
 // File Section
 
 // UploadFile will upload a file to a channel, to be later attached to a post.
@@ -1291,6 +1432,36 @@ func (c *Client4) GetFileInfosForPost(postId string, etag string) ([]*FileInfo,
 	}
 }
 
+// SearchFiles returns the files in channels the user belongs to on a team that match the search.
+func (c *Client4) SearchFiles(teamId string, params *FileSearch) ([]*FileInfo, *Response) {
+	if r, err := c.DoApiPost(c.GetTeamRoute(teamId)+"/files/search", params.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return FileInfosFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetTeamStorageUsage returns the total size of the files uploaded to a team and the team's storage quota.
+func (c *Client4) GetTeamStorageUsage(teamId string) (*StorageUsage, *Response) {
+	if r, err := c.DoApiGet(c.GetTeamRoute(teamId)+"/files/usage", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return StorageUsageFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetUserStorageUsage returns the total size of the files uploaded by a user and the user's storage quota.
+func (c *Client4) GetUserStorageUsage(userId string) (*StorageUsage, *Response) {
+	if r, err := c.DoApiGet(c.GetUserRoute(userId)+"/files/usage", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return StorageUsageFromJson(r.Body), BuildResponse(r)
+	}
+}
+
 // General Section
 
 // GetPing will ping the server and to see if it is up and running.
@@ -1726,6 +1897,61 @@ func (c *Client4) DownloadComplianceReport(reportId string) ([]byte, *Response)
 	}
 }
 
+// CreateComplianceExport starts exporting the posts that have changed since the last compliance export.
+func (c *Client4) CreateComplianceExport() (*Compliance, *Response) {
+	if r, err := c.DoApiPost(c.GetComplianceExportsRoute(), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return ComplianceFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetComplianceExports returns a page of compliance exports.
+func (c *Client4) GetComplianceExports(page, perPage int) (Compliances, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetComplianceExportsRoute()+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CompliancesFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetComplianceExport returns a compliance export.
+func (c *Client4) GetComplianceExport(exportId string) (*Compliance, *Response) {
+	if r, err := c.DoApiGet(c.GetComplianceExportRoute(exportId), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return ComplianceFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// DownloadComplianceExport returns the zip file of a finished compliance export.
+func (c *Client4) DownloadComplianceExport(exportId string) ([]byte, *Response) {
+	var rq *http.Request
+	rq, _ = http.NewRequest("GET", c.ApiUrl+c.GetComplianceExportRoute(exportId)+"/download", nil)
+	rq.Close = true
+
+	if len(c.AuthToken) > 0 {
+		rq.Header.Set(HEADER_AUTH, "BEARER "+c.AuthToken)
+	}
+
+	if rp, err := c.HttpClient.Do(rq); err != nil {
+		return nil, &Response{Error: NewAppError("DownloadComplianceExport", "model.client.connecting.app_error", nil, err.Error(), http.StatusBadRequest)}
+	} else if rp.StatusCode >= 300 {
+		defer rp.Body.Close()
+		return nil, &Response{StatusCode: rp.StatusCode, Error: AppErrorFromJson(rp.Body)}
+	} else if data, err := ioutil.ReadAll(rp.Body); err != nil {
+		defer closeBody(rp)
+		return nil, &Response{StatusCode: rp.StatusCode, Error: NewAppError("DownloadComplianceExport", "model.client.read_file.app_error", nil, err.Error(), rp.StatusCode)}
+	} else {
+		defer closeBody(rp)
+		return data, BuildResponse(rp)
+	}
+}
+
 // Cluster Section
 
 // GetClusterStatus returns the status of all the configured cluster nodes.
@@ -1774,6 +2000,137 @@ func (c *Client4) GetAudits(page int, perPage int, etag string) (Audits, *Respon
 	}
 }
 
+// Login Lockouts Section
+
+// GetLoginLockouts returns the IP addresses and login IDs that are currently locked out
+// after too many failed logins.
+func (c *Client4) GetLoginLockouts() ([]*LoginLockout, *Response) {
+	if r, err := c.DoApiGet(c.GetLoginLockoutsRoute(), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return LoginLockoutListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// RemoveLoginLockout clears a lockout so that logins are allowed again before it expires.
+func (c *Client4) RemoveLoginLockout(lockoutId string) (bool, *Response) {
+	if r, err := c.DoApiDelete(c.GetLoginLockoutRoute(lockoutId)); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// Legal Holds Section
+
+// CreateLegalHold starts preserving the content of the users and channels named by the hold.
+func (c *Client4) CreateLegalHold(hold *LegalHold) (*LegalHold, *Response) {
+	if r, err := c.DoApiPost(c.GetLegalHoldsRoute(), hold.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return LegalHoldFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetLegalHolds returns a page of legal holds, including ones that have been released.
+func (c *Client4) GetLegalHolds(page, perPage int) ([]*LegalHold, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetLegalHoldsRoute()+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return LegalHoldListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetLegalHold returns a legal hold.
+func (c *Client4) GetLegalHold(holdId string) (*LegalHold, *Response) {
+	if r, err := c.DoApiGet(c.GetLegalHoldRoute(holdId), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return LegalHoldFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// ReleaseLegalHold ends a legal hold so that the content it covered can be deleted again.
+func (c *Client4) ReleaseLegalHold(holdId string) (*LegalHold, *Response) {
+	if r, err := c.DoApiPost(c.GetLegalHoldRoute(holdId)+"/release", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return LegalHoldFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// ExportLegalHold returns a zip file of all of the content covered by a legal hold.
+func (c *Client4) ExportLegalHold(holdId string) ([]byte, *Response) {
+	if r, err := c.DoApiGet(c.GetLegalHoldRoute(holdId)+"/export", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else if data, err := ioutil.ReadAll(r.Body); err != nil {
+		defer closeBody(r)
+		return nil, &Response{StatusCode: r.StatusCode, Error: NewAppError("ExportLegalHold", "model.client.read_file.app_error", nil, err.Error(), r.StatusCode)}
+	} else {
+		defer closeBody(r)
+		return data, BuildResponse(r)
+	}
+}
+
+// Mention Groups Section
+
+// CreateMentionGroup creates a group of users on a team that can be mentioned together.
+func (c *Client4) CreateMentionGroup(group *MentionGroup) (*MentionGroup, *Response) {
+	if r, err := c.DoApiPost(c.GetMentionGroupsRoute(), group.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return MentionGroupFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetMentionGroup returns a mention group.
+func (c *Client4) GetMentionGroup(groupId string) (*MentionGroup, *Response) {
+	if r, err := c.DoApiGet(c.GetMentionGroupRoute(groupId), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return MentionGroupFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetMentionGroupsForTeam returns all of the mention groups on a team.
+func (c *Client4) GetMentionGroupsForTeam(teamId string) ([]*MentionGroup, *Response) {
+	if r, err := c.DoApiGet(c.GetTeamRoute(teamId)+"/mention_groups", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return MentionGroupListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// PatchMentionGroup partially updates the name or users of a mention group. Any missing fields are left as they are.
+func (c *Client4) PatchMentionGroup(groupId string, patch *MentionGroupPatch) (*MentionGroup, *Response) {
+	if r, err := c.DoApiPut(c.GetMentionGroupRoute(groupId)+"/patch", patch.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return MentionGroupFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// DeleteMentionGroup deletes a mention group.
+func (c *Client4) DeleteMentionGroup(groupId string) (bool, *Response) {
+	if r, err := c.DoApiDelete(c.GetMentionGroupRoute(groupId)); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
 // Brand Section
 
 // GetBrandImage retrieves the previously uploaded brand image.
@@ -1845,6 +2202,405 @@ func (c *Client4) CreateCommand(cmd *Command) (*Command, *Response) {
 	}
 }
 
+// UpdateCommand updates a command based on the provided Command struct
+func (c *Client4) UpdateCommand(cmd *Command) (*Command, *Response) {
+	if r, err := c.DoApiPut(c.GetCommandRoute(cmd.Id), cmd.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CommandFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// DeleteCommand deletes a command based on the provided command id string
+func (c *Client4) DeleteCommand(commandId string) (bool, *Response) {
+	if r, err := c.DoApiDelete(c.GetCommandRoute(commandId)); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// ListCommands will retrieve a list of commands available in the team. When customOnly
+// is true only the team's custom commands are returned.
+func (c *Client4) ListCommands(teamId string, customOnly bool) ([]*Command, *Response) {
+	query := fmt.Sprintf("?team_id=%v&custom_only=%v", teamId, customOnly)
+	if r, err := c.DoApiGet(c.GetCommandsRoute()+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CommandListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetCommandAutocompleteSuggestions returns suggestions for a partially typed command line in a team.
+// The channel is optional and is passed on to integrations that fetch their own suggestions.
+func (c *Client4) GetCommandAutocompleteSuggestions(userInput, teamId, channelId string) ([]*AutocompleteSuggestion, *Response) {
+	query := fmt.Sprintf("?user_input=%v&team_id=%v&channel_id=%v", url.QueryEscape(userInput), teamId, channelId)
+	if r, err := c.DoApiGet(c.GetCommandsRoute()+"/autocomplete_suggestions"+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return AutocompleteSuggestionsFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// ExecuteCommand executes a given command in the specified channel.
+func (c *Client4) ExecuteCommand(channelId, command string) (*CommandResponse, *Response) {
+	commandArgs := &CommandArgs{
+		ChannelId: channelId,
+		Command:   command,
+	}
+	if r, err := c.DoApiPost(c.GetCommandsRoute()+"/execute", commandArgs.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CommandResponseFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// ExecuteCommandWithTeam executes a given command in a direct or group channel against the specified team.
+func (c *Client4) ExecuteCommandWithTeam(channelId, teamId, command string) (*CommandResponse, *Response) {
+	commandArgs := &CommandArgs{
+		ChannelId: channelId,
+		TeamId:    teamId,
+		Command:   command,
+	}
+	if r, err := c.DoApiPost(c.GetCommandsRoute()+"/execute", commandArgs.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CommandResponseFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// RegenCommandToken will create a new token if the user have the right permissions.
+func (c *Client4) RegenCommandToken(commandId string) (string, *Response) {
+	if r, err := c.DoApiPost(c.GetCommandRoute(commandId)+"/regen_token", ""); err != nil {
+		return "", &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CommandFromJson(r.Body).Token, BuildResponse(r)
+	}
+}
+
+// Emoji Section
+
+// CreateEmoji will save an emoji to the server if the current user has permission
+// to do so. If successful, the provided emoji will be returned with its Id field
+// filled in. Otherwise, an error will be returned.
+func (c *Client4) CreateEmoji(emoji *Emoji, image []byte, filename string) (*Emoji, *Response) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	if part, err := writer.CreateFormFile("image", filename); err != nil {
+		return nil, &Response{StatusCode: http.StatusForbidden, Error: NewAppError("CreateEmoji", "model.client.create_emoji.image.app_error", nil, err.Error(), 0)}
+	} else if _, err = io.Copy(part, bytes.NewBuffer(image)); err != nil {
+		return nil, &Response{StatusCode: http.StatusForbidden, Error: NewAppError("CreateEmoji", "model.client.create_emoji.image.app_error", nil, err.Error(), 0)}
+	}
+
+	if err := writer.WriteField("emoji", emoji.ToJson()); err != nil {
+		return nil, &Response{StatusCode: http.StatusForbidden, Error: NewAppError("CreateEmoji", "model.client.create_emoji.emoji.app_error", nil, err.Error(), 0)}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, &Response{StatusCode: http.StatusForbidden, Error: NewAppError("CreateEmoji", "model.client.create_emoji.writer.app_error", nil, err.Error(), 0)}
+	}
+
+	return c.DoEmojiUploadFile(c.GetEmojisRoute(), body.Bytes(), writer.FormDataContentType())
+}
+
+// GetEmojiList returns a page of custom emoji on the system.
+func (c *Client4) GetEmojiList(page, perPage int) ([]*Emoji, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetEmojisRoute()+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return EmojiListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// DeleteEmoji delete an custom emoji on the provided emoji id string.
+func (c *Client4) DeleteEmoji(emojiId string) (bool, *Response) {
+	if r, err := c.DoApiDelete(c.GetEmojiRoute(emojiId)); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// GetEmoji returns a custom emoji in the system on the provided emoji id string.
+func (c *Client4) GetEmoji(emojiId string) (*Emoji, *Response) {
+	if r, err := c.DoApiGet(c.GetEmojiRoute(emojiId), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return EmojiFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetEmojiImage returns the emoji image.
+func (c *Client4) GetEmojiImage(emojiId string) ([]byte, *Response) {
+	if r, err := c.DoApiGet(c.GetEmojiRoute(emojiId)+"/image", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+
+		if data, err := ioutil.ReadAll(r.Body); err != nil {
+			return nil, &Response{StatusCode: r.StatusCode, Error: NewAppError("GetEmojiImage", "model.client.read_file.app_error", nil, err.Error(), r.StatusCode)}
+		} else {
+			return data, BuildResponse(r)
+		}
+	}
+}
+
+func (c *Client4) DoEmojiUploadFile(url string, data []byte, contentType string) (*Emoji, *Response) {
+	rq, _ := http.NewRequest("POST", c.ApiUrl+url, bytes.NewReader(data))
+	rq.Header.Set("Content-Type", contentType)
+	rq.Close = true
+
+	if len(c.AuthToken) > 0 {
+		rq.Header.Set(HEADER_AUTH, c.AuthType+" "+c.AuthToken)
+	}
+
+	if rp, err := c.HttpClient.Do(rq); err != nil {
+		return nil, &Response{Error: NewAppError(url, "model.client.connecting.app_error", nil, err.Error(), 0)}
+	} else if rp.StatusCode >= 300 {
+		return nil, &Response{StatusCode: rp.StatusCode, Error: AppErrorFromJson(rp.Body)}
+	} else {
+		defer closeBody(rp)
+		return EmojiFromJson(rp.Body), BuildResponse(rp)
+	}
+}
+
+// Reaction Section
+
+// SaveReaction saves an emoji reaction for a post. Returns the saved reaction if successful, otherwise an error will be returned.
+func (c *Client4) SaveReaction(reaction *Reaction) (*Reaction, *Response) {
+	if r, err := c.DoApiPost(c.GetReactionsRoute(), reaction.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return ReactionFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetReactions returns a list of reactions to a post.
+func (c *Client4) GetReactions(postId string) ([]*Reaction, *Response) {
+	if r, err := c.DoApiGet(c.GetPostRoute(postId)+"/reactions", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return ReactionsFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// DeleteReaction deletes reaction of a user in a post.
+func (c *Client4) DeleteReaction(reaction *Reaction) (bool, *Response) {
+	if r, err := c.DoApiDelete(c.GetUserRoute(reaction.UserId) + c.GetPostRoute(reaction.PostId) + fmt.Sprintf("/reactions/%v", reaction.EmojiName)); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// OAuth Section
+
+// CreateOAuthApp will register a new OAuth 2.0 client application with Mattermost acting as an OAuth 2.0 service provider.
+func (c *Client4) CreateOAuthApp(app *OAuthApp) (*OAuthApp, *Response) {
+	if r, err := c.DoApiPost(c.GetOAuthAppsRoute(), app.ToJson()); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return OAuthAppFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetOAuthApps gets a page of registered OAuth 2.0 client applications with Mattermost acting as an OAuth 2.0 service provider.
+func (c *Client4) GetOAuthApps(page, perPage int) ([]*OAuthApp, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetOAuthAppsRoute()+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return OAuthAppListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetOAuthApp gets a registered OAuth 2.0 client application with Mattermost acting as an OAuth 2.0 service provider.
+func (c *Client4) GetOAuthApp(appId string) (*OAuthApp, *Response) {
+	if r, err := c.DoApiGet(c.GetOAuthAppRoute(appId), ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return OAuthAppFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetOAuthAppInfo gets a sanitized version of a registered OAuth 2.0 client application with Mattermost acting as an OAuth 2.0 service provider.
+func (c *Client4) GetOAuthAppInfo(appId string) (*OAuthApp, *Response) {
+	if r, err := c.DoApiGet(c.GetOAuthAppRoute(appId)+"/info", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return OAuthAppFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// DeleteOAuthApp deletes a registered OAuth 2.0 client application.
+func (c *Client4) DeleteOAuthApp(appId string) (bool, *Response) {
+	if r, err := c.DoApiDelete(c.GetOAuthAppRoute(appId)); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// RegenerateOAuthAppSecret regenerates the client secret for a registered OAuth 2.0 client application.
+func (c *Client4) RegenerateOAuthAppSecret(appId string) (*OAuthApp, *Response) {
+	if r, err := c.DoApiPost(c.GetOAuthAppRoute(appId)+"/regen_secret", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return OAuthAppFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// GetAuthorizedOAuthAppsForUser gets a page of OAuth 2.0 client applications the user has authorized to use access their account.
+func (c *Client4) GetAuthorizedOAuthAppsForUser(userId string, page, perPage int) ([]*OAuthApp, *Response) {
+	query := fmt.Sprintf("?page=%v&per_page=%v", page, perPage)
+	if r, err := c.DoApiGet(c.GetUserRoute(userId)+"/oauth/apps/authorized"+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return OAuthAppListFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// DeauthorizeOAuthApp revokes a user's authorization of an OAuth 2.0 client application along with its access tokens.
+func (c *Client4) DeauthorizeOAuthApp(userId, appId string) (bool, *Response) {
+	if r, err := c.DoApiPost(c.GetUserRoute(userId)+fmt.Sprintf("/oauth/apps/%v/deauthorize", appId), ""); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// License Section
+
+// UploadLicenseFile will add a license file to the system.
+func (c *Client4) UploadLicenseFile(data []byte) (bool, *Response) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	if part, err := writer.CreateFormFile("license", "test-license.mattermost-license"); err != nil {
+		return false, &Response{Error: NewAppError("UploadLicenseFile", "model.client.set_profile_user.no_file.app_error", nil, err.Error(), http.StatusBadRequest)}
+	} else if _, err = io.Copy(part, bytes.NewBuffer(data)); err != nil {
+		return false, &Response{Error: NewAppError("UploadLicenseFile", "model.client.set_profile_user.no_file.app_error", nil, err.Error(), http.StatusBadRequest)}
+	}
+
+	if err := writer.Close(); err != nil {
+		return false, &Response{Error: NewAppError("UploadLicenseFile", "model.client.set_profile_user.writer.app_error", nil, err.Error(), http.StatusBadRequest)}
+	}
+
+	rq, _ := http.NewRequest("POST", c.ApiUrl+c.GetLicenseRoute(), bytes.NewReader(body.Bytes()))
+	rq.Header.Set("Content-Type", writer.FormDataContentType())
+	rq.Close = true
+
+	if len(c.AuthToken) > 0 {
+		rq.Header.Set(HEADER_AUTH, c.AuthType+" "+c.AuthToken)
+	}
+
+	if rp, err := c.HttpClient.Do(rq); err != nil {
+		return false, &Response{StatusCode: http.StatusForbidden, Error: NewAppError(c.GetLicenseRoute(), "model.client.connecting.app_error", nil, err.Error(), http.StatusForbidden)}
+	} else if rp.StatusCode >= 300 {
+		return false, &Response{StatusCode: rp.StatusCode, Error: AppErrorFromJson(rp.Body)}
+	} else {
+		defer closeBody(rp)
+		return true, BuildResponse(rp)
+	}
+}
+
+// RemoveLicenseFile will remove the server license it exists. Note that this will
+// disable all enterprise features.
+func (c *Client4) RemoveLicenseFile() (bool, *Response) {
+	if r, err := c.DoApiDelete(c.GetLicenseRoute()); err != nil {
+		return false, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return CheckStatusOK(r), BuildResponse(r)
+	}
+}
+
+// GetOldClientLicense will retrieve the parts of the server license needed by the
+// client, formatted in the old format.
+func (c *Client4) GetOldClientLicense(etag string) (map[string]string, *Response) {
+	if r, err := c.DoApiGet(c.GetLicenseRoute()+"/client?format=old", etag); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return MapFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// Analytics Section
+
+// GetAnalyticsOld will retrieve analytics using the old format. New format is not
+// available but the "/analytics" endpoint is reserved for it. The "name" argument is optional
+// and defaults to "standard". The "teamId" argument is optional and will limit results
+// to a specific team.
+func (c *Client4) GetAnalyticsOld(name, teamId string) (AnalyticsRows, *Response) {
+	query := fmt.Sprintf("?name=%v&team_id=%v", name, teamId)
+	if r, err := c.DoApiGet(c.GetAnalyticsRoute()+"/old"+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return AnalyticsRowsFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// WebRTC Section
+
+// GetWebrtcToken returns a valid token, stun server and turn server with credentials to
+// use with the Mattermost WebRTC service.
+func (c *Client4) GetWebrtcToken() (map[string]string, *Response) {
+	if r, err := c.DoApiGet(c.GetWebrtcRoute()+"/token", ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+		return MapFromJson(r.Body), BuildResponse(r)
+	}
+}
+
+// Image Section
+
+// GetProxiedImage returns an external image through the image proxy. The signature is the one included
+// in the proxy URLs of posts. The image is scaled down to width if it's greater than 0.
+func (c *Client4) GetProxiedImage(imageURL, signature string, width int) ([]byte, *Response) {
+	query := fmt.Sprintf("?url=%v&sig=%v", url.QueryEscape(imageURL), url.QueryEscape(signature))
+	if width > 0 {
+		query += fmt.Sprintf("&width=%v", width)
+	}
+
+	if r, err := c.DoApiGet(c.GetImageRoute()+query, ""); err != nil {
+		return nil, &Response{StatusCode: r.StatusCode, Error: err}
+	} else {
+		defer closeBody(r)
+
+		if data, err := ioutil.ReadAll(r.Body); err != nil {
+			return nil, &Response{StatusCode: r.StatusCode, Error: NewAppError("GetProxiedImage", "model.client.read_file.app_error", nil, err.Error(), r.StatusCode)}
+		} else {
+			return data, BuildResponse(r)
+		}
+	}
+}
+
 // Status Section
 
 // GetUserStatus returns a user based on the provided user id string.
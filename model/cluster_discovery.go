@@ -0,0 +1,97 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+)
+
+const (
+	CDS_OFFLINE_AFTER_MILLIS = 1000 * 60 * 2 // 2 minutes
+	CDS_TYPE_APP             = "mattermost_app"
+)
+
+type ClusterDiscovery struct {
+	Id           string `json:"id"`
+	Type         string `json:"type"`
+	Hostname     string `json:"hostname"`
+	InterNodeUrl string `json:"internode_url"`
+	Version      string `json:"version"`
+	CreateAt     int64  `json:"create_at"`
+	LastPingAt   int64  `json:"last_ping_at"`
+}
+
+func (o *ClusterDiscovery) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+		o.LastPingAt = o.CreateAt
+	}
+}
+
+func (o *ClusterDiscovery) AutoFillHostname() {
+	// attempt to set the hostname from the OS
+	if len(o.Hostname) == 0 {
+		if hn, err := os.Hostname(); err == nil {
+			o.Hostname = hn
+		}
+	}
+}
+
+func (o *ClusterDiscovery) IsAlive() bool {
+	return o.LastPingAt > GetMillis()-CDS_OFFLINE_AFTER_MILLIS
+}
+
+func (o *ClusterDiscovery) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.id.app_error", nil, "")
+	}
+
+	if len(o.Type) == 0 || len(o.Type) > 64 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.type.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.Hostname) == 0 || len(o.Hostname) > 512 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.hostname.app_error", nil, "id="+o.Id)
+	}
+
+	if !IsValidHttpUrl(o.InterNodeUrl) || len(o.InterNodeUrl) > 512 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.internode_url.app_error", nil, "id="+o.Id)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if o.LastPingAt == 0 {
+		return NewLocAppError("ClusterDiscovery.IsValid", "model.cluster.is_valid.last_ping_at.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *ClusterDiscovery) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func ClusterDiscoveryFromJson(data io.Reader) *ClusterDiscovery {
+	decoder := json.NewDecoder(data)
+	var o ClusterDiscovery
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterDiscoveryIsValid(t *testing.T) {
+	o := ClusterDiscovery{
+		Type:         CDS_TYPE_APP,
+		Hostname:     "localhost",
+		InterNodeUrl: "http://localhost:8075",
+	}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Id = "1234"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Id = NewId()
+	o.Type = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Type = CDS_TYPE_APP
+	o.Hostname = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Hostname = "localhost"
+	o.InterNodeUrl = "localhost:8075"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.InterNodeUrl = "http://localhost:8075"
+	o.LastPingAt = 0
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestClusterDiscoveryIsAlive(t *testing.T) {
+	o := ClusterDiscovery{}
+	o.PreSave()
+
+	if !o.IsAlive() {
+		t.Fatal("should be alive")
+	}
+
+	o.LastPingAt = GetMillis() - CDS_OFFLINE_AFTER_MILLIS - 1000
+	if o.IsAlive() {
+		t.Fatal("should not be alive")
+	}
+}
+
+func TestClusterDiscoveryJson(t *testing.T) {
+	o := ClusterDiscovery{Type: CDS_TYPE_APP, Hostname: "localhost", InterNodeUrl: "http://localhost:8075"}
+	o.PreSave()
+	json := o.ToJson()
+	ro := ClusterDiscoveryFromJson(strings.NewReader(json))
+
+	if o.Id != ro.Id || o.InterNodeUrl != ro.InterNodeUrl {
+		t.Fatal("discoveries do not match")
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestClusterMessage(t *testing.T) {
+	m := ClusterMessage{
+		Event:    CLUSTER_EVENT_PUBLISH,
+		SendType: CLUSTER_SEND_BEST_EFFORT,
+		Data:     "hello",
+		Props:    map[string]string{"team_id": NewId()},
+	}
+	json := m.ToJson()
+	result := ClusterMessageFromJson(strings.NewReader(json))
+
+	if result.Event != CLUSTER_EVENT_PUBLISH || result.Data != "hello" || result.Props["team_id"] != m.Props["team_id"] {
+		t.Fatal("messages do not match")
+	}
+
+	if result.SendType != "" {
+		t.Fatal("send type should not be serialized")
+	}
+
+	if ClusterMessageFromJson(strings.NewReader("junk")) != nil {
+		t.Fatal("should have failed to parse")
+	}
+}
@@ -17,23 +17,24 @@ const (
 )
 
 type Command struct {
-	Id               string `json:"id"`
-	Token            string `json:"token"`
-	CreateAt         int64  `json:"create_at"`
-	UpdateAt         int64  `json:"update_at"`
-	DeleteAt         int64  `json:"delete_at"`
-	CreatorId        string `json:"creator_id"`
-	TeamId           string `json:"team_id"`
-	Trigger          string `json:"trigger"`
-	Method           string `json:"method"`
-	Username         string `json:"username"`
-	IconURL          string `json:"icon_url"`
-	AutoComplete     bool   `json:"auto_complete"`
-	AutoCompleteDesc string `json:"auto_complete_desc"`
-	AutoCompleteHint string `json:"auto_complete_hint"`
-	DisplayName      string `json:"display_name"`
-	Description      string `json:"description"`
-	URL              string `json:"url"`
+	Id               string              `json:"id"`
+	Token            string              `json:"token"`
+	CreateAt         int64               `json:"create_at"`
+	UpdateAt         int64               `json:"update_at"`
+	DeleteAt         int64               `json:"delete_at"`
+	CreatorId        string              `json:"creator_id"`
+	TeamId           string              `json:"team_id"`
+	Trigger          string              `json:"trigger"`
+	Method           string              `json:"method"`
+	Username         string              `json:"username"`
+	IconURL          string              `json:"icon_url"`
+	AutoComplete     bool                `json:"auto_complete"`
+	AutoCompleteDesc string              `json:"auto_complete_desc"`
+	AutoCompleteHint string              `json:"auto_complete_hint"`
+	AutocompleteArgs AutocompleteArgList `json:"autocomplete_args,omitempty"`
+	DisplayName      string              `json:"display_name"`
+	Description      string              `json:"description"`
+	URL              string              `json:"url"`
 }
 
 func (o *Command) ToJson() string {
@@ -126,6 +127,10 @@ func (o *Command) IsValid() *AppError {
 		return NewLocAppError("Command.IsValid", "model.command.is_valid.description.app_error", nil, "")
 	}
 
+	if err := o.AutocompleteArgs.IsValid(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -138,6 +143,10 @@ func (o *Command) PreSave() {
 		o.Token = NewId()
 	}
 
+	if o.AutocompleteArgs == nil {
+		o.AutocompleteArgs = AutocompleteArgList{}
+	}
+
 	o.CreateAt = GetMillis()
 	o.UpdateAt = o.CreateAt
 }
@@ -153,4 +162,12 @@ func (o *Command) Sanitize() {
 	o.URL = ""
 	o.Username = ""
 	o.IconURL = ""
+
+	args := make(AutocompleteArgList, len(o.AutocompleteArgs))
+	for i, arg := range o.AutocompleteArgs {
+		cpy := *arg
+		cpy.FetchURL = ""
+		args[i] = &cpy
+	}
+	o.AutocompleteArgs = args
 }
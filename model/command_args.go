@@ -20,6 +20,11 @@ type CommandArgs struct {
 	SiteURL   string               `json:"-"`
 	T         goi18n.TranslateFunc `json:"-"`
 	Session   Session              `json:"-"`
+
+	// ResponseURL and ResponseToken are set when executing a custom command so that it can send delayed
+	// responses until its webhook expires or runs out of uses.
+	ResponseURL   string `json:"-"`
+	ResponseToken string `json:"-"`
 }
 
 func (o *CommandArgs) ToJson() string {
@@ -0,0 +1,170 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	AUTOCOMPLETE_ARG_TYPE_TEXT         = "text"
+	AUTOCOMPLETE_ARG_TYPE_STATIC_LIST  = "static_list"
+	AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST = "dynamic_list"
+
+	AUTOCOMPLETE_ARG_FLAG_PREFIX = "--"
+
+	AUTOCOMPLETE_ARG_MAX_NAME_LENGTH = 64
+	AUTOCOMPLETE_ARGS_MAX_COUNT      = 20
+	AUTOCOMPLETE_ARGS_MAX_JSON_RUNES = 4000
+)
+
+// AutocompleteArg describes an argument of a slash command. Arguments without a name are positional
+// and are matched in order, named arguments are flags typed as --name followed by their value.
+type AutocompleteArg struct {
+	Name     string                  `json:"name,omitempty"`
+	HelpText string                  `json:"help_text"`
+	Hint     string                  `json:"hint,omitempty"`
+	Type     string                  `json:"type"`
+	Required bool                    `json:"required,omitempty"`
+	Choices  []*AutocompleteListItem `json:"choices,omitempty"`
+	FetchURL string                  `json:"fetch_url,omitempty"`
+}
+
+// AutocompleteListItem is one of the values offered for a list argument. Dynamic list arguments
+// receive them from the integration's fetch URL as a JSON array.
+type AutocompleteListItem struct {
+	Item     string `json:"item"`
+	HelpText string `json:"help_text,omitempty"`
+	Hint     string `json:"hint,omitempty"`
+}
+
+type AutocompleteArgList []*AutocompleteArg
+
+// AutocompleteSuggestion is returned for a partially typed command line. Complete holds the whole
+// command line once the suggestion is accepted.
+type AutocompleteSuggestion struct {
+	Complete    string `json:"complete"`
+	Suggestion  string `json:"suggestion"`
+	Hint        string `json:"hint"`
+	Description string `json:"description"`
+}
+
+func (o *AutocompleteArg) IsFlag() bool {
+	return len(o.Name) > 0
+}
+
+func (o *AutocompleteArg) IsValid() *AppError {
+	if len(o.Name) > AUTOCOMPLETE_ARG_MAX_NAME_LENGTH || strings.ContainsAny(o.Name, " \t") || strings.HasPrefix(o.Name, "-") {
+		return NewLocAppError("AutocompleteArg.IsValid", "model.command.is_valid.autocomplete_arg_name.app_error", nil, "name="+o.Name)
+	}
+
+	switch o.Type {
+	case AUTOCOMPLETE_ARG_TYPE_TEXT:
+	case AUTOCOMPLETE_ARG_TYPE_STATIC_LIST:
+		if len(o.Choices) == 0 {
+			return NewLocAppError("AutocompleteArg.IsValid", "model.command.is_valid.autocomplete_arg_choices.app_error", nil, "name="+o.Name)
+		}
+	case AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST:
+		if len(o.FetchURL) > 0 && !IsValidHttpUrl(o.FetchURL) {
+			return NewLocAppError("AutocompleteArg.IsValid", "model.command.is_valid.autocomplete_arg_url.app_error", nil, "name="+o.Name)
+		}
+	default:
+		return NewLocAppError("AutocompleteArg.IsValid", "model.command.is_valid.autocomplete_arg_type.app_error", nil, "name="+o.Name)
+	}
+
+	return nil
+}
+
+func (l AutocompleteArgList) IsValid() *AppError {
+	if len(l) > AUTOCOMPLETE_ARGS_MAX_COUNT {
+		return NewLocAppError("AutocompleteArgList.IsValid", "model.command.is_valid.autocomplete_args.app_error", nil, "")
+	}
+
+	seen := make(map[string]bool)
+	for _, arg := range l {
+		if arg == nil {
+			return NewLocAppError("AutocompleteArgList.IsValid", "model.command.is_valid.autocomplete_args.app_error", nil, "")
+		}
+
+		if err := arg.IsValid(); err != nil {
+			return err
+		}
+
+		if arg.IsFlag() {
+			if seen[arg.Name] {
+				return NewLocAppError("AutocompleteArgList.IsValid", "model.command.is_valid.autocomplete_arg_name.app_error", nil, "name="+arg.Name)
+			}
+			seen[arg.Name] = true
+		}
+	}
+
+	// the arguments are stored as JSON so they must fit in the column once serialized
+	if b, err := json.Marshal(l); err != nil || utf8.RuneCount(b) > AUTOCOMPLETE_ARGS_MAX_JSON_RUNES {
+		return NewLocAppError("AutocompleteArgList.IsValid", "model.command.is_valid.autocomplete_args.app_error", nil, "")
+	}
+
+	return nil
+}
+
+// Positional returns the arguments that aren't flags in the order they are typed.
+func (l AutocompleteArgList) Positional() []*AutocompleteArg {
+	args := []*AutocompleteArg{}
+	for _, arg := range l {
+		if !arg.IsFlag() {
+			args = append(args, arg)
+		}
+	}
+
+	return args
+}
+
+// Flag returns the flag with the given name or nil if the command doesn't have one.
+func (l AutocompleteArgList) Flag(name string) *AutocompleteArg {
+	for _, arg := range l {
+		if arg.IsFlag() && arg.Name == name {
+			return arg
+		}
+	}
+
+	return nil
+}
+
+func AutocompleteListItemsToJson(l []*AutocompleteListItem) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func AutocompleteListItemsFromJson(data io.Reader) []*AutocompleteListItem {
+	decoder := json.NewDecoder(data)
+	var o []*AutocompleteListItem
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	}
+	return o
+}
+
+func AutocompleteSuggestionsToJson(l []*AutocompleteSuggestion) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func AutocompleteSuggestionsFromJson(data io.Reader) []*AutocompleteSuggestion {
+	decoder := json.NewDecoder(data)
+	var o []*AutocompleteSuggestion
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	}
+	return o
+}
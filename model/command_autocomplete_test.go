@@ -0,0 +1,98 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAutocompleteArgListIsValid(t *testing.T) {
+	args := AutocompleteArgList{
+		{Type: AUTOCOMPLETE_ARG_TYPE_TEXT},
+	}
+	if err := args.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	args[0].Type = "junk"
+	if err := args.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	args[0].Type = AUTOCOMPLETE_ARG_TYPE_STATIC_LIST
+	if err := args.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	args[0].Choices = []*AutocompleteListItem{{Item: "item"}}
+	if err := args.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	args = append(args, &AutocompleteArg{Name: "flag", Type: AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST, FetchURL: "junk"})
+	if err := args.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	args[1].FetchURL = "http://example.com/suggestions"
+	if err := args.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	args = append(args, &AutocompleteArg{Name: "flag", Type: AUTOCOMPLETE_ARG_TYPE_TEXT})
+	if err := args.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	args[2].Name = "--other"
+	if err := args.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	args[2].Name = "other"
+	if err := args.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(args.Positional()) != 1 || args.Flag("other") != args[2] || args.Flag("junk") != nil {
+		t.Fatal("should have split positional arguments and flags")
+	}
+
+	args[0].Choices = []*AutocompleteListItem{}
+	for i := 0; i < 100; i++ {
+		args[0].Choices = append(args[0].Choices, &AutocompleteListItem{Item: NewId(), HelpText: NewId()})
+	}
+	if err := args.IsValid(); err == nil {
+		t.Fatal("should be invalid - too long once serialized")
+	}
+}
+
+func TestCommandSanitizeAutocompleteArgs(t *testing.T) {
+	arg := &AutocompleteArg{Name: "flag", Type: AUTOCOMPLETE_ARG_TYPE_DYNAMIC_LIST, FetchURL: "http://example.com"}
+	o := Command{AutocompleteArgs: AutocompleteArgList{arg}}
+	o.Sanitize()
+
+	if o.AutocompleteArgs[0].FetchURL != "" || o.AutocompleteArgs[0].Name != "flag" {
+		t.Fatal("should have removed the fetch url")
+	}
+
+	if arg.FetchURL == "" {
+		t.Fatal("should not have modified the original argument")
+	}
+}
+
+func TestAutocompleteJson(t *testing.T) {
+	items := []*AutocompleteListItem{{Item: "item", HelpText: "help"}}
+	ritems := AutocompleteListItemsFromJson(strings.NewReader(AutocompleteListItemsToJson(items)))
+	if len(ritems) != 1 || ritems[0].Item != "item" || ritems[0].HelpText != "help" {
+		t.Fatal("items do not match")
+	}
+
+	suggestions := []*AutocompleteSuggestion{{Complete: "/cmd item", Suggestion: "item"}}
+	rsuggestions := AutocompleteSuggestionsFromJson(strings.NewReader(AutocompleteSuggestionsToJson(suggestions)))
+	if len(rsuggestions) != 1 || rsuggestions[0].Complete != "/cmd item" {
+		t.Fatal("suggestions do not match")
+	}
+}
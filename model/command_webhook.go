@@ -0,0 +1,80 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+// CommandWebhook is created for each execution of a custom slash command. Its id and token are part of
+// the response_url sent to the integration, which can use it to post a limited number of delayed
+// responses to the channel the command was run in until it expires.
+type CommandWebhook struct {
+	Id        string
+	Token     string
+	CreateAt  int64
+	CommandId string
+	UserId    string
+	ChannelId string
+	RootId    string
+	ParentId  string
+	UseCount  int
+}
+
+func (o *CommandWebhook) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.Token == "" {
+		o.Token = NewId()
+	}
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *CommandWebhook) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("CommandWebhook.IsValid", "model.command_hook.id.app_error", nil, "")
+	}
+
+	if len(o.Token) != 26 {
+		return NewLocAppError("CommandWebhook.IsValid", "model.command_hook.token.app_error", nil, "id="+o.Id)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("CommandWebhook.IsValid", "model.command_hook.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.CommandId) != 26 {
+		return NewLocAppError("CommandWebhook.IsValid", "model.command_hook.command_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.UserId) != 26 {
+		return NewLocAppError("CommandWebhook.IsValid", "model.command_hook.user_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.ChannelId) != 26 {
+		return NewLocAppError("CommandWebhook.IsValid", "model.command_hook.channel_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.RootId) != 0 && len(o.RootId) != 26 {
+		return NewLocAppError("CommandWebhook.IsValid", "model.command_hook.root_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.ParentId) != 0 && len(o.ParentId) != 26 {
+		return NewLocAppError("CommandWebhook.IsValid", "model.command_hook.parent_id.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+// GetResponseURL returns the address that the integration posts delayed responses to.
+func (o *CommandWebhook) GetResponseURL(siteURL string) string {
+	return siteURL + API_URL_SUFFIX + "/hooks/commands/" + o.Id + "?token=" + o.Token
+}
+
+// IsExpired returns true once the webhook is older than the given number of milliseconds and can no longer be
+// used to respond to its command.
+func (o *CommandWebhook) IsExpired(lifetime int64) bool {
+	return GetMillis() > o.CreateAt+lifetime
+}
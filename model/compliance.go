@@ -16,8 +16,9 @@ const (
 	COMPLIANCE_STATUS_FAILED   = "failed"
 	COMPLIANCE_STATUS_REMOVED  = "removed"
 
-	COMPLIANCE_TYPE_DAILY = "daily"
-	COMPLIANCE_TYPE_ADHOC = "adhoc"
+	COMPLIANCE_TYPE_DAILY  = "daily"
+	COMPLIANCE_TYPE_ADHOC  = "adhoc"
+	COMPLIANCE_TYPE_EXPORT = "export"
 )
 
 type Compliance struct {
@@ -63,7 +64,7 @@ func (me *Compliance) PreSave() {
 
 func (me *Compliance) JobName() string {
 	jobName := me.Type
-	if me.Type == COMPLIANCE_TYPE_DAILY {
+	if me.Type == COMPLIANCE_TYPE_DAILY || me.Type == COMPLIANCE_TYPE_EXPORT {
 		jobName += "-" + me.Desc
 	}
 
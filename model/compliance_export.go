@@ -0,0 +1,182 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	COMPLIANCE_EXPORT_EVENT_MESSAGE          = "message"
+	COMPLIANCE_EXPORT_EVENT_EDITED           = "edited"
+	COMPLIANCE_EXPORT_EVENT_PREVIOUS_VERSION = "previous_version"
+	COMPLIANCE_EXPORT_EVENT_DELETED          = "deleted"
+	COMPLIANCE_EXPORT_EVENT_JOIN             = "join"
+	COMPLIANCE_EXPORT_EVENT_LEAVE            = "leave"
+)
+
+// A ComplianceExportPost is a post that has been created or changed since the last compliance export along
+// with the team, channel and user that it belongs to.
+type ComplianceExportPost struct {
+
+	// From Team
+	TeamName        string
+	TeamDisplayName string
+
+	// From Channel
+	ChannelId          string
+	ChannelName        string
+	ChannelDisplayName string
+	ChannelType        string
+
+	// From User
+	UserId       string
+	UserUsername string
+	UserEmail    string
+	UserNickname string
+
+	// From Post
+	PostId         string
+	PostCreateAt   int64
+	PostUpdateAt   int64
+	PostEditAt     int64
+	PostDeleteAt   int64
+	PostRootId     string
+	PostOriginalId string
+	PostMessage    string
+	PostType       string
+	PostProps      string
+	PostFileIds    string
+
+	// Attached files, which are loaded separately
+	Files []*FileInfo `db:"-"`
+}
+
+// A ComplianceExportEvent is something that happened to a post that's written to a compliance export.
+type ComplianceExportEvent struct {
+	Type string
+	Time int64
+	Post *ComplianceExportPost
+}
+
+// GetFileIds returns the ids of the files attached to the post.
+func (me *ComplianceExportPost) GetFileIds() StringArray {
+	if me.PostFileIds == "" {
+		return StringArray{}
+	}
+
+	return ArrayFromJson(strings.NewReader(me.PostFileIds))
+}
+
+// Events returns what happened to the post after the given time. A post can be exported more than once
+// since its UpdateAt also changes when it's replied to or reacted to, so nothing is returned for changes
+// that don't need to be recorded. Copies of edited posts are exported as the previous version of the
+// original post at the time that they were replaced.
+func (me *ComplianceExportPost) Events(since int64) []*ComplianceExportEvent {
+	events := []*ComplianceExportEvent{}
+
+	if me.PostOriginalId != "" {
+		if me.PostDeleteAt > since {
+			events = append(events, &ComplianceExportEvent{Type: COMPLIANCE_EXPORT_EVENT_PREVIOUS_VERSION, Time: me.PostDeleteAt, Post: me})
+		}
+
+		return events
+	}
+
+	if me.PostCreateAt > since {
+		eventType := COMPLIANCE_EXPORT_EVENT_MESSAGE
+		switch me.PostType {
+		case POST_JOIN_CHANNEL, POST_ADD_TO_CHANNEL, POST_JOIN_LEAVE:
+			eventType = COMPLIANCE_EXPORT_EVENT_JOIN
+		case POST_LEAVE_CHANNEL, POST_REMOVE_FROM_CHANNEL:
+			eventType = COMPLIANCE_EXPORT_EVENT_LEAVE
+		}
+
+		events = append(events, &ComplianceExportEvent{Type: eventType, Time: me.PostCreateAt, Post: me})
+	} else if me.PostEditAt > since {
+		events = append(events, &ComplianceExportEvent{Type: COMPLIANCE_EXPORT_EVENT_EDITED, Time: me.PostEditAt, Post: me})
+	}
+
+	if me.PostDeleteAt > since {
+		events = append(events, &ComplianceExportEvent{Type: COMPLIANCE_EXPORT_EVENT_DELETED, Time: me.PostDeleteAt, Post: me})
+	}
+
+	return events
+}
+
+func ComplianceExportHeader() []string {
+	return []string{
+		"EventType",
+		"EventTime",
+
+		"TeamName",
+		"TeamDisplayName",
+
+		"ChannelId",
+		"ChannelName",
+		"ChannelDisplayName",
+		"ChannelType",
+
+		"UserId",
+		"UserUsername",
+		"UserEmail",
+		"UserNickname",
+
+		"PostId",
+		"PostCreateAt",
+		"PostEditAt",
+		"PostDeleteAt",
+		"PostRootId",
+		"PostOriginalId",
+		"PostMessage",
+		"PostType",
+		"PostProps",
+		"PostFiles",
+	}
+}
+
+func (me *ComplianceExportEvent) Row() []string {
+	files := make([]string, len(me.Post.Files))
+	for i, info := range me.Post.Files {
+		files[i] = info.Name + " (" + info.Path + ")"
+	}
+
+	return []string{
+		me.Type,
+		complianceExportTime(me.Time),
+
+		me.Post.TeamName,
+		me.Post.TeamDisplayName,
+
+		me.Post.ChannelId,
+		me.Post.ChannelName,
+		me.Post.ChannelDisplayName,
+		me.Post.ChannelType,
+
+		me.Post.UserId,
+		me.Post.UserUsername,
+		me.Post.UserEmail,
+		me.Post.UserNickname,
+
+		me.Post.PostId,
+		complianceExportTime(me.Post.PostCreateAt),
+		complianceExportTime(me.Post.PostEditAt),
+		complianceExportTime(me.Post.PostDeleteAt),
+		me.Post.PostRootId,
+		me.Post.PostOriginalId,
+		me.Post.PostMessage,
+		me.Post.PostType,
+		me.Post.PostProps,
+		strings.Join(files, ", "),
+	}
+}
+
+func complianceExportTime(millis int64) string {
+	if millis == 0 {
+		return ""
+	}
+
+	return time.Unix(0, millis*int64(1000*1000)).UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"testing"
+)
+
+func TestComplianceExportPostEvents(t *testing.T) {
+	post := &ComplianceExportPost{PostId: NewId(), PostCreateAt: 2000, PostUpdateAt: 2000}
+
+	if events := post.Events(1000); len(events) != 1 || events[0].Type != COMPLIANCE_EXPORT_EVENT_MESSAGE || events[0].Time != 2000 {
+		t.Fatal("new post should be exported as a message")
+	}
+
+	if events := post.Events(3000); len(events) != 0 {
+		t.Fatal("unchanged post shouldn't be exported")
+	}
+
+	post.PostEditAt = 4000
+	if events := post.Events(3000); len(events) != 1 || events[0].Type != COMPLIANCE_EXPORT_EVENT_EDITED || events[0].Time != 4000 {
+		t.Fatal("edited post should be exported as an edit")
+	}
+
+	if events := post.Events(1000); len(events) != 1 || events[0].Type != COMPLIANCE_EXPORT_EVENT_MESSAGE {
+		t.Fatal("post that was edited before being exported should only be exported as a message")
+	}
+
+	post.PostDeleteAt = 5000
+	if events := post.Events(1000); len(events) != 2 || events[0].Type != COMPLIANCE_EXPORT_EVENT_MESSAGE || events[1].Type != COMPLIANCE_EXPORT_EVENT_DELETED {
+		t.Fatal("post that was created and deleted should be exported as both")
+	}
+
+	if events := post.Events(4500); len(events) != 1 || events[0].Type != COMPLIANCE_EXPORT_EVENT_DELETED || events[0].Time != 5000 {
+		t.Fatal("deleted post should be exported as a deletion")
+	}
+
+	post.PostOriginalId = NewId()
+	if events := post.Events(1000); len(events) != 1 || events[0].Type != COMPLIANCE_EXPORT_EVENT_PREVIOUS_VERSION || events[0].Time != 5000 {
+		t.Fatal("copy of an edited post should be exported as a previous version")
+	}
+
+	if events := post.Events(5000); len(events) != 0 {
+		t.Fatal("previous version shouldn't be exported again")
+	}
+
+	join := &ComplianceExportPost{PostType: POST_ADD_TO_CHANNEL, PostCreateAt: 2000}
+	if events := join.Events(1000); len(events) != 1 || events[0].Type != COMPLIANCE_EXPORT_EVENT_JOIN {
+		t.Fatal("should be exported as a join")
+	}
+
+	leave := &ComplianceExportPost{PostType: POST_LEAVE_CHANNEL, PostCreateAt: 2000}
+	if events := leave.Events(1000); len(events) != 1 || events[0].Type != COMPLIANCE_EXPORT_EVENT_LEAVE {
+		t.Fatal("should be exported as a leave")
+	}
+}
+
+func TestComplianceExportPostGetFileIds(t *testing.T) {
+	post := &ComplianceExportPost{}
+	if len(post.GetFileIds()) != 0 {
+		t.Fatal("should have no files")
+	}
+
+	post.PostFileIds = `["file1","file2"]`
+	if fileIds := post.GetFileIds(); len(fileIds) != 2 || fileIds[0] != "file1" || fileIds[1] != "file2" {
+		t.Fatal("got incorrect file ids", fileIds)
+	}
+}
+
+func TestComplianceExportEventRow(t *testing.T) {
+	post := &ComplianceExportPost{
+		TeamName:     "test",
+		PostCreateAt: GetMillis(),
+		Files:        []*FileInfo{{Name: "a.txt", Path: "a/a.txt"}, {Name: "b.txt", Path: "b/b.txt"}},
+	}
+	event := &ComplianceExportEvent{Type: COMPLIANCE_EXPORT_EVENT_MESSAGE, Time: post.PostCreateAt, Post: post}
+
+	r := event.Row()
+	if len(r) != len(ComplianceExportHeader()) {
+		t.Fatal("row should match the header")
+	}
+
+	if r[0] != COMPLIANCE_EXPORT_EVENT_MESSAGE || r[2] != "test" {
+		t.Fatal("got incorrect row", r)
+	}
+
+	if r[14] != "" {
+		t.Fatal("post that wasn't edited shouldn't have an edit time")
+	}
+
+	if r[len(r)-1] != "a.txt (a/a.txt), b.txt (b/b.txt)" {
+		t.Fatal("got incorrect files", r[len(r)-1])
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"io"
 	"net/url"
+	"strings"
 )
 
 const (
@@ -73,7 +74,9 @@ const (
 	TEAM_SETTINGS_DEFAULT_CUSTOM_DESCRIPTION_TEXT  = ""
 	TEAM_SETTINGS_DEFAULT_USER_STATUS_AWAY_TIMEOUT = 300
 
-	EMAIL_SETTINGS_DEFAULT_FEEDBACK_ORGANIZATION = ""
+	EMAIL_SETTINGS_DEFAULT_FEEDBACK_ORGANIZATION           = ""
+	EMAIL_SETTINGS_DEFAULT_REPLY_BY_EMAIL_LISTEN           = "127.0.0.1:2525"
+	EMAIL_SETTINGS_DEFAULT_REPLY_BY_EMAIL_MAX_MESSAGE_SIZE = 25 * 1024 * 1024 // 25MB
 
 	SUPPORT_SETTINGS_DEFAULT_TERMS_OF_SERVICE_LINK = "https://about.mattermost.com/default-terms/"
 	SUPPORT_SETTINGS_DEFAULT_PRIVACY_POLICY_LINK   = "https://about.mattermost.com/default-privacy-policy/"
@@ -107,6 +110,20 @@ const (
 	WEBRTC_SETTINGS_DEFAULT_TURN_URI = ""
 
 	ANALYTICS_SETTINGS_DEFAULT_MAX_USERS_FOR_STATISTICS = 2500
+
+	IMAGE_PROXY_SETTINGS_DEFAULT_MAX_IMAGE_SIZE = 10 * 1024 * 1024 // 10MB
+
+	FILE_SETTINGS_DEFAULT_MAX_TEXT_PREVIEW_SIZE = 64 * 1024 // 64KB
+	FILE_SETTINGS_DEFAULT_CLAMAV_ADDRESS        = "localhost:3310"
+	FILE_SETTINGS_DEFAULT_FILE_SCAN_TIMEOUT     = 30
+	FILE_SETTINGS_DEFAULT_MAX_FILE_SCAN_SIZE    = 25 * 1024 * 1024 // 25MB, clamd's default StreamMaxLength
+
+	INFECTED_FILE_ACTION_REJECT     = "reject"
+	INFECTED_FILE_ACTION_QUARANTINE = "quarantine"
+
+	COMPLIANCE_EXPORT_FORMAT_CSV         = "csv"
+	COMPLIANCE_EXPORT_FORMAT_ACTIANCE    = "actiance"
+	COMPLIANCE_EXPORT_FORMAT_GLOBALRELAY = "globalrelay"
 )
 
 type ServiceSettings struct {
@@ -121,19 +138,31 @@ type ServiceSettings struct {
 	ReadTimeout                              *int
 	WriteTimeout                             *int
 	MaximumLoginAttempts                     int
+	EnableLoginLockout                       *bool
+	LoginAttemptWindowSeconds                *int
+	MaximumLoginAttemptsPerIpAddress         *int
+	MaximumLoginAttemptsPerLoginId           *int
+	LoginLockoutDurationSeconds              *int
 	GoogleDeveloperKey                       string
 	EnableOAuthServiceProvider               bool
 	EnableIncomingWebhooks                   bool
 	EnableOutgoingWebhooks                   bool
 	EnableCommands                           *bool
 	EnableOnlyAdminIntegrations              *bool
+	CommandWebhookMaxUses                    *int
+	CommandWebhookLifetimeInMinutes          *int
 	EnablePostUsernameOverride               bool
 	EnablePostIconOverride                   bool
 	EnableLinkPreviews                       *bool
+	LinkPreviewCacheInMinutes                *int
 	EnableTesting                            bool
 	EnableDeveloper                          *bool
 	EnableSecurityFixAlert                   *bool
 	EnableInsecureOutgoingConnections        *bool
+	AllowedUntrustedInternalConnections      *string
+	OutgoingProxyURL                         *string
+	OutgoingConnectTimeoutInSeconds          *int
+	OutgoingRequestTimeoutInSeconds          *int
 	EnableMultifactorAuthentication          *bool
 	EnforceMultifactorAuthentication         *bool
 	AllowCorsFrom                            *string
@@ -170,6 +199,12 @@ type AnalyticsSettings struct {
 	MaxUsersForStatistics *int
 }
 
+type ImageProxySettings struct {
+	Enable       *bool
+	SigningKey   *string
+	MaxImageSize *int64
+}
+
 type SSOSettings struct {
 	Enable          bool
 	Secret          string
@@ -228,39 +263,66 @@ type FileSettings struct {
 	AmazonS3Region          string
 	AmazonS3Endpoint        string
 	AmazonS3SSL             *bool
+	PdfPreviewConverter     *string
+	VideoPreviewConverter   *string
+	MaxTextPreviewSize      *int64
+	ExtractContent          *bool
+	PdfContentConverter     *string
+	EnableFileScanning      *bool
+	ClamAVAddress           *string
+	InfectedFileAction      *string
+	FileScanTimeout         *int
+	MaxFileScanSize         *int64
+	MaxStoragePerTeam       *int64
+	MaxStoragePerUser       *int64
 }
 
 type EmailSettings struct {
-	EnableSignUpWithEmail    bool
-	EnableSignInWithEmail    *bool
-	EnableSignInWithUsername *bool
-	SendEmailNotifications   bool
-	RequireEmailVerification bool
-	FeedbackName             string
-	FeedbackEmail            string
-	FeedbackOrganization     *string
-	SMTPUsername             string
-	SMTPPassword             string
-	SMTPServer               string
-	SMTPPort                 string
-	ConnectionSecurity       string
-	InviteSalt               string
-	PasswordResetSalt        string
-	SendPushNotifications    *bool
-	PushNotificationServer   *string
-	PushNotificationContents *string
-	EnableEmailBatching      *bool
-	EmailBatchingBufferSize  *int
-	EmailBatchingInterval    *int
+	EnableSignUpWithEmail      bool
+	EnableSignInWithEmail      *bool
+	EnableSignInWithUsername   *bool
+	SendEmailNotifications     bool
+	RequireEmailVerification   bool
+	FeedbackName               string
+	FeedbackEmail              string
+	FeedbackOrganization       *string
+	SMTPUsername               string
+	SMTPPassword               string
+	SMTPServer                 string
+	SMTPPort                   string
+	ConnectionSecurity         string
+	InviteSalt                 string
+	PasswordResetSalt          string
+	SendPushNotifications      *bool
+	PushNotificationServer     *string
+	PushNotificationContents   *string
+	EnableEmailBatching        *bool
+	EmailBatchingBufferSize    *int
+	EmailBatchingInterval      *int
+	EnableReplyByEmail         *bool
+	ReplyByEmailAddress        *string
+	ReplyByEmailListen         *string
+	ReplyByEmailMaxMessageSize *int64
+	ReplyByEmailSalt           *string
 }
 
 type RateLimitSettings struct {
-	Enable           *bool
-	PerSec           int
-	MaxBurst         *int
-	MemoryStoreSize  int
-	VaryByRemoteAddr bool
-	VaryByHeader     string
+	Enable             *bool
+	PerSec             int
+	MaxBurst           *int
+	MemoryStoreSize    int
+	VaryByRemoteAddr   bool
+	VaryByHeader       string
+	VaryByUser         *bool
+	LoginPerSec        *int
+	LoginMaxBurst      *int
+	PostCreatePerSec   *int
+	PostCreateMaxBurst *int
+	SearchPerSec       *int
+	SearchMaxBurst     *int
+	FileUploadPerSec   *int
+	FileUploadMaxBurst *int
+	ShareAcrossCluster *bool
 }
 
 type PrivacySettings struct {
@@ -335,9 +397,11 @@ type LdapSettings struct {
 }
 
 type ComplianceSettings struct {
-	Enable      *bool
-	Directory   *string
-	EnableDaily *bool
+	Enable       *bool
+	Directory    *string
+	EnableDaily  *bool
+	EnableExport *bool
+	ExportFormat *string
 }
 
 type LocalizationSettings struct {
@@ -412,6 +476,7 @@ type Config struct {
 	MetricsSettings      MetricsSettings
 	AnalyticsSettings    AnalyticsSettings
 	WebrtcSettings       WebrtcSettings
+	ImageProxySettings   ImageProxySettings
 }
 
 func (o *Config) ToJson() string {
@@ -473,6 +538,66 @@ func (o *Config) SetDefaults() {
 		*o.FileSettings.MaxFileSize = 52428800 // 50 MB
 	}
 
+	if o.FileSettings.PdfPreviewConverter == nil {
+		o.FileSettings.PdfPreviewConverter = new(string)
+		*o.FileSettings.PdfPreviewConverter = ""
+	}
+
+	if o.FileSettings.VideoPreviewConverter == nil {
+		o.FileSettings.VideoPreviewConverter = new(string)
+		*o.FileSettings.VideoPreviewConverter = ""
+	}
+
+	if o.FileSettings.MaxTextPreviewSize == nil {
+		o.FileSettings.MaxTextPreviewSize = new(int64)
+		*o.FileSettings.MaxTextPreviewSize = FILE_SETTINGS_DEFAULT_MAX_TEXT_PREVIEW_SIZE
+	}
+
+	if o.FileSettings.ExtractContent == nil {
+		o.FileSettings.ExtractContent = new(bool)
+		*o.FileSettings.ExtractContent = true
+	}
+
+	if o.FileSettings.PdfContentConverter == nil {
+		o.FileSettings.PdfContentConverter = new(string)
+		*o.FileSettings.PdfContentConverter = ""
+	}
+
+	if o.FileSettings.MaxStoragePerTeam == nil {
+		o.FileSettings.MaxStoragePerTeam = new(int64)
+		*o.FileSettings.MaxStoragePerTeam = 0
+	}
+
+	if o.FileSettings.MaxStoragePerUser == nil {
+		o.FileSettings.MaxStoragePerUser = new(int64)
+		*o.FileSettings.MaxStoragePerUser = 0
+	}
+
+	if o.FileSettings.EnableFileScanning == nil {
+		o.FileSettings.EnableFileScanning = new(bool)
+		*o.FileSettings.EnableFileScanning = false
+	}
+
+	if o.FileSettings.ClamAVAddress == nil {
+		o.FileSettings.ClamAVAddress = new(string)
+		*o.FileSettings.ClamAVAddress = FILE_SETTINGS_DEFAULT_CLAMAV_ADDRESS
+	}
+
+	if o.FileSettings.InfectedFileAction == nil {
+		o.FileSettings.InfectedFileAction = new(string)
+		*o.FileSettings.InfectedFileAction = INFECTED_FILE_ACTION_REJECT
+	}
+
+	if o.FileSettings.FileScanTimeout == nil {
+		o.FileSettings.FileScanTimeout = new(int)
+		*o.FileSettings.FileScanTimeout = FILE_SETTINGS_DEFAULT_FILE_SCAN_TIMEOUT
+	}
+
+	if o.FileSettings.MaxFileScanSize == nil {
+		o.FileSettings.MaxFileScanSize = new(int64)
+		*o.FileSettings.MaxFileScanSize = FILE_SETTINGS_DEFAULT_MAX_FILE_SCAN_SIZE
+	}
+
 	if o.FileSettings.PublicLinkSalt == nil || len(*o.FileSettings.PublicLinkSalt) == 0 {
 		o.FileSettings.PublicLinkSalt = new(string)
 		*o.FileSettings.PublicLinkSalt = NewRandomString(32)
@@ -501,6 +626,11 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.EnableLinkPreviews = false
 	}
 
+	if o.ServiceSettings.LinkPreviewCacheInMinutes == nil {
+		o.ServiceSettings.LinkPreviewCacheInMinutes = new(int)
+		*o.ServiceSettings.LinkPreviewCacheInMinutes = 60
+	}
+
 	if o.ServiceSettings.EnableDeveloper == nil {
 		o.ServiceSettings.EnableDeveloper = new(bool)
 		*o.ServiceSettings.EnableDeveloper = false
@@ -516,6 +646,26 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.EnableInsecureOutgoingConnections = false
 	}
 
+	if o.ServiceSettings.AllowedUntrustedInternalConnections == nil {
+		o.ServiceSettings.AllowedUntrustedInternalConnections = new(string)
+		*o.ServiceSettings.AllowedUntrustedInternalConnections = ""
+	}
+
+	if o.ServiceSettings.OutgoingProxyURL == nil {
+		o.ServiceSettings.OutgoingProxyURL = new(string)
+		*o.ServiceSettings.OutgoingProxyURL = ""
+	}
+
+	if o.ServiceSettings.OutgoingConnectTimeoutInSeconds == nil {
+		o.ServiceSettings.OutgoingConnectTimeoutInSeconds = new(int)
+		*o.ServiceSettings.OutgoingConnectTimeoutInSeconds = 5
+	}
+
+	if o.ServiceSettings.OutgoingRequestTimeoutInSeconds == nil {
+		o.ServiceSettings.OutgoingRequestTimeoutInSeconds = new(int)
+		*o.ServiceSettings.OutgoingRequestTimeoutInSeconds = 30
+	}
+
 	if o.ServiceSettings.EnableMultifactorAuthentication == nil {
 		o.ServiceSettings.EnableMultifactorAuthentication = new(bool)
 		*o.ServiceSettings.EnableMultifactorAuthentication = false
@@ -680,6 +830,31 @@ func (o *Config) SetDefaults() {
 		*o.EmailSettings.EmailBatchingInterval = EMAIL_BATCHING_INTERVAL
 	}
 
+	if o.EmailSettings.EnableReplyByEmail == nil {
+		o.EmailSettings.EnableReplyByEmail = new(bool)
+		*o.EmailSettings.EnableReplyByEmail = false
+	}
+
+	if o.EmailSettings.ReplyByEmailAddress == nil {
+		o.EmailSettings.ReplyByEmailAddress = new(string)
+		*o.EmailSettings.ReplyByEmailAddress = ""
+	}
+
+	if o.EmailSettings.ReplyByEmailListen == nil {
+		o.EmailSettings.ReplyByEmailListen = new(string)
+		*o.EmailSettings.ReplyByEmailListen = EMAIL_SETTINGS_DEFAULT_REPLY_BY_EMAIL_LISTEN
+	}
+
+	if o.EmailSettings.ReplyByEmailMaxMessageSize == nil {
+		o.EmailSettings.ReplyByEmailMaxMessageSize = new(int64)
+		*o.EmailSettings.ReplyByEmailMaxMessageSize = EMAIL_SETTINGS_DEFAULT_REPLY_BY_EMAIL_MAX_MESSAGE_SIZE
+	}
+
+	if o.EmailSettings.ReplyByEmailSalt == nil || len(*o.EmailSettings.ReplyByEmailSalt) == 0 {
+		o.EmailSettings.ReplyByEmailSalt = new(string)
+		*o.EmailSettings.ReplyByEmailSalt = NewRandomString(32)
+	}
+
 	if !IsSafeLink(o.SupportSettings.TermsOfServiceLink) {
 		o.SupportSettings.TermsOfServiceLink = nil
 	}
@@ -860,6 +1035,16 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.EnableOnlyAdminIntegrations = true
 	}
 
+	if o.ServiceSettings.CommandWebhookMaxUses == nil {
+		o.ServiceSettings.CommandWebhookMaxUses = new(int)
+		*o.ServiceSettings.CommandWebhookMaxUses = 5
+	}
+
+	if o.ServiceSettings.CommandWebhookLifetimeInMinutes == nil {
+		o.ServiceSettings.CommandWebhookLifetimeInMinutes = new(int)
+		*o.ServiceSettings.CommandWebhookLifetimeInMinutes = 30
+	}
+
 	if o.ServiceSettings.WebsocketPort == nil {
 		o.ServiceSettings.WebsocketPort = new(int)
 		*o.ServiceSettings.WebsocketPort = 80
@@ -936,6 +1121,21 @@ func (o *Config) SetDefaults() {
 		*o.AnalyticsSettings.MaxUsersForStatistics = ANALYTICS_SETTINGS_DEFAULT_MAX_USERS_FOR_STATISTICS
 	}
 
+	if o.ImageProxySettings.Enable == nil {
+		o.ImageProxySettings.Enable = new(bool)
+		*o.ImageProxySettings.Enable = false
+	}
+
+	if o.ImageProxySettings.SigningKey == nil || len(*o.ImageProxySettings.SigningKey) == 0 {
+		o.ImageProxySettings.SigningKey = new(string)
+		*o.ImageProxySettings.SigningKey = NewRandomString(32)
+	}
+
+	if o.ImageProxySettings.MaxImageSize == nil {
+		o.ImageProxySettings.MaxImageSize = new(int64)
+		*o.ImageProxySettings.MaxImageSize = IMAGE_PROXY_SETTINGS_DEFAULT_MAX_IMAGE_SIZE
+	}
+
 	if o.ComplianceSettings.Enable == nil {
 		o.ComplianceSettings.Enable = new(bool)
 		*o.ComplianceSettings.Enable = false
@@ -951,6 +1151,16 @@ func (o *Config) SetDefaults() {
 		*o.ComplianceSettings.EnableDaily = false
 	}
 
+	if o.ComplianceSettings.EnableExport == nil {
+		o.ComplianceSettings.EnableExport = new(bool)
+		*o.ComplianceSettings.EnableExport = false
+	}
+
+	if o.ComplianceSettings.ExportFormat == nil {
+		o.ComplianceSettings.ExportFormat = new(string)
+		*o.ComplianceSettings.ExportFormat = COMPLIANCE_EXPORT_FORMAT_CSV
+	}
+
 	if o.LocalizationSettings.DefaultServerLocale == nil {
 		o.LocalizationSettings.DefaultServerLocale = new(string)
 		*o.LocalizationSettings.DefaultServerLocale = DEFAULT_LOCALE
@@ -1081,6 +1291,56 @@ func (o *Config) SetDefaults() {
 		*o.RateLimitSettings.MaxBurst = 100
 	}
 
+	if o.RateLimitSettings.VaryByUser == nil {
+		o.RateLimitSettings.VaryByUser = new(bool)
+		*o.RateLimitSettings.VaryByUser = false
+	}
+
+	if o.RateLimitSettings.LoginPerSec == nil {
+		o.RateLimitSettings.LoginPerSec = new(int)
+		*o.RateLimitSettings.LoginPerSec = 1
+	}
+
+	if o.RateLimitSettings.LoginMaxBurst == nil {
+		o.RateLimitSettings.LoginMaxBurst = new(int)
+		*o.RateLimitSettings.LoginMaxBurst = 10
+	}
+
+	if o.RateLimitSettings.PostCreatePerSec == nil {
+		o.RateLimitSettings.PostCreatePerSec = new(int)
+		*o.RateLimitSettings.PostCreatePerSec = 5
+	}
+
+	if o.RateLimitSettings.PostCreateMaxBurst == nil {
+		o.RateLimitSettings.PostCreateMaxBurst = new(int)
+		*o.RateLimitSettings.PostCreateMaxBurst = 50
+	}
+
+	if o.RateLimitSettings.SearchPerSec == nil {
+		o.RateLimitSettings.SearchPerSec = new(int)
+		*o.RateLimitSettings.SearchPerSec = 2
+	}
+
+	if o.RateLimitSettings.SearchMaxBurst == nil {
+		o.RateLimitSettings.SearchMaxBurst = new(int)
+		*o.RateLimitSettings.SearchMaxBurst = 20
+	}
+
+	if o.RateLimitSettings.FileUploadPerSec == nil {
+		o.RateLimitSettings.FileUploadPerSec = new(int)
+		*o.RateLimitSettings.FileUploadPerSec = 2
+	}
+
+	if o.RateLimitSettings.FileUploadMaxBurst == nil {
+		o.RateLimitSettings.FileUploadMaxBurst = new(int)
+		*o.RateLimitSettings.FileUploadMaxBurst = 20
+	}
+
+	if o.RateLimitSettings.ShareAcrossCluster == nil {
+		o.RateLimitSettings.ShareAcrossCluster = new(bool)
+		*o.RateLimitSettings.ShareAcrossCluster = false
+	}
+
 	if o.ServiceSettings.ConnectionSecurity == nil {
 		o.ServiceSettings.ConnectionSecurity = new(string)
 		*o.ServiceSettings.ConnectionSecurity = ""
@@ -1141,6 +1401,31 @@ func (o *Config) SetDefaults() {
 		*o.ServiceSettings.ClusterLogTimeoutMilliseconds = 2000
 	}
 
+	if o.ServiceSettings.EnableLoginLockout == nil {
+		o.ServiceSettings.EnableLoginLockout = new(bool)
+		*o.ServiceSettings.EnableLoginLockout = true
+	}
+
+	if o.ServiceSettings.LoginAttemptWindowSeconds == nil {
+		o.ServiceSettings.LoginAttemptWindowSeconds = new(int)
+		*o.ServiceSettings.LoginAttemptWindowSeconds = 300
+	}
+
+	if o.ServiceSettings.MaximumLoginAttemptsPerIpAddress == nil {
+		o.ServiceSettings.MaximumLoginAttemptsPerIpAddress = new(int)
+		*o.ServiceSettings.MaximumLoginAttemptsPerIpAddress = 30
+	}
+
+	if o.ServiceSettings.MaximumLoginAttemptsPerLoginId == nil {
+		o.ServiceSettings.MaximumLoginAttemptsPerLoginId = new(int)
+		*o.ServiceSettings.MaximumLoginAttemptsPerLoginId = 5
+	}
+
+	if o.ServiceSettings.LoginLockoutDurationSeconds == nil {
+		o.ServiceSettings.LoginLockoutDurationSeconds = new(int)
+		*o.ServiceSettings.LoginLockoutDurationSeconds = 900
+	}
+
 	o.defaultWebrtcSettings()
 }
 
@@ -1150,6 +1435,18 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.login_attempts.app_error", nil, "")
 	}
 
+	if *o.ServiceSettings.LoginAttemptWindowSeconds <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.login_attempt_window.app_error", nil, "")
+	}
+
+	if *o.ServiceSettings.MaximumLoginAttemptsPerIpAddress <= 0 || *o.ServiceSettings.MaximumLoginAttemptsPerLoginId <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.login_attempts.app_error", nil, "")
+	}
+
+	if *o.ServiceSettings.LoginLockoutDurationSeconds <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.login_lockout_duration.app_error", nil, "")
+	}
+
 	if len(*o.ServiceSettings.SiteURL) != 0 {
 		if _, err := url.ParseRequestURI(*o.ServiceSettings.SiteURL); err != nil {
 			return NewLocAppError("Config.IsValid", "model.config.is_valid.site_url.app_error", nil, "")
@@ -1168,6 +1465,14 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.site_url_email_batching.app_error", nil, "")
 	}
 
+	if *o.EmailSettings.EnableReplyByEmail && (len(*o.EmailSettings.ReplyByEmailListen) == 0 || strings.Count(*o.EmailSettings.ReplyByEmailAddress, "@") != 1) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.reply_by_email.app_error", nil, "")
+	}
+
+	if *o.EmailSettings.ReplyByEmailMaxMessageSize <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.reply_by_email_max_message_size.app_error", nil, "")
+	}
+
 	if o.TeamSettings.MaxUsersPerTeam <= 0 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_users.app_error", nil, "")
 	}
@@ -1208,6 +1513,34 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_file_size.app_error", nil, "")
 	}
 
+	if *o.FileSettings.MaxStoragePerTeam < 0 || *o.FileSettings.MaxStoragePerUser < 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_storage.app_error", nil, "")
+	}
+
+	if *o.FileSettings.MaxTextPreviewSize <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_text_preview_size.app_error", nil, "")
+	}
+
+	if !(*o.FileSettings.InfectedFileAction == INFECTED_FILE_ACTION_REJECT || *o.FileSettings.InfectedFileAction == INFECTED_FILE_ACTION_QUARANTINE) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.infected_file_action.app_error", nil, "")
+	}
+
+	if *o.FileSettings.FileScanTimeout <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_scan_timeout.app_error", nil, "")
+	}
+
+	if *o.FileSettings.MaxFileScanSize <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_file_scan_size.app_error", nil, "")
+	}
+
+	if !(*o.ComplianceSettings.ExportFormat == COMPLIANCE_EXPORT_FORMAT_CSV || *o.ComplianceSettings.ExportFormat == COMPLIANCE_EXPORT_FORMAT_ACTIANCE || *o.ComplianceSettings.ExportFormat == COMPLIANCE_EXPORT_FORMAT_GLOBALRELAY) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.compliance_export_format.app_error", nil, "")
+	}
+
+	if *o.ImageProxySettings.MaxImageSize <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.image_proxy_max_image_size.app_error", nil, "")
+	}
+
 	if !(o.FileSettings.DriverName == IMAGE_DRIVER_LOCAL || o.FileSettings.DriverName == IMAGE_DRIVER_S3) {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.file_driver.app_error", nil, "")
 	}
@@ -1252,6 +1585,10 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.email_reset_salt.app_error", nil, "")
 	}
 
+	if len(*o.EmailSettings.ReplyByEmailSalt) < 32 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.email_reply_salt.app_error", nil, "")
+	}
+
 	if *o.EmailSettings.EmailBatchingBufferSize <= 0 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.email_batching_buffer_size.app_error", nil, "")
 	}
@@ -1356,6 +1693,16 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.max_burst.app_error", nil, "")
 	}
 
+	if *o.RateLimitSettings.LoginPerSec <= 0 || *o.RateLimitSettings.PostCreatePerSec <= 0 ||
+		*o.RateLimitSettings.SearchPerSec <= 0 || *o.RateLimitSettings.FileUploadPerSec <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.rate_route_sec.app_error", nil, "")
+	}
+
+	if *o.RateLimitSettings.LoginMaxBurst <= 0 || *o.RateLimitSettings.PostCreateMaxBurst <= 0 ||
+		*o.RateLimitSettings.SearchMaxBurst <= 0 || *o.RateLimitSettings.FileUploadMaxBurst <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.rate_route_max_burst.app_error", nil, "")
+	}
+
 	if err := o.isValidWebrtcSettings(); err != nil {
 		return err
 	}
@@ -1368,6 +1715,26 @@ func (o *Config) IsValid() *AppError {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.read_timeout.app_error", nil, "")
 	}
 
+	if len(*o.ServiceSettings.OutgoingProxyURL) > 0 && !IsValidHttpUrl(*o.ServiceSettings.OutgoingProxyURL) {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.outgoing_proxy_url.app_error", nil, "")
+	}
+
+	if *o.ServiceSettings.OutgoingConnectTimeoutInSeconds <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.outgoing_connect_timeout.app_error", nil, "")
+	}
+
+	if *o.ServiceSettings.OutgoingRequestTimeoutInSeconds <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.outgoing_request_timeout.app_error", nil, "")
+	}
+
+	if *o.ServiceSettings.CommandWebhookMaxUses <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.command_webhook_max_uses.app_error", nil, "")
+	}
+
+	if *o.ServiceSettings.CommandWebhookLifetimeInMinutes <= 0 {
+		return NewLocAppError("Config.IsValid", "model.config.is_valid.command_webhook_lifetime.app_error", nil, "")
+	}
+
 	if *o.ServiceSettings.WriteTimeout <= 0 {
 		return NewLocAppError("Config.IsValid", "model.config.is_valid.write_timeout.app_error", nil, "")
 	}
@@ -1393,12 +1760,14 @@ func (o *Config) Sanitize() {
 	}
 
 	*o.FileSettings.PublicLinkSalt = FAKE_SETTING
+	*o.ImageProxySettings.SigningKey = FAKE_SETTING
 	if len(o.FileSettings.AmazonS3SecretAccessKey) > 0 {
 		o.FileSettings.AmazonS3SecretAccessKey = FAKE_SETTING
 	}
 
 	o.EmailSettings.InviteSalt = FAKE_SETTING
 	o.EmailSettings.PasswordResetSalt = FAKE_SETTING
+	*o.EmailSettings.ReplyByEmailSalt = FAKE_SETTING
 	if len(o.EmailSettings.SMTPPassword) > 0 {
 		o.EmailSettings.SMTPPassword = FAKE_SETTING
 	}
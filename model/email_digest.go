@@ -0,0 +1,152 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+const (
+	EMAIL_DIGEST_FREQUENCY_HOURLY = "hourly"
+	EMAIL_DIGEST_FREQUENCY_DAILY  = "daily"
+	EMAIL_DIGEST_FREQUENCY_WEEKLY = "weekly"
+
+	EMAIL_DIGEST_TIMEZONE_MAX_LENGTH = 64
+)
+
+// EmailDigest is a user's subscription to a periodic email summarising their unread activity. Daily and weekly
+// digests are sent at the start of Hour in the user's Timezone, and weekly ones only on DayOfWeek (0 is Sunday).
+// NextSendAt is kept in the database so that digests survive restarts and each one is only claimed by a single
+// server in a cluster.
+type EmailDigest struct {
+	UserId     string `json:"user_id"`
+	CreateAt   int64  `json:"create_at"`
+	UpdateAt   int64  `json:"update_at"`
+	Frequency  string `json:"frequency"`
+	Hour       int    `json:"hour"`
+	DayOfWeek  int    `json:"day_of_week"`
+	Timezone   string `json:"timezone"`
+	LastSentAt int64  `json:"last_sent_at"`
+	NextSendAt int64  `json:"next_send_at"`
+}
+
+// EmailDigestChannel is the unread activity in one of a user's channels.
+type EmailDigestChannel struct {
+	TeamId             string
+	ChannelId          string
+	ChannelName        string
+	ChannelDisplayName string
+	ChannelType        string
+	MsgCount           int64
+	MentionCount       int64
+	LastPostAt         int64
+}
+
+func (o *EmailDigest) PreSave() {
+	if o.Timezone == "" {
+		o.Timezone = "UTC"
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+	o.LastSentAt = 0
+	o.NextSendAt = o.GetNextSendAt(time.Now())
+}
+
+func (o *EmailDigest) PreUpdate() {
+	if o.Timezone == "" {
+		o.Timezone = "UTC"
+	}
+
+	o.UpdateAt = GetMillis()
+	o.NextSendAt = o.GetNextSendAt(time.Now())
+}
+
+func (o *EmailDigest) IsValid() *AppError {
+	if len(o.UserId) != 26 {
+		return NewLocAppError("EmailDigest.IsValid", "model.email_digest.is_valid.user_id.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("EmailDigest.IsValid", "model.email_digest.is_valid.create_at.app_error", nil, "user_id="+o.UserId)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewLocAppError("EmailDigest.IsValid", "model.email_digest.is_valid.update_at.app_error", nil, "user_id="+o.UserId)
+	}
+
+	if o.Frequency != EMAIL_DIGEST_FREQUENCY_HOURLY && o.Frequency != EMAIL_DIGEST_FREQUENCY_DAILY && o.Frequency != EMAIL_DIGEST_FREQUENCY_WEEKLY {
+		return NewLocAppError("EmailDigest.IsValid", "model.email_digest.is_valid.frequency.app_error", nil, "user_id="+o.UserId)
+	}
+
+	if o.Hour < 0 || o.Hour > 23 {
+		return NewLocAppError("EmailDigest.IsValid", "model.email_digest.is_valid.hour.app_error", nil, "user_id="+o.UserId)
+	}
+
+	if o.DayOfWeek < 0 || o.DayOfWeek > 6 {
+		return NewLocAppError("EmailDigest.IsValid", "model.email_digest.is_valid.day_of_week.app_error", nil, "user_id="+o.UserId)
+	}
+
+	if len(o.Timezone) > EMAIL_DIGEST_TIMEZONE_MAX_LENGTH {
+		return NewLocAppError("EmailDigest.IsValid", "model.email_digest.is_valid.timezone.app_error", nil, "user_id="+o.UserId)
+	} else if _, err := time.LoadLocation(o.Timezone); err != nil {
+		return NewLocAppError("EmailDigest.IsValid", "model.email_digest.is_valid.timezone.app_error", nil, "user_id="+o.UserId+", "+err.Error())
+	}
+
+	return nil
+}
+
+// GetNextSendAt returns the first time after now that the digest should be sent, in milliseconds.
+func (o *EmailDigest) GetNextSendAt(now time.Time) int64 {
+	location, err := time.LoadLocation(o.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	now = now.In(location)
+
+	if o.Frequency == EMAIL_DIGEST_FREQUENCY_HOURLY {
+		return now.Truncate(time.Hour).Add(time.Hour).UnixNano() / int64(time.Millisecond)
+	}
+
+	// AddDate keeps the same local hour when crossing a daylight saving change
+	next := time.Date(now.Year(), now.Month(), now.Day(), o.Hour, 0, 0, 0, location)
+	for !next.After(now) || (o.Frequency == EMAIL_DIGEST_FREQUENCY_WEEKLY && int(next.Weekday()) != o.DayOfWeek) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next.UnixNano() / int64(time.Millisecond)
+}
+
+// GetPeriodStart returns the time in milliseconds from which activity should be included in a digest sent now.
+// That's when the last digest was sent or, for the first one, when the subscription was created.
+func (o *EmailDigest) GetPeriodStart() int64 {
+	if o.LastSentAt != 0 {
+		return o.LastSentAt
+	}
+
+	return o.CreateAt
+}
+
+func (o *EmailDigest) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func EmailDigestFromJson(data io.Reader) *EmailDigest {
+	decoder := json.NewDecoder(data)
+	var o EmailDigest
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEmailDigestJson(t *testing.T) {
+	o := EmailDigest{UserId: NewId(), Frequency: EMAIL_DIGEST_FREQUENCY_WEEKLY, Hour: 9, DayOfWeek: 1}
+	ro := EmailDigestFromJson(strings.NewReader(o.ToJson()))
+
+	if ro.UserId != o.UserId || ro.Frequency != o.Frequency || ro.Hour != o.Hour || ro.DayOfWeek != o.DayOfWeek {
+		t.Fatal("digests do not match")
+	}
+}
+
+func TestEmailDigestIsValid(t *testing.T) {
+	o := EmailDigest{UserId: NewId(), Frequency: EMAIL_DIGEST_FREQUENCY_DAILY, Hour: 9}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.Timezone != "UTC" || o.NextSendAt <= o.CreateAt {
+		t.Fatal("should've set the defaults", o.Timezone, o.NextSendAt)
+	}
+
+	o.Frequency = "monthly"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Frequency = EMAIL_DIGEST_FREQUENCY_DAILY
+	o.Hour = 24
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Hour = 9
+	o.DayOfWeek = 7
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.DayOfWeek = 0
+	o.Timezone = "Nowhere/Junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Timezone = "UTC"
+	o.UserId = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestEmailDigestGetNextSendAt(t *testing.T) {
+	// A Wednesday
+	now := time.Date(2017, time.June, 14, 10, 30, 0, 0, time.UTC)
+
+	toTime := func(millis int64) time.Time {
+		return time.Unix(0, millis*int64(time.Millisecond)).UTC()
+	}
+
+	hourly := &EmailDigest{Frequency: EMAIL_DIGEST_FREQUENCY_HOURLY, Timezone: "UTC"}
+	if next := toTime(hourly.GetNextSendAt(now)); !next.Equal(time.Date(2017, time.June, 14, 11, 0, 0, 0, time.UTC)) {
+		t.Fatal("should be sent at the next hour", next)
+	}
+
+	daily := &EmailDigest{Frequency: EMAIL_DIGEST_FREQUENCY_DAILY, Hour: 11, Timezone: "UTC"}
+	if next := toTime(daily.GetNextSendAt(now)); !next.Equal(time.Date(2017, time.June, 14, 11, 0, 0, 0, time.UTC)) {
+		t.Fatal("should be sent later today", next)
+	}
+
+	daily.Hour = 10
+	if next := toTime(daily.GetNextSendAt(now)); !next.Equal(time.Date(2017, time.June, 15, 10, 0, 0, 0, time.UTC)) {
+		t.Fatal("should be sent tomorrow", next)
+	}
+
+	weekly := &EmailDigest{Frequency: EMAIL_DIGEST_FREQUENCY_WEEKLY, Hour: 8, DayOfWeek: int(time.Monday), Timezone: "UTC"}
+	if next := toTime(weekly.GetNextSendAt(now)); !next.Equal(time.Date(2017, time.June, 19, 8, 0, 0, 0, time.UTC)) {
+		t.Fatal("should be sent next monday", next)
+	}
+
+	if location, err := time.LoadLocation("America/Toronto"); err == nil {
+		local := &EmailDigest{Frequency: EMAIL_DIGEST_FREQUENCY_DAILY, Hour: 8, Timezone: "America/Toronto"}
+		if next := toTime(local.GetNextSendAt(now)).In(location); !next.Equal(time.Date(2017, time.June, 14, 8, 0, 0, 0, location)) {
+			t.Fatal("should be sent at the local time", next)
+		}
+	}
+}
@@ -0,0 +1,12 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+// FileBlob counts the files that share the data stored at a path. A RefCount of -1 means that the data is being
+// removed and can't be referenced until that's finished.
+type FileBlob struct {
+	Path     string
+	RefCount int
+	UpdateAt int64
+}
@@ -17,6 +17,7 @@ import (
 type FileInfo struct {
 	Id              string `json:"id"`
 	CreatorId       string `json:"user_id"`
+	TeamId          string `json:"-"` // not sent back to the client
 	PostId          string `json:"post_id,omitempty"`
 	CreateAt        int64  `json:"create_at"`
 	UpdateAt        int64  `json:"update_at"`
@@ -24,6 +25,7 @@ type FileInfo struct {
 	Path            string `json:"-"` // not sent back to the client
 	ThumbnailPath   string `json:"-"` // not sent back to the client
 	PreviewPath     string `json:"-"` // not sent back to the client
+	ContentHash     string `json:"-"` // not sent back to the client
 	Name            string `json:"name"`
 	Extension       string `json:"extension"`
 	Size            int64  `json:"size"`
@@ -31,6 +33,26 @@ type FileInfo struct {
 	Width           int    `json:"width,omitempty"`
 	Height          int    `json:"height,omitempty"`
 	HasPreviewImage bool   `json:"has_preview_image,omitempty"`
+	ScanStatus      string `json:"scan_status,omitempty"`
+	ScanAttempts    int    `json:"-"` // not sent back to the client
+	BlobReleaseAt   int64  `json:"-"` // not sent back to the client
+}
+
+const (
+	FILE_INFO_CONTENT_MAX_SIZE = 65535
+
+	// Files uploaded while scanning is disabled have no scan status and are always served
+	FILE_SCAN_STATUS_PENDING     = "pending"
+	FILE_SCAN_STATUS_CLEAN       = "clean"
+	FILE_SCAN_STATUS_QUARANTINED = "quarantined"
+	FILE_SCAN_STATUS_FAILED      = "failed"
+)
+
+// FileInfoContent holds the text extracted from a file so that the file can be found by searching for
+// the words inside it.
+type FileInfoContent struct {
+	FileId  string
+	Content string
 }
 
 func (info *FileInfo) ToJson() string {
@@ -109,6 +131,27 @@ func (o *FileInfo) IsValid() *AppError {
 		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.path.app_error", nil, "id="+o.Id)
 	}
 
+	if !(o.ScanStatus == "" || o.ScanStatus == FILE_SCAN_STATUS_PENDING || o.ScanStatus == FILE_SCAN_STATUS_CLEAN || o.ScanStatus == FILE_SCAN_STATUS_QUARANTINED || o.ScanStatus == FILE_SCAN_STATUS_FAILED) {
+		return NewLocAppError("FileInfo.IsValid", "model.file_info.is_valid.scan_status.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+// CanBeServed returns false if the file may be harmful because it was quarantined or hasn't been scanned.
+func (o *FileInfo) CanBeServed() bool {
+	return o.ScanStatus != FILE_SCAN_STATUS_PENDING && o.ScanStatus != FILE_SCAN_STATUS_QUARANTINED && o.ScanStatus != FILE_SCAN_STATUS_FAILED
+}
+
+func (o *FileInfoContent) IsValid() *AppError {
+	if len(o.FileId) != 26 {
+		return NewLocAppError("FileInfoContent.IsValid", "model.file_info.is_valid.id.app_error", nil, "")
+	}
+
+	if len(o.Content) > FILE_INFO_CONTENT_MAX_SIZE {
+		return NewLocAppError("FileInfoContent.IsValid", "model.file_info.is_valid.content.app_error", nil, "id="+o.FileId)
+	}
+
 	return nil
 }
 
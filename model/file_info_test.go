@@ -58,6 +58,48 @@ func TestFileInfoIsValid(t *testing.T) {
 	if err := info.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	info.ScanStatus = "junk"
+	if err := info.IsValid(); err == nil {
+		t.Fatal("unknown ScanStatus isn't valid")
+	}
+
+	info.ScanStatus = FILE_SCAN_STATUS_QUARANTINED
+	if err := info.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileInfoCanBeServed(t *testing.T) {
+	for status, canBeServed := range map[string]bool{
+		"":                           true,
+		FILE_SCAN_STATUS_CLEAN:       true,
+		FILE_SCAN_STATUS_PENDING:     false,
+		FILE_SCAN_STATUS_QUARANTINED: false,
+		FILE_SCAN_STATUS_FAILED:      false,
+	} {
+		if (&FileInfo{ScanStatus: status}).CanBeServed() != canBeServed {
+			t.Fatalf("file with scan status %q should have CanBeServed=%v", status, canBeServed)
+		}
+	}
+}
+
+func TestFileInfoContentIsValid(t *testing.T) {
+	content := &FileInfoContent{FileId: NewId(), Content: "quarterly numbers"}
+	if err := content.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	content.FileId = ""
+	if err := content.IsValid(); err == nil {
+		t.Fatal("empty FileId isn't valid")
+	}
+
+	content.FileId = NewId()
+	content.Content = strings.Repeat("a", FILE_INFO_CONTENT_MAX_SIZE+1)
+	if err := content.IsValid(); err == nil {
+		t.Fatal("content over the maximum size isn't valid")
+	}
 }
 
 func TestFileInfoIsImage(t *testing.T) {
@@ -0,0 +1,46 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type FileSearch struct {
+	Terms      string   `json:"terms"`
+	IsOrSearch bool     `json:"is_or_search"`
+	Name       string   `json:"name"`
+	Extensions []string `json:"extensions"`
+	InChannels []string `json:"in_channels"`
+	FromUsers  []string `json:"from_users"`
+	After      int64    `json:"after"`
+	Before     int64    `json:"before"`
+}
+
+// IsEmpty returns true if the search has nothing to filter files by.
+func (s *FileSearch) IsEmpty() bool {
+	return s.Terms == "" && s.Name == "" && len(s.Extensions) == 0 && len(s.InChannels) == 0 &&
+		len(s.FromUsers) == 0 && s.After == 0 && s.Before == 0
+}
+
+func (s *FileSearch) ToJson() string {
+	b, err := json.Marshal(s)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func FileSearchFromJson(data io.Reader) *FileSearch {
+	decoder := json.NewDecoder(data)
+	var s FileSearch
+	err := decoder.Decode(&s)
+	if err == nil {
+		return &s
+	} else {
+		return nil
+	}
+}
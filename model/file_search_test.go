@@ -0,0 +1,33 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileSearchJson(t *testing.T) {
+	fileSearch := FileSearch{Terms: NewId(), Extensions: []string{"pdf"}, After: GetMillis()}
+	json := fileSearch.ToJson()
+	rfileSearch := FileSearchFromJson(strings.NewReader(json))
+
+	if fileSearch.Terms != rfileSearch.Terms || rfileSearch.Extensions[0] != "pdf" || fileSearch.After != rfileSearch.After {
+		t.Fatal("searches do not match")
+	}
+
+	if FileSearchFromJson(strings.NewReader("junk")) != nil {
+		t.Fatal("should have failed to decode")
+	}
+}
+
+func TestFileSearchIsEmpty(t *testing.T) {
+	if !(&FileSearch{IsOrSearch: true}).IsEmpty() {
+		t.Fatal("should be empty")
+	}
+
+	if (&FileSearch{Extensions: []string{"pdf"}}).IsEmpty() || (&FileSearch{Before: GetMillis()}).IsEmpty() {
+		t.Fatal("shouldn't be empty")
+	}
+}
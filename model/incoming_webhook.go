@@ -14,18 +14,28 @@ import (
 
 const (
 	DEFAULT_WEBHOOK_USERNAME = "webhook"
+
+	INCOMING_WEBHOOK_MAX_FILES = 5
+
+	INCOMING_WEBHOOK_MAX_RATE_LIMIT = 600
 )
 
 type IncomingWebhook struct {
-	Id          string `json:"id"`
-	CreateAt    int64  `json:"create_at"`
-	UpdateAt    int64  `json:"update_at"`
-	DeleteAt    int64  `json:"delete_at"`
-	UserId      string `json:"user_id"`
-	ChannelId   string `json:"channel_id"`
-	TeamId      string `json:"team_id"`
-	DisplayName string `json:"display_name"`
-	Description string `json:"description"`
+	Id                      string `json:"id"`
+	CreateAt                int64  `json:"create_at"`
+	UpdateAt                int64  `json:"update_at"`
+	DeleteAt                int64  `json:"delete_at"`
+	UserId                  string `json:"user_id"`
+	ChannelId               string `json:"channel_id"`
+	TeamId                  string `json:"team_id"`
+	DisplayName             string `json:"display_name"`
+	Description             string `json:"description"`
+	ChannelLocked           bool   `json:"channel_locked"`
+	DisableUsernameOverride bool   `json:"disable_username_override"`
+	DisableIconOverride     bool   `json:"disable_icon_override"`
+	RateLimit               int    `json:"rate_limit"`
+	PostCount               int64  `json:"post_count"`
+	LastUsedAt              int64  `json:"last_used_at"`
 }
 
 type IncomingWebhookRequest struct {
@@ -36,6 +46,7 @@ type IncomingWebhookRequest struct {
 	Props       StringInterface    `json:"props"`
 	Attachments []*SlackAttachment `json:"attachments"`
 	Type        string             `json:"type"`
+	RootId      string             `json:"root_id"`
 }
 
 func (o *IncomingWebhook) ToJson() string {
@@ -112,6 +123,10 @@ func (o *IncomingWebhook) IsValid() *AppError {
 		return NewLocAppError("IncomingWebhook.IsValid", "model.incoming_hook.description.app_error", nil, "")
 	}
 
+	if o.RateLimit < 0 || o.RateLimit > INCOMING_WEBHOOK_MAX_RATE_LIMIT {
+		return NewLocAppError("IncomingWebhook.IsValid", "model.incoming_hook.rate_limit.app_error", nil, "")
+	}
+
 	return nil
 }
 
@@ -122,6 +137,10 @@ func (o *IncomingWebhook) PreSave() {
 
 	o.CreateAt = GetMillis()
 	o.UpdateAt = o.CreateAt
+
+	// usage is only recorded by the server
+	o.PostCount = 0
+	o.LastUsedAt = 0
 }
 
 func (o *IncomingWebhook) PreUpdate() {
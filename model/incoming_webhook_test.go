@@ -89,11 +89,30 @@ func TestIncomingWebhookIsValid(t *testing.T) {
 	if err := o.IsValid(); err != nil {
 		t.Fatal(err)
 	}
+
+	o.RateLimit = -1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.RateLimit = INCOMING_WEBHOOK_MAX_RATE_LIMIT + 1
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.RateLimit = INCOMING_WEBHOOK_MAX_RATE_LIMIT
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
 }
 
 func TestIncomingWebhookPreSave(t *testing.T) {
-	o := IncomingWebhook{}
+	o := IncomingWebhook{PostCount: 10, LastUsedAt: GetMillis()}
 	o.PreSave()
+
+	if o.PostCount != 0 || o.LastUsedAt != 0 {
+		t.Fatal("shouldn't have kept the usage")
+	}
 }
 
 func TestIncomingWebhookPreUpdate(t *testing.T) {
@@ -0,0 +1,169 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	LEGAL_HOLD_NAME_MAX_LENGTH        = 64
+	LEGAL_HOLD_DESCRIPTION_MAX_LENGTH = 1024
+	LEGAL_HOLD_MAX_IDS                = 200
+)
+
+// LegalHold preserves the posts, files and reactions of a set of users and channels that were created within
+// its date range so that they can't be destroyed while it's active. Content is covered if it belongs to any
+// of the users or channels. An EndAt of 0 covers everything created since StartAt.
+type LegalHold struct {
+	Id          string      `json:"id"`
+	CreateAt    int64       `json:"create_at"`
+	UpdateAt    int64       `json:"update_at"`
+	ReleaseAt   int64       `json:"release_at"`
+	CreatorId   string      `json:"creator_id"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	UserIds     StringArray `json:"user_ids"`
+	ChannelIds  StringArray `json:"channel_ids"`
+	StartAt     int64       `json:"start_at"`
+	EndAt       int64       `json:"end_at"`
+}
+
+func (o *LegalHold) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.UserIds == nil {
+		o.UserIds = StringArray{}
+	}
+
+	if o.ChannelIds == nil {
+		o.ChannelIds = StringArray{}
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+	o.ReleaseAt = 0
+}
+
+func (o *LegalHold) PreUpdate() {
+	o.UpdateAt = GetMillis()
+}
+
+func (o *LegalHold) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.id.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.update_at.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.CreatorId) != 26 {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.creator_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.Name) == 0 || len(o.Name) > LEGAL_HOLD_NAME_MAX_LENGTH {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.name.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.Description) > LEGAL_HOLD_DESCRIPTION_MAX_LENGTH {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.description.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.UserIds)+len(o.ChannelIds) == 0 || len(o.UserIds)+len(o.ChannelIds) > LEGAL_HOLD_MAX_IDS {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.ids.app_error", nil, "id="+o.Id)
+	}
+
+	for _, id := range append(append([]string{}, o.UserIds...), o.ChannelIds...) {
+		if len(id) != 26 {
+			return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.ids.app_error", nil, "id="+o.Id)
+		}
+	}
+
+	if o.StartAt < 0 || (o.EndAt != 0 && o.EndAt < o.StartAt) {
+		return NewLocAppError("LegalHold.IsValid", "model.legal_hold.is_valid.start_end_at.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *LegalHold) IsActive() bool {
+	return o.ReleaseAt == 0
+}
+
+func (o *LegalHold) HasUser(userId string) bool {
+	for _, id := range o.UserIds {
+		if id == userId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *LegalHold) HasChannel(channelId string) bool {
+	for _, id := range o.ChannelIds {
+		if id == channelId {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Covers returns true if content created by the user in the channel at the given time must be preserved.
+func (o *LegalHold) Covers(userId, channelId string, createAt int64) bool {
+	if !o.IsActive() || createAt < o.StartAt || (o.EndAt != 0 && createAt > o.EndAt) {
+		return false
+	}
+
+	return o.HasUser(userId) || o.HasChannel(channelId)
+}
+
+func (o *LegalHold) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func LegalHoldFromJson(data io.Reader) *LegalHold {
+	decoder := json.NewDecoder(data)
+	var o LegalHold
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func LegalHoldListToJson(l []*LegalHold) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func LegalHoldListFromJson(data io.Reader) []*LegalHold {
+	decoder := json.NewDecoder(data)
+	var o []*LegalHold
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLegalHoldJson(t *testing.T) {
+	o := LegalHold{Id: NewId(), Name: "case", UserIds: StringArray{NewId()}}
+	ro := LegalHoldFromJson(strings.NewReader(o.ToJson()))
+
+	if ro.Id != o.Id || ro.Name != o.Name || len(ro.UserIds) != 1 || ro.UserIds[0] != o.UserIds[0] {
+		t.Fatal("ids do not match")
+	}
+
+	list := LegalHoldListFromJson(strings.NewReader(LegalHoldListToJson([]*LegalHold{&o})))
+	if len(list) != 1 || list[0].Id != o.Id {
+		t.Fatal("list should have been decoded")
+	}
+}
+
+func TestLegalHoldIsValid(t *testing.T) {
+	o := LegalHold{CreatorId: NewId(), Name: "case", UserIds: StringArray{NewId()}}
+	o.PreSave()
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Name = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Name = strings.Repeat("a", LEGAL_HOLD_NAME_MAX_LENGTH+1)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Name = "case"
+	o.UserIds = StringArray{}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid without users or channels")
+	}
+
+	o.ChannelIds = StringArray{"junk"}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ChannelIds = StringArray{NewId()}
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.StartAt = 2000
+	o.EndAt = 1000
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestLegalHoldCovers(t *testing.T) {
+	userId := NewId()
+	channelId := NewId()
+
+	o := LegalHold{UserIds: StringArray{userId}, ChannelIds: StringArray{channelId}, StartAt: 1000}
+
+	if !o.Covers(userId, NewId(), 2000) {
+		t.Fatal("should cover the user's content in any channel")
+	}
+
+	if !o.Covers(NewId(), channelId, 2000) {
+		t.Fatal("should cover anyone's content in the channel")
+	}
+
+	if o.Covers(NewId(), NewId(), 2000) {
+		t.Fatal("shouldn't cover other users and channels")
+	}
+
+	if o.Covers(userId, channelId, 500) {
+		t.Fatal("shouldn't cover content from before it starts")
+	}
+
+	o.EndAt = 3000
+	if o.Covers(userId, channelId, 4000) || !o.Covers(userId, channelId, 3000) {
+		t.Fatal("should only cover content from before it ends")
+	}
+
+	o.ReleaseAt = GetMillis()
+	if o.Covers(userId, channelId, 2000) {
+		t.Fatal("shouldn't cover anything once released")
+	}
+}
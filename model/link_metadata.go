@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"unicode/utf8"
+)
+
+const (
+	LINK_METADATA_TYPE_OPENGRAPH = "opengraph"
+	LINK_METADATA_TYPE_IMAGE     = "image"
+	LINK_METADATA_TYPE_NONE      = "none"
+
+	LINK_METADATA_URL_MAX_LENGTH  = 2048
+	LINK_METADATA_DATA_MAX_LENGTH = 8000
+)
+
+// LinkMetadata caches the preview generated for a link so that it isn't fetched again each time
+// the link is viewed. Data holds the preview as JSON and is empty for links without one.
+type LinkMetadata struct {
+	Hash     string `json:"hash"`
+	URL      string `json:"url"`
+	CreateAt int64  `json:"create_at"`
+	Type     string `json:"type"`
+	Data     string `json:"data"`
+}
+
+// LinkMetadataHash returns the key a link's metadata is stored under. URLs can be much longer
+// than is practical to index so a hash of them is used instead.
+func LinkMetadataHash(url string) string {
+	hash := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(hash[:])
+}
+
+func (o *LinkMetadata) PreSave() {
+	o.Hash = LinkMetadataHash(o.URL)
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *LinkMetadata) IsValid() *AppError {
+	if len(o.Hash) != 64 {
+		return NewLocAppError("LinkMetadata.IsValid", "model.link_metadata.hash.app_error", nil, "")
+	}
+
+	if len(o.URL) == 0 || utf8.RuneCountInString(o.URL) > LINK_METADATA_URL_MAX_LENGTH {
+		return NewLocAppError("LinkMetadata.IsValid", "model.link_metadata.url.app_error", nil, "hash="+o.Hash)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("LinkMetadata.IsValid", "model.link_metadata.create_at.app_error", nil, "hash="+o.Hash)
+	}
+
+	switch o.Type {
+	case LINK_METADATA_TYPE_OPENGRAPH, LINK_METADATA_TYPE_IMAGE, LINK_METADATA_TYPE_NONE:
+	default:
+		return NewLocAppError("LinkMetadata.IsValid", "model.link_metadata.type.app_error", nil, "hash="+o.Hash)
+	}
+
+	if utf8.RuneCountInString(o.Data) > LINK_METADATA_DATA_MAX_LENGTH {
+		return NewLocAppError("LinkMetadata.IsValid", "model.link_metadata.data.app_error", nil, "hash="+o.Hash)
+	}
+
+	return nil
+}
+
+// IsExpired returns true once the metadata is older than the given number of milliseconds.
+func (o *LinkMetadata) IsExpired(ttl int64) bool {
+	return GetMillis() > o.CreateAt+ttl
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLinkMetadataIsValid(t *testing.T) {
+	o := LinkMetadata{}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.PreSave()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.URL = "http://example.com/" + strings.Repeat("a", LINK_METADATA_URL_MAX_LENGTH)
+	o.PreSave()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.URL = "http://example.com/"
+	o.PreSave()
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Type = LINK_METADATA_TYPE_NONE
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Type = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Type = LINK_METADATA_TYPE_OPENGRAPH
+	o.Data = strings.Repeat("a", LINK_METADATA_DATA_MAX_LENGTH+1)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Data = `{"title":"Example"}`
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLinkMetadataHash(t *testing.T) {
+	if LinkMetadataHash("http://example.com/a") == LinkMetadataHash("http://example.com/b") {
+		t.Fatal("different urls should have different hashes")
+	}
+
+	o := LinkMetadata{URL: "http://example.com/a"}
+	o.PreSave()
+	if o.Hash != LinkMetadataHash(o.URL) {
+		t.Fatal("should have hashed the url")
+	}
+}
+
+func TestLinkMetadataIsExpired(t *testing.T) {
+	o := LinkMetadata{URL: "http://example.com/"}
+	o.PreSave()
+
+	if o.IsExpired(60 * 1000) {
+		t.Fatal("should not have expired")
+	}
+
+	o.CreateAt = GetMillis() - 61*1000
+	if !o.IsExpired(60 * 1000) {
+		t.Fatal("should have expired")
+	}
+}
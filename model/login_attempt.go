@@ -0,0 +1,154 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+)
+
+const (
+	LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS = "ip_address"
+	LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID   = "login_id"
+)
+
+// LoginAttempt records a single failed login so that failures can be counted over a sliding window
+type LoginAttempt struct {
+	Id       string `json:"id"`
+	KeyType  string `json:"key_type"`
+	KeyValue string `json:"key_value"`
+	CreateAt int64  `json:"create_at"`
+}
+
+// LoginLockout blocks logins for an IP address or login ID until it expires
+type LoginLockout struct {
+	Id             string `json:"id"`
+	KeyType        string `json:"key_type"`
+	KeyValue       string `json:"key_value"`
+	FailedAttempts int64  `json:"failed_attempts"`
+	CreateAt       int64  `json:"create_at"`
+	ExpireAt       int64  `json:"expire_at"`
+}
+
+// NormalizeLoginAttemptKeyValue makes login IDs that only differ by case or surrounding whitespace count together
+func NormalizeLoginAttemptKeyValue(keyType, keyValue string) string {
+	if keyType == LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID {
+		return strings.ToLower(strings.TrimSpace(keyValue))
+	}
+
+	return keyValue
+}
+
+func isValidLoginAttemptKey(keyType, keyValue string) bool {
+	if keyType != LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS && keyType != LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID {
+		return false
+	}
+
+	return len(keyValue) > 0 && len(keyValue) <= 256
+}
+
+func (o *LoginAttempt) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.KeyValue = NormalizeLoginAttemptKeyValue(o.KeyType, o.KeyValue)
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *LoginAttempt) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("LoginAttempt.IsValid", "model.login_attempt.is_valid.id.app_error", nil, "")
+	}
+
+	if !isValidLoginAttemptKey(o.KeyType, o.KeyValue) {
+		return NewLocAppError("LoginAttempt.IsValid", "model.login_attempt.is_valid.key.app_error", nil, "id="+o.Id)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("LoginAttempt.IsValid", "model.login_attempt.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *LoginLockout) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	o.KeyValue = NormalizeLoginAttemptKeyValue(o.KeyType, o.KeyValue)
+
+	if o.CreateAt == 0 {
+		o.CreateAt = GetMillis()
+	}
+}
+
+func (o *LoginLockout) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("LoginLockout.IsValid", "model.login_lockout.is_valid.id.app_error", nil, "")
+	}
+
+	if !isValidLoginAttemptKey(o.KeyType, o.KeyValue) {
+		return NewLocAppError("LoginLockout.IsValid", "model.login_lockout.is_valid.key.app_error", nil, "id="+o.Id)
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("LoginLockout.IsValid", "model.login_lockout.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if o.ExpireAt <= o.CreateAt {
+		return NewLocAppError("LoginLockout.IsValid", "model.login_lockout.is_valid.expire_at.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *LoginLockout) IsExpired() bool {
+	return o.ExpireAt <= GetMillis()
+}
+
+func (o *LoginLockout) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func LoginLockoutFromJson(data io.Reader) *LoginLockout {
+	decoder := json.NewDecoder(data)
+	var o LoginLockout
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func LoginLockoutListToJson(l []*LoginLockout) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func LoginLockoutListFromJson(data io.Reader) []*LoginLockout {
+	decoder := json.NewDecoder(data)
+	var o []*LoginLockout
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
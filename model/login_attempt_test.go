@@ -0,0 +1,79 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoginAttemptIsValid(t *testing.T) {
+	o := LoginAttempt{KeyType: LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, KeyValue: " User@Example.com "}
+	o.PreSave()
+
+	if o.KeyValue != "user@example.com" {
+		t.Fatal("login id should have been normalized")
+	}
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.KeyType = "junk"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.KeyType = LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS
+	o.KeyValue = strings.Repeat("1", 257)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.KeyValue = "10.0.0.1"
+	o.CreateAt = 0
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestLoginLockoutIsValid(t *testing.T) {
+	o := LoginLockout{KeyType: LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS, KeyValue: "10.0.0.1", FailedAttempts: 30}
+	o.PreSave()
+	o.ExpireAt = o.CreateAt + 1000
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.IsExpired() {
+		t.Fatal("should not be expired")
+	}
+
+	o.ExpireAt = o.CreateAt
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	if !o.IsExpired() {
+		t.Fatal("should be expired")
+	}
+
+	o.KeyValue = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+}
+
+func TestLoginLockoutJson(t *testing.T) {
+	o := &LoginLockout{Id: NewId(), KeyType: LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, KeyValue: "user"}
+
+	if ro := LoginLockoutFromJson(strings.NewReader(o.ToJson())); ro == nil || ro.Id != o.Id {
+		t.Fatal("lockout should have round tripped")
+	}
+
+	if l := LoginLockoutListFromJson(strings.NewReader(LoginLockoutListToJson([]*LoginLockout{o}))); len(l) != 1 || l[0].KeyValue != o.KeyValue {
+		t.Fatal("lockout list should have round tripped")
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+	"regexp"
+)
+
+const (
+	MENTION_GROUP_NAME_MAX_LENGTH = 64
+	MENTION_GROUP_MAX_USERS       = 1000
+)
+
+var validMentionGroupName = regexp.MustCompile(`^[a-z0-9][a-z0-9\-_]*$`)
+
+// The names of system mentions that can't be used by a mention group
+var reservedMentionGroupNames = map[string]bool{"here": true, "channel": true, "all": true}
+
+// A MentionGroup lets a set of users on a team be mentioned at once with @name.
+type MentionGroup struct {
+	Id        string      `json:"id"`
+	CreateAt  int64       `json:"create_at"`
+	UpdateAt  int64       `json:"update_at"`
+	DeleteAt  int64       `json:"delete_at"`
+	TeamId    string      `json:"team_id"`
+	CreatorId string      `json:"creator_id"`
+	Name      string      `json:"name"`
+	UserIds   StringArray `json:"user_ids"`
+}
+
+type MentionGroupPatch struct {
+	Name    *string   `json:"name"`
+	UserIds *[]string `json:"user_ids"`
+}
+
+func (o *MentionGroup) PreSave() {
+	if o.Id == "" {
+		o.Id = NewId()
+	}
+
+	if o.UserIds == nil {
+		o.UserIds = StringArray{}
+	}
+
+	o.CreateAt = GetMillis()
+	o.UpdateAt = o.CreateAt
+}
+
+func (o *MentionGroup) PreUpdate() {
+	o.UpdateAt = GetMillis()
+}
+
+func (o *MentionGroup) IsValid() *AppError {
+	if len(o.Id) != 26 {
+		return NewLocAppError("MentionGroup.IsValid", "model.mention_group.is_valid.id.app_error", nil, "")
+	}
+
+	if o.CreateAt == 0 {
+		return NewLocAppError("MentionGroup.IsValid", "model.mention_group.is_valid.create_at.app_error", nil, "id="+o.Id)
+	}
+
+	if o.UpdateAt == 0 {
+		return NewLocAppError("MentionGroup.IsValid", "model.mention_group.is_valid.update_at.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.TeamId) != 26 {
+		return NewLocAppError("MentionGroup.IsValid", "model.mention_group.is_valid.team_id.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.CreatorId) != 26 {
+		return NewLocAppError("MentionGroup.IsValid", "model.mention_group.is_valid.creator_id.app_error", nil, "id="+o.Id)
+	}
+
+	if !IsValidMentionGroupName(o.Name) {
+		return NewLocAppError("MentionGroup.IsValid", "model.mention_group.is_valid.name.app_error", nil, "id="+o.Id)
+	}
+
+	if len(o.UserIds) == 0 || len(o.UserIds) > MENTION_GROUP_MAX_USERS {
+		return NewLocAppError("MentionGroup.IsValid", "model.mention_group.is_valid.user_ids.app_error", nil, "id="+o.Id)
+	}
+
+	for _, userId := range o.UserIds {
+		if len(userId) != 26 {
+			return NewLocAppError("MentionGroup.IsValid", "model.mention_group.is_valid.user_ids.app_error", nil, "id="+o.Id)
+		}
+	}
+
+	return nil
+}
+
+func (o *MentionGroup) Patch(patch *MentionGroupPatch) {
+	if patch.Name != nil {
+		o.Name = *patch.Name
+	}
+
+	if patch.UserIds != nil {
+		o.UserIds = *patch.UserIds
+	}
+}
+
+// IsValidMentionGroupName returns true if the name can be mentioned as a single word. Names are lower case so
+// that they're matched case insensitively like usernames.
+func IsValidMentionGroupName(name string) bool {
+	if len(name) == 0 || len(name) > MENTION_GROUP_NAME_MAX_LENGTH {
+		return false
+	}
+
+	return validMentionGroupName.MatchString(name) && !reservedMentionGroupNames[name]
+}
+
+func (o *MentionGroup) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func MentionGroupFromJson(data io.Reader) *MentionGroup {
+	decoder := json.NewDecoder(data)
+	var o MentionGroup
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func (o *MentionGroupPatch) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func MentionGroupPatchFromJson(data io.Reader) *MentionGroupPatch {
+	decoder := json.NewDecoder(data)
+	var o MentionGroupPatch
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+func MentionGroupListToJson(l []*MentionGroup) string {
+	b, err := json.Marshal(l)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func MentionGroupListFromJson(data io.Reader) []*MentionGroup {
+	decoder := json.NewDecoder(data)
+	var o []*MentionGroup
+	err := decoder.Decode(&o)
+	if err == nil {
+		return o
+	} else {
+		return nil
+	}
+}
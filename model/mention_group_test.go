@@ -0,0 +1,92 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMentionGroupJson(t *testing.T) {
+	group := MentionGroup{Id: NewId(), Name: "oncall", UserIds: StringArray{NewId()}}
+	json := group.ToJson()
+	rgroup := MentionGroupFromJson(strings.NewReader(json))
+
+	if rgroup.Id != group.Id || rgroup.Name != group.Name || len(rgroup.UserIds) != 1 || rgroup.UserIds[0] != group.UserIds[0] {
+		t.Fatal("ids do not match")
+	}
+
+	list := MentionGroupListFromJson(strings.NewReader(MentionGroupListToJson([]*MentionGroup{&group})))
+	if len(list) != 1 || list[0].Id != group.Id {
+		t.Fatal("list should've round tripped")
+	}
+}
+
+func TestMentionGroupIsValid(t *testing.T) {
+	group := MentionGroup{
+		TeamId:    NewId(),
+		CreatorId: NewId(),
+		Name:      "oncall",
+		UserIds:   StringArray{NewId()},
+	}
+	group.PreSave()
+
+	if err := group.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	group.TeamId = ""
+	if err := group.IsValid(); err == nil {
+		t.Fatal("should be invalid without a team")
+	}
+
+	group.TeamId = NewId()
+	group.UserIds = StringArray{}
+	if err := group.IsValid(); err == nil {
+		t.Fatal("should be invalid without users")
+	}
+
+	group.UserIds = StringArray{"junk"}
+	if err := group.IsValid(); err == nil {
+		t.Fatal("should be invalid with a bad user id")
+	}
+
+	group.UserIds = StringArray{NewId()}
+	group.Name = "On Call"
+	if err := group.IsValid(); err == nil {
+		t.Fatal("should be invalid with a bad name")
+	}
+}
+
+func TestIsValidMentionGroupName(t *testing.T) {
+	for _, name := range []string{"oncall", "on-call", "on_call", "team2"} {
+		if !IsValidMentionGroupName(name) {
+			t.Fatal("should be valid", name)
+		}
+	}
+
+	for _, name := range []string{"", "OnCall", "on.call", "-oncall", "on call", "here", "channel", "all", strings.Repeat("a", 65)} {
+		if IsValidMentionGroupName(name) {
+			t.Fatal("should be invalid", name)
+		}
+	}
+}
+
+func TestMentionGroupPatch(t *testing.T) {
+	group := MentionGroup{Name: "oncall", UserIds: StringArray{NewId()}}
+
+	userIds := []string{NewId(), NewId()}
+	group.Patch(&MentionGroupPatch{UserIds: &userIds})
+
+	if group.Name != "oncall" || len(group.UserIds) != 2 {
+		t.Fatal("should only have patched users")
+	}
+
+	name := "support"
+	group.Patch(&MentionGroupPatch{Name: &name})
+
+	if group.Name != "support" || len(group.UserIds) != 2 {
+		t.Fatal("should only have patched name")
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+const (
+	MENTION_KEYWORD_MAX_LENGTH = 128
+
+	// Keywords are stored as JSON in a user's or channel member's NotifyProps, which have limited space
+	MENTION_KEYWORDS_MAX_LENGTH = 1000
+)
+
+// A MentionKeyword is a word or regular expression that mentions a user when it appears in a post. Literal
+// keywords can be required to match a whole word, and any keyword can be made case sensitive.
+type MentionKeyword struct {
+	Keyword       string `json:"keyword"`
+	CaseSensitive bool   `json:"case_sensitive,omitempty"`
+	WholeWord     bool   `json:"whole_word,omitempty"`
+	Regex         bool   `json:"regex,omitempty"`
+}
+
+func (o *MentionKeyword) IsValid() bool {
+	if len(strings.TrimSpace(o.Keyword)) == 0 || len(o.Keyword) > MENTION_KEYWORD_MAX_LENGTH {
+		return false
+	}
+
+	if _, err := o.Compile(); err != nil {
+		return false
+	}
+
+	return true
+}
+
+// Compile returns a regular expression that matches the keyword within a message. Whole words are separated
+// by anything other than a letter, number or underscore. Go's regular expressions don't backtrack, so matching
+// one takes time linear in the length of the message no matter what the user entered.
+func (o *MentionKeyword) Compile() (*regexp.Regexp, error) {
+	pattern := o.Keyword
+	if !o.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+
+	if o.WholeWord {
+		pattern = `(?:^|[^\pL\pN_])(?:` + pattern + `)(?:$|[^\pL\pN_])`
+	}
+
+	if !o.CaseSensitive {
+		pattern = "(?i)" + pattern
+	}
+
+	return regexp.Compile(pattern)
+}
+
+func MentionKeywordsToJson(keywords []*MentionKeyword) string {
+	b, err := json.Marshal(keywords)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+// MentionKeywordsFromJson parses the keywords stored in NotifyProps. Anything that can't be parsed is treated as
+// having no keywords.
+func MentionKeywordsFromJson(data string) []*MentionKeyword {
+	var keywords []*MentionKeyword
+	if err := json.Unmarshal([]byte(data), &keywords); err != nil {
+		return nil
+	}
+
+	return keywords
+}
+
+// IsValidMentionKeywords returns true if the NotifyProps value is a list of valid keywords that's small enough to
+// be stored.
+func IsValidMentionKeywords(data string) bool {
+	if data == "" {
+		return true
+	}
+
+	if len(data) > MENTION_KEYWORDS_MAX_LENGTH {
+		return false
+	}
+
+	var keywords []*MentionKeyword
+	if err := json.Unmarshal([]byte(data), &keywords); err != nil {
+		return false
+	}
+
+	for _, keyword := range keywords {
+		if keyword == nil || !keyword.IsValid() {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMentionKeywordCompile(t *testing.T) {
+	cases := []struct {
+		Keyword  MentionKeyword
+		Message  string
+		Expected bool
+	}{
+		{MentionKeyword{Keyword: "deploy"}, "Time to DEPLOY", true},
+		{MentionKeyword{Keyword: "deploy"}, "redeployed", true},
+		{MentionKeyword{Keyword: "deploy", WholeWord: true}, "redeployed", false},
+		{MentionKeyword{Keyword: "deploy", WholeWord: true}, "deploy!", true},
+		{MentionKeyword{Keyword: "Deploy", CaseSensitive: true}, "deploy", false},
+		{MentionKeyword{Keyword: "Deploy", CaseSensitive: true}, "Deploy", true},
+		{MentionKeyword{Keyword: "c++"}, "is c++ good", true},
+		{MentionKeyword{Keyword: "c++"}, "cc", false},
+		{MentionKeyword{Keyword: `PLT-\d+`, Regex: true}, "see plt-1234", true},
+		{MentionKeyword{Keyword: `PLT-\d+`, Regex: true, CaseSensitive: true}, "see plt-1234", false},
+		{MentionKeyword{Keyword: `PLT-\d+`, Regex: true, WholeWord: true}, "see xPLT-1", false},
+		{MentionKeyword{Keyword: "café", WholeWord: true}, "a café.", true},
+		{MentionKeyword{Keyword: "café", WholeWord: true}, "cafés", false},
+	}
+
+	for _, c := range cases {
+		re, err := c.Keyword.Compile()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if re.MatchString(c.Message) != c.Expected {
+			t.Fatalf("keyword %+v should've matched %q: %v", c.Keyword, c.Message, c.Expected)
+		}
+	}
+}
+
+func TestIsValidMentionKeywords(t *testing.T) {
+	keywords := []*MentionKeyword{{Keyword: "deploy"}, {Keyword: `PLT-\d+`, Regex: true}}
+	data := MentionKeywordsToJson(keywords)
+
+	if !IsValidMentionKeywords(data) || !IsValidMentionKeywords("") {
+		t.Fatal("should be valid")
+	}
+
+	if !IsValidMentionKeywords(MentionKeywordsToJson([]*MentionKeyword{{Keyword: strings.Repeat("a", MENTION_KEYWORD_MAX_LENGTH), Regex: true}})) {
+		t.Fatal("long regular expressions should be valid")
+	}
+
+	if parsed := MentionKeywordsFromJson(data); len(parsed) != 2 || parsed[1].Keyword != keywords[1].Keyword || !parsed[1].Regex {
+		t.Fatal("should've round tripped")
+	}
+
+	tooMany := []*MentionKeyword{}
+	for i := 0; i < 10; i++ {
+		tooMany = append(tooMany, &MentionKeyword{Keyword: strings.Repeat("a", 100)})
+	}
+
+	invalid := []string{
+		"junk",
+		`[{"keyword": ""}]`,
+		`[{"keyword": "(", "regex": true}]`,
+		`[null]`,
+		MentionKeywordsToJson([]*MentionKeyword{{Keyword: strings.Repeat("a", MENTION_KEYWORD_MAX_LENGTH+1)}}),
+		MentionKeywordsToJson(tooMany),
+	}
+
+	for _, data := range invalid {
+		if IsValidMentionKeywords(data) {
+			t.Fatal("should be invalid", data)
+		}
+	}
+
+	if MentionKeywordsFromJson("junk") != nil {
+		t.Fatal("shouldn't parse junk")
+	}
+}
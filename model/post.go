@@ -190,3 +190,60 @@ func (o *Post) AddProp(key string, value interface{}) {
 func (o *Post) IsSystemMessage() bool {
 	return len(o.Type) >= len(POST_SYSTEM_MESSAGE_PREFIX) && o.Type[:len(POST_SYSTEM_MESSAGE_PREFIX)] == POST_SYSTEM_MESSAGE_PREFIX
 }
+
+// Attachments returns the message attachments stored in the post's props. Props read back from the
+// database or decoded from JSON hold generic maps so they are converted to SlackAttachments.
+func (o *Post) Attachments() []*SlackAttachment {
+	if attachments, ok := o.Props["attachments"].([]*SlackAttachment); ok {
+		return attachments
+	}
+
+	var ret []*SlackAttachment
+	if attachments, ok := o.Props["attachments"].([]interface{}); ok {
+		for _, attachment := range attachments {
+			if enc, err := json.Marshal(attachment); err == nil {
+				var decoded SlackAttachment
+				if json.Unmarshal(enc, &decoded) == nil {
+					ret = append(ret, &decoded)
+				}
+			}
+		}
+	}
+
+	return ret
+}
+
+// GenerateActionIds assigns an id to every attachment action that doesn't have one yet and
+// defaults their type to a button.
+func (o *Post) GenerateActionIds() {
+	if o.Props["attachments"] == nil {
+		return
+	}
+
+	attachments := o.Attachments()
+	for _, attachment := range attachments {
+		for _, action := range attachment.Actions {
+			if action.Id == "" {
+				action.Id = NewId()
+			}
+
+			if action.Type == "" {
+				action.Type = POST_ACTION_TYPE_BUTTON
+			}
+		}
+	}
+
+	o.Props["attachments"] = attachments
+}
+
+func (o *Post) GetAction(id string) *PostAction {
+	for _, attachment := range o.Attachments() {
+		for _, action := range attachment.Actions {
+			if action.Id == id {
+				return action
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+const (
+	POST_ACTION_TYPE_BUTTON = "button"
+	POST_ACTION_TYPE_SELECT = "select"
+
+	POST_ACTION_DATA_SOURCE_USERS    = "users"
+	POST_ACTION_DATA_SOURCE_CHANNELS = "channels"
+
+	POST_ACTION_CONTEXT_SELECTED_OPTION = "selected_option"
+)
+
+// PostAction is a button or select menu attached to a message attachment. When a user clicks the
+// button or picks an option, the server calls the integration's URL with the user, channel and post.
+// The integration is only kept on the server since its context may hold secrets.
+type PostAction struct {
+	Id          string                 `json:"id,omitempty"`
+	Name        string                 `json:"name"`
+	Type        string                 `json:"type,omitempty"`
+	DataSource  string                 `json:"data_source,omitempty"`
+	Options     []*PostActionOptions   `json:"options,omitempty"`
+	Integration *PostActionIntegration `json:"integration,omitempty"`
+}
+
+type PostActionOptions struct {
+	Text  string `json:"text"`
+	Value string `json:"value"`
+}
+
+type PostActionIntegration struct {
+	URL     string          `json:"url,omitempty"`
+	Context StringInterface `json:"context,omitempty"`
+}
+
+// PostActionIntegrationRequest is sent to the integration's URL when a user triggers an action.
+type PostActionIntegrationRequest struct {
+	UserId    string          `json:"user_id"`
+	ChannelId string          `json:"channel_id"`
+	TeamId    string          `json:"team_id"`
+	PostId    string          `json:"post_id"`
+	Type      string          `json:"type"`
+	Context   StringInterface `json:"context,omitempty"`
+}
+
+// PostActionIntegrationResponse is returned by the integration. Update replaces the message of the original
+// post and adds its props to the post's props, and EphemeralText is shown only to the user who triggered the action.
+type PostActionIntegrationResponse struct {
+	Update        *Post  `json:"update"`
+	EphemeralText string `json:"ephemeral_text"`
+}
+
+func (o *PostAction) IsValid() *AppError {
+	if len(o.Name) == 0 {
+		return NewAppError("PostAction.IsValid", "model.post_action.is_valid.name.app_error", nil, "id="+o.Id, 400)
+	}
+
+	if o.Type != POST_ACTION_TYPE_BUTTON && o.Type != POST_ACTION_TYPE_SELECT {
+		return NewAppError("PostAction.IsValid", "model.post_action.is_valid.type.app_error", nil, "id="+o.Id, 400)
+	}
+
+	if o.Type == POST_ACTION_TYPE_SELECT && len(o.Options) == 0 &&
+		o.DataSource != POST_ACTION_DATA_SOURCE_USERS && o.DataSource != POST_ACTION_DATA_SOURCE_CHANNELS {
+		return NewAppError("PostAction.IsValid", "model.post_action.is_valid.options.app_error", nil, "id="+o.Id, 400)
+	}
+
+	if o.Integration == nil || !IsValidHttpUrl(o.Integration.URL) {
+		return NewAppError("PostAction.IsValid", "model.post_action.is_valid.url.app_error", nil, "id="+o.Id, 400)
+	}
+
+	return nil
+}
+
+// HasOption returns true if the value is one of the select menu's static options or the menu draws
+// its options from a data source.
+func (o *PostAction) HasOption(value string) bool {
+	if len(o.DataSource) > 0 {
+		return len(value) == 26
+	}
+
+	for _, option := range o.Options {
+		if option.Value == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (o *PostActionIntegrationRequest) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func PostActionIntegrationRequestFromJson(data io.Reader) *PostActionIntegrationRequest {
+	decoder := json.NewDecoder(data)
+	var o PostActionIntegrationRequest
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	}
+	return &o
+}
+
+func (o *PostActionIntegrationResponse) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func PostActionIntegrationResponseFromJson(data io.Reader) *PostActionIntegrationResponse {
+	decoder := json.NewDecoder(data)
+	var o PostActionIntegrationResponse
+	if err := decoder.Decode(&o); err != nil {
+		return nil
+	}
+	return &o
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostActionIsValid(t *testing.T) {
+	o := PostAction{Id: NewId()}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Name = "name"
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Type = POST_ACTION_TYPE_SELECT
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.DataSource = POST_ACTION_DATA_SOURCE_USERS
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Integration = &PostActionIntegration{URL: "junk"}
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Integration.URL = "http://example.com/action"
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	o.Type = POST_ACTION_TYPE_BUTTON
+	o.DataSource = ""
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPostActionHasOption(t *testing.T) {
+	o := PostAction{Options: []*PostActionOptions{{Text: "One", Value: "one"}}}
+	if !o.HasOption("one") {
+		t.Fatal("should have the option")
+	}
+
+	if o.HasOption("two") {
+		t.Fatal("should not have the option")
+	}
+
+	o.DataSource = POST_ACTION_DATA_SOURCE_CHANNELS
+	if !o.HasOption(NewId()) {
+		t.Fatal("should accept any id from a data source")
+	}
+
+	if o.HasOption("junk") {
+		t.Fatal("should not accept invalid ids from a data source")
+	}
+}
+
+func TestPostActionIntegrationJson(t *testing.T) {
+	request := PostActionIntegrationRequest{UserId: NewId(), PostId: NewId(), Context: StringInterface{"a": "b"}}
+	rrequest := PostActionIntegrationRequestFromJson(strings.NewReader(request.ToJson()))
+	if rrequest.UserId != request.UserId || rrequest.PostId != request.PostId || rrequest.Context["a"] != "b" {
+		t.Fatal("requests do not match")
+	}
+
+	response := PostActionIntegrationResponse{Update: &Post{Message: "message"}, EphemeralText: "text"}
+	rresponse := PostActionIntegrationResponseFromJson(strings.NewReader(response.ToJson()))
+	if rresponse.Update.Message != "message" || rresponse.EphemeralText != "text" {
+		t.Fatal("responses do not match")
+	}
+
+	if PostActionIntegrationResponseFromJson(strings.NewReader("junk")) != nil {
+		t.Fatal("should have failed to decode")
+	}
+}
@@ -113,3 +113,40 @@ func TestPostIsSystemMessage(t *testing.T) {
 		t.Fatalf("TestPostIsSystemMessage failed, expected post2.IsSystemMessage() to be true")
 	}
 }
+
+func TestPostAttachments(t *testing.T) {
+	o := Post{Props: StringInterface{}}
+	if len(o.Attachments()) != 0 {
+		t.Fatal("should have no attachments")
+	}
+
+	o.AddProp("attachments", []*SlackAttachment{
+		{
+			Text: "text",
+			Actions: []*PostAction{
+				{Name: "button"},
+				{Id: NewId(), Name: "select", Type: POST_ACTION_TYPE_SELECT},
+			},
+		},
+	})
+	o.GenerateActionIds()
+
+	ro := PostFromJson(strings.NewReader(o.ToJson()))
+	attachments := ro.Attachments()
+	if len(attachments) != 1 || attachments[0].Text != "text" || len(attachments[0].Actions) != 2 {
+		t.Fatal("attachments should have survived the round trip")
+	}
+
+	button := attachments[0].Actions[0]
+	if len(button.Id) != 26 || button.Type != POST_ACTION_TYPE_BUTTON {
+		t.Fatal("button should have been given an id and a type")
+	}
+
+	if action := ro.GetAction(button.Id); action == nil || action.Name != "button" {
+		t.Fatal("should have found the action")
+	}
+
+	if ro.GetAction(NewId()) != nil {
+		t.Fatal("should not have found an action")
+	}
+}
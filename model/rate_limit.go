@@ -0,0 +1,40 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+const (
+	RATE_LIMIT_CLASS_DEFAULT     = "default"
+	RATE_LIMIT_CLASS_LOGIN       = "login"
+	RATE_LIMIT_CLASS_POST_CREATE = "post_create"
+	RATE_LIMIT_CLASS_SEARCH      = "search"
+	RATE_LIMIT_CLASS_FILE_UPLOAD = "file_upload"
+
+	HEADER_RATE_LIMIT_LIMIT     = "X-RateLimit-Limit"
+	HEADER_RATE_LIMIT_REMAINING = "X-RateLimit-Remaining"
+	HEADER_RATE_LIMIT_RESET     = "X-RateLimit-Reset"
+	HEADER_RETRY_AFTER          = "Retry-After"
+)
+
+// RateLimit is the shared state of a single rate limiter key when limits are shared across a cluster
+type RateLimit struct {
+	Id       string `json:"id"`
+	Value    int64  `json:"value"`
+	ExpireAt int64  `json:"expire_at"`
+}
+
+func (o *RateLimit) IsValid() *AppError {
+	if len(o.Id) == 0 || len(o.Id) > 256 {
+		return NewLocAppError("RateLimit.IsValid", "model.rate_limit.is_valid.id.app_error", nil, "")
+	}
+
+	if o.ExpireAt == 0 {
+		return NewLocAppError("RateLimit.IsValid", "model.rate_limit.is_valid.expire_at.app_error", nil, "id="+o.Id)
+	}
+
+	return nil
+}
+
+func (o *RateLimit) IsExpired() bool {
+	return o.ExpireAt < GetMillis()
+}
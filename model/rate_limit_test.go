@@ -0,0 +1,42 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRateLimitIsValid(t *testing.T) {
+	o := RateLimit{Id: "login:127.0.0.1", Value: 1, ExpireAt: GetMillis() + 1000}
+
+	if err := o.IsValid(); err != nil {
+		t.Fatal(err)
+	}
+
+	if o.IsExpired() {
+		t.Fatal("should not be expired")
+	}
+
+	o.Id = ""
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Id = strings.Repeat("a", 257)
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.Id = "login:127.0.0.1"
+	o.ExpireAt = 0
+	if err := o.IsValid(); err == nil {
+		t.Fatal("should be invalid")
+	}
+
+	o.ExpireAt = GetMillis() - 1000
+	if !o.IsExpired() {
+		t.Fatal("should be expired")
+	}
+}
@@ -13,6 +13,7 @@ type Reaction struct {
 	PostId    string `json:"post_id"`
 	EmojiName string `json:"emoji_name"`
 	CreateAt  int64  `json:"create_at"`
+	DeleteAt  int64  `json:"delete_at,omitempty"` // only set for reactions that are kept because of a legal hold
 }
 
 func (o *Reaction) ToJson() string {
@@ -75,4 +76,6 @@ func (o *Reaction) PreSave() {
 	if o.CreateAt == 0 {
 		o.CreateAt = GetMillis()
 	}
+
+	o.DeleteAt = 0
 }
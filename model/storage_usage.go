@@ -0,0 +1,41 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// StorageUsage is the total size in bytes of the files uploaded to a team or by a user, along with the most
+// that they're allowed to upload. A quota of 0 means that there's no limit.
+type StorageUsage struct {
+	Usage int64 `json:"usage"`
+	Quota int64 `json:"quota"`
+}
+
+func (o *StorageUsage) ToJson() string {
+	b, err := json.Marshal(o)
+	if err != nil {
+		return ""
+	} else {
+		return string(b)
+	}
+}
+
+func StorageUsageFromJson(data io.Reader) *StorageUsage {
+	decoder := json.NewDecoder(data)
+	var o StorageUsage
+	err := decoder.Decode(&o)
+	if err == nil {
+		return &o
+	} else {
+		return nil
+	}
+}
+
+// IsOverQuota returns true if uploading another size bytes would take the usage over the quota.
+func (o *StorageUsage) IsOverQuota(size int64) bool {
+	return o.Quota > 0 && o.Usage+size > o.Quota
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStorageUsageJson(t *testing.T) {
+	o := StorageUsage{Usage: 1024, Quota: 4096}
+	json := o.ToJson()
+	ro := StorageUsageFromJson(strings.NewReader(json))
+
+	if ro.Usage != o.Usage || ro.Quota != o.Quota {
+		t.Fatal("Usage and Quota should match")
+	}
+}
+
+func TestStorageUsageIsOverQuota(t *testing.T) {
+	o := StorageUsage{Usage: 1024, Quota: 0}
+	if o.IsOverQuota(1024 * 1024) {
+		t.Fatal("should never be over a quota of 0")
+	}
+
+	o.Quota = 2048
+	if o.IsOverQuota(1024) {
+		t.Fatal("should be able to fill the quota exactly")
+	}
+
+	if !o.IsOverQuota(1025) {
+		t.Fatal("should be over quota")
+	}
+}
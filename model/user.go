@@ -25,6 +25,7 @@ const (
 	MARK_UNREAD_NOTIFY_PROP = "mark_unread"
 	PUSH_NOTIFY_PROP        = "push"
 	EMAIL_NOTIFY_PROP       = "email"
+	KEYWORDS_NOTIFY_PROP    = "mention_keywords"
 
 	DEFAULT_LOCALE             = "en"
 	USER_AUTH_SERVICE_EMAIL    = "email"
@@ -124,6 +125,10 @@ func (u *User) IsValid() *AppError {
 		return NewAppError("User.IsValid", "model.user.is_valid.auth_data.app_error", nil, "user_id="+u.Id, http.StatusBadRequest)
 	}
 
+	if keywords, ok := u.NotifyProps[KEYWORDS_NOTIFY_PROP]; ok && !IsValidMentionKeywords(keywords) {
+		return NewAppError("User.IsValid", "model.user.is_valid.mention_keywords.app_error", nil, "user_id="+u.Id, http.StatusBadRequest)
+	}
+
 	if u.AuthData != nil && len(*u.AuthData) > 0 && len(u.AuthService) == 0 {
 		return NewAppError("User.IsValid", "model.user.is_valid.auth_data_type.app_error", nil, "user_id="+u.Id, http.StatusBadRequest)
 	}
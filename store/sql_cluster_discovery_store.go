@@ -0,0 +1,140 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"github.com/mattermost/platform/model"
+)
+
+type SqlClusterDiscoveryStore struct {
+	*SqlStore
+}
+
+func NewSqlClusterDiscoveryStore(sqlStore *SqlStore) ClusterDiscoveryStore {
+	s := &SqlClusterDiscoveryStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.ClusterDiscovery{}, "ClusterDiscovery").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("Type").SetMaxSize(64)
+		table.ColMap("Hostname").SetMaxSize(512)
+		table.ColMap("InterNodeUrl").SetMaxSize(512)
+		table.ColMap("Version").SetMaxSize(64)
+	}
+
+	return s
+}
+
+func (s SqlClusterDiscoveryStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_cluster_discovery_type", "ClusterDiscovery", "Type")
+	s.CreateIndexIfNotExists("idx_cluster_discovery_last_ping_at", "ClusterDiscovery", "LastPingAt")
+}
+
+func (s SqlClusterDiscoveryStore) Save(discovery *model.ClusterDiscovery) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		discovery.PreSave()
+		if result.Err = discovery.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(discovery); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.Save", "store.sql_cluster_discovery.save.app_error", nil, "id="+discovery.Id+", "+err.Error())
+		} else {
+			result.Data = discovery
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlClusterDiscoveryStore) Delete(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM ClusterDiscovery WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.Delete", "store.sql_cluster_discovery.delete.app_error", nil, "id="+id+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlClusterDiscoveryStore) GetAll(discoveryType string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var discoveries []*model.ClusterDiscovery
+		if _, err := s.GetMaster().Select(&discoveries,
+			`SELECT
+				*
+			FROM
+				ClusterDiscovery
+			WHERE
+				Type = :Type
+				AND LastPingAt > :LastPingAt
+			ORDER BY CreateAt`,
+			map[string]interface{}{"Type": discoveryType, "LastPingAt": model.GetMillis() - model.CDS_OFFLINE_AFTER_MILLIS}); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.GetAll", "store.sql_cluster_discovery.get_all.app_error", nil, err.Error())
+		} else {
+			result.Data = discoveries
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlClusterDiscoveryStore) SetLastPingAt(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("UPDATE ClusterDiscovery SET LastPingAt = :LastPingAt WHERE Id = :Id", map[string]interface{}{"LastPingAt": model.GetMillis(), "Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.SetLastPingAt", "store.sql_cluster_discovery.set_last_ping_at.app_error", nil, "id="+id+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows != 1 {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.SetLastPingAt", "store.sql_cluster_discovery.set_last_ping_at.app_error", nil, "id="+id)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlClusterDiscoveryStore) Cleanup() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM ClusterDiscovery WHERE LastPingAt < :LastPingAt", map[string]interface{}{"LastPingAt": model.GetMillis() - model.CDS_OFFLINE_AFTER_MILLIS}); err != nil {
+			result.Err = model.NewLocAppError("SqlClusterDiscoveryStore.Cleanup", "store.sql_cluster_discovery.cleanup.app_error", nil, err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestSqlClusterDiscoveryStore(t *testing.T) {
+	Setup()
+
+	discoveryType := "test_" + model.NewId()
+
+	d1 := &model.ClusterDiscovery{
+		Type:         discoveryType,
+		Hostname:     "node1",
+		InterNodeUrl: "http://node1:8075",
+	}
+
+	if result := <-store.ClusterDiscovery().Save(d1); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	d2 := &model.ClusterDiscovery{
+		Type:         discoveryType,
+		Hostname:     "node2",
+		InterNodeUrl: "http://node2:8075",
+	}
+
+	if result := <-store.ClusterDiscovery().Save(d2); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.ClusterDiscovery().Save(&model.ClusterDiscovery{Type: discoveryType}); result.Err == nil {
+		t.Fatal("should have failed to save an invalid discovery")
+	}
+
+	if result := <-store.ClusterDiscovery().GetAll(discoveryType); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if discoveries := result.Data.([]*model.ClusterDiscovery); len(discoveries) != 2 {
+		t.Fatal("should have returned 2 discoveries")
+	} else if discoveries[0].Id != d1.Id || discoveries[1].Id != d2.Id {
+		t.Fatal("discoveries should be ordered by create time")
+	}
+
+	if result := <-store.ClusterDiscovery().SetLastPingAt(d1.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.ClusterDiscovery().SetLastPingAt(model.NewId()); result.Err == nil {
+		t.Fatal("should have failed to ping a missing discovery")
+	}
+
+	if _, err := store.(*SqlStore).GetMaster().Exec("UPDATE ClusterDiscovery SET LastPingAt = :LastPingAt WHERE Id = :Id", map[string]interface{}{"LastPingAt": model.GetMillis() - model.CDS_OFFLINE_AFTER_MILLIS - 1000, "Id": d2.Id}); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := <-store.ClusterDiscovery().GetAll(discoveryType); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if discoveries := result.Data.([]*model.ClusterDiscovery); len(discoveries) != 1 || discoveries[0].Id != d1.Id {
+		t.Fatal("should only have returned the live discovery")
+	}
+
+	if result := <-store.ClusterDiscovery().Cleanup(); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.ClusterDiscovery().SetLastPingAt(d2.Id); result.Err == nil {
+		t.Fatal("stale discovery should have been cleaned up")
+	}
+
+	if result := <-store.ClusterDiscovery().Delete(d1.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.ClusterDiscovery().GetAll(discoveryType); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if discoveries := result.Data.([]*model.ClusterDiscovery); len(discoveries) != 0 {
+		t.Fatal("should have deleted all discoveries")
+	}
+}
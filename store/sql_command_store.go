@@ -4,6 +4,9 @@
 package store
 
 import (
+	"database/sql"
+	"net/http"
+
 	"github.com/mattermost/platform/model"
 )
 
@@ -27,6 +30,7 @@ func NewSqlCommandStore(sqlStore *SqlStore) CommandStore {
 		tableo.ColMap("IconURL").SetMaxSize(1024)
 		tableo.ColMap("AutoCompleteDesc").SetMaxSize(1024)
 		tableo.ColMap("AutoCompleteHint").SetMaxSize(1024)
+		tableo.ColMap("AutocompleteArgs").SetMaxSize(4000)
 		tableo.ColMap("DisplayName").SetMaxSize(64)
 		tableo.ColMap("Description").SetMaxSize(128)
 	}
@@ -82,8 +86,10 @@ func (s SqlCommandStore) Get(id string) StoreChannel {
 
 		var command model.Command
 
-		if err := s.GetReplica().SelectOne(&command, "SELECT * FROM Commands WHERE Id = :Id AND DeleteAt = 0", map[string]interface{}{"Id": id}); err != nil {
-			result.Err = model.NewLocAppError("SqlCommandStore.Get", "store.sql_command.save.get.app_error", nil, "id="+id+", err="+err.Error())
+		if err := s.GetReplica().SelectOne(&command, "SELECT * FROM Commands WHERE Id = :Id AND DeleteAt = 0", map[string]interface{}{"Id": id}); err == sql.ErrNoRows {
+			result.Err = model.NewAppError("SqlCommandStore.Get", "store.sql_command.save.get.app_error", nil, "id="+id+", err="+err.Error(), http.StatusNotFound)
+		} else if err != nil {
+			result.Err = model.NewAppError("SqlCommandStore.Get", "store.sql_command.save.get.app_error", nil, "id="+id+", err="+err.Error(), http.StatusInternalServerError)
 		}
 
 		result.Data = &command
@@ -0,0 +1,71 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestSqlCommandWebhookStore(t *testing.T) {
+	Setup()
+
+	expireTime := model.GetMillis() - 30*60*1000
+
+	h1 := &model.CommandWebhook{CommandId: model.NewId(), UserId: model.NewId(), ChannelId: model.NewId()}
+	if result := <-store.CommandWebhook().Save(h1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		h1 = result.Data.(*model.CommandWebhook)
+	}
+
+	if result := <-store.CommandWebhook().Save(h1); result.Err == nil {
+		t.Fatal("should have failed to save an existing webhook")
+	}
+
+	if result := <-store.CommandWebhook().Get(h1.Id, expireTime); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.CommandWebhook).CommandId != h1.CommandId {
+		t.Fatal("should have returned the webhook")
+	}
+
+	if result := <-store.CommandWebhook().Get(model.NewId(), expireTime); result.Err == nil || result.Err.StatusCode != http.StatusNotFound {
+		t.Fatal("should have failed to get a missing webhook")
+	}
+
+	for i := 0; i < 2; i++ {
+		if result := <-store.CommandWebhook().TryUse(h1.Id, 2, expireTime); result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+
+	if result := <-store.CommandWebhook().TryUse(h1.Id, 2, expireTime); result.Err == nil || result.Err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should have reached the use limit")
+	}
+
+	h2 := &model.CommandWebhook{CommandId: model.NewId(), UserId: model.NewId(), ChannelId: model.NewId(), CreateAt: expireTime - 1000}
+	Must(store.CommandWebhook().Save(h2))
+
+	if result := <-store.CommandWebhook().Get(h2.Id, expireTime); result.Err == nil {
+		t.Fatal("should not have returned an expired webhook")
+	}
+
+	if result := <-store.CommandWebhook().TryUse(h2.Id, 2, expireTime); result.Err == nil || result.Err.StatusCode != http.StatusBadRequest {
+		t.Fatal("should not have used an expired webhook")
+	}
+
+	Must(store.CommandWebhook().Cleanup(expireTime))
+
+	if result := <-store.CommandWebhook().Get(h1.Id, expireTime); result.Err != nil {
+		t.Fatal("cleanup should not have removed a live webhook")
+	}
+
+	if count, err := store.(*SqlStore).GetMaster().SelectInt("SELECT COUNT(*) FROM CommandWebhooks WHERE Id = :Id", map[string]interface{}{"Id": h2.Id}); err != nil {
+		t.Fatal(err)
+	} else if count != 0 {
+		t.Fatal("cleanup should have removed the expired webhook")
+	}
+}
@@ -110,6 +110,29 @@ func (s SqlComplianceStore) GetAll(offset, limit int) StoreChannel {
 	return storeChannel
 }
 
+func (s SqlComplianceStore) GetAllByType(complianceType string, offset, limit int) StoreChannel {
+
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		query := "SELECT * FROM Compliances WHERE Type = :Type ORDER BY CreateAt DESC LIMIT :Limit OFFSET :Offset"
+
+		var compliances model.Compliances
+		if _, err := s.GetReplica().Select(&compliances, query, map[string]interface{}{"Type": complianceType, "Offset": offset, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlComplianceStore.GetAllByType", "store.sql_compliance.get.finding.app_error", nil, err.Error())
+		} else {
+			result.Data = compliances
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (us SqlComplianceStore) Get(id string) StoreChannel {
 
 	storeChannel := make(StoreChannel, 1)
@@ -120,7 +143,7 @@ func (us SqlComplianceStore) Get(id string) StoreChannel {
 		if obj, err := us.GetReplica().Get(model.Compliance{}, id); err != nil {
 			result.Err = model.NewLocAppError("SqlComplianceStore.Get", "store.sql_compliance.get.finding.app_error", nil, err.Error())
 		} else if obj == nil {
-			result.Err = model.NewLocAppError("SqlComplianceStore.Get", "store.sql_compliance.get.finding.app_error", nil, err.Error())
+			result.Err = model.NewLocAppError("SqlComplianceStore.Get", "store.sql_compliance.get.finding.app_error", nil, "id="+id)
 		} else {
 			result.Data = obj.(*model.Compliance)
 		}
@@ -230,3 +253,63 @@ func (s SqlComplianceStore) ComplianceExport(job *model.Compliance) StoreChannel
 
 	return storeChannel
 }
+
+// MessageExport returns the posts that were created or changed after the given post in order of when they
+// were last updated. Posts are ordered by id as well so that ones updated at the same time are never skipped
+// between batches.
+func (s SqlComplianceStore) MessageExport(afterUpdateAt int64, afterPostId string, endTime int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"UpdateAt": afterUpdateAt, "PostId": afterPostId, "EndTime": endTime, "Limit": limit}
+
+		query :=
+			`SELECT
+			    COALESCE(Teams.Name, '') AS TeamName,
+			    COALESCE(Teams.DisplayName, '') AS TeamDisplayName,
+			    Channels.Id AS ChannelId,
+			    Channels.Name AS ChannelName,
+			    Channels.DisplayName AS ChannelDisplayName,
+			    Channels.Type AS ChannelType,
+			    Users.Id AS UserId,
+			    Users.Username AS UserUsername,
+			    Users.Email AS UserEmail,
+			    Users.Nickname AS UserNickname,
+			    Posts.Id AS PostId,
+			    Posts.CreateAt AS PostCreateAt,
+			    Posts.UpdateAt AS PostUpdateAt,
+			    Posts.EditAt AS PostEditAt,
+			    Posts.DeleteAt AS PostDeleteAt,
+			    Posts.RootId AS PostRootId,
+			    Posts.OriginalId AS PostOriginalId,
+			    Posts.Message AS PostMessage,
+			    Posts.Type AS PostType,
+			    Posts.Props AS PostProps,
+			    Posts.FileIds AS PostFileIds
+			FROM
+			    Posts
+			        INNER JOIN Channels ON Posts.ChannelId = Channels.Id
+			        INNER JOIN Users ON Posts.UserId = Users.Id
+			        LEFT JOIN Teams ON Channels.TeamId = Teams.Id
+			WHERE
+			    (Posts.UpdateAt > :UpdateAt OR (Posts.UpdateAt = :UpdateAt AND Posts.Id > :PostId))
+			        AND Posts.UpdateAt <= :EndTime
+			ORDER BY Posts.UpdateAt, Posts.Id
+			LIMIT :Limit`
+
+		var posts []*model.ComplianceExportPost
+
+		if _, err := s.GetReplica().Select(&posts, query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlComplianceStore.MessageExport", "store.sql_compliance.message_export.app_error", nil, err.Error())
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
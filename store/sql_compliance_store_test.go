@@ -224,3 +224,101 @@ func TestComplianceExport(t *testing.T) {
 		}
 	}
 }
+
+func TestMessageExport(t *testing.T) {
+	Setup()
+
+	t1 := &model.Team{}
+	t1.DisplayName = "DisplayName"
+	t1.Name = "a" + model.NewId() + "b"
+	t1.Email = model.NewId() + "@nowhere.com"
+	t1.Type = model.TEAM_OPEN
+	t1 = Must(store.Team().Save(t1)).(*model.Team)
+
+	u1 := &model.User{}
+	u1.Email = model.NewId()
+	u1.Username = "n" + model.NewId()
+	u1 = Must(store.User().Save(u1)).(*model.User)
+
+	u2 := &model.User{}
+	u2.Email = model.NewId()
+	u2.Username = "n" + model.NewId()
+	u2 = Must(store.User().Save(u2)).(*model.User)
+
+	c1 := &model.Channel{}
+	c1.TeamId = t1.Id
+	c1.DisplayName = "Channel2"
+	c1.Name = "a" + model.NewId() + "b"
+	c1.Type = model.CHANNEL_OPEN
+	c1 = Must(store.Channel().Save(c1)).(*model.Channel)
+
+	c2 := &model.Channel{}
+	c2.Name = model.GetDMNameFromIds(u1.Id, u2.Id)
+	c2.Type = model.CHANNEL_DIRECT
+	m1 := &model.ChannelMember{UserId: u1.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}
+	m2 := &model.ChannelMember{UserId: u2.Id, NotifyProps: model.GetDefaultChannelNotifyProps()}
+	c2 = Must(store.Channel().SaveDirectChannel(c2, m1, m2)).(*model.Channel)
+
+	// Use times in the future so that posts from other tests aren't included
+	start := model.GetMillis() + 1000*60*60*24
+
+	o1 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: u1.Id, CreateAt: start + 10, Message: "a" + model.NewId() + "b"})).(*model.Post)
+	o2 := Must(store.Post().Save(&model.Post{ChannelId: c2.Id, UserId: u2.Id, CreateAt: start + 20, Message: "a" + model.NewId() + "b"})).(*model.Post)
+	o3 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: u2.Id, CreateAt: start + 20, Message: "a" + model.NewId() + "b"})).(*model.Post)
+	Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: u2.Id, CreateAt: start + 40, Message: "a" + model.NewId() + "b"}))
+
+	// o2 and o3 have the same UpdateAt, so they're ordered by id
+	first, second := o2, o3
+	if o3.Id < o2.Id {
+		first, second = o3, o2
+	}
+
+	if result := <-store.Compliance().MessageExport(start, "", start+30, 1000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if posts := result.Data.([]*model.ComplianceExportPost); len(posts) != 3 {
+		t.Fatal("returned wrong results length", len(posts))
+	} else if posts[0].PostId != o1.Id || posts[1].PostId != first.Id || posts[2].PostId != second.Id {
+		t.Fatal("wrong sort")
+	} else if posts[0].TeamName != t1.Name || posts[0].ChannelName != c1.Name || posts[0].UserUsername != u1.Username || posts[0].PostMessage != o1.Message {
+		t.Fatal("returned wrong post details")
+	} else if posts[1].PostId == o2.Id && (posts[1].TeamName != "" || posts[1].ChannelType != model.CHANNEL_DIRECT) {
+		t.Fatal("direct message should be exported without a team")
+	}
+
+	if result := <-store.Compliance().MessageExport(start, "", start+30, 2); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if posts := result.Data.([]*model.ComplianceExportPost); len(posts) != 2 || posts[1].PostId != first.Id {
+		t.Fatal("should've only returned the first batch")
+	}
+
+	if result := <-store.Compliance().MessageExport(first.UpdateAt, first.Id, start+30, 1000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if posts := result.Data.([]*model.ComplianceExportPost); len(posts) != 1 || posts[0].PostId != second.Id {
+		t.Fatal("should've continued after the last exported post")
+	}
+}
+
+func TestComplianceGetAllByType(t *testing.T) {
+	Setup()
+
+	compliance1 := &model.Compliance{Desc: "csv", UserId: model.NewId(), Status: model.COMPLIANCE_STATUS_FINISHED, StartAt: model.GetMillis() - 1, EndAt: model.GetMillis() + 1, Type: model.COMPLIANCE_TYPE_EXPORT}
+	Must(store.Compliance().Save(compliance1))
+
+	compliance2 := &model.Compliance{Desc: "Audit for federal subpoena case #22443", UserId: model.NewId(), Status: model.COMPLIANCE_STATUS_FINISHED, StartAt: model.GetMillis() - 1, EndAt: model.GetMillis() + 1, Type: model.COMPLIANCE_TYPE_ADHOC}
+	Must(store.Compliance().Save(compliance2))
+
+	compliances := Must(store.Compliance().GetAllByType(model.COMPLIANCE_TYPE_EXPORT, 0, 1000)).(model.Compliances)
+
+	found := false
+	for _, compliance := range compliances {
+		if compliance.Type != model.COMPLIANCE_TYPE_EXPORT {
+			t.Fatal("returned compliance job of the wrong type")
+		} else if compliance.Id == compliance1.Id {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Fatal("should've returned export job")
+	}
+}
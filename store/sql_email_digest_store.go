@@ -0,0 +1,218 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlEmailDigestStore struct {
+	*SqlStore
+}
+
+func NewSqlEmailDigestStore(sqlStore *SqlStore) EmailDigestStore {
+	s := &SqlEmailDigestStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.EmailDigest{}, "EmailDigests").SetKeys(false, "UserId")
+		table.ColMap("UserId").SetMaxSize(26)
+		table.ColMap("Frequency").SetMaxSize(16)
+		table.ColMap("Timezone").SetMaxSize(model.EMAIL_DIGEST_TIMEZONE_MAX_LENGTH)
+	}
+
+	return s
+}
+
+func (s SqlEmailDigestStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_email_digests_next_send_at", "EmailDigests", "NextSendAt")
+}
+
+func (s SqlEmailDigestStore) Save(digest *model.EmailDigest) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		digest.PreSave()
+		if result.Err = digest.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(digest); err != nil {
+			if IsUniqueConstraintError(err.Error(), []string{"PRIMARY", "emaildigests_pkey"}) {
+				result.Err = model.NewAppError("SqlEmailDigestStore.Save", "store.sql_email_digest.save.exists.app_error", nil, "user_id="+digest.UserId+", "+err.Error(), http.StatusBadRequest)
+			} else {
+				result.Err = model.NewLocAppError("SqlEmailDigestStore.Save", "store.sql_email_digest.save.app_error", nil, "user_id="+digest.UserId+", "+err.Error())
+			}
+		} else {
+			result.Data = digest
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlEmailDigestStore) Update(digest *model.EmailDigest) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		digest.PreUpdate()
+		if result.Err = digest.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Update(digest); err != nil {
+			result.Err = model.NewLocAppError("SqlEmailDigestStore.Update", "store.sql_email_digest.update.app_error", nil, "user_id="+digest.UserId+", "+err.Error())
+		} else {
+			result.Data = digest
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlEmailDigestStore) Get(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if obj, err := s.GetMaster().Get(model.EmailDigest{}, userId); err != nil {
+			result.Err = model.NewLocAppError("SqlEmailDigestStore.Get", "store.sql_email_digest.get.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else if obj == nil {
+			result.Err = model.NewAppError("SqlEmailDigestStore.Get", "store.sql_email_digest.get.missing.app_error", nil, "user_id="+userId, http.StatusNotFound)
+		} else {
+			result.Data = obj.(*model.EmailDigest)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlEmailDigestStore) Delete(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM EmailDigests WHERE UserId = :UserId", map[string]interface{}{"UserId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlEmailDigestStore.Delete", "store.sql_email_digest.delete.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = userId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetDue returns the digests that should've been sent by now, oldest first.
+func (s SqlEmailDigestStore) GetDue(now int64, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var digests []*model.EmailDigest
+		if _, err := s.GetMaster().Select(&digests, "SELECT * FROM EmailDigests WHERE NextSendAt <= :Now ORDER BY NextSendAt LIMIT :Limit", map[string]interface{}{"Now": now, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlEmailDigestStore.GetDue", "store.sql_email_digest.get_due.app_error", nil, err.Error())
+		} else {
+			result.Data = digests
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Claim moves a due digest on to its next send time, returning true if this call was the one that did so. Only
+// the server that claims a digest should send it, so each digest is sent once even when running in a cluster.
+func (s SqlEmailDigestStore) Claim(digest *model.EmailDigest, nextSendAt int64, now int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				EmailDigests
+			SET
+				LastSentAt = :LastSentAt, NextSendAt = :NextSendAt
+			WHERE
+				UserId = :UserId
+				AND NextSendAt = :PreviousNextSendAt`,
+			map[string]interface{}{"LastSentAt": now, "NextSendAt": nextSendAt, "UserId": digest.UserId, "PreviousNextSendAt": digest.NextSendAt}); err != nil {
+			result.Err = model.NewLocAppError("SqlEmailDigestStore.Claim", "store.sql_email_digest.claim.app_error", nil, "user_id="+digest.UserId+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows != 1 {
+			result.Data = false
+		} else {
+			digest.LastSentAt = now
+			digest.NextSendAt = nextSendAt
+			result.Data = true
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetUnreadChannels returns the user's channels that have unread posts or mentions and have been posted in since
+// the given time, ordered by team and then by most recent activity.
+func (s SqlEmailDigestStore) GetUnreadChannels(userId string, since int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var channels []*model.EmailDigestChannel
+		if _, err := s.GetReplica().Select(&channels,
+			`SELECT
+				Channels.TeamId TeamId, Channels.Id ChannelId, Channels.Name ChannelName, Channels.DisplayName ChannelDisplayName,
+				Channels.Type ChannelType, (Channels.TotalMsgCount - ChannelMembers.MsgCount) MsgCount,
+				ChannelMembers.MentionCount MentionCount, Channels.LastPostAt LastPostAt
+			FROM
+				Channels, ChannelMembers
+			WHERE
+				Channels.Id = ChannelMembers.ChannelId
+				AND ChannelMembers.UserId = :UserId
+				AND Channels.DeleteAt = 0
+				AND Channels.LastPostAt > :Since
+				AND Channels.LastPostAt > ChannelMembers.LastViewedAt
+				AND (Channels.TotalMsgCount > ChannelMembers.MsgCount OR ChannelMembers.MentionCount > 0)
+			ORDER BY Channels.TeamId, Channels.LastPostAt DESC`,
+			map[string]interface{}{"UserId": userId, "Since": since}); err != nil {
+			result.Err = model.NewLocAppError("SqlEmailDigestStore.GetUnreadChannels", "store.sql_email_digest.get_unread_channels.app_error", nil, "user_id="+userId+", "+err.Error())
+		} else {
+			result.Data = channels
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestEmailDigestStore(t *testing.T) {
+	Setup()
+
+	digest := &model.EmailDigest{UserId: model.NewId(), Frequency: model.EMAIL_DIGEST_FREQUENCY_DAILY, Hour: 9}
+	Must(store.EmailDigest().Save(digest))
+
+	if result := <-store.EmailDigest().Save(&model.EmailDigest{UserId: digest.UserId, Frequency: model.EMAIL_DIGEST_FREQUENCY_HOURLY}); result.Err == nil {
+		t.Fatal("shouldn't save a second digest for the user")
+	}
+
+	if rdigest := Must(store.EmailDigest().Get(digest.UserId)).(*model.EmailDigest); rdigest.Frequency != digest.Frequency || rdigest.NextSendAt != digest.NextSendAt {
+		t.Fatal("should've gotten digest", rdigest)
+	}
+
+	digest.Frequency = model.EMAIL_DIGEST_FREQUENCY_WEEKLY
+	Must(store.EmailDigest().Update(digest))
+
+	if rdigest := Must(store.EmailDigest().Get(digest.UserId)).(*model.EmailDigest); rdigest.Frequency != model.EMAIL_DIGEST_FREQUENCY_WEEKLY {
+		t.Fatal("should've updated digest", rdigest)
+	}
+
+	Must(store.EmailDigest().Delete(digest.UserId))
+
+	if result := <-store.EmailDigest().Get(digest.UserId); result.Err == nil {
+		t.Fatal("shouldn't get a deleted digest")
+	}
+}
+
+func TestEmailDigestStoreClaim(t *testing.T) {
+	Setup()
+
+	digest := &model.EmailDigest{UserId: model.NewId(), Frequency: model.EMAIL_DIGEST_FREQUENCY_HOURLY}
+	Must(store.EmailDigest().Save(digest))
+
+	due := false
+	for _, d := range Must(store.EmailDigest().GetDue(digest.NextSendAt, 1000)).([]*model.EmailDigest) {
+		if d.UserId == digest.UserId {
+			due = true
+		}
+	}
+
+	if !due {
+		t.Fatal("should've been due")
+	}
+
+	first := *digest
+	second := *digest
+
+	if claimed := Must(store.EmailDigest().Claim(&first, digest.NextSendAt+1000, digest.NextSendAt)).(bool); !claimed {
+		t.Fatal("should've claimed the digest")
+	} else if first.NextSendAt != digest.NextSendAt+1000 || first.LastSentAt != digest.NextSendAt {
+		t.Fatal("should've updated the claimed digest", first)
+	}
+
+	if claimed := Must(store.EmailDigest().Claim(&second, digest.NextSendAt+1000, digest.NextSendAt)).(bool); claimed {
+		t.Fatal("shouldn't claim a digest twice")
+	}
+
+	for _, d := range Must(store.EmailDigest().GetDue(digest.NextSendAt, 1000)).([]*model.EmailDigest) {
+		if d.UserId == digest.UserId {
+			t.Fatal("shouldn't be due after being claimed")
+		}
+	}
+}
+
+func TestEmailDigestStoreGetUnreadChannels(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+	teamId := model.NewId()
+	notifyProps := model.GetDefaultChannelNotifyProps()
+
+	c1 := &model.Channel{TeamId: teamId, Name: model.NewId(), DisplayName: "Unread", Type: model.CHANNEL_OPEN, TotalMsgCount: 10, LastPostAt: 2000}
+	Must(store.Channel().Save(c1))
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: userId, NotifyProps: notifyProps, MsgCount: 7, MentionCount: 1, LastViewedAt: 1000}))
+
+	c2 := &model.Channel{TeamId: teamId, Name: model.NewId(), DisplayName: "Read", Type: model.CHANNEL_OPEN, TotalMsgCount: 10, LastPostAt: 2000}
+	Must(store.Channel().Save(c2))
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c2.Id, UserId: userId, NotifyProps: notifyProps, MsgCount: 10, LastViewedAt: 2000}))
+
+	c3 := &model.Channel{TeamId: teamId, Name: model.NewId(), DisplayName: "Old", Type: model.CHANNEL_OPEN, TotalMsgCount: 10, LastPostAt: 500}
+	Must(store.Channel().Save(c3))
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c3.Id, UserId: userId, NotifyProps: notifyProps, MsgCount: 5, LastViewedAt: 100}))
+
+	channels := Must(store.EmailDigest().GetUnreadChannels(userId, 1000)).([]*model.EmailDigestChannel)
+	if len(channels) != 1 {
+		t.Fatal("should've only gotten the unread channel with new posts", len(channels))
+	}
+
+	if channels[0].ChannelId != c1.Id || channels[0].TeamId != teamId || channels[0].ChannelDisplayName != "Unread" {
+		t.Fatal("got the wrong channel", channels[0])
+	} else if channels[0].MsgCount != 3 || channels[0].MentionCount != 1 {
+		t.Fatal("got the wrong counts", channels[0])
+	}
+
+	if channels := Must(store.EmailDigest().GetUnreadChannels(userId, 100)).([]*model.EmailDigestChannel); len(channels) != 2 {
+		t.Fatal("should've gotten the older unread channel", len(channels))
+	}
+}
@@ -4,6 +4,9 @@
 package store
 
 import (
+	"database/sql"
+	"net/http"
+
 	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
@@ -104,7 +107,11 @@ func (es SqlEmojiStore) Get(id string, allowFromCache bool) StoreChannel {
 			WHERE
 				Id = :Id
 				AND DeleteAt = 0`, map[string]interface{}{"Id": id}); err != nil {
-			result.Err = model.NewLocAppError("SqlEmojiStore.Get", "store.sql_emoji.get.app_error", nil, "id="+id+", "+err.Error())
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlEmojiStore.Get", "store.sql_emoji.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusNotFound)
+			} else {
+				result.Err = model.NewAppError("SqlEmojiStore.Get", "store.sql_emoji.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusInternalServerError)
+			}
 		} else {
 			result.Data = emoji
 
@@ -175,6 +182,35 @@ func (es SqlEmojiStore) GetAll() StoreChannel {
 	return storeChannel
 }
 
+func (es SqlEmojiStore) GetList(offset, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var emoji []*model.Emoji
+
+		if _, err := es.GetReplica().Select(&emoji,
+			`SELECT
+				*
+			FROM
+				Emoji
+			WHERE
+				DeleteAt = 0
+			ORDER BY Name
+			LIMIT :Limit OFFSET :Offset`, map[string]interface{}{"Offset": offset, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlEmojiStore.GetList", "store.sql_emoji.get_all.app_error", nil, err.Error())
+		} else {
+			result.Data = emoji
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (es SqlEmojiStore) Delete(id string, time int64) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
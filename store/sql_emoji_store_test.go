@@ -172,3 +172,56 @@ func TestEmojiGetAll(t *testing.T) {
 		}
 	}
 }
+
+func TestEmojiGetList(t *testing.T) {
+	Setup()
+
+	emojis := []model.Emoji{
+		{
+			CreatorId: model.NewId(),
+			Name:      model.NewId(),
+		},
+		{
+			CreatorId: model.NewId(),
+			Name:      model.NewId(),
+		},
+		{
+			CreatorId: model.NewId(),
+			Name:      model.NewId(),
+		},
+	}
+
+	for i, emoji := range emojis {
+		emojis[i] = *Must(store.Emoji().Save(&emoji)).(*model.Emoji)
+	}
+	defer func() {
+		for _, emoji := range emojis {
+			Must(store.Emoji().Delete(emoji.Id, time.Now().Unix()))
+		}
+	}()
+
+	if result := <-store.Emoji().GetList(0, 100); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		for _, emoji := range emojis {
+			found := false
+
+			for _, savedEmoji := range result.Data.([]*model.Emoji) {
+				if emoji.Id == savedEmoji.Id {
+					found = true
+					break
+				}
+			}
+
+			if !found {
+				t.Fatalf("failed to get emoji with id %v", emoji.Id)
+			}
+		}
+	}
+
+	if result := <-store.Emoji().GetList(0, 1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if len(result.Data.([]*model.Emoji)) != 1 {
+		t.Fatal("should have only returned one emoji")
+	}
+}
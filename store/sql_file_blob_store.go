@@ -0,0 +1,138 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlFileBlobStore struct {
+	*SqlStore
+}
+
+func NewSqlFileBlobStore(sqlStore *SqlStore) FileBlobStore {
+	s := &SqlFileBlobStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.FileBlob{}, "FileBlobs").SetKeys(false, "Path")
+		table.ColMap("Path").SetMaxSize(512)
+	}
+
+	return s
+}
+
+func (s SqlFileBlobStore) CreateIndexesIfNotExists() {
+}
+
+// Acquire adds a reference to the data stored at a path. It returns true if the data has already been stored,
+// otherwise the caller must store it. A blob that's being removed can't be acquired until it's gone unless its
+// removal started before staleBefore, in which case it's assumed to have been abandoned.
+func (s SqlFileBlobStore) Acquire(path string, staleBefore int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		now := model.GetMillis()
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				FileBlobs
+			SET
+				RefCount = RefCount + 1, UpdateAt = :UpdateAt
+			WHERE
+				Path = :Path
+				AND RefCount >= 0`, map[string]interface{}{"Path": path, "UpdateAt": now}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileBlobStore.Acquire", "store.sql_file_blob.acquire.app_error", nil, "path="+path+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 1 {
+			result.Data = true
+		} else if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				FileBlobs
+			SET
+				RefCount = 1, UpdateAt = :UpdateAt
+			WHERE
+				Path = :Path
+				AND RefCount < 0
+				AND UpdateAt < :StaleBefore`, map[string]interface{}{"Path": path, "UpdateAt": now, "StaleBefore": staleBefore}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileBlobStore.Acquire", "store.sql_file_blob.acquire.app_error", nil, "path="+path+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 1 {
+			result.Data = false
+		} else if err := s.GetMaster().Insert(&model.FileBlob{Path: path, RefCount: 1, UpdateAt: now}); err != nil {
+			if IsUniqueConstraintError(err.Error(), []string{"PRIMARY", "fileblobs_pkey"}) {
+				result.Err = model.NewAppError("SqlFileBlobStore.Acquire", "store.sql_file_blob.acquire.removing.app_error", nil, "path="+path, http.StatusConflict)
+			} else {
+				result.Err = model.NewLocAppError("SqlFileBlobStore.Acquire", "store.sql_file_blob.acquire.app_error", nil, "path="+path+", "+err.Error())
+			}
+		} else {
+			result.Data = false
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Release removes a reference to the data stored at a path. It returns true if that was the last reference, in
+// which case the caller must remove the data and then call Delete. Only one caller is told to remove it, even
+// when the last references are released by different servers at the same time.
+func (s SqlFileBlobStore) Release(path string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		now := model.GetMillis()
+
+		if _, err := s.GetMaster().Exec(
+			`UPDATE
+				FileBlobs
+			SET
+				RefCount = RefCount - 1, UpdateAt = :UpdateAt
+			WHERE
+				Path = :Path
+				AND RefCount > 0`, map[string]interface{}{"Path": path, "UpdateAt": now}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileBlobStore.Release", "store.sql_file_blob.release.app_error", nil, "path="+path+", "+err.Error())
+		} else if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				FileBlobs
+			SET
+				RefCount = -1, UpdateAt = :UpdateAt
+			WHERE
+				Path = :Path
+				AND RefCount = 0`, map[string]interface{}{"Path": path, "UpdateAt": now}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileBlobStore.Release", "store.sql_file_blob.release.app_error", nil, "path="+path+", "+err.Error())
+		} else {
+			rows, _ := sqlResult.RowsAffected()
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Delete forgets about a blob once its data has been removed so that the same data can be stored again.
+func (s SqlFileBlobStore) Delete(path string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM FileBlobs WHERE Path = :Path AND RefCount = -1", map[string]interface{}{"Path": path}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileBlobStore.Delete", "store.sql_file_blob.delete.app_error", nil, "path="+path+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
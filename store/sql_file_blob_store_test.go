@@ -0,0 +1,52 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestFileBlobStore(t *testing.T) {
+	Setup()
+
+	path := "blobs/" + model.NewId()
+
+	if stored := Must(store.FileBlob().Acquire(path, 0)).(bool); stored {
+		t.Fatal("new blob shouldn't have been stored yet")
+	}
+
+	if stored := Must(store.FileBlob().Acquire(path, 0)).(bool); !stored {
+		t.Fatal("existing blob should've been stored")
+	}
+
+	if last := Must(store.FileBlob().Release(path)).(bool); last {
+		t.Fatal("blob is still referenced")
+	}
+
+	if last := Must(store.FileBlob().Release(path)).(bool); !last {
+		t.Fatal("should've released the last reference")
+	}
+
+	if last := Must(store.FileBlob().Release(path)).(bool); last {
+		t.Fatal("shouldn't remove a blob twice")
+	}
+
+	if result := <-store.FileBlob().Acquire(path, 0); result.Err == nil || result.Err.StatusCode != http.StatusConflict {
+		t.Fatal("shouldn't acquire a blob that's being removed")
+	}
+
+	if stored := Must(store.FileBlob().Acquire(path, model.GetMillis()+1)).(bool); stored {
+		t.Fatal("should've taken over an abandoned removal")
+	}
+
+	Must(store.FileBlob().Release(path))
+	Must(store.FileBlob().Delete(path))
+
+	if stored := Must(store.FileBlob().Acquire(path, 0)).(bool); stored {
+		t.Fatal("deleted blob shouldn't have been stored")
+	}
+}
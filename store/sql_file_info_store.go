@@ -5,7 +5,11 @@ package store
 import (
 	"database/sql"
 	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
 
+	l4g "github.com/alecthomas/log4go"
 	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
@@ -33,13 +37,20 @@ func NewSqlFileInfoStore(sqlStore *SqlStore) FileInfoStore {
 		table := db.AddTableWithName(model.FileInfo{}, "FileInfo").SetKeys(false, "Id")
 		table.ColMap("Id").SetMaxSize(26)
 		table.ColMap("CreatorId").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
 		table.ColMap("PostId").SetMaxSize(26)
 		table.ColMap("Path").SetMaxSize(512)
 		table.ColMap("ThumbnailPath").SetMaxSize(512)
 		table.ColMap("PreviewPath").SetMaxSize(512)
+		table.ColMap("ContentHash").SetMaxSize(64)
 		table.ColMap("Name").SetMaxSize(256)
 		table.ColMap("Extension").SetMaxSize(64)
 		table.ColMap("MimeType").SetMaxSize(256)
+		table.ColMap("ScanStatus").SetMaxSize(32)
+
+		tableContent := db.AddTableWithName(model.FileInfoContent{}, "FileInfoContents").SetKeys(false, "FileId")
+		tableContent.ColMap("FileId").SetMaxSize(26)
+		tableContent.ColMap("Content").SetMaxSize(model.FILE_INFO_CONTENT_MAX_SIZE)
 	}
 
 	return s
@@ -50,6 +61,13 @@ func (fs SqlFileInfoStore) CreateIndexesIfNotExists() {
 	fs.CreateIndexIfNotExists("idx_fileinfo_create_at", "FileInfo", "CreateAt")
 	fs.CreateIndexIfNotExists("idx_fileinfo_delete_at", "FileInfo", "DeleteAt")
 	fs.CreateIndexIfNotExists("idx_fileinfo_postid_at", "FileInfo", "PostId")
+	fs.CreateIndexIfNotExists("idx_fileinfo_name", "FileInfo", "Name")
+	fs.CreateIndexIfNotExists("idx_fileinfo_scan_status", "FileInfo", "ScanStatus")
+	fs.CreateIndexIfNotExists("idx_fileinfo_creator_id", "FileInfo", "CreatorId")
+	fs.CreateIndexIfNotExists("idx_fileinfo_team_id", "FileInfo", "TeamId")
+	fs.CreateIndexIfNotExists("idx_fileinfo_content_hash", "FileInfo", "ContentHash")
+
+	fs.CreateFullTextIndexIfNotExists("idx_fileinfocontents_content_txt", "FileInfoContents", "Content")
 }
 
 func (fs SqlFileInfoStore) Save(info *model.FileInfo) StoreChannel {
@@ -139,6 +157,345 @@ func (fs SqlFileInfoStore) GetByPath(path string) StoreChannel {
 	return storeChannel
 }
 
+// CountByPath returns the number of files that are stored at a path, including deleted ones since their data
+// may still be needed. Before files had ids, the same file could be attached to multiple posts.
+func (fs SqlFileInfoStore) CountByPath(path string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if count, err := fs.GetMaster().SelectInt(
+			`SELECT
+				COUNT(*)
+			FROM
+				FileInfo
+			WHERE
+				Path = :Path`, map[string]interface{}{"Path": path}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.CountByPath",
+				"store.sql_file_info.count_by_path.app_error", nil, "path="+path+", err="+err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetWithoutContentHash returns files that haven't been moved to shared storage, including deleted ones, ordered
+// by id and starting after the given id so that files that can't be moved are skipped.
+func (fs SqlFileInfoStore) GetWithoutContentHash(afterId string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var infos []*model.FileInfo
+
+		if _, err := fs.GetReplica().Select(&infos,
+			`SELECT
+				*
+			FROM
+				FileInfo
+			WHERE
+				ContentHash = ''
+				AND Id > :AfterId
+			ORDER BY
+				Id
+			LIMIT :Limit`, map[string]interface{}{"AfterId": afterId, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetWithoutContentHash",
+				"store.sql_file_info.get_without_content_hash.app_error", nil, err.Error())
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateContentHash saves the hash of a file along with the path that it's now stored at.
+func (fs SqlFileInfoStore) UpdateContentHash(info *model.FileInfo) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		info.UpdateAt = model.GetMillis()
+
+		if _, err := fs.GetMaster().Exec(
+			`UPDATE
+				FileInfo
+			SET
+				ContentHash = :ContentHash,
+				Path = :Path,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id`, map[string]interface{}{
+				"ContentHash": info.ContentHash,
+				"Path":        info.Path,
+				"UpdateAt":    info.UpdateAt,
+				"Id":          info.Id,
+			}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.UpdateContentHash",
+				"store.sql_file_info.update_content_hash.app_error", nil, "file_id="+info.Id+", err="+err.Error())
+		} else {
+			result.Data = info
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// MarkBlobReleased records that a deleted file has given up its reference to its shared data. It returns true
+// if the file held a reference that hadn't been released yet, in which case the caller must release it.
+func (fs SqlFileInfoStore) MarkBlobReleased(fileId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := fs.GetMaster().Exec(
+			`UPDATE
+				FileInfo
+			SET
+				BlobReleaseAt = :BlobReleaseAt
+			WHERE
+				Id = :Id
+				AND ContentHash != ''
+				AND BlobReleaseAt = 0`, map[string]interface{}{"BlobReleaseAt": model.GetMillis(), "Id": fileId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.MarkBlobReleased",
+				"store.sql_file_info.mark_blob_released.app_error", nil, "file_id="+fileId+", err="+err.Error())
+		} else if rows, err := sqlResult.RowsAffected(); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.MarkBlobReleased",
+				"store.sql_file_info.mark_blob_released.app_error", nil, "file_id="+fileId+", err="+err.Error())
+		} else {
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetByScanStatus returns the oldest files with the given scan status.
+func (fs SqlFileInfoStore) GetByScanStatus(status string, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var infos []*model.FileInfo
+
+		if _, err := fs.GetReplica().Select(&infos,
+			`SELECT
+				*
+			FROM
+				FileInfo
+			WHERE
+				ScanStatus = :ScanStatus
+				AND DeleteAt = 0
+			ORDER BY
+				CreateAt
+			LIMIT :Limit`, map[string]interface{}{"ScanStatus": status, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetByScanStatus",
+				"store.sql_file_info.get_by_scan_status.app_error", nil, "scan_status="+status+", err="+err.Error())
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdateScanStatus saves the scan status of a file and the number of times it's been scanned along with its paths,
+// which change if the file is quarantined.
+func (fs SqlFileInfoStore) UpdateScanStatus(info *model.FileInfo) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		info.UpdateAt = model.GetMillis()
+
+		if _, err := fs.GetMaster().Exec(
+			`UPDATE
+				FileInfo
+			SET
+				ScanStatus = :ScanStatus,
+				ScanAttempts = :ScanAttempts,
+				Path = :Path,
+				ThumbnailPath = :ThumbnailPath,
+				PreviewPath = :PreviewPath,
+				HasPreviewImage = :HasPreviewImage,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id`, map[string]interface{}{
+				"ScanStatus":      info.ScanStatus,
+				"ScanAttempts":    info.ScanAttempts,
+				"Path":            info.Path,
+				"ThumbnailPath":   info.ThumbnailPath,
+				"PreviewPath":     info.PreviewPath,
+				"HasPreviewImage": info.HasPreviewImage,
+				"UpdateAt":        info.UpdateAt,
+				"Id":              info.Id,
+			}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.UpdateScanStatus",
+				"store.sql_file_info.update_scan_status.app_error", nil, "file_id="+info.Id+", err="+err.Error())
+		} else {
+			result.Data = info
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// UpdatePreview saves the paths of a file's previews once they've been generated. Quarantined files are left
+// unchanged since they can't have previews.
+func (fs SqlFileInfoStore) UpdatePreview(info *model.FileInfo) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		info.UpdateAt = model.GetMillis()
+
+		if _, err := fs.GetMaster().Exec(
+			`UPDATE
+				FileInfo
+			SET
+				ThumbnailPath = :ThumbnailPath,
+				PreviewPath = :PreviewPath,
+				HasPreviewImage = :HasPreviewImage,
+				UpdateAt = :UpdateAt
+			WHERE
+				Id = :Id
+				AND ScanStatus != :Quarantined`, map[string]interface{}{
+				"ThumbnailPath":   info.ThumbnailPath,
+				"PreviewPath":     info.PreviewPath,
+				"HasPreviewImage": info.HasPreviewImage,
+				"UpdateAt":        info.UpdateAt,
+				"Id":              info.Id,
+				"Quarantined":     model.FILE_SCAN_STATUS_QUARANTINED,
+			}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.UpdatePreview",
+				"store.sql_file_info.update_preview.app_error", nil, "file_id="+info.Id+", err="+err.Error())
+		} else {
+			result.Data = info
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetStorageUsageForTeam returns the total size of the files uploaded to a team that haven't been deleted.
+func (fs SqlFileInfoStore) GetStorageUsageForTeam(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if usage, err := fs.GetReplica().SelectInt(
+			`SELECT
+				COALESCE(SUM(Size), 0)
+			FROM
+				FileInfo
+			WHERE
+				TeamId = :TeamId
+				AND DeleteAt = 0`, map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetStorageUsageForTeam",
+				"store.sql_file_info.get_storage_usage.app_error", nil, "team_id="+teamId+", err="+err.Error())
+		} else {
+			result.Data = usage
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetStorageUsageForUser returns the total size of the files uploaded by a user that haven't been deleted.
+func (fs SqlFileInfoStore) GetStorageUsageForUser(userId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if usage, err := fs.GetReplica().SelectInt(
+			`SELECT
+				COALESCE(SUM(Size), 0)
+			FROM
+				FileInfo
+			WHERE
+				CreatorId = :CreatorId
+				AND DeleteAt = 0`, map[string]interface{}{"CreatorId": userId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.GetStorageUsageForUser",
+				"store.sql_file_info.get_storage_usage.app_error", nil, "user_id="+userId+", err="+err.Error())
+		} else {
+			result.Data = usage
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// AnalyticsStorageUsageByTeam returns the teams using the most storage as rows of team ids and sizes.
+func (fs SqlFileInfoStore) AnalyticsStorageUsageByTeam() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var rows model.AnalyticsRows
+		if _, err := fs.GetReplica().Select(&rows,
+			`SELECT
+				TeamId AS Name,
+				SUM(Size) AS Value
+			FROM
+				FileInfo
+			WHERE
+				TeamId != ''
+				AND DeleteAt = 0
+			GROUP BY TeamId
+			ORDER BY Value DESC
+			LIMIT 50`); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.AnalyticsStorageUsageByTeam",
+				"store.sql_file_info.analytics_storage_usage_by_team.app_error", nil, err.Error())
+		} else {
+			result.Data = rows
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlFileInfoStore) InvalidateFileInfosForPostCache(postId string) {
 	fileInfoCache.Remove(postId)
 }
@@ -257,3 +614,235 @@ func (fs SqlFileInfoStore) DeleteForPost(postId string) StoreChannel {
 
 	return storeChannel
 }
+
+// PermanentDelete removes a file's info along with the text extracted from it.
+func (fs SqlFileInfoStore) PermanentDelete(fileId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := fs.GetMaster().Exec("DELETE FROM FileInfo WHERE Id = :FileId", map[string]interface{}{"FileId": fileId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.PermanentDelete", "store.sql_file_info.permanent_delete.app_error", nil, "file_id="+fileId+", err="+err.Error())
+		} else if _, err := fs.GetMaster().Exec("DELETE FROM FileInfoContents WHERE FileId = :FileId", map[string]interface{}{"FileId": fileId}); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.PermanentDelete", "store.sql_file_info.permanent_delete.app_error", nil, "file_id="+fileId+", err="+err.Error())
+		} else {
+			result.Data = fileId
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (fs SqlFileInfoStore) SaveContent(content *model.FileInfoContent) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if result.Err = content.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := fs.GetMaster().Update(content); err != nil {
+			result.Err = model.NewLocAppError("SqlFileInfoStore.SaveContent", "store.sql_file_info.save_content.app_error", nil, "file_id="+content.FileId+", "+err.Error())
+		} else if count == 0 {
+			if err := fs.GetMaster().Insert(content); err != nil {
+				result.Err = model.NewLocAppError("SqlFileInfoStore.SaveContent", "store.sql_file_info.save_content.app_error", nil, "file_id="+content.FileId+", "+err.Error())
+			}
+		}
+
+		if result.Err == nil {
+			result.Data = content
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Search returns the files attached to posts in channels that the user belongs to that match the search.
+// Terms are matched against the text extracted from the files.
+func (fs SqlFileInfoStore) Search(teamId string, userId string, params *model.FileSearch) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if params.IsEmpty() {
+			result.Data = []*model.FileInfo{}
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		queryParams := map[string]interface{}{
+			"TeamId": teamId,
+			"UserId": userId,
+		}
+
+		inClause := func(prefix string, values []string) string {
+			names := []string{}
+			for i, value := range values {
+				paramName := prefix + strconv.Itoa(i)
+				names = append(names, ":"+paramName)
+				queryParams[paramName] = value
+			}
+
+			return strings.Join(names, ", ")
+		}
+
+		searchQuery := `
+			SELECT
+				FileInfo.*
+			FROM
+				FileInfo,
+				Posts
+			WHERE
+				FileInfo.PostId = Posts.Id
+				AND FileInfo.DeleteAt = 0
+				AND Posts.DeleteAt = 0
+				AND Posts.ChannelId IN (
+					SELECT
+						Id
+					FROM
+						Channels,
+						ChannelMembers
+					WHERE
+						Id = ChannelId
+							AND (TeamId = :TeamId OR TeamId = '')
+							AND UserId = :UserId
+							AND DeleteAt = 0
+							CHANNEL_FILTER)
+				FILE_FILTER
+				SEARCH_CLAUSE
+			ORDER BY FileInfo.CreateAt DESC
+			LIMIT 100`
+
+		if len(params.InChannels) > 0 {
+			searchQuery = strings.Replace(searchQuery, "CHANNEL_FILTER", "AND Name IN ("+inClause("InChannel", params.InChannels)+")", 1)
+		} else {
+			searchQuery = strings.Replace(searchQuery, "CHANNEL_FILTER", "", 1)
+		}
+
+		fileFilter := ""
+
+		if len(params.FromUsers) > 0 {
+			fileFilter += `
+				AND FileInfo.CreatorId IN (
+					SELECT
+						Id
+					FROM
+						Users,
+						TeamMembers
+					WHERE
+						TeamMembers.TeamId = :TeamId
+						AND Users.Id = TeamMembers.UserId
+						AND Username IN (` + inClause("FromUser", params.FromUsers) + `))`
+		}
+
+		if len(params.Extensions) > 0 {
+			extensions := make([]string, len(params.Extensions))
+			for i, extension := range params.Extensions {
+				extensions[i] = strings.ToLower(strings.TrimPrefix(extension, "."))
+			}
+
+			fileFilter += " AND FileInfo.Extension IN (" + inClause("Extension", extensions) + ")"
+		}
+
+		if params.Name != "" {
+			fileFilter += " AND LOWER(FileInfo.Name) LIKE :Name"
+			queryParams["Name"] = "%" + escapeLikeSearchTerm(strings.ToLower(params.Name)) + "%"
+		}
+
+		if params.After > 0 {
+			fileFilter += " AND FileInfo.CreateAt > :After"
+			queryParams["After"] = params.After
+		}
+
+		if params.Before > 0 {
+			fileFilter += " AND FileInfo.CreateAt < :Before"
+			queryParams["Before"] = params.Before
+		}
+
+		searchQuery = strings.Replace(searchQuery, "FILE_FILTER", fileFilter, 1)
+
+		terms := params.Terms
+
+		// these chars have special meaning and can be treated as spaces
+		for _, c := range specialSearchChar {
+			terms = strings.Replace(terms, c, " ", -1)
+		}
+
+		if strings.TrimSpace(terms) == "" {
+			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", "", 1)
+		} else {
+			searchClause := `
+				AND FileInfo.Id IN (
+					SELECT
+						FileId
+					FROM
+						FileInfoContents
+					WHERE
+						CONTENT_CLAUSE)`
+
+			if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_POSTGRES {
+				// Parse text for wildcards
+				if wildcard, err := regexp.Compile("\\*($| )"); err == nil {
+					terms = wildcard.ReplaceAllLiteralString(terms, ":* ")
+				}
+
+				if params.IsOrSearch {
+					terms = strings.Join(strings.Fields(terms), " | ")
+				} else {
+					terms = strings.Join(strings.Fields(terms), " & ")
+				}
+
+				searchClause = strings.Replace(searchClause, "CONTENT_CLAUSE", "Content @@ to_tsquery(:Terms)", 1)
+			} else if utils.Cfg.SqlSettings.DriverName == model.DATABASE_DRIVER_MYSQL {
+				if !params.IsOrSearch {
+					splitTerms := strings.Fields(terms)
+					for i, t := range splitTerms {
+						splitTerms[i] = "+" + t
+					}
+
+					terms = strings.Join(splitTerms, " ")
+				}
+
+				searchClause = strings.Replace(searchClause, "CONTENT_CLAUSE", "MATCH (Content) AGAINST (:Terms IN BOOLEAN MODE)", 1)
+			}
+
+			searchQuery = strings.Replace(searchQuery, "SEARCH_CLAUSE", searchClause, 1)
+			queryParams["Terms"] = terms
+		}
+
+		var infos []*model.FileInfo
+		if _, err := fs.GetReplica().Select(&infos, searchQuery, queryParams); err != nil {
+			l4g.Warn(utils.T("store.sql_file_info.search.warn"), err.Error())
+			// Don't return the error to the caller as it is of no use to the user. Instead return an empty set of search results.
+			infos = []*model.FileInfo{}
+		}
+
+		result.Data = infos
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func escapeLikeSearchTerm(term string) string {
+	for _, c := range []string{"\\", "%", "_"} {
+		term = strings.Replace(term, c, "\\"+c, -1)
+	}
+
+	return term
+}
@@ -218,3 +218,308 @@ func TestFileInfoDeleteForPost(t *testing.T) {
 		t.Fatal("shouldn't have returned any file infos")
 	}
 }
+
+func TestFileInfoPermanentDelete(t *testing.T) {
+	Setup()
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		Path:      "file.txt",
+	})).(*model.FileInfo)
+
+	Must(store.FileInfo().SaveContent(&model.FileInfoContent{FileId: info.Id, Content: "some text"}))
+
+	if result := <-store.FileInfo().PermanentDelete(info.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.FileInfo().Get(info.Id); result.Err == nil {
+		t.Fatal("should have deleted the file info")
+	}
+
+	if count, err := store.(*SqlStore).GetMaster().SelectInt("SELECT COUNT(*) FROM FileInfoContents WHERE FileId = :FileId", map[string]interface{}{"FileId": info.Id}); err != nil {
+		t.Fatal(err)
+	} else if count != 0 {
+		t.Fatal("should have deleted the file's content")
+	}
+}
+
+func TestFileInfoScanStatus(t *testing.T) {
+	Setup()
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId:  model.NewId(),
+		Path:       "file.txt",
+		ScanStatus: model.FILE_SCAN_STATUS_PENDING,
+	})).(*model.FileInfo)
+
+	containsInfo := func(infos []*model.FileInfo) bool {
+		for _, other := range infos {
+			if other.Id == info.Id {
+				return true
+			}
+		}
+		return false
+	}
+
+	if infos := Must(store.FileInfo().GetByScanStatus(model.FILE_SCAN_STATUS_PENDING, 1000)).([]*model.FileInfo); !containsInfo(infos) {
+		t.Fatal("should've returned pending file")
+	}
+
+	info.ScanStatus = model.FILE_SCAN_STATUS_QUARANTINED
+	info.Path = "quarantine/file.txt"
+	if result := <-store.FileInfo().UpdateScanStatus(info); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if infos := Must(store.FileInfo().GetByScanStatus(model.FILE_SCAN_STATUS_PENDING, 1000)).([]*model.FileInfo); containsInfo(infos) {
+		t.Fatal("shouldn't have returned scanned file")
+	}
+
+	if updated := Must(store.FileInfo().Get(info.Id)).(*model.FileInfo); updated.ScanStatus != model.FILE_SCAN_STATUS_QUARANTINED {
+		t.Fatal("should've updated scan status")
+	} else if updated.Path != "quarantine/file.txt" {
+		t.Fatal("should've updated path")
+	}
+}
+
+func TestFileInfoUpdatePreview(t *testing.T) {
+	Setup()
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		Path:      "file.pdf",
+	})).(*model.FileInfo)
+
+	info.ThumbnailPath = "file_thumb.jpg"
+	info.PreviewPath = "file_preview.jpg"
+	info.HasPreviewImage = true
+	if result := <-store.FileInfo().UpdatePreview(info); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if updated := Must(store.FileInfo().Get(info.Id)).(*model.FileInfo); updated.PreviewPath != info.PreviewPath || updated.ThumbnailPath != info.ThumbnailPath || !updated.HasPreviewImage {
+		t.Fatal("should've saved the preview", updated)
+	}
+
+	quarantined := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId:  model.NewId(),
+		Path:       "quarantine/file.pdf",
+		ScanStatus: model.FILE_SCAN_STATUS_QUARANTINED,
+	})).(*model.FileInfo)
+
+	quarantined.PreviewPath = "file_preview.jpg"
+	Must(store.FileInfo().UpdatePreview(quarantined))
+
+	if updated := Must(store.FileInfo().Get(quarantined.Id)).(*model.FileInfo); updated.PreviewPath != "" {
+		t.Fatal("shouldn't have saved a preview for a quarantined file")
+	}
+}
+
+func TestFileInfoStorageUsage(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	userId := model.NewId()
+
+	infos := []*model.FileInfo{
+		{
+			CreatorId: userId,
+			TeamId:    teamId,
+			Path:      "file.txt",
+			Size:      100,
+		},
+		{
+			CreatorId: model.NewId(),
+			TeamId:    teamId,
+			Path:      "file.txt",
+			Size:      200,
+		},
+		{
+			CreatorId: userId,
+			Path:      "file.txt",
+			Size:      400,
+		},
+		{
+			CreatorId: userId,
+			TeamId:    teamId,
+			Path:      "file.txt",
+			Size:      800,
+			DeleteAt:  123,
+		},
+	}
+
+	for _, info := range infos {
+		Must(store.FileInfo().Save(info))
+	}
+
+	if usage := Must(store.FileInfo().GetStorageUsageForTeam(teamId)).(int64); usage != 300 {
+		t.Fatal("wrong team usage", usage)
+	}
+
+	if usage := Must(store.FileInfo().GetStorageUsageForUser(userId)).(int64); usage != 500 {
+		t.Fatal("wrong user usage", usage)
+	}
+
+	if usage := Must(store.FileInfo().GetStorageUsageForTeam(model.NewId())).(int64); usage != 0 {
+		t.Fatal("team without files should have no usage", usage)
+	}
+
+	found := false
+	for _, row := range Must(store.FileInfo().AnalyticsStorageUsageByTeam()).(model.AnalyticsRows) {
+		if row.Name == teamId {
+			found = true
+			if row.Value != 300 {
+				t.Fatal("wrong team usage in analytics", row.Value)
+			}
+		}
+	}
+
+	if !found {
+		t.Fatal("should've returned team usage in analytics")
+	}
+}
+
+func TestFileInfoContentHash(t *testing.T) {
+	Setup()
+
+	path := "blobs/" + model.NewId()
+
+	info1 := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		Path:      "file.txt",
+	})).(*model.FileInfo)
+
+	info2 := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId:   model.NewId(),
+		Path:        path,
+		ContentHash: "hash",
+	})).(*model.FileInfo)
+
+	Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId:   model.NewId(),
+		Path:        path,
+		ContentHash: "hash",
+		DeleteAt:    123,
+	}))
+
+	deleted := Must(store.FileInfo().Save(&model.FileInfo{
+		CreatorId: model.NewId(),
+		Path:      "deleted.txt",
+		DeleteAt:  123,
+	})).(*model.FileInfo)
+
+	if count := Must(store.FileInfo().CountByPath(path)).(int64); count != 2 {
+		t.Fatal("should've counted deleted files too", count)
+	}
+
+	found := false
+	foundDeleted := false
+	for _, info := range Must(store.FileInfo().GetWithoutContentHash("", 10000)).([]*model.FileInfo) {
+		if info.Id == info2.Id {
+			t.Fatal("shouldn't have returned file with a content hash")
+		} else if info.Id == info1.Id {
+			found = true
+		} else if info.Id == deleted.Id {
+			foundDeleted = true
+		}
+	}
+
+	if !found || !foundDeleted {
+		t.Fatal("should've returned files without a content hash")
+	}
+
+	info1.ContentHash = "hash"
+	info1.Path = path
+	if result := <-store.FileInfo().UpdateContentHash(info1); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if count := Must(store.FileInfo().CountByPath(path)).(int64); count != 3 {
+		t.Fatal("should've counted updated file", count)
+	}
+
+	if infos := Must(store.FileInfo().GetWithoutContentHash(info1.Id, 10000)).([]*model.FileInfo); len(infos) > 0 && infos[0].Id <= info1.Id {
+		t.Fatal("should've only returned files after the given id")
+	}
+
+	if released := Must(store.FileInfo().MarkBlobReleased(info2.Id)).(bool); !released {
+		t.Fatal("should've released file's blob")
+	}
+
+	if released := Must(store.FileInfo().MarkBlobReleased(info2.Id)).(bool); released {
+		t.Fatal("shouldn't release file's blob twice")
+	}
+
+	if released := Must(store.FileInfo().MarkBlobReleased(deleted.Id)).(bool); released {
+		t.Fatal("shouldn't release blob of file without a content hash")
+	}
+}
+
+func TestFileInfoSearch(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+	userId := model.NewId()
+
+	c1 := &model.Channel{TeamId: teamId, DisplayName: "Channel1", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	c1 = Must(store.Channel().Save(c1)).(*model.Channel)
+	Must(store.Channel().SaveMember(&model.ChannelMember{ChannelId: c1.Id, UserId: userId, NotifyProps: model.GetDefaultChannelNotifyProps()}))
+
+	c2 := &model.Channel{TeamId: teamId, DisplayName: "Channel2", Name: "a" + model.NewId() + "b", Type: model.CHANNEL_OPEN}
+	c2 = Must(store.Channel().Save(c2)).(*model.Channel)
+
+	p1 := Must(store.Post().Save(&model.Post{ChannelId: c1.Id, UserId: userId})).(*model.Post)
+	p2 := Must(store.Post().Save(&model.Post{ChannelId: c2.Id, UserId: userId})).(*model.Post)
+
+	f1 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, PostId: p1.Id, Path: "report.pdf", Name: "Q3 Report.pdf", Extension: "pdf"})).(*model.FileInfo)
+	Must(store.FileInfo().SaveContent(&model.FileInfoContent{FileId: f1.Id, Content: "quarterly revenue numbers"}))
+
+	f2 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, PostId: p1.Id, Path: "notes.txt", Name: "notes.txt", Extension: "txt"})).(*model.FileInfo)
+	Must(store.FileInfo().SaveContent(&model.FileInfoContent{FileId: f2.Id, Content: "meeting notes"}))
+
+	// Not visible to the user since they aren't a member of the channel
+	f3 := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, PostId: p2.Id, Path: "secret.pdf", Name: "secret.pdf", Extension: "pdf"})).(*model.FileInfo)
+	Must(store.FileInfo().SaveContent(&model.FileInfoContent{FileId: f3.Id, Content: "quarterly revenue numbers"}))
+
+	search := func(params *model.FileSearch) []*model.FileInfo {
+		return Must(store.FileInfo().Search(teamId, userId, params)).([]*model.FileInfo)
+	}
+
+	if infos := search(&model.FileSearch{Terms: "revenue"}); len(infos) != 1 || infos[0].Id != f1.Id {
+		t.Fatal("should have found the file by its content", infos)
+	}
+
+	if infos := search(&model.FileSearch{Terms: "revenue meeting"}); len(infos) != 0 {
+		t.Fatal("shouldn't have found a file with both terms", infos)
+	}
+
+	if infos := search(&model.FileSearch{Terms: "revenue meeting", IsOrSearch: true}); len(infos) != 2 {
+		t.Fatal("should have found a file with either term", infos)
+	}
+
+	if infos := search(&model.FileSearch{Name: "q3 rep"}); len(infos) != 1 || infos[0].Id != f1.Id {
+		t.Fatal("should have found the file by its name", infos)
+	}
+
+	if infos := search(&model.FileSearch{Extensions: []string{".TXT"}}); len(infos) != 1 || infos[0].Id != f2.Id {
+		t.Fatal("should have found the file by its extension", infos)
+	}
+
+	if infos := search(&model.FileSearch{Extensions: []string{"pdf"}, InChannels: []string{c2.Name}}); len(infos) != 0 {
+		t.Fatal("shouldn't have found files in a channel the user isn't a member of", infos)
+	}
+
+	if infos := search(&model.FileSearch{Extensions: []string{"pdf"}, After: f1.CreateAt}); len(infos) != 0 {
+		t.Fatal("should have filtered by date", infos)
+	}
+
+	if infos := search(&model.FileSearch{}); len(infos) != 0 {
+		t.Fatal("shouldn't have returned every file", infos)
+	}
+
+	Must(store.FileInfo().DeleteForPost(p1.Id))
+	if infos := search(&model.FileSearch{Terms: "revenue"}); len(infos) != 0 {
+		t.Fatal("shouldn't have found deleted files", infos)
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlLegalHoldStore struct {
+	*SqlStore
+}
+
+func NewSqlLegalHoldStore(sqlStore *SqlStore) LegalHoldStore {
+	s := &SqlLegalHoldStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.LegalHold{}, "LegalHolds").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("CreatorId").SetMaxSize(26)
+		table.ColMap("Name").SetMaxSize(model.LEGAL_HOLD_NAME_MAX_LENGTH)
+		table.ColMap("Description").SetMaxSize(model.LEGAL_HOLD_DESCRIPTION_MAX_LENGTH)
+		table.ColMap("UserIds").SetMaxSize(8000)
+		table.ColMap("ChannelIds").SetMaxSize(8000)
+	}
+
+	return s
+}
+
+func (s SqlLegalHoldStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_legal_holds_release_at", "LegalHolds", "ReleaseAt")
+}
+
+func (s SqlLegalHoldStore) Save(hold *model.LegalHold) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		hold.PreSave()
+		if result.Err = hold.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(hold); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.Save", "store.sql_legal_hold.save.app_error", nil, "id="+hold.Id+", "+err.Error())
+		} else {
+			result.Data = hold
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLegalHoldStore) Update(hold *model.LegalHold) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		hold.PreUpdate()
+		if result.Err = hold.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Update(hold); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.Update", "store.sql_legal_hold.update.app_error", nil, "id="+hold.Id+", "+err.Error())
+		} else {
+			result.Data = hold
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLegalHoldStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if obj, err := s.GetReplica().Get(model.LegalHold{}, id); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.Get", "store.sql_legal_hold.get.app_error", nil, "id="+id+", "+err.Error())
+		} else if obj == nil {
+			result.Err = model.NewAppError("SqlLegalHoldStore.Get", "store.sql_legal_hold.get.missing.app_error", nil, "id="+id, http.StatusNotFound)
+		} else {
+			result.Data = obj.(*model.LegalHold)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLegalHoldStore) GetAll(offset, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var holds []*model.LegalHold
+		if _, err := s.GetReplica().Select(&holds, "SELECT * FROM LegalHolds ORDER BY CreateAt DESC LIMIT :Limit OFFSET :Offset", map[string]interface{}{"Offset": offset, "Limit": limit}); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.GetAll", "store.sql_legal_hold.get_all.app_error", nil, err.Error())
+		} else {
+			result.Data = holds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetActive returns the holds that haven't been released. It reads from the master since content may be
+// about to be deleted based on the result.
+func (s SqlLegalHoldStore) GetActive() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var holds []*model.LegalHold
+		if _, err := s.GetMaster().Select(&holds, "SELECT * FROM LegalHolds WHERE ReleaseAt = 0", map[string]interface{}{}); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.GetActive", "store.sql_legal_hold.get_all.app_error", nil, err.Error())
+		} else {
+			result.Data = holds
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// HasPosts returns true if any posts covered by the hold were made by the user or in the channel. Either may
+// be empty to check all of the hold's posts.
+func (s SqlLegalHoldStore) HasPosts(hold *model.LegalHold, userId, channelId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{}
+		query := "SELECT COUNT(*) FROM (SELECT Id FROM Posts WHERE " + legalHoldConditions(hold, "UserId", "ChannelId IN (%s)", props)
+
+		if userId != "" {
+			query += " AND UserId = :UserId"
+			props["UserId"] = userId
+		}
+
+		if channelId != "" {
+			query += " AND ChannelId = :ChannelId"
+			props["ChannelId"] = channelId
+		}
+
+		query += " LIMIT 1) AS HeldPosts"
+
+		if count, err := s.GetMaster().SelectInt(query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.HasPosts", "store.sql_legal_hold.get_content.app_error", nil, "id="+hold.Id+", "+err.Error())
+		} else {
+			result.Data = count > 0
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetPosts returns a page of the posts covered by the hold, including ones that have been deleted or edited.
+func (s SqlLegalHoldStore) GetPosts(hold *model.LegalHold, offset, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"Offset": offset, "Limit": limit}
+		query := "SELECT * FROM Posts WHERE " + legalHoldConditions(hold, "UserId", "ChannelId IN (%s)", props) + " ORDER BY CreateAt, Id LIMIT :Limit OFFSET :Offset"
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts, query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.GetPosts", "store.sql_legal_hold.get_content.app_error", nil, "id="+hold.Id+", "+err.Error())
+		} else {
+			result.Data = posts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetFileInfos returns a page of the files covered by the hold, including ones that have been deleted.
+func (s SqlLegalHoldStore) GetFileInfos(hold *model.LegalHold, offset, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"Offset": offset, "Limit": limit}
+		query := "SELECT * FROM FileInfo WHERE " +
+			legalHoldConditions(hold, "CreatorId", "PostId IN (SELECT Id FROM Posts WHERE ChannelId IN (%s))", props) +
+			" ORDER BY CreateAt, Id LIMIT :Limit OFFSET :Offset"
+
+		var infos []*model.FileInfo
+		if _, err := s.GetReplica().Select(&infos, query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.GetFileInfos", "store.sql_legal_hold.get_content.app_error", nil, "id="+hold.Id+", "+err.Error())
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetUnreleasedFileInfos returns deleted files covered by the hold that still hold a reference to their shared
+// data and aren't covered by any of the other holds. Files drop out of the results once their reference has
+// been released, so there's no offset.
+func (s SqlLegalHoldStore) GetUnreleasedFileInfos(hold *model.LegalHold, otherHolds []*model.LegalHold, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"Limit": limit}
+		query := "SELECT * FROM FileInfo WHERE DeleteAt != 0 AND ContentHash != '' AND BlobReleaseAt = 0 AND " +
+			legalHoldConditions(hold, "CreatorId", "PostId IN (SELECT Id FROM Posts WHERE ChannelId IN (%s))", props)
+
+		for i, otherHold := range otherHolds {
+			query += " AND NOT (" + prefixedLegalHoldConditions("OtherHold"+strconv.Itoa(i), otherHold, "CreatorId",
+				"PostId IN (SELECT Id FROM Posts WHERE ChannelId IN (%s))", props) + ")"
+		}
+
+		query += " ORDER BY CreateAt, Id LIMIT :Limit"
+
+		var infos []*model.FileInfo
+		if _, err := s.GetMaster().Select(&infos, query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.GetUnreleasedFileInfos", "store.sql_legal_hold.get_content.app_error", nil, "id="+hold.Id+", "+err.Error())
+		} else {
+			result.Data = infos
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetReactions returns a page of the reactions covered by the hold, including ones that have been removed.
+func (s SqlLegalHoldStore) GetReactions(hold *model.LegalHold, offset, limit int) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		props := map[string]interface{}{"Offset": offset, "Limit": limit}
+		query := "SELECT * FROM Reactions WHERE " +
+			legalHoldConditions(hold, "UserId", "PostId IN (SELECT Id FROM Posts WHERE ChannelId IN (%s))", props) +
+			" ORDER BY CreateAt, PostId, UserId, EmojiName LIMIT :Limit OFFSET :Offset"
+
+		var reactions []*model.Reaction
+		if _, err := s.GetReplica().Select(&reactions, query, props); err != nil {
+			result.Err = model.NewLocAppError("SqlLegalHoldStore.GetReactions", "store.sql_legal_hold.get_content.app_error", nil, "id="+hold.Id+", "+err.Error())
+		} else {
+			result.Data = reactions
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// legalHoldConditions returns the WHERE conditions matching content covered by the hold. The channel condition
+// is formatted with the list of the hold's channel ids.
+func legalHoldConditions(hold *model.LegalHold, userIdColumn, channelCondition string, props map[string]interface{}) string {
+	return prefixedLegalHoldConditions("Hold", hold, userIdColumn, channelCondition, props)
+}
+
+// prefixedLegalHoldConditions is like legalHoldConditions, but names its parameters with the given prefix so
+// that the conditions of multiple holds can be used in one query.
+func prefixedLegalHoldConditions(prefix string, hold *model.LegalHold, userIdColumn, channelCondition string, props map[string]interface{}) string {
+	conditions := []string{}
+
+	if len(hold.UserIds) > 0 {
+		keys := make([]string, len(hold.UserIds))
+		for i, userId := range hold.UserIds {
+			keys[i] = ":" + prefix + "UserId" + strconv.Itoa(i)
+			props[prefix+"UserId"+strconv.Itoa(i)] = userId
+		}
+
+		conditions = append(conditions, userIdColumn+" IN ("+strings.Join(keys, ", ")+")")
+	}
+
+	if len(hold.ChannelIds) > 0 {
+		keys := make([]string, len(hold.ChannelIds))
+		for i, channelId := range hold.ChannelIds {
+			keys[i] = ":" + prefix + "ChannelId" + strconv.Itoa(i)
+			props[prefix+"ChannelId"+strconv.Itoa(i)] = channelId
+		}
+
+		conditions = append(conditions, strings.Replace(channelCondition, "%s", strings.Join(keys, ", "), 1))
+	}
+
+	query := "(" + strings.Join(conditions, " OR ") + ") AND CreateAt >= :" + prefix + "StartAt"
+	props[prefix+"StartAt"] = hold.StartAt
+
+	if hold.EndAt != 0 {
+		query += " AND CreateAt <= :" + prefix + "EndAt"
+		props[prefix+"EndAt"] = hold.EndAt
+	}
+
+	return query
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestLegalHoldStore(t *testing.T) {
+	Setup()
+
+	hold := &model.LegalHold{
+		CreatorId: model.NewId(),
+		Name:      "hold",
+		UserIds:   []string{model.NewId()},
+	}
+
+	if result := <-store.LegalHold().Save(hold); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.LegalHold().Save(&model.LegalHold{CreatorId: model.NewId(), Name: "hold"}); result.Err == nil {
+		t.Fatal("shouldn't save a hold without users or channels")
+	}
+
+	if rhold := Must(store.LegalHold().Get(hold.Id)).(*model.LegalHold); rhold.Name != hold.Name || len(rhold.UserIds) != 1 || rhold.UserIds[0] != hold.UserIds[0] {
+		t.Fatal("should've gotten hold", rhold)
+	}
+
+	if result := <-store.LegalHold().Get(model.NewId()); result.Err == nil {
+		t.Fatal("shouldn't get a missing hold")
+	}
+
+	if holds := Must(store.LegalHold().GetAll(0, 10)).([]*model.LegalHold); len(holds) == 0 || holds[0].Id != hold.Id {
+		t.Fatal("should've gotten newest hold first")
+	}
+
+	found := false
+	for _, active := range Must(store.LegalHold().GetActive()).([]*model.LegalHold) {
+		found = found || active.Id == hold.Id
+	}
+
+	if !found {
+		t.Fatal("should've gotten active hold")
+	}
+
+	hold.ReleaseAt = model.GetMillis()
+	Must(store.LegalHold().Update(hold))
+
+	for _, active := range Must(store.LegalHold().GetActive()).([]*model.LegalHold) {
+		if active.Id == hold.Id {
+			t.Fatal("shouldn't get released hold")
+		}
+	}
+}
+
+func TestLegalHoldGetContent(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+	channelId := model.NewId()
+
+	hold := Must(store.LegalHold().Save(&model.LegalHold{
+		CreatorId:  model.NewId(),
+		Name:       "hold",
+		UserIds:    []string{userId},
+		ChannelIds: []string{channelId},
+		StartAt:    model.GetMillis() - 10000,
+	})).(*model.LegalHold)
+
+	post1 := Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: userId, Message: "held user"})).(*model.Post)
+	post2 := Must(store.Post().Save(&model.Post{ChannelId: channelId, UserId: model.NewId(), Message: "held channel"})).(*model.Post)
+	Must(store.Post().Save(&model.Post{ChannelId: model.NewId(), UserId: model.NewId(), Message: "not held"}))
+	Must(store.Post().Save(&model.Post{ChannelId: channelId, UserId: userId, Message: "too old", CreateAt: hold.StartAt - 1}))
+
+	if posts := Must(store.LegalHold().GetPosts(hold, 0, 10)).([]*model.Post); len(posts) != 2 || posts[0].Id != post1.Id || posts[1].Id != post2.Id {
+		t.Fatal("should've gotten held posts", posts)
+	}
+
+	if held := Must(store.LegalHold().HasPosts(hold, post2.UserId, "")).(bool); !held {
+		t.Fatal("should've found the user's post in the held channel")
+	}
+
+	if held := Must(store.LegalHold().HasPosts(hold, "", post1.ChannelId)).(bool); !held {
+		t.Fatal("should've found the held user's post in the channel")
+	}
+
+	if held := Must(store.LegalHold().HasPosts(hold, model.NewId(), "")).(bool); held {
+		t.Fatal("shouldn't have found posts for another user")
+	}
+
+	info := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), PostId: post2.Id, Path: "file.txt"})).(*model.FileInfo)
+	Must(store.FileInfo().Save(&model.FileInfo{CreatorId: model.NewId(), PostId: model.NewId(), Path: "file.txt"}))
+
+	if infos := Must(store.LegalHold().GetFileInfos(hold, 0, 10)).([]*model.FileInfo); len(infos) != 1 || infos[0].Id != info.Id {
+		t.Fatal("should've gotten held file", infos)
+	}
+
+	reaction := Must(store.Reaction().Save(&model.Reaction{UserId: userId, PostId: model.NewId(), EmojiName: "smile"})).(*model.Reaction)
+	Must(store.Reaction().Save(&model.Reaction{UserId: model.NewId(), PostId: model.NewId(), EmojiName: "smile"}))
+
+	if reactions := Must(store.LegalHold().GetReactions(hold, 0, 10)).([]*model.Reaction); len(reactions) != 1 || reactions[0].PostId != reaction.PostId {
+		t.Fatal("should've gotten held reaction", reactions)
+	}
+}
+
+func TestLegalHoldGetUnreleasedFileInfos(t *testing.T) {
+	Setup()
+
+	userId := model.NewId()
+	otherUserId := model.NewId()
+
+	hold := Must(store.LegalHold().Save(&model.LegalHold{
+		CreatorId: model.NewId(),
+		Name:      "hold",
+		UserIds:   []string{userId, otherUserId},
+		StartAt:   model.GetMillis() - 10000,
+	})).(*model.LegalHold)
+
+	otherHold := Must(store.LegalHold().Save(&model.LegalHold{
+		CreatorId: model.NewId(),
+		Name:      "other hold",
+		UserIds:   []string{otherUserId},
+		StartAt:   model.GetMillis() - 10000,
+	})).(*model.LegalHold)
+
+	deleted := Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, Path: "file.txt", ContentHash: "hash", DeleteAt: 123})).(*model.FileInfo)
+	Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, Path: "file.txt", ContentHash: "hash"}))
+	Must(store.FileInfo().Save(&model.FileInfo{CreatorId: userId, Path: "file.txt", DeleteAt: 123}))
+	Must(store.FileInfo().Save(&model.FileInfo{CreatorId: otherUserId, Path: "file.txt", ContentHash: "hash", DeleteAt: 123}))
+
+	if infos := Must(store.LegalHold().GetUnreleasedFileInfos(hold, []*model.LegalHold{otherHold}, 10)).([]*model.FileInfo); len(infos) != 1 || infos[0].Id != deleted.Id {
+		t.Fatal("should've only gotten deleted file with a content hash that isn't covered by the other hold", infos)
+	}
+
+	Must(store.FileInfo().MarkBlobReleased(deleted.Id))
+
+	if infos := Must(store.LegalHold().GetUnreleasedFileInfos(hold, []*model.LegalHold{otherHold}, 10)).([]*model.FileInfo); len(infos) != 0 {
+		t.Fatal("shouldn't have gotten released file", infos)
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlLinkMetadataStore struct {
+	*SqlStore
+}
+
+func NewSqlLinkMetadataStore(sqlStore *SqlStore) LinkMetadataStore {
+	s := &SqlLinkMetadataStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.LinkMetadata{}, "LinkMetadata").SetKeys(false, "Hash")
+		table.ColMap("Hash").SetMaxSize(64)
+		table.ColMap("URL").SetMaxSize(model.LINK_METADATA_URL_MAX_LENGTH)
+		table.ColMap("Type").SetMaxSize(16)
+		table.ColMap("Data").SetMaxSize(model.LINK_METADATA_DATA_MAX_LENGTH)
+	}
+
+	return s
+}
+
+func (s SqlLinkMetadataStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_link_metadata_create_at", "LinkMetadata", "CreateAt")
+}
+
+// Save stores the metadata for a link, replacing any that was previously stored for it.
+func (s SqlLinkMetadataStore) Save(metadata *model.LinkMetadata) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		metadata.PreSave()
+		if result.Err = metadata.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if count, err := s.GetMaster().Update(metadata); err != nil {
+			result.Err = model.NewAppError("SqlLinkMetadataStore.Save", "store.sql_link_metadata.save.app_error", nil, "url="+metadata.URL+", "+err.Error(), http.StatusInternalServerError)
+		} else if count == 0 {
+			if err := s.GetMaster().Insert(metadata); err != nil && !IsUniqueConstraintError(err.Error(), []string{"PRIMARY", "linkmetadata_pkey"}) {
+				result.Err = model.NewAppError("SqlLinkMetadataStore.Save", "store.sql_link_metadata.save.app_error", nil, "url="+metadata.URL+", "+err.Error(), http.StatusInternalServerError)
+			}
+		}
+
+		if result.Err == nil {
+			result.Data = metadata
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLinkMetadataStore) Get(url string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var metadata model.LinkMetadata
+		if err := s.GetReplica().SelectOne(&metadata, "SELECT * FROM LinkMetadata WHERE Hash = :Hash", map[string]interface{}{"Hash": model.LinkMetadataHash(url)}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlLinkMetadataStore.Get", "store.sql_link_metadata.get.missing.app_error", nil, "url="+url, http.StatusNotFound)
+			} else {
+				result.Err = model.NewAppError("SqlLinkMetadataStore.Get", "store.sql_link_metadata.get.app_error", nil, "url="+url+", "+err.Error(), http.StatusInternalServerError)
+			}
+		} else {
+			result.Data = &metadata
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Cleanup removes metadata that was stored before the given time.
+func (s SqlLinkMetadataStore) Cleanup(expireTime int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM LinkMetadata WHERE CreateAt < :ExpireTime", map[string]interface{}{"ExpireTime": expireTime}); err != nil {
+			result.Err = model.NewAppError("SqlLinkMetadataStore.Cleanup", "store.sql_link_metadata.cleanup.app_error", nil, err.Error(), http.StatusInternalServerError)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
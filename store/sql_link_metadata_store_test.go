@@ -0,0 +1,55 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestSqlLinkMetadataStore(t *testing.T) {
+	Setup()
+
+	url := "http://example.com/" + model.NewId()
+
+	m1 := &model.LinkMetadata{URL: url, Type: model.LINK_METADATA_TYPE_OPENGRAPH, Data: `{"title":"first"}`, CreateAt: model.GetMillis() - 10000}
+	if result := <-store.LinkMetadata().Save(m1); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.LinkMetadata().Get(url); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.LinkMetadata).Data != m1.Data {
+		t.Fatal("should have returned the metadata")
+	}
+
+	m2 := &model.LinkMetadata{URL: url, Type: model.LINK_METADATA_TYPE_NONE}
+	if result := <-store.LinkMetadata().Save(m2); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.LinkMetadata().Get(url); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if metadata := result.Data.(*model.LinkMetadata); metadata.Type != model.LINK_METADATA_TYPE_NONE || metadata.CreateAt != m2.CreateAt {
+		t.Fatal("should have replaced the metadata")
+	}
+
+	if result := <-store.LinkMetadata().Save(&model.LinkMetadata{URL: url, Type: "junk"}); result.Err == nil {
+		t.Fatal("should have failed to save invalid metadata")
+	}
+
+	if result := <-store.LinkMetadata().Get("http://example.com/" + model.NewId()); result.Err == nil || result.Err.StatusCode != http.StatusNotFound {
+		t.Fatal("should have failed to get missing metadata")
+	}
+
+	if result := <-store.LinkMetadata().Cleanup(m2.CreateAt + 1); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.LinkMetadata().Get(url); result.Err == nil {
+		t.Fatal("should have removed the expired metadata")
+	}
+}
@@ -0,0 +1,332 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+
+	"github.com/go-gorp/gorp"
+	"github.com/mattermost/platform/model"
+)
+
+const (
+	MISSING_LOGIN_LOCKOUT_ERROR = "store.sql_login_attempt.get_lockout.missing.app_error"
+)
+
+type SqlLoginAttemptStore struct {
+	*SqlStore
+}
+
+func NewSqlLoginAttemptStore(sqlStore *SqlStore) LoginAttemptStore {
+	s := &SqlLoginAttemptStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		attempts := db.AddTableWithName(model.LoginAttempt{}, "LoginAttempts").SetKeys(false, "Id")
+		attempts.ColMap("Id").SetMaxSize(26)
+		attempts.ColMap("KeyType").SetMaxSize(32)
+		attempts.ColMap("KeyValue").SetMaxSize(256)
+
+		lockouts := db.AddTableWithName(model.LoginLockout{}, "LoginLockouts").SetKeys(false, "Id")
+		lockouts.ColMap("Id").SetMaxSize(26)
+		lockouts.ColMap("KeyType").SetMaxSize(32)
+		lockouts.ColMap("KeyValue").SetMaxSize(256)
+	}
+
+	return s
+}
+
+func (s SqlLoginAttemptStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_login_attempts_key_value", "LoginAttempts", "KeyValue")
+	s.CreateIndexIfNotExists("idx_login_attempts_create_at", "LoginAttempts", "CreateAt")
+	s.CreateIndexIfNotExists("idx_login_lockouts_key_value", "LoginLockouts", "KeyValue")
+	s.CreateIndexIfNotExists("idx_login_lockouts_expire_at", "LoginLockouts", "ExpireAt")
+}
+
+func (s SqlLoginAttemptStore) SaveAttempt(attempt *model.LoginAttempt) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		attempt.PreSave()
+		if result.Err = attempt.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(attempt); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.SaveAttempt", "store.sql_login_attempt.save_attempt.app_error", nil, "key_type="+attempt.KeyType+", "+err.Error())
+		} else {
+			result.Data = attempt
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// CountAttempts returns the number of failed logins recorded for the key since the given time.
+func (s SqlLoginAttemptStore) CountAttempts(keyType, keyValue string, since int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		keyValue = model.NormalizeLoginAttemptKeyValue(keyType, keyValue)
+
+		if count, err := s.GetMaster().SelectInt(
+			`SELECT
+				COUNT(*)
+			FROM
+				LoginAttempts
+			WHERE
+				KeyType = :KeyType
+				AND KeyValue = :KeyValue
+				AND CreateAt >= :Since`,
+			map[string]interface{}{"KeyType": keyType, "KeyValue": keyValue, "Since": since}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.CountAttempts", "store.sql_login_attempt.count_attempts.app_error", nil, "key_type="+keyType+", "+err.Error())
+		} else {
+			result.Data = count
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// SaveAttemptAndLockout saves a failed login and, in the same transaction, saves the lockout if the key has
+// now failed at least maxAttempts times since the given time and isn't already locked out. It returns the
+// lockout if one was saved or nil otherwise.
+func (s SqlLoginAttemptStore) SaveAttemptAndLockout(attempt *model.LoginAttempt, since int64, maxAttempts int64, lockout *model.LoginLockout) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		attempt.PreSave()
+		if result.Err = attempt.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.SaveAttemptAndLockout", "store.sql_login_attempt.save_attempt.app_error", nil, "key_type="+attempt.KeyType+", "+err.Error())
+		} else if saved, appErr := saveAttemptAndLockout(transaction, attempt, since, maxAttempts, lockout); appErr != nil {
+			transaction.Rollback()
+			result.Err = appErr
+		} else if err := transaction.Commit(); err != nil {
+			// don't need to rollback here since the transaction is already closed
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.SaveAttemptAndLockout", "store.sql_login_attempt.save_attempt.app_error", nil, "key_type="+attempt.KeyType+", "+err.Error())
+		} else if saved {
+			result.Data = lockout
+		} else {
+			result.Data = (*model.LoginLockout)(nil)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func saveAttemptAndLockout(transaction *gorp.Transaction, attempt *model.LoginAttempt, since int64, maxAttempts int64, lockout *model.LoginLockout) (bool, *model.AppError) {
+	props := map[string]interface{}{"KeyType": attempt.KeyType, "KeyValue": attempt.KeyValue, "Since": since, "Now": model.GetMillis()}
+
+	// Locking the key's earlier attempts makes concurrent failures for the same key wait for each other so that
+	// each of them counts the others and only one of them locks the key out
+	var ids []string
+	if _, err := transaction.Select(&ids, "SELECT Id FROM LoginAttempts WHERE KeyType = :KeyType AND KeyValue = :KeyValue AND CreateAt >= :Since FOR UPDATE", props); err != nil {
+		return false, model.NewLocAppError("SqlLoginAttemptStore.SaveAttemptAndLockout", "store.sql_login_attempt.count_attempts.app_error", nil, "key_type="+attempt.KeyType+", "+err.Error())
+	}
+
+	if err := transaction.Insert(attempt); err != nil {
+		return false, model.NewLocAppError("SqlLoginAttemptStore.SaveAttemptAndLockout", "store.sql_login_attempt.save_attempt.app_error", nil, "key_type="+attempt.KeyType+", "+err.Error())
+	}
+
+	count, err := transaction.SelectInt("SELECT COUNT(*) FROM LoginAttempts WHERE KeyType = :KeyType AND KeyValue = :KeyValue AND CreateAt >= :Since", props)
+	if err != nil {
+		return false, model.NewLocAppError("SqlLoginAttemptStore.SaveAttemptAndLockout", "store.sql_login_attempt.count_attempts.app_error", nil, "key_type="+attempt.KeyType+", "+err.Error())
+	} else if count < maxAttempts {
+		return false, nil
+	}
+
+	if locked, err := transaction.SelectInt("SELECT COUNT(*) FROM LoginLockouts WHERE KeyType = :KeyType AND KeyValue = :KeyValue AND ExpireAt > :Now", props); err != nil {
+		return false, model.NewLocAppError("SqlLoginAttemptStore.SaveAttemptAndLockout", "store.sql_login_attempt.get_lockout.app_error", nil, "key_type="+attempt.KeyType+", "+err.Error())
+	} else if locked > 0 {
+		return false, nil
+	}
+
+	lockout.KeyType = attempt.KeyType
+	lockout.KeyValue = attempt.KeyValue
+	lockout.FailedAttempts = count
+
+	lockout.PreSave()
+	if appErr := lockout.IsValid(); appErr != nil {
+		return false, appErr
+	}
+
+	if err := transaction.Insert(lockout); err != nil {
+		return false, model.NewLocAppError("SqlLoginAttemptStore.SaveAttemptAndLockout", "store.sql_login_attempt.save_lockout.app_error", nil, "key_type="+attempt.KeyType+", "+err.Error())
+	}
+
+	return true, nil
+}
+
+func (s SqlLoginAttemptStore) DeleteAttempts(keyType, keyValue string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		keyValue = model.NormalizeLoginAttemptKeyValue(keyType, keyValue)
+
+		if _, err := s.GetMaster().Exec("DELETE FROM LoginAttempts WHERE KeyType = :KeyType AND KeyValue = :KeyValue", map[string]interface{}{"KeyType": keyType, "KeyValue": keyValue}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.DeleteAttempts", "store.sql_login_attempt.delete_attempts.app_error", nil, "key_type="+keyType+", "+err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlLoginAttemptStore) SaveLockout(lockout *model.LoginLockout) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		lockout.PreSave()
+		if result.Err = lockout.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(lockout); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.SaveLockout", "store.sql_login_attempt.save_lockout.app_error", nil, "key_type="+lockout.KeyType+", "+err.Error())
+		} else {
+			result.Data = lockout
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetLockout returns the lockout for the key that expires last, ignoring lockouts that have already expired.
+func (s SqlLoginAttemptStore) GetLockout(keyType, keyValue string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		keyValue = model.NormalizeLoginAttemptKeyValue(keyType, keyValue)
+
+		var lockouts []*model.LoginLockout
+		if _, err := s.GetMaster().Select(&lockouts,
+			`SELECT
+				*
+			FROM
+				LoginLockouts
+			WHERE
+				KeyType = :KeyType
+				AND KeyValue = :KeyValue
+				AND ExpireAt > :Now
+			ORDER BY ExpireAt DESC
+			LIMIT 1`,
+			map[string]interface{}{"KeyType": keyType, "KeyValue": keyValue, "Now": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.GetLockout", "store.sql_login_attempt.get_lockout.app_error", nil, "key_type="+keyType+", "+err.Error())
+		} else if len(lockouts) == 0 {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.GetLockout", MISSING_LOGIN_LOCKOUT_ERROR, nil, "key_type="+keyType)
+		} else {
+			result.Data = lockouts[0]
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// GetLockouts returns every lockout that hasn't expired yet, newest first.
+func (s SqlLoginAttemptStore) GetLockouts() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var lockouts []*model.LoginLockout
+		if _, err := s.GetReplica().Select(&lockouts, "SELECT * FROM LoginLockouts WHERE ExpireAt > :Now ORDER BY CreateAt DESC", map[string]interface{}{"Now": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.GetLockouts", "store.sql_login_attempt.get_lockouts.app_error", nil, err.Error())
+		} else {
+			result.Data = lockouts
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// DeleteLockout removes the lockout with the given id along with the failed attempts that caused it.
+func (s SqlLoginAttemptStore) DeleteLockout(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var lockout model.LoginLockout
+		if err := s.GetMaster().SelectOne(&lockout, "SELECT * FROM LoginLockouts WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewAppError("SqlLoginAttemptStore.DeleteLockout", "store.sql_login_attempt.delete_lockout.missing.app_error", nil, "id="+id, 404)
+			} else {
+				result.Err = model.NewLocAppError("SqlLoginAttemptStore.DeleteLockout", "store.sql_login_attempt.delete_lockout.app_error", nil, "id="+id+", "+err.Error())
+			}
+		} else if _, err := s.GetMaster().Exec("DELETE FROM LoginLockouts WHERE Id = :Id", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.DeleteLockout", "store.sql_login_attempt.delete_lockout.app_error", nil, "id="+id+", "+err.Error())
+		} else if _, err := s.GetMaster().Exec("DELETE FROM LoginAttempts WHERE KeyType = :KeyType AND KeyValue = :KeyValue", map[string]interface{}{"KeyType": lockout.KeyType, "KeyValue": lockout.KeyValue}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.DeleteLockout", "store.sql_login_attempt.delete_attempts.app_error", nil, "id="+id+", "+err.Error())
+		} else {
+			result.Data = &lockout
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// Cleanup removes expired lockouts and any failed attempts older than the given time.
+func (s SqlLoginAttemptStore) Cleanup(attemptsBefore int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM LoginAttempts WHERE CreateAt < :Before", map[string]interface{}{"Before": attemptsBefore}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.Cleanup", "store.sql_login_attempt.cleanup.app_error", nil, err.Error())
+		} else if _, err := s.GetMaster().Exec("DELETE FROM LoginLockouts WHERE ExpireAt <= :Now", map[string]interface{}{"Now": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlLoginAttemptStore.Cleanup", "store.sql_login_attempt.cleanup.app_error", nil, err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
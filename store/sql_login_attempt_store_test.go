@@ -0,0 +1,161 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestSqlLoginAttemptStoreAttempts(t *testing.T) {
+	Setup()
+
+	loginId := model.NewId() + "@example.com"
+	start := model.GetMillis()
+
+	for i := 0; i < 3; i++ {
+		if result := <-store.LoginAttempt().SaveAttempt(&model.LoginAttempt{KeyType: model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, KeyValue: loginId}); result.Err != nil {
+			t.Fatal(result.Err)
+		}
+	}
+
+	Must(store.LoginAttempt().SaveAttempt(&model.LoginAttempt{KeyType: model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, KeyValue: loginId, CreateAt: start - 600000}))
+
+	if result := <-store.LoginAttempt().SaveAttempt(&model.LoginAttempt{KeyType: "junk", KeyValue: loginId}); result.Err == nil {
+		t.Fatal("should have failed to save an invalid attempt")
+	}
+
+	if result := <-store.LoginAttempt().CountAttempts(model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, loginId, start); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) != 3 {
+		t.Fatal("should only have counted the attempts inside the window")
+	}
+
+	if result := <-store.LoginAttempt().CountAttempts(model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS, loginId, start); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) != 0 {
+		t.Fatal("attempts should be counted separately for each key type")
+	}
+
+	Must(store.LoginAttempt().Cleanup(start - 1000))
+
+	if result := <-store.LoginAttempt().CountAttempts(model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, loginId, 0); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) != 3 {
+		t.Fatal("cleanup should have only removed the old attempt")
+	}
+
+	Must(store.LoginAttempt().DeleteAttempts(model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, loginId))
+
+	if result := <-store.LoginAttempt().CountAttempts(model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, loginId, 0); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) != 0 {
+		t.Fatal("attempts should have been deleted")
+	}
+}
+
+func TestSqlLoginAttemptStoreLockouts(t *testing.T) {
+	Setup()
+
+	ipAddress := "10.1." + model.NewId()
+
+	if result := <-store.LoginAttempt().GetLockout(model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS, ipAddress); result.Err == nil || result.Err.Id != MISSING_LOGIN_LOCKOUT_ERROR {
+		t.Fatal("should not have been locked out")
+	}
+
+	now := model.GetMillis()
+
+	expired := &model.LoginLockout{KeyType: model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS, KeyValue: ipAddress, CreateAt: now - 2000, ExpireAt: now - 1000}
+	Must(store.LoginAttempt().SaveLockout(expired))
+
+	if result := <-store.LoginAttempt().GetLockout(model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS, ipAddress); result.Err == nil {
+		t.Fatal("expired lockout should not be returned")
+	}
+
+	lockout := &model.LoginLockout{KeyType: model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS, KeyValue: ipAddress, FailedAttempts: 30, ExpireAt: now + 60000}
+	if result := <-store.LoginAttempt().SaveLockout(lockout); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.LoginAttempt().GetLockout(model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS, ipAddress); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.LoginLockout).Id != lockout.Id {
+		t.Fatal("wrong lockout returned")
+	}
+
+	if result := <-store.LoginAttempt().GetLockouts(); result.Err != nil {
+		t.Fatal(result.Err)
+	} else {
+		found := false
+		for _, l := range result.Data.([]*model.LoginLockout) {
+			if l.Id == expired.Id {
+				t.Fatal("expired lockout should not be listed")
+			} else if l.Id == lockout.Id {
+				found = true
+			}
+		}
+
+		if !found {
+			t.Fatal("live lockout should have been listed")
+		}
+	}
+
+	Must(store.LoginAttempt().SaveAttempt(&model.LoginAttempt{KeyType: model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS, KeyValue: ipAddress}))
+
+	if result := <-store.LoginAttempt().DeleteLockout(lockout.Id); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.LoginAttempt().GetLockout(model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS, ipAddress); result.Err == nil {
+		t.Fatal("lockout should have been deleted")
+	}
+
+	if result := <-store.LoginAttempt().CountAttempts(model.LOGIN_ATTEMPT_KEY_TYPE_IP_ADDRESS, ipAddress, 0); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(int64) != 0 {
+		t.Fatal("deleting a lockout should have cleared its failed attempts")
+	}
+
+	if result := <-store.LoginAttempt().DeleteLockout(lockout.Id); result.Err == nil {
+		t.Fatal("should have failed to delete a missing lockout")
+	}
+
+	Must(store.LoginAttempt().Cleanup(0))
+}
+
+func TestSqlLoginAttemptStoreSaveAttemptAndLockout(t *testing.T) {
+	Setup()
+
+	loginId := model.NewId()
+	since := model.GetMillis() - 60000
+
+	newLockout := func() *model.LoginLockout {
+		now := model.GetMillis()
+		return &model.LoginLockout{CreateAt: now, ExpireAt: now + 60000}
+	}
+
+	for i := 0; i < 2; i++ {
+		attempt := &model.LoginAttempt{KeyType: model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, KeyValue: loginId}
+		if lockout := Must(store.LoginAttempt().SaveAttemptAndLockout(attempt, since, 3, newLockout())).(*model.LoginLockout); lockout != nil {
+			t.Fatal("shouldn't have locked out before reaching the maximum attempts")
+		}
+	}
+
+	attempt := &model.LoginAttempt{KeyType: model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, KeyValue: loginId}
+	if lockout := Must(store.LoginAttempt().SaveAttemptAndLockout(attempt, since, 3, newLockout())).(*model.LoginLockout); lockout == nil {
+		t.Fatal("should've locked out after the maximum attempts")
+	} else if lockout.KeyValue != loginId || lockout.FailedAttempts != 3 {
+		t.Fatal("saved the wrong lockout", lockout)
+	}
+
+	attempt = &model.LoginAttempt{KeyType: model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, KeyValue: loginId}
+	if lockout := Must(store.LoginAttempt().SaveAttemptAndLockout(attempt, since, 3, newLockout())).(*model.LoginLockout); lockout != nil {
+		t.Fatal("shouldn't have locked out again while already locked out")
+	}
+
+	if count := Must(store.LoginAttempt().CountAttempts(model.LOGIN_ATTEMPT_KEY_TYPE_LOGIN_ID, loginId, since)).(int64); count != 4 {
+		t.Fatal("should've saved every attempt", count)
+	}
+}
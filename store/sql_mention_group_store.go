@@ -0,0 +1,155 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"net/http"
+
+	"github.com/mattermost/platform/model"
+)
+
+type SqlMentionGroupStore struct {
+	*SqlStore
+}
+
+func NewSqlMentionGroupStore(sqlStore *SqlStore) MentionGroupStore {
+	s := &SqlMentionGroupStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.MentionGroup{}, "MentionGroups").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(26)
+		table.ColMap("TeamId").SetMaxSize(26)
+		table.ColMap("CreatorId").SetMaxSize(26)
+		table.ColMap("Name").SetMaxSize(model.MENTION_GROUP_NAME_MAX_LENGTH)
+		table.ColMap("UserIds").SetMaxSize(32000)
+
+		table.SetUniqueTogether("TeamId", "Name", "DeleteAt")
+	}
+
+	return s
+}
+
+func (s SqlMentionGroupStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_mention_groups_team_id", "MentionGroups", "TeamId")
+	s.CreateIndexIfNotExists("idx_mention_groups_delete_at", "MentionGroups", "DeleteAt")
+}
+
+func (s SqlMentionGroupStore) Save(group *model.MentionGroup) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		group.PreSave()
+		if result.Err = group.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if err := s.GetMaster().Insert(group); err != nil {
+			if IsUniqueConstraintError(err.Error(), []string{"TeamId", "mentiongroups_teamid_name_deleteat_key"}) {
+				result.Err = model.NewAppError("SqlMentionGroupStore.Save", "store.sql_mention_group.save.exists.app_error", nil, "id="+group.Id+", "+err.Error(), http.StatusBadRequest)
+			} else {
+				result.Err = model.NewLocAppError("SqlMentionGroupStore.Save", "store.sql_mention_group.save.app_error", nil, "id="+group.Id+", "+err.Error())
+			}
+		} else {
+			result.Data = group
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlMentionGroupStore) Update(group *model.MentionGroup) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		group.PreUpdate()
+		if result.Err = group.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Update(group); err != nil {
+			if IsUniqueConstraintError(err.Error(), []string{"TeamId", "mentiongroups_teamid_name_deleteat_key"}) {
+				result.Err = model.NewAppError("SqlMentionGroupStore.Update", "store.sql_mention_group.save.exists.app_error", nil, "id="+group.Id+", "+err.Error(), http.StatusBadRequest)
+			} else {
+				result.Err = model.NewLocAppError("SqlMentionGroupStore.Update", "store.sql_mention_group.update.app_error", nil, "id="+group.Id+", "+err.Error())
+			}
+		} else {
+			result.Data = group
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlMentionGroupStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var group *model.MentionGroup
+		if err := s.GetReplica().SelectOne(&group, "SELECT * FROM MentionGroups WHERE Id = :Id AND DeleteAt = 0", map[string]interface{}{"Id": id}); err != nil {
+			result.Err = model.NewAppError("SqlMentionGroupStore.Get", "store.sql_mention_group.get.app_error", nil, "id="+id+", "+err.Error(), http.StatusNotFound)
+		} else {
+			result.Data = group
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlMentionGroupStore) GetForTeam(teamId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var groups []*model.MentionGroup
+		if _, err := s.GetReplica().Select(&groups, "SELECT * FROM MentionGroups WHERE TeamId = :TeamId AND DeleteAt = 0 ORDER BY Name", map[string]interface{}{"TeamId": teamId}); err != nil {
+			result.Err = model.NewLocAppError("SqlMentionGroupStore.GetForTeam", "store.sql_mention_group.get_for_team.app_error", nil, "team_id="+teamId+", "+err.Error())
+		} else {
+			result.Data = groups
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlMentionGroupStore) Delete(id string, time int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("UPDATE MentionGroups SET DeleteAt = :DeleteAt, UpdateAt = :UpdateAt WHERE Id = :Id AND DeleteAt = 0", map[string]interface{}{"DeleteAt": time, "UpdateAt": time, "Id": id}); err != nil {
+			result.Err = model.NewLocAppError("SqlMentionGroupStore.Delete", "store.sql_mention_group.delete.app_error", nil, "id="+id+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+			result.Err = model.NewAppError("SqlMentionGroupStore.Delete", "store.sql_mention_group.get.app_error", nil, "id="+id, http.StatusNotFound)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestMentionGroupStore(t *testing.T) {
+	Setup()
+
+	teamId := model.NewId()
+
+	group := &model.MentionGroup{
+		TeamId:    teamId,
+		CreatorId: model.NewId(),
+		Name:      "oncall",
+		UserIds:   []string{model.NewId(), model.NewId()},
+	}
+
+	if result := <-store.MentionGroup().Save(group); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.MentionGroup().Save(&model.MentionGroup{TeamId: teamId, CreatorId: model.NewId(), Name: "oncall", UserIds: []string{model.NewId()}}); result.Err == nil {
+		t.Fatal("shouldn't save a duplicate name on the same team")
+	}
+
+	Must(store.MentionGroup().Save(&model.MentionGroup{TeamId: model.NewId(), CreatorId: model.NewId(), Name: "oncall", UserIds: []string{model.NewId()}}))
+
+	if rgroup := Must(store.MentionGroup().Get(group.Id)).(*model.MentionGroup); rgroup.Name != group.Name || len(rgroup.UserIds) != 2 {
+		t.Fatal("should've gotten group", rgroup)
+	}
+
+	group.UserIds = []string{group.UserIds[0]}
+	Must(store.MentionGroup().Update(group))
+
+	if groups := Must(store.MentionGroup().GetForTeam(teamId)).([]*model.MentionGroup); len(groups) != 1 || groups[0].Id != group.Id || len(groups[0].UserIds) != 1 {
+		t.Fatal("should've gotten the team's updated group", groups)
+	}
+
+	Must(store.MentionGroup().Delete(group.Id, model.GetMillis()))
+
+	if result := <-store.MentionGroup().Get(group.Id); result.Err == nil {
+		t.Fatal("shouldn't get a deleted group")
+	}
+
+	if groups := Must(store.MentionGroup().GetForTeam(teamId)).([]*model.MentionGroup); len(groups) != 0 {
+		t.Fatal("shouldn't get a deleted group for the team")
+	}
+
+	if result := <-store.MentionGroup().Delete(group.Id, model.GetMillis()); result.Err == nil {
+		t.Fatal("shouldn't delete a group twice")
+	}
+
+	Must(store.MentionGroup().Save(&model.MentionGroup{TeamId: teamId, CreatorId: model.NewId(), Name: "oncall", UserIds: []string{model.NewId()}}))
+}
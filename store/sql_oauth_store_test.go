@@ -6,6 +6,7 @@ package store
 import (
 	"github.com/mattermost/platform/model"
 	"testing"
+	"time"
 )
 
 func TestOAuthStoreSaveApp(t *testing.T) {
@@ -36,13 +37,27 @@ func TestOAuthStoreGetApp(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if err := (<-store.OAuth().GetAppByUser(a1.CreatorId)).Err; err != nil {
+	if err := (<-store.OAuth().GetAppByUser(a1.CreatorId, 0, 1000)).Err; err != nil {
 		t.Fatal(err)
 	}
 
-	if err := (<-store.OAuth().GetApps()).Err; err != nil {
+	if err := (<-store.OAuth().GetApps(0, 1000)).Err; err != nil {
 		t.Fatal(err)
 	}
+
+	a2 := model.OAuthApp{}
+	a2.CreatorId = a1.CreatorId
+	a2.Name = "TestApp" + model.NewId()
+	a2.CallbackUrls = []string{"https://nowhere.com"}
+	a2.Homepage = "https://nowhere.com"
+	time.Sleep(2 * time.Millisecond)
+	Must(store.OAuth().SaveApp(&a2))
+
+	if result := <-store.OAuth().GetAppByUser(a1.CreatorId, 1, 1); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if apps := result.Data.([]*model.OAuthApp); len(apps) != 1 || apps[0].Id != a2.Id {
+		t.Fatal("should have returned the apps in the order they were created")
+	}
 }
 
 func TestOAuthStoreUpdateApp(t *testing.T) {
@@ -217,7 +232,7 @@ func TestOAuthGetAuthorizedApps(t *testing.T) {
 	p.Value = "true"
 	Must(store.Preference().Save(&model.Preferences{p}))
 
-	if result := <-store.OAuth().GetAuthorizedApps(a1.CreatorId); result.Err != nil {
+	if result := <-store.OAuth().GetAuthorizedApps(a1.CreatorId, 0, 1000); result.Err != nil {
 		t.Fatal(result.Err)
 	} else {
 		apps := result.Data.([]*model.OAuthApp)
@@ -245,7 +260,7 @@ func TestOAuthGetAccessDataByUserForApp(t *testing.T) {
 	p.Value = "true"
 	Must(store.Preference().Save(&model.Preferences{p}))
 
-	if result := <-store.OAuth().GetAuthorizedApps(a1.CreatorId); result.Err != nil {
+	if result := <-store.OAuth().GetAuthorizedApps(a1.CreatorId, 0, 1000); result.Err != nil {
 		t.Fatal(result.Err)
 	} else {
 		apps := result.Data.([]*model.OAuthApp)
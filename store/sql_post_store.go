@@ -65,6 +65,7 @@ func (s SqlPostStore) CreateIndexesIfNotExists() {
 	s.CreateIndexIfNotExists("idx_posts_root_id", "Posts", "RootId")
 	s.CreateIndexIfNotExists("idx_posts_user_id", "Posts", "UserId")
 	s.CreateIndexIfNotExists("idx_posts_is_pinned", "Posts", "IsPinned")
+	s.CreateIndexIfNotExists("idx_posts_original_id", "Posts", "OriginalId")
 
 	s.CreateFullTextIndexIfNotExists("idx_posts_message_txt", "Posts", "Message")
 	s.CreateFullTextIndexIfNotExists("idx_posts_hashtags_txt", "Posts", "Hashtags")
@@ -137,7 +138,16 @@ func (s SqlPostStore) Update(newPost *model.Post, oldPost *model.Post) StoreChan
 			return
 		}
 
-		if _, err := s.GetMaster().Update(newPost); err != nil {
+		// The old post is kept as a deleted copy so that the post's edit history isn't lost
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.Update", "store.sql_post.update.app_error", nil, "id="+newPost.Id+", "+err.Error())
+		} else if err := transaction.Insert(oldPost); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewLocAppError("SqlPostStore.Update", "store.sql_post.update.save_version.app_error", nil, "id="+newPost.Id+", "+err.Error())
+		} else if _, err := transaction.Update(newPost); err != nil {
+			transaction.Rollback()
+			result.Err = model.NewLocAppError("SqlPostStore.Update", "store.sql_post.update.app_error", nil, "id="+newPost.Id+", "+err.Error())
+		} else if err := transaction.Commit(); err != nil {
 			result.Err = model.NewLocAppError("SqlPostStore.Update", "store.sql_post.update.app_error", nil, "id="+newPost.Id+", "+err.Error())
 		} else {
 			time := model.GetMillis()
@@ -147,9 +157,6 @@ func (s SqlPostStore) Update(newPost *model.Post, oldPost *model.Post) StoreChan
 				s.GetMaster().Exec("UPDATE Posts SET UpdateAt = :UpdateAt WHERE Id = :RootId", map[string]interface{}{"UpdateAt": time, "RootId": newPost.RootId})
 			}
 
-			// mark the old post as deleted
-			s.GetMaster().Insert(oldPost)
-
 			result.Data = newPost
 		}
 
@@ -294,6 +301,33 @@ func (s SqlPostStore) GetSingle(id string) StoreChannel {
 	return storeChannel
 }
 
+// GetEditHistoryForPost returns the previous versions of a post, with the most recently replaced first.
+func (s SqlPostStore) GetEditHistoryForPost(postId string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+		pl := model.NewPostList()
+
+		var posts []*model.Post
+		if _, err := s.GetReplica().Select(&posts, "SELECT * FROM Posts WHERE OriginalId = :PostId ORDER BY DeleteAt DESC", map[string]interface{}{"PostId": postId}); err != nil {
+			result.Err = model.NewLocAppError("SqlPostStore.GetEditHistoryForPost", "store.sql_post.get_edit_history.app_error", nil, "id="+postId+", "+err.Error())
+		} else {
+			for _, post := range posts {
+				pl.AddPost(post)
+				pl.AddOrder(post.Id)
+			}
+		}
+
+		result.Data = pl
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 type etagPosts struct {
 	Id       string
 	UpdateAt int64
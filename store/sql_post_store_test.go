@@ -219,6 +219,40 @@ func TestPostStoreUpdate(t *testing.T) {
 	}
 }
 
+func TestPostStoreGetEditHistoryForPost(t *testing.T) {
+	Setup()
+
+	post := Must(store.Post().Save(&model.Post{
+		ChannelId: model.NewId(),
+		UserId:    model.NewId(),
+		Message:   "first",
+	})).(*model.Post)
+
+	for _, message := range []string{"second", "third"} {
+		oldPost := Must(store.Post().Get(post.Id)).(*model.PostList).Posts[post.Id]
+
+		newPost := &model.Post{}
+		*newPost = *oldPost
+		newPost.Message = message
+		newPost.EditAt = model.GetMillis()
+
+		Must(store.Post().Update(newPost, oldPost))
+		time.Sleep(2 * time.Millisecond)
+	}
+
+	if history := Must(store.Post().GetEditHistoryForPost(post.Id)).(*model.PostList); len(history.Order) != 2 {
+		t.Fatal("should've returned both previous versions", len(history.Order))
+	} else if first, second := history.Posts[history.Order[1]], history.Posts[history.Order[0]]; first.Message != "first" || second.Message != "second" {
+		t.Fatal("returned versions in the wrong order")
+	} else if first.OriginalId != post.Id || first.DeleteAt == 0 {
+		t.Fatal("versions should be deleted copies of the post")
+	}
+
+	if history := Must(store.Post().GetEditHistoryForPost(model.NewId())).(*model.PostList); len(history.Order) != 0 {
+		t.Fatal("unedited post shouldn't have history")
+	}
+}
+
 func TestPostStoreDelete(t *testing.T) {
 	Setup()
 
@@ -0,0 +1,138 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"database/sql"
+
+	"github.com/mattermost/platform/model"
+)
+
+const (
+	MISSING_RATE_LIMIT_ERROR = "store.sql_rate_limit.get.missing.app_error"
+)
+
+type SqlRateLimitStore struct {
+	*SqlStore
+}
+
+func NewSqlRateLimitStore(sqlStore *SqlStore) RateLimitStore {
+	s := &SqlRateLimitStore{sqlStore}
+
+	for _, db := range sqlStore.GetAllConns() {
+		table := db.AddTableWithName(model.RateLimit{}, "RateLimits").SetKeys(false, "Id")
+		table.ColMap("Id").SetMaxSize(256)
+	}
+
+	return s
+}
+
+func (s SqlRateLimitStore) CreateIndexesIfNotExists() {
+	s.CreateIndexIfNotExists("idx_rate_limits_expire_at", "RateLimits", "ExpireAt")
+}
+
+func (s SqlRateLimitStore) Get(id string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var rateLimit model.RateLimit
+		if err := s.GetMaster().SelectOne(&rateLimit, "SELECT * FROM RateLimits WHERE Id = :Id AND ExpireAt >= :Now", map[string]interface{}{"Id": id, "Now": model.GetMillis()}); err != nil {
+			if err == sql.ErrNoRows {
+				result.Err = model.NewLocAppError("SqlRateLimitStore.Get", MISSING_RATE_LIMIT_ERROR, nil, "id="+id)
+			} else {
+				result.Err = model.NewLocAppError("SqlRateLimitStore.Get", "store.sql_rate_limit.get.app_error", nil, "id="+id+", "+err.Error())
+			}
+		} else {
+			result.Data = &rateLimit
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// SetIfNotExists inserts the rate limit unless a live one already exists for its key.
+// Data is true when the rate limit was inserted.
+func (s SqlRateLimitStore) SetIfNotExists(rateLimit *model.RateLimit) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if result.Err = rateLimit.IsValid(); result.Err != nil {
+			storeChannel <- result
+			close(storeChannel)
+			return
+		}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM RateLimits WHERE Id = :Id AND ExpireAt < :Now", map[string]interface{}{"Id": rateLimit.Id, "Now": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlRateLimitStore.SetIfNotExists", "store.sql_rate_limit.save.app_error", nil, "id="+rateLimit.Id+", "+err.Error())
+		} else if err := s.GetMaster().Insert(rateLimit); err != nil {
+			if IsUniqueConstraintError(err.Error(), []string{"PRIMARY", "ratelimits_pkey"}) {
+				result.Data = false
+			} else {
+				result.Err = model.NewLocAppError("SqlRateLimitStore.SetIfNotExists", "store.sql_rate_limit.save.app_error", nil, "id="+rateLimit.Id+", "+err.Error())
+			}
+		} else {
+			result.Data = true
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+// CompareAndSwap atomically replaces the value of a live rate limit if it still holds oldValue.
+// Data is true when the swap happened.
+func (s SqlRateLimitStore) CompareAndSwap(id string, oldValue, newValue int64, expireAt int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec(
+			`UPDATE
+				RateLimits
+			SET
+				Value = :NewValue, ExpireAt = :ExpireAt
+			WHERE
+				Id = :Id
+				AND Value = :OldValue
+				AND ExpireAt >= :Now`,
+			map[string]interface{}{"Id": id, "OldValue": oldValue, "NewValue": newValue, "ExpireAt": expireAt, "Now": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlRateLimitStore.CompareAndSwap", "store.sql_rate_limit.update.app_error", nil, "id="+id+", "+err.Error())
+		} else {
+			rows, _ := sqlResult.RowsAffected()
+			result.Data = rows == 1
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
+func (s SqlRateLimitStore) Cleanup() StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if _, err := s.GetMaster().Exec("DELETE FROM RateLimits WHERE ExpireAt < :Now", map[string]interface{}{"Now": model.GetMillis()}); err != nil {
+			result.Err = model.NewLocAppError("SqlRateLimitStore.Cleanup", "store.sql_rate_limit.cleanup.app_error", nil, err.Error())
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
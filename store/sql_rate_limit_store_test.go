@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Mattermost, Inc. All Rights Reserved.
+// See License.txt for license information.
+
+package store
+
+import (
+	"testing"
+
+	"github.com/mattermost/platform/model"
+)
+
+func TestSqlRateLimitStore(t *testing.T) {
+	Setup()
+
+	id := "test:" + model.NewId()
+
+	if result := <-store.RateLimit().Get(id); result.Err == nil || result.Err.Id != MISSING_RATE_LIMIT_ERROR {
+		t.Fatal("should have been missing")
+	}
+
+	rateLimit := &model.RateLimit{Id: id, Value: 10, ExpireAt: model.GetMillis() + 60000}
+	if result := <-store.RateLimit().SetIfNotExists(rateLimit); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) {
+		t.Fatal("should have been set")
+	}
+
+	if result := <-store.RateLimit().SetIfNotExists(&model.RateLimit{Id: id, Value: 20, ExpireAt: model.GetMillis() + 60000}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("should not have overwritten a live rate limit")
+	}
+
+	if result := <-store.RateLimit().Get(id); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(*model.RateLimit).Value != 10 {
+		t.Fatal("wrong value")
+	}
+
+	if result := <-store.RateLimit().CompareAndSwap(id, 9, 11, model.GetMillis()+60000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("should not have swapped a stale value")
+	}
+
+	if result := <-store.RateLimit().CompareAndSwap(id, 10, 11, model.GetMillis()-1000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) {
+		t.Fatal("should have swapped")
+	}
+
+	if result := <-store.RateLimit().Get(id); result.Err == nil {
+		t.Fatal("expired rate limit should not be returned")
+	}
+
+	if result := <-store.RateLimit().CompareAndSwap(id, 11, 12, model.GetMillis()+60000); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if result.Data.(bool) {
+		t.Fatal("should not have swapped an expired rate limit")
+	}
+
+	if result := <-store.RateLimit().SetIfNotExists(&model.RateLimit{Id: id, Value: 30, ExpireAt: model.GetMillis() + 60000}); result.Err != nil {
+		t.Fatal(result.Err)
+	} else if !result.Data.(bool) {
+		t.Fatal("should have replaced the expired rate limit")
+	}
+
+	if result := <-store.RateLimit().Cleanup(); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if result := <-store.RateLimit().Get(id); result.Err != nil {
+		t.Fatal("cleanup should not remove live rate limits")
+	}
+}
@@ -116,9 +116,57 @@ func (s SqlReactionStore) Delete(reaction *model.Reaction) StoreChannel {
 	return storeChannel
 }
 
+// SoftDelete hides a reaction without removing it so that it's kept for a legal hold.
+func (s SqlReactionStore) SoftDelete(reaction *model.Reaction) StoreChannel {
+	storeChannel := make(StoreChannel)
+
+	go func() {
+		result := StoreResult{}
+
+		if transaction, err := s.GetMaster().Begin(); err != nil {
+			result.Err = model.NewLocAppError("SqlReactionStore.SoftDelete", "store.sql_reaction.delete.begin.app_error", nil, err.Error())
+		} else {
+			reaction.DeleteAt = model.GetMillis()
+			err := softDeleteReactionAndUpdatePost(transaction, reaction)
+
+			if err != nil {
+				transaction.Rollback()
+
+				result.Err = model.NewLocAppError("SqlReactionStore.SoftDelete", "store.sql_reaction.delete.app_error", nil, err.Error())
+			} else if err := transaction.Commit(); err != nil {
+				// don't need to rollback here since the transaction is already closed
+				result.Err = model.NewLocAppError("SqlReactionStore.SoftDelete", "store.sql_preference.delete.commit.app_error", nil, err.Error())
+			} else {
+				result.Data = reaction
+			}
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func saveReactionAndUpdatePost(transaction *gorp.Transaction, reaction *model.Reaction) error {
-	if err := transaction.Insert(reaction); err != nil {
+	// A reaction that was removed while under a legal hold is still stored, so it's restored instead
+	if sqlResult, err := transaction.Exec(
+		`UPDATE
+			Reactions
+		SET
+			CreateAt = :CreateAt,
+			DeleteAt = 0
+		WHERE
+			PostId = :PostId AND
+			UserId = :UserId AND
+			EmojiName = :EmojiName AND
+			DeleteAt != 0`,
+		map[string]interface{}{"CreateAt": reaction.CreateAt, "PostId": reaction.PostId, "UserId": reaction.UserId, "EmojiName": reaction.EmojiName}); err != nil {
 		return err
+	} else if rows, _ := sqlResult.RowsAffected(); rows == 0 {
+		if err := transaction.Insert(reaction); err != nil {
+			return err
+		}
 	}
 
 	return updatePostForReactions(transaction, reaction.PostId)
@@ -139,16 +187,34 @@ func deleteReactionAndUpdatePost(transaction *gorp.Transaction, reaction *model.
 	return updatePostForReactions(transaction, reaction.PostId)
 }
 
+func softDeleteReactionAndUpdatePost(transaction *gorp.Transaction, reaction *model.Reaction) error {
+	if _, err := transaction.Exec(
+		`UPDATE
+			Reactions
+		SET
+			DeleteAt = :DeleteAt
+		WHERE
+			PostId = :PostId AND
+			UserId = :UserId AND
+			EmojiName = :EmojiName AND
+			DeleteAt = 0`,
+		map[string]interface{}{"DeleteAt": reaction.DeleteAt, "PostId": reaction.PostId, "UserId": reaction.UserId, "EmojiName": reaction.EmojiName}); err != nil {
+		return err
+	}
+
+	return updatePostForReactions(transaction, reaction.PostId)
+}
+
 const (
 	// Set HasReactions = true if and only if the post has reactions, update UpdateAt only if HasReactions changes
 	UPDATE_POST_HAS_REACTIONS_QUERY = `UPDATE
 			Posts
 		SET
 			UpdateAt = (CASE
-				WHEN HasReactions != (SELECT count(0) > 0 FROM Reactions WHERE PostId = :PostId) THEN :UpdateAt
+				WHEN HasReactions != (SELECT count(0) > 0 FROM Reactions WHERE PostId = :PostId AND DeleteAt = 0) THEN :UpdateAt
 				ELSE UpdateAt
 			END),
-			HasReactions = (SELECT count(0) > 0 FROM Reactions WHERE PostId = :PostId)
+			HasReactions = (SELECT count(0) > 0 FROM Reactions WHERE PostId = :PostId AND DeleteAt = 0)
 		WHERE
 			Id = :PostId`
 )
@@ -202,7 +268,8 @@ func (s SqlReactionStore) GetForPost(postId string, allowFromCache bool) StoreCh
 			FROM
 				Reactions
 			WHERE
-				PostId = :PostId
+				PostId = :PostId AND
+				DeleteAt = 0
 			ORDER BY
 				CreateAt`, map[string]interface{}{"PostId": postId}); err != nil {
 			result.Err = model.NewLocAppError("SqlReactionStore.GetForPost", "store.sql_reaction.get_for_post.app_error", nil, "")
@@ -219,6 +286,36 @@ func (s SqlReactionStore) GetForPost(postId string, allowFromCache bool) StoreCh
 	return storeChannel
 }
 
+// GetAllWithEmojiName returns the reactions using an emoji that haven't been removed.
+func (s SqlReactionStore) GetAllWithEmojiName(emojiName string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		var reactions []*model.Reaction
+
+		if _, err := s.GetReplica().Select(&reactions,
+			`SELECT
+				*
+			FROM
+				Reactions
+			WHERE
+				EmojiName = :EmojiName AND
+				DeleteAt = 0`, map[string]interface{}{"EmojiName": emojiName}); err != nil {
+			result.Err = model.NewLocAppError("SqlReactionStore.GetAllWithEmojiName",
+				"store.sql_reaction.get_all_with_emoji_name.app_error", nil, "emoji_name="+emojiName+", error="+err.Error())
+		} else {
+			result.Data = reactions
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlReactionStore) DeleteAllWithEmojiName(emojiName string) StoreChannel {
 	storeChannel := make(StoreChannel)
 
@@ -122,6 +122,51 @@ func TestReactionDelete(t *testing.T) {
 	}
 }
 
+func TestReactionSoftDelete(t *testing.T) {
+	Setup()
+
+	post := Must(store.Post().Save(&model.Post{
+		ChannelId: model.NewId(),
+		UserId:    model.NewId(),
+	})).(*model.Post)
+
+	reaction := &model.Reaction{
+		UserId:    model.NewId(),
+		PostId:    post.Id,
+		EmojiName: model.NewId(),
+	}
+
+	Must(store.Reaction().Save(reaction))
+
+	if result := <-store.Reaction().SoftDelete(reaction); result.Err != nil {
+		t.Fatal(result.Err)
+	}
+
+	if reactions := Must(store.Reaction().GetForPost(post.Id, false)).([]*model.Reaction); len(reactions) != 0 {
+		t.Fatal("should've hidden reaction")
+	}
+
+	if postList := Must(store.Post().Get(post.Id)).(*model.PostList); postList.Posts[post.Id].HasReactions {
+		t.Fatal("should've set HasReactions = false on post")
+	}
+
+	hold := Must(store.LegalHold().Save(&model.LegalHold{
+		CreatorId: model.NewId(),
+		Name:      "hold",
+		UserIds:   []string{reaction.UserId},
+	})).(*model.LegalHold)
+
+	if reactions := Must(store.LegalHold().GetReactions(hold, 0, 10)).([]*model.Reaction); len(reactions) != 1 || reactions[0].DeleteAt == 0 {
+		t.Fatal("should've kept reaction")
+	}
+
+	Must(store.Reaction().Save(reaction))
+
+	if reactions := Must(store.Reaction().GetForPost(post.Id, false)).([]*model.Reaction); len(reactions) != 1 || reactions[0].DeleteAt != 0 {
+		t.Fatal("should've restored reaction")
+	}
+}
+
 func TestReactionGetForPost(t *testing.T) {
 	Setup()
 
@@ -258,6 +303,10 @@ func TestReactionDeleteAllWithEmojiName(t *testing.T) {
 		Must(store.Reaction().Save(reaction))
 	}
 
+	if returned := Must(store.Reaction().GetAllWithEmojiName(emojiToDelete)).([]*model.Reaction); len(returned) != 3 {
+		t.Fatal("should've returned the reactions with emoji name", len(returned))
+	}
+
 	if result := <-store.Reaction().DeleteAllWithEmojiName(emojiToDelete); result.Err != nil {
 		t.Fatal(result.Err)
 	}
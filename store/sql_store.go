@@ -65,28 +65,37 @@ const (
 )
 
 type SqlStore struct {
-	master        *gorp.DbMap
-	replicas      []*gorp.DbMap
-	team          TeamStore
-	channel       ChannelStore
-	post          PostStore
-	user          UserStore
-	audit         AuditStore
-	compliance    ComplianceStore
-	session       SessionStore
-	oauth         OAuthStore
-	system        SystemStore
-	webhook       WebhookStore
-	command       CommandStore
-	preference    PreferenceStore
-	license       LicenseStore
-	recovery      PasswordRecoveryStore
-	emoji         EmojiStore
-	status        StatusStore
-	fileInfo      FileInfoStore
-	reaction      ReactionStore
-	SchemaVersion string
-	rrCounter     int64
+	master           *gorp.DbMap
+	replicas         []*gorp.DbMap
+	team             TeamStore
+	channel          ChannelStore
+	post             PostStore
+	user             UserStore
+	audit            AuditStore
+	compliance       ComplianceStore
+	session          SessionStore
+	oauth            OAuthStore
+	system           SystemStore
+	webhook          WebhookStore
+	command          CommandStore
+	preference       PreferenceStore
+	license          LicenseStore
+	recovery         PasswordRecoveryStore
+	emoji            EmojiStore
+	status           StatusStore
+	fileInfo         FileInfoStore
+	fileBlob         FileBlobStore
+	reaction         ReactionStore
+	clusterDiscovery ClusterDiscoveryStore
+	rateLimit        RateLimitStore
+	loginAttempt     LoginAttemptStore
+	commandWebhook   CommandWebhookStore
+	linkMetadata     LinkMetadataStore
+	legalHold        LegalHoldStore
+	mentionGroup     MentionGroupStore
+	emailDigest      EmailDigestStore
+	SchemaVersion    string
+	rrCounter        int64
 }
 
 func initConnection() *SqlStore {
@@ -135,7 +144,16 @@ func NewSqlStore() Store {
 	sqlStore.emoji = NewSqlEmojiStore(sqlStore)
 	sqlStore.status = NewSqlStatusStore(sqlStore)
 	sqlStore.fileInfo = NewSqlFileInfoStore(sqlStore)
+	sqlStore.fileBlob = NewSqlFileBlobStore(sqlStore)
 	sqlStore.reaction = NewSqlReactionStore(sqlStore)
+	sqlStore.clusterDiscovery = NewSqlClusterDiscoveryStore(sqlStore)
+	sqlStore.rateLimit = NewSqlRateLimitStore(sqlStore)
+	sqlStore.loginAttempt = NewSqlLoginAttemptStore(sqlStore)
+	sqlStore.commandWebhook = NewSqlCommandWebhookStore(sqlStore)
+	sqlStore.linkMetadata = NewSqlLinkMetadataStore(sqlStore)
+	sqlStore.legalHold = NewSqlLegalHoldStore(sqlStore)
+	sqlStore.mentionGroup = NewSqlMentionGroupStore(sqlStore)
+	sqlStore.emailDigest = NewSqlEmailDigestStore(sqlStore)
 
 	err := sqlStore.master.CreateTablesIfNotExists()
 	if err != nil {
@@ -163,7 +181,16 @@ func NewSqlStore() Store {
 	sqlStore.emoji.(*SqlEmojiStore).CreateIndexesIfNotExists()
 	sqlStore.status.(*SqlStatusStore).CreateIndexesIfNotExists()
 	sqlStore.fileInfo.(*SqlFileInfoStore).CreateIndexesIfNotExists()
+	sqlStore.fileBlob.(*SqlFileBlobStore).CreateIndexesIfNotExists()
 	sqlStore.reaction.(*SqlReactionStore).CreateIndexesIfNotExists()
+	sqlStore.clusterDiscovery.(*SqlClusterDiscoveryStore).CreateIndexesIfNotExists()
+	sqlStore.rateLimit.(*SqlRateLimitStore).CreateIndexesIfNotExists()
+	sqlStore.loginAttempt.(*SqlLoginAttemptStore).CreateIndexesIfNotExists()
+	sqlStore.commandWebhook.(*SqlCommandWebhookStore).CreateIndexesIfNotExists()
+	sqlStore.linkMetadata.(*SqlLinkMetadataStore).CreateIndexesIfNotExists()
+	sqlStore.legalHold.(*SqlLegalHoldStore).CreateIndexesIfNotExists()
+	sqlStore.mentionGroup.(*SqlMentionGroupStore).CreateIndexesIfNotExists()
+	sqlStore.emailDigest.(*SqlEmailDigestStore).CreateIndexesIfNotExists()
 
 	sqlStore.preference.(*SqlPreferenceStore).DeleteUnusedFeatures()
 
@@ -683,10 +710,46 @@ func (ss *SqlStore) FileInfo() FileInfoStore {
 	return ss.fileInfo
 }
 
+func (ss *SqlStore) FileBlob() FileBlobStore {
+	return ss.fileBlob
+}
+
 func (ss *SqlStore) Reaction() ReactionStore {
 	return ss.reaction
 }
 
+func (ss *SqlStore) ClusterDiscovery() ClusterDiscoveryStore {
+	return ss.clusterDiscovery
+}
+
+func (ss *SqlStore) RateLimit() RateLimitStore {
+	return ss.rateLimit
+}
+
+func (ss *SqlStore) LoginAttempt() LoginAttemptStore {
+	return ss.loginAttempt
+}
+
+func (ss *SqlStore) CommandWebhook() CommandWebhookStore {
+	return ss.commandWebhook
+}
+
+func (ss *SqlStore) LinkMetadata() LinkMetadataStore {
+	return ss.linkMetadata
+}
+
+func (ss *SqlStore) LegalHold() LegalHoldStore {
+	return ss.legalHold
+}
+
+func (ss *SqlStore) MentionGroup() MentionGroupStore {
+	return ss.mentionGroup
+}
+
+func (ss *SqlStore) EmailDigest() EmailDigestStore {
+	return ss.emailDigest
+}
+
 func (ss *SqlStore) DropAllTables() {
 	ss.master.TruncateTables()
 }
@@ -704,6 +767,9 @@ func (me mattermConverter) ToDb(val interface{}) (interface{}, error) {
 		return encrypt([]byte(utils.Cfg.SqlSettings.AtRestEncryptKey), model.MapToJson(t))
 	case model.StringInterface:
 		return model.StringInterfaceToJson(t), nil
+	case model.AutocompleteArgList:
+		b, err := json.Marshal(t)
+		return string(b), err
 	}
 
 	return val, nil
@@ -757,6 +823,19 @@ func (me mattermConverter) FromDb(target interface{}) (gorp.CustomScanner, bool)
 			return json.Unmarshal(b, target)
 		}
 		return gorp.CustomScanner{Holder: new(string), Target: target, Binder: binder}, true
+	case *model.AutocompleteArgList:
+		binder := func(holder, target interface{}) error {
+			s, ok := holder.(*string)
+			if !ok {
+				return errors.New(utils.T("store.sql.convert_autocomplete_args"))
+			}
+			if len(*s) == 0 {
+				return nil
+			}
+			b := []byte(*s)
+			return json.Unmarshal(b, target)
+		}
+		return gorp.CustomScanner{Holder: new(string), Target: target, Binder: binder}, true
 	}
 
 	return gorp.CustomScanner{}, false
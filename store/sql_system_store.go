@@ -132,3 +132,31 @@ func (s SqlSystemStore) GetByName(name string) StoreChannel {
 
 	return storeChannel
 }
+
+// Claim changes a value only if it hasn't been changed since it was read, returning true if this call was the one
+// that changed it. A value that doesn't exist yet is read as an empty string. This is used to make sure that only
+// one server in a cluster does something.
+func (s SqlSystemStore) Claim(name string, oldValue string, newValue string) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		if sqlResult, err := s.GetMaster().Exec("UPDATE Systems SET Value = :NewValue WHERE Name = :Name AND Value = :OldValue",
+			map[string]interface{}{"Name": name, "OldValue": oldValue, "NewValue": newValue}); err != nil {
+			result.Err = model.NewLocAppError("SqlSystemStore.Claim", "store.sql_system.claim.app_error", nil, "name="+name+", "+err.Error())
+		} else if rows, _ := sqlResult.RowsAffected(); rows == 1 {
+			result.Data = true
+		} else if oldValue != "" {
+			result.Data = false
+		} else {
+			// Another server has claimed it first if the insert fails because the value now exists
+			result.Data = s.GetMaster().Insert(&model.System{Name: name, Value: newValue}) == nil
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
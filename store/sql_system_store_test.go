@@ -54,3 +54,29 @@ func TestSqlSystemStoreSaveOrUpdate(t *testing.T) {
 		t.Fatal(r.Err)
 	}
 }
+
+func TestSqlSystemStoreClaim(t *testing.T) {
+	Setup()
+
+	name := model.NewId()
+
+	if claimed := Must(store.System().Claim(name, "", "value")).(bool); !claimed {
+		t.Fatal("should've claimed a value that didn't exist")
+	}
+
+	if claimed := Must(store.System().Claim(name, "", "value2")).(bool); claimed {
+		t.Fatal("shouldn't have claimed a value that already existed")
+	}
+
+	if claimed := Must(store.System().Claim(name, "value", "value2")).(bool); !claimed {
+		t.Fatal("should've claimed an unchanged value")
+	}
+
+	if claimed := Must(store.System().Claim(name, "value", "value3")).(bool); claimed {
+		t.Fatal("shouldn't have claimed a changed value")
+	}
+
+	if rsystem := Must(store.System().GetByName(name)).(*model.System); rsystem.Value != "value2" {
+		t.Fatal("should've kept the value of the successful claim", rsystem.Value)
+	}
+}
@@ -250,6 +250,38 @@ func UpgradeDatabaseToVersion38(sqlStore *SqlStore) {
 	// Add the IsPinned column to posts.
 	sqlStore.CreateColumnIfNotExists("Posts", "IsPinned", "boolean", "boolean", "0")
 
+	// Add the argument definitions used to autocomplete slash commands.
+	sqlStore.CreateColumnIfNotExists("Commands", "AutocompleteArgs", "varchar(4000)", "varchar(4000)", "[]")
+
+	// Add the per-hook restrictions and usage tracking to incoming webhooks.
+	sqlStore.CreateColumnIfNotExists("IncomingWebhooks", "ChannelLocked", "boolean", "boolean", "0")
+	sqlStore.CreateColumnIfNotExists("IncomingWebhooks", "DisableUsernameOverride", "boolean", "boolean", "0")
+	sqlStore.CreateColumnIfNotExists("IncomingWebhooks", "DisableIconOverride", "boolean", "boolean", "0")
+	sqlStore.CreateColumnIfNotExists("IncomingWebhooks", "RateLimit", "int", "integer", "0")
+	sqlStore.CreateColumnIfNotExists("IncomingWebhooks", "PostCount", "bigint", "bigint", "0")
+	sqlStore.CreateColumnIfNotExists("IncomingWebhooks", "LastUsedAt", "bigint", "bigint", "0")
+
+	// Add the result of scanning uploaded files for viruses.
+	sqlStore.CreateColumnIfNotExists("FileInfo", "ScanStatus", "varchar(32)", "varchar(32)", "")
+	sqlStore.CreateColumnIfNotExists("FileInfo", "ScanAttempts", "int", "integer", "0")
+
+	// Add the hash of files that are stored once and shared between uploads of the same content, and the time
+	// that deleted files gave up their reference to the shared data.
+	sqlStore.CreateColumnIfNotExists("FileInfo", "ContentHash", "varchar(64)", "varchar(64)", "")
+	sqlStore.CreateColumnIfNotExists("FileInfo", "BlobReleaseAt", "bigint", "bigint", "0")
+
+	// Add the team that files were uploaded to so that storage can be limited per team. Existing files take
+	// the team of the channel that they were posted in, or otherwise the team in their path.
+	if sqlStore.CreateColumnIfNotExists("FileInfo", "TeamId", "varchar(26)", "varchar(26)", "") {
+		sqlStore.GetMaster().Exec(`UPDATE FileInfo SET TeamId = COALESCE((SELECT Channels.TeamId FROM Posts, Channels
+			WHERE Posts.Id = FileInfo.PostId AND Channels.Id = Posts.ChannelId), '') WHERE TeamId = '' AND PostId != ''`)
+		sqlStore.GetMaster().Exec(`UPDATE FileInfo SET TeamId = SUBSTRING(Path, 7, 26)
+			WHERE TeamId = '' AND PostId = '' AND Path LIKE 'teams/%' AND Path NOT LIKE 'teams/noteam/%' AND Path NOT LIKE 'teams//%'`)
+	}
+
+	// Add the time that reactions were removed, which is only set for reactions kept because of a legal hold.
+	sqlStore.CreateColumnIfNotExists("Reactions", "DeleteAt", "bigint", "bigint", "0")
+
 	// saveSchemaVersion(sqlStore, VERSION_3_8_0)
 	// }
 }
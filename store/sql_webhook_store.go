@@ -8,6 +8,7 @@ import (
 
 	"database/sql"
 
+	"github.com/go-gorp/gorp"
 	"github.com/mattermost/platform/einterfaces"
 	"github.com/mattermost/platform/model"
 	"github.com/mattermost/platform/utils"
@@ -117,7 +118,11 @@ func (s SqlWebhookStore) UpdateIncoming(hook *model.IncomingWebhook) StoreChanne
 
 		hook.UpdateAt = model.GetMillis()
 
-		if _, err := s.GetMaster().Update(hook); err != nil {
+		// PostCount and LastUsedAt are only changed by UpdateIncomingUsage so that editing a hook
+		// doesn't overwrite usage recorded since it was loaded
+		if _, err := s.GetMaster().UpdateColumns(func(col *gorp.ColumnMap) bool {
+			return col.ColumnName != "PostCount" && col.ColumnName != "LastUsedAt"
+		}, hook); err != nil {
 			result.Err = model.NewLocAppError("SqlWebhookStore.UpdateIncoming", "store.sql_webhooks.update_incoming.app_error", nil, "id="+hook.Id+", "+err.Error())
 		} else {
 			result.Data = hook
@@ -175,6 +180,24 @@ func (s SqlWebhookStore) GetIncoming(id string, allowFromCache bool) StoreChanne
 	return storeChannel
 }
 
+func (s SqlWebhookStore) UpdateIncomingUsage(webhookId string, time int64) StoreChannel {
+	storeChannel := make(StoreChannel, 1)
+
+	go func() {
+		result := StoreResult{}
+
+		_, err := s.GetMaster().Exec("UPDATE IncomingWebhooks SET PostCount = PostCount + 1, LastUsedAt = :LastUsedAt WHERE Id = :Id", map[string]interface{}{"LastUsedAt": time, "Id": webhookId})
+		if err != nil {
+			result.Err = model.NewAppError("SqlWebhookStore.UpdateIncomingUsage", "store.sql_webhooks.update_incoming_usage.app_error", nil, "id="+webhookId+", err="+err.Error(), http.StatusInternalServerError)
+		}
+
+		storeChannel <- result
+		close(storeChannel)
+	}()
+
+	return storeChannel
+}
+
 func (s SqlWebhookStore) DeleteIncoming(webhookId string, time int64) StoreChannel {
 	storeChannel := make(StoreChannel, 1)
 
@@ -45,6 +45,31 @@ func TestWebhookStoreUpdateIncoming(t *testing.T) {
 	}
 }
 
+func TestWebhookStoreUpdateIncomingUsage(t *testing.T) {
+	Setup()
+	o1 := buildIncomingWebhook()
+	o1 = (<-store.Webhook().SaveIncoming(o1)).Data.(*model.IncomingWebhook)
+
+	Must(store.Webhook().UpdateIncomingUsage(o1.Id, 1000))
+	Must(store.Webhook().UpdateIncomingUsage(o1.Id, 2000))
+
+	if r1 := <-store.Webhook().GetIncoming(o1.Id, false); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if hook := r1.Data.(*model.IncomingWebhook); hook.PostCount != 2 || hook.LastUsedAt != 2000 {
+		t.Fatal("usage wasn't recorded", hook.PostCount, hook.LastUsedAt)
+	}
+
+	// updating the hook from a stale copy shouldn't reset its usage
+	o1.DisplayName = "TestHook"
+	Must(store.Webhook().UpdateIncoming(o1))
+
+	if r1 := <-store.Webhook().GetIncoming(o1.Id, false); r1.Err != nil {
+		t.Fatal(r1.Err)
+	} else if hook := r1.Data.(*model.IncomingWebhook); hook.PostCount != 2 || hook.DisplayName != "TestHook" {
+		t.Fatal("update overwrote usage", hook.PostCount, hook.DisplayName)
+	}
+}
+
 func TestWebhookStoreGetIncoming(t *testing.T) {
 	Setup()
 
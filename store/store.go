@@ -46,7 +46,16 @@ type Store interface {
 	Emoji() EmojiStore
 	Status() StatusStore
 	FileInfo() FileInfoStore
+	FileBlob() FileBlobStore
 	Reaction() ReactionStore
+	ClusterDiscovery() ClusterDiscoveryStore
+	RateLimit() RateLimitStore
+	LoginAttempt() LoginAttemptStore
+	CommandWebhook() CommandWebhookStore
+	LinkMetadata() LinkMetadataStore
+	LegalHold() LegalHoldStore
+	MentionGroup() MentionGroupStore
+	EmailDigest() EmailDigestStore
 	MarkSystemRanUnitTests()
 	Close()
 	DropAllTables()
@@ -142,6 +151,7 @@ type PostStore interface {
 	Update(newPost *model.Post, oldPost *model.Post) StoreChannel
 	Get(id string) StoreChannel
 	GetSingle(id string) StoreChannel
+	GetEditHistoryForPost(postId string) StoreChannel
 	Delete(postId string, time int64) StoreChannel
 	PermanentDeleteByUser(userId string) StoreChannel
 	PermanentDeleteByChannel(channelId string) StoreChannel
@@ -231,16 +241,18 @@ type ComplianceStore interface {
 	Update(compliance *model.Compliance) StoreChannel
 	Get(id string) StoreChannel
 	GetAll(offset, limit int) StoreChannel
+	GetAllByType(complianceType string, offset, limit int) StoreChannel
 	ComplianceExport(compliance *model.Compliance) StoreChannel
+	MessageExport(afterUpdateAt int64, afterPostId string, endTime int64, limit int) StoreChannel
 }
 
 type OAuthStore interface {
 	SaveApp(app *model.OAuthApp) StoreChannel
 	UpdateApp(app *model.OAuthApp) StoreChannel
 	GetApp(id string) StoreChannel
-	GetAppByUser(userId string) StoreChannel
-	GetApps() StoreChannel
-	GetAuthorizedApps(userId string) StoreChannel
+	GetAppByUser(userId string, offset, limit int) StoreChannel
+	GetApps(offset, limit int) StoreChannel
+	GetAuthorizedApps(userId string, offset, limit int) StoreChannel
 	DeleteApp(id string) StoreChannel
 	SaveAuthData(authData *model.AuthData) StoreChannel
 	GetAuthData(code string) StoreChannel
@@ -261,6 +273,7 @@ type SystemStore interface {
 	Update(system *model.System) StoreChannel
 	Get() StoreChannel
 	GetByName(name string) StoreChannel
+	Claim(name string, oldValue string, newValue string) StoreChannel
 }
 
 type WebhookStore interface {
@@ -269,6 +282,7 @@ type WebhookStore interface {
 	GetIncomingList(offset, limit int) StoreChannel
 	GetIncomingByTeam(teamId string, offset, limit int) StoreChannel
 	UpdateIncoming(webhook *model.IncomingWebhook) StoreChannel
+	UpdateIncomingUsage(webhookId string, time int64) StoreChannel
 	GetIncomingByChannel(channelId string) StoreChannel
 	DeleteIncoming(webhookId string, time int64) StoreChannel
 	PermanentDeleteIncomingByUser(userId string) StoreChannel
@@ -326,6 +340,7 @@ type EmojiStore interface {
 	Get(id string, allowFromCache bool) StoreChannel
 	GetByName(name string) StoreChannel
 	GetAll() StoreChannel
+	GetList(offset, limit int) StoreChannel
 	Delete(id string, time int64) StoreChannel
 }
 
@@ -345,17 +360,109 @@ type FileInfoStore interface {
 	Save(info *model.FileInfo) StoreChannel
 	Get(id string) StoreChannel
 	GetByPath(path string) StoreChannel
+	CountByPath(path string) StoreChannel
+	GetWithoutContentHash(afterId string, limit int) StoreChannel
+	UpdateContentHash(info *model.FileInfo) StoreChannel
+	MarkBlobReleased(fileId string) StoreChannel
+	GetByScanStatus(status string, limit int) StoreChannel
+	UpdateScanStatus(info *model.FileInfo) StoreChannel
+	UpdatePreview(info *model.FileInfo) StoreChannel
+	GetStorageUsageForTeam(teamId string) StoreChannel
+	GetStorageUsageForUser(userId string) StoreChannel
+	AnalyticsStorageUsageByTeam() StoreChannel
 	GetForPost(postId string, readFromMaster bool, allowFromCache bool) StoreChannel
 	InvalidateFileInfosForPostCache(postId string)
 	AttachToPost(fileId string, postId string) StoreChannel
 	DeleteForPost(postId string) StoreChannel
+	PermanentDelete(fileId string) StoreChannel
+	SaveContent(content *model.FileInfoContent) StoreChannel
+	Search(teamId string, userId string, params *model.FileSearch) StoreChannel
+}
+
+type FileBlobStore interface {
+	Acquire(path string, staleBefore int64) StoreChannel
+	Release(path string) StoreChannel
+	Delete(path string) StoreChannel
 }
 
 type ReactionStore interface {
 	Save(reaction *model.Reaction) StoreChannel
 	Delete(reaction *model.Reaction) StoreChannel
+	SoftDelete(reaction *model.Reaction) StoreChannel
 	InvalidateCacheForPost(postId string)
 	InvalidateCache()
 	GetForPost(postId string, allowFromCache bool) StoreChannel
+	GetAllWithEmojiName(emojiName string) StoreChannel
 	DeleteAllWithEmojiName(emojiName string) StoreChannel
 }
+
+type ClusterDiscoveryStore interface {
+	Save(discovery *model.ClusterDiscovery) StoreChannel
+	Delete(id string) StoreChannel
+	GetAll(discoveryType string) StoreChannel
+	SetLastPingAt(id string) StoreChannel
+	Cleanup() StoreChannel
+}
+
+type RateLimitStore interface {
+	Get(id string) StoreChannel
+	SetIfNotExists(rateLimit *model.RateLimit) StoreChannel
+	CompareAndSwap(id string, oldValue, newValue int64, expireAt int64) StoreChannel
+	Cleanup() StoreChannel
+}
+
+type LoginAttemptStore interface {
+	SaveAttempt(attempt *model.LoginAttempt) StoreChannel
+	CountAttempts(keyType, keyValue string, since int64) StoreChannel
+	SaveAttemptAndLockout(attempt *model.LoginAttempt, since int64, maxAttempts int64, lockout *model.LoginLockout) StoreChannel
+	DeleteAttempts(keyType, keyValue string) StoreChannel
+	SaveLockout(lockout *model.LoginLockout) StoreChannel
+	GetLockout(keyType, keyValue string) StoreChannel
+	GetLockouts() StoreChannel
+	DeleteLockout(id string) StoreChannel
+	Cleanup(attemptsBefore int64) StoreChannel
+}
+
+type CommandWebhookStore interface {
+	Save(webhook *model.CommandWebhook) StoreChannel
+	Get(id string, expireTime int64) StoreChannel
+	TryUse(id string, limit int, expireTime int64) StoreChannel
+	Cleanup(expireTime int64) StoreChannel
+}
+
+type LinkMetadataStore interface {
+	Save(metadata *model.LinkMetadata) StoreChannel
+	Get(url string) StoreChannel
+	Cleanup(expireTime int64) StoreChannel
+}
+
+type LegalHoldStore interface {
+	Save(hold *model.LegalHold) StoreChannel
+	Update(hold *model.LegalHold) StoreChannel
+	Get(id string) StoreChannel
+	GetAll(offset, limit int) StoreChannel
+	GetActive() StoreChannel
+	HasPosts(hold *model.LegalHold, userId, channelId string) StoreChannel
+	GetPosts(hold *model.LegalHold, offset, limit int) StoreChannel
+	GetFileInfos(hold *model.LegalHold, offset, limit int) StoreChannel
+	GetUnreleasedFileInfos(hold *model.LegalHold, otherHolds []*model.LegalHold, limit int) StoreChannel
+	GetReactions(hold *model.LegalHold, offset, limit int) StoreChannel
+}
+
+type MentionGroupStore interface {
+	Save(group *model.MentionGroup) StoreChannel
+	Update(group *model.MentionGroup) StoreChannel
+	Get(id string) StoreChannel
+	GetForTeam(teamId string) StoreChannel
+	Delete(id string, time int64) StoreChannel
+}
+
+type EmailDigestStore interface {
+	Save(digest *model.EmailDigest) StoreChannel
+	Update(digest *model.EmailDigest) StoreChannel
+	Get(userId string) StoreChannel
+	Delete(userId string) StoreChannel
+	GetDue(now int64, limit int) StoreChannel
+	Claim(digest *model.EmailDigest, nextSendAt int64, now int64) StoreChannel
+	GetUnreadChannels(userId string, since int64) StoreChannel
+}
@@ -182,7 +182,9 @@ func LoadConfig(fileName string) {
 	}
 
 	needSave := len(config.SqlSettings.AtRestEncryptKey) == 0 || len(*config.FileSettings.PublicLinkSalt) == 0 ||
-		len(config.EmailSettings.InviteSalt) == 0 || len(config.EmailSettings.PasswordResetSalt) == 0
+		len(config.EmailSettings.InviteSalt) == 0 || len(config.EmailSettings.PasswordResetSalt) == 0 ||
+		config.EmailSettings.ReplyByEmailSalt == nil || len(*config.EmailSettings.ReplyByEmailSalt) == 0 ||
+		config.ImageProxySettings.SigningKey == nil || len(*config.ImageProxySettings.SigningKey) == 0
 
 	config.SetDefaults()
 
@@ -427,12 +429,19 @@ func Desanitize(cfg *model.Config) {
 		cfg.FileSettings.AmazonS3SecretAccessKey = Cfg.FileSettings.AmazonS3SecretAccessKey
 	}
 
+	if *cfg.ImageProxySettings.SigningKey == model.FAKE_SETTING {
+		*cfg.ImageProxySettings.SigningKey = *Cfg.ImageProxySettings.SigningKey
+	}
+
 	if cfg.EmailSettings.InviteSalt == model.FAKE_SETTING {
 		cfg.EmailSettings.InviteSalt = Cfg.EmailSettings.InviteSalt
 	}
 	if cfg.EmailSettings.PasswordResetSalt == model.FAKE_SETTING {
 		cfg.EmailSettings.PasswordResetSalt = Cfg.EmailSettings.PasswordResetSalt
 	}
+	if *cfg.EmailSettings.ReplyByEmailSalt == model.FAKE_SETTING {
+		*cfg.EmailSettings.ReplyByEmailSalt = *Cfg.EmailSettings.ReplyByEmailSalt
+	}
 	if cfg.EmailSettings.SMTPPassword == model.FAKE_SETTING {
 		cfg.EmailSettings.SMTPPassword = Cfg.EmailSettings.SMTPPassword
 	}
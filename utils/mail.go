@@ -98,6 +98,15 @@ func SendMail(to, subject, body string) *model.AppError {
 }
 
 func SendMailUsingConfig(to, subject, body string, config *model.Config) *model.AppError {
+	return sendMailUsingConfig(to, "", subject, body, config)
+}
+
+// SendMailWithReplyTo sends an email whose replies go to the given address instead of the feedback email.
+func SendMailWithReplyTo(to, replyTo, subject, body string) *model.AppError {
+	return sendMailUsingConfig(to, replyTo, subject, body, Cfg)
+}
+
+func sendMailUsingConfig(to, replyTo, subject, body string, config *model.Config) *model.AppError {
 	if !config.EmailSettings.SendEmailNotifications || len(config.EmailSettings.SMTPServer) == 0 {
 		return nil
 	}
@@ -116,6 +125,10 @@ func SendMailUsingConfig(to, subject, body string, config *model.Config) *model.
 	headers["Content-Transfer-Encoding"] = "8bit"
 	headers["Date"] = time.Now().Format(time.RFC1123Z)
 
+	if replyTo != "" {
+		headers["Reply-To"] = (&mail.Address{Name: "", Address: replyTo}).String()
+	}
+
 	message := ""
 	for k, v := range headers {
 		message += fmt.Sprintf("%s: %s\r\n", k, v)